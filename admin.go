@@ -0,0 +1,336 @@
+package twbot
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pauseState suspends the calls to the twitter API while the bot is paused.
+type pauseState struct {
+	mutex  sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+// Pause suspends all the calls to the twitter API of the bot, after the
+// current ones, until Resume is called. The sleeps and waits of the bot
+// still elapse while it is paused.
+func (t *TwitterBot) Pause() {
+	t.pause.mutex.Lock()
+	defer t.pause.mutex.Unlock()
+	if t.pause.paused {
+		return
+	}
+	logInfo("[twitter] pausing bot...")
+	t.pause.paused = true
+	t.pause.resume = make(chan struct{})
+}
+
+// Resume resumes the calls to the twitter API of a paused bot.
+func (t *TwitterBot) Resume() {
+	t.pause.mutex.Lock()
+	defer t.pause.mutex.Unlock()
+	if !t.pause.paused {
+		return
+	}
+	logInfo("[twitter] resuming bot...")
+	t.pause.paused = false
+	close(t.pause.resume)
+}
+
+// Paused returns true if the bot is paused.
+func (t *TwitterBot) Paused() bool {
+	t.pause.mutex.Lock()
+	defer t.pause.mutex.Unlock()
+	return t.pause.paused
+}
+
+// waitResume waits for the bot to be resumed if paused. It returns
+// false if the bot is stopped before.
+func (t *TwitterBot) waitResume() bool {
+	t.pause.mutex.Lock()
+	paused, resume := t.pause.paused, t.pause.resume
+	t.pause.mutex.Unlock()
+	ctx := t.botContext()
+	if !paused {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-resume:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Admin is the HTTP handler of the administration of a bot, see NewAdmin.
+type Admin struct {
+	bot   *TwitterBot
+	token string
+	mux   *http.ServeMux
+}
+
+// adminStatus is the status served by the administration.
+type adminStatus struct {
+	Paused      bool `json:"paused"`
+	DryRun      bool `json:"dry_run"`
+	CircuitOpen bool `json:"circuit_open"`
+	Jobs        int  `json:"jobs"`
+	Followers   int  `json:"followers"`
+	Friends     int  `json:"friends"`
+}
+
+// adminJob is a job served by the administration, see JobStats.
+type adminJob struct {
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+	Runs      int       `json:"runs"`
+	Failures  int       `json:"failures"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	NextRun   time.Time `json:"next_run,omitempty"`
+	Queued    int       `json:"queued,omitempty"`
+}
+
+func makeAdminJobs(stats []JobStats) []adminJob {
+	jobs := []adminJob{}
+	for _, stat := range stats {
+		job := adminJob{
+			Name:     stat.Name,
+			Started:  stat.Started,
+			Runs:     stat.Runs,
+			Failures: stat.Failures,
+			LastRun:  stat.LastRun,
+			NextRun:  stat.NextRun,
+			Queued:   stat.Queued,
+		}
+		if stat.LastError != nil {
+			job.LastError = stat.LastError.Error()
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// NewAdmin returns the HTTP handler of the administration of the bot:
+//
+//  GET  /status  returns the status of the bot
+//  GET  /stats   returns the live statistics of the bot, see Stats, including
+//                the recent errors and tweets, and requires the token
+//  GET  /jobs    returns the running jobs
+//  GET  /blocked returns the contents blocked by the content filter, see
+//                ContentReport, and requires the token
+//  GET  /healthz answers "ok" if the bot is healthy, see HealthHandler
+//  POST /pause   pauses the bot, see Pause
+//  POST /resume  resumes the bot, see Resume
+//  POST /tweet   tweets the request body, failing with 409 Conflict while
+//                the bot is paused
+//  POST /enqueue enqueues a tweet, see Enqueue, given as a JSON object with
+//                "text", "link" and "image", base64 encoded, fields, or as a
+//                multipart form with "text" and "link" fields and an "image" file
+//
+// The control actions, the statistics and the blocked contents require the
+// given 'token' as a bearer token in the Authorization header. They are
+// disabled if the token is empty.
+func (t *TwitterBot) NewAdmin(token string) *Admin {
+	admin := &Admin{
+		bot:   t,
+		token: token,
+		mux:   http.NewServeMux(),
+	}
+	admin.mux.HandleFunc("/status", admin.read(admin.status))
+	admin.mux.HandleFunc("/stats", admin.private(admin.read(admin.stats)))
+	admin.mux.HandleFunc("/jobs", admin.read(admin.jobs))
+	admin.mux.HandleFunc("/blocked", admin.private(admin.read(admin.blocked)))
+	admin.mux.Handle("/healthz", t.HealthHandler())
+	admin.mux.HandleFunc("/pause", admin.control(admin.pause))
+	admin.mux.HandleFunc("/resume", admin.control(admin.resume))
+	admin.mux.HandleFunc("/tweet", admin.control(admin.tweet))
+	admin.mux.HandleFunc("/enqueue", admin.control(admin.enqueue))
+	return admin
+}
+
+func (a *Admin) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(rw, r)
+}
+
+// read wraps a read-only endpoint returning the value to serve as JSON.
+func (a *Admin) read(get func() interface{}) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		buffer := &bytes.Buffer{}
+		err := json.NewEncoder(buffer).Encode(get())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(buffer.Bytes())
+	}
+}
+
+// private wraps an endpoint requiring the token, like the control actions.
+func (a *Admin) private(handler http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if !a.authorized(r) {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(rw, r)
+	}
+}
+
+// errAdminPaused is returned by the control actions which cannot be done
+// while the bot is paused.
+var errAdminPaused = errors.New("[twitter] bot is paused")
+
+// control wraps an authenticated control endpoint. The endpoints bounding
+// their request body, see http.MaxBytesReader, fail with 413 Request Entity
+// Too Large if it is exceeded.
+func (a *Admin) control(do func(rw http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !a.authorized(r) {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		err := do(rw, r)
+		if errors.Is(err, errAdminPaused) {
+			http.Error(rw, err.Error(), http.StatusConflict)
+			return
+		}
+		tooLarge := &http.MaxBytesError{}
+		if errors.As(err, &tooLarge) {
+			http.Error(rw, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *Admin) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if a.token == "" || !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header[len(prefix):]), []byte(a.token)) == 1
+}
+
+func (a *Admin) status() interface{} {
+	stats := a.bot.Stats()
+	return adminStatus{
+		Paused:      a.bot.Paused(),
+		DryRun:      a.bot.isDryRun(),
+		CircuitOpen: stats.CircuitOpen,
+		Jobs:        len(stats.Jobs),
+		Followers:   stats.Followers,
+		Friends:     stats.Friends,
+	}
+}
+
+func (a *Admin) stats() interface{} {
+	stats := a.bot.Stats()
+	return struct {
+		BotStats
+		Jobs []adminJob
+	}{stats, makeAdminJobs(stats.Jobs)}
+}
+
+func (a *Admin) jobs() interface{} {
+	return makeAdminJobs(a.bot.Stats().Jobs)
+}
+
+func (a *Admin) blocked() interface{} {
+	return a.bot.ContentReport()
+}
+
+func (a *Admin) pause(rw http.ResponseWriter, r *http.Request) error {
+	a.bot.Pause()
+	return nil
+}
+
+func (a *Admin) resume(rw http.ResponseWriter, r *http.Request) error {
+	a.bot.Resume()
+	return nil
+}
+
+func (a *Admin) tweet(rw http.ResponseWriter, r *http.Request) error {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(rw, r.Body, adminMaxTweetSize))
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(string(body))
+	if text == "" {
+		return fmt.Errorf("[twitter] empty tweet")
+	}
+	// the tweet would wait for the bot to be resumed
+	if a.bot.Paused() {
+		return errAdminPaused
+	}
+	return a.bot.TweetOnce(func() (string, error) {
+		return text, nil
+	})
+}
+
+const (
+	adminMaxTweetSize   = 1 << 16  // maximum size of the request body of /tweet
+	adminMaxEnqueueSize = 16 << 20 // maximum size of the request body of /enqueue
+)
+
+func (a *Admin) enqueue(rw http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(rw, r.Body, adminMaxEnqueueSize)
+	tweet := struct {
+		Text  string `json:"text"`
+		Link  string `json:"link"`
+		Image []byte `json:"image"`
+	}{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		err := r.ParseMultipartForm(adminMaxEnqueueSize)
+		if err != nil {
+			return err
+		}
+		tweet.Text, tweet.Link = r.FormValue("text"), r.FormValue("link")
+		file, _, err := r.FormFile("image")
+		if err == nil {
+			defer file.Close()
+			tweet.Image, err = ioutil.ReadAll(file)
+		}
+		if err != nil && err != http.ErrMissingFile {
+			return err
+		}
+	} else {
+		err := json.NewDecoder(r.Body).Decode(&tweet)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := a.bot.Enqueue(strings.TrimSpace(tweet.Text), tweet.Link, tweet.Image)
+	return err
+}
+
+// ServeAdminAsync serves asynchronously the administration of the bot, see
+// NewAdmin, on the given 'addr' address, ":8081" for instance. It logs an
+// error if the server failed. Stopping the returned job shuts the server down.
+func (t *TwitterBot) ServeAdminAsync(addr, token string) *Job {
+	logInfo("[twitter] serving admin -> addr: %s", addr)
+	return t.serveAsync("ServeAdmin", addr, t.NewAdmin(token))
+}
@@ -0,0 +1,65 @@
+package twbot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func serveAdmin(admin *Admin, method, path, token, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	admin.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (s *MySuite) TestAdmin(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.noSleep = true
+	admin := bot.NewAdmin("secret")
+
+	recorder := serveAdmin(admin, http.MethodGet, "/status", "", "")
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	status := adminStatus{}
+	c.Assert(json.Unmarshal(recorder.Body.Bytes(), &status), IsNil)
+	c.Assert(status.Paused, Equals, false)
+	recorder = serveAdmin(admin, http.MethodGet, "/jobs", "", "")
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	c.Assert(strings.TrimSpace(recorder.Body.String()), Equals, "[]")
+	c.Assert(serveAdmin(admin, http.MethodPost, "/status", "", "").Code, Equals, http.StatusMethodNotAllowed)
+
+	// control actions require the token
+	c.Assert(serveAdmin(admin, http.MethodPost, "/pause", "", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/pause", "wrong", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(bot.Paused(), Equals, false)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/pause", "secret", "").Code, Equals, http.StatusNoContent)
+	c.Assert(bot.Paused(), Equals, true)
+	recorder = serveAdmin(admin, http.MethodGet, "/status", "", "")
+	c.Assert(json.Unmarshal(recorder.Body.Bytes(), &status), IsNil)
+	c.Assert(status.Paused, Equals, true)
+	// tweets are refused while paused
+	c.Assert(serveAdmin(admin, http.MethodPost, "/tweet", "secret", "hello").Code, Equals, http.StatusConflict)
+	c.Assert(client.tweets, HasLen, 0)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/resume", "secret", "").Code, Equals, http.StatusNoContent)
+	c.Assert(bot.Paused(), Equals, false)
+
+	c.Assert(serveAdmin(admin, http.MethodPost, "/tweet", "secret", " ").Code, Equals, http.StatusBadRequest)
+	tooLarge := strings.Repeat("a", adminMaxTweetSize+1)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/tweet", "secret", tooLarge).Code, Equals, http.StatusRequestEntityTooLarge)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/tweet", "secret", "hello").Code, Equals, http.StatusNoContent)
+
+	// statistics require the token
+	c.Assert(serveAdmin(admin, http.MethodGet, "/stats", "", "").Code, Equals, http.StatusUnauthorized)
+	c.Assert(serveAdmin(admin, http.MethodGet, "/stats", "secret", "").Code, Equals, http.StatusOK)
+
+	// control actions are disabled without token
+	admin = bot.NewAdmin("")
+	c.Assert(serveAdmin(admin, http.MethodPost, "/pause", "", "").Code, Equals, http.StatusUnauthorized)
+}
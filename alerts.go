@@ -0,0 +1,54 @@
+package twbot
+
+import (
+	"fmt"
+)
+
+// SetOwner sets the screen name of the owner of the bot. Critical events,
+// like a locked account, an expired token, a follow lockout or a finished
+// follow campaign, are sent to the owner as direct messages in addition
+// to the logs. The owner must follow the bot in order to receive them.
+// An empty screen name, the default, disables the alerts.
+func (t *TwitterBot) SetOwner(screenName string) {
+	logInfo("[twitter] setting owner -> screenName: %s", screenName)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.owner = screenName
+}
+
+func (t *TwitterBot) getOwner() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.owner
+}
+
+// alert logs the critical event, notifies it, see AddNotifier,
+// and sends it to the owner if any.
+// It does not go through SendDMToScreenName since a failure of the
+// direct message must not trigger another alert. The direct message
+// bypasses the circuit breaker so that it is not suspended with the
+// other calls, see SetCircuitBreaker.
+func (t *TwitterBot) alert(format string, args ...interface{}) {
+	text := fmt.Sprintf(format, args...)
+	logWarn("[twitter] alert: %s", text)
+	for _, notifier := range t.getNotifiers() {
+		t.notify(notifier, text)
+	}
+	owner := t.getOwner()
+	if owner == "" {
+		return
+	}
+	send := func(client TwitterClient) error {
+		_, err := client.PostDMToScreenName("[twbot] "+text, owner)
+		return err
+	}
+	var err error
+	if api, ok := t.twitterClient.(*apiClient); ok {
+		err = api.inner(send)
+	} else {
+		err = send(t.twitterClient)
+	}
+	if err != nil {
+		logError("[twitter] failed to send alert to owner %s, error: %v", owner, err)
+	}
+}
@@ -0,0 +1,17 @@
+package twbot
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAlert(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	// no owner, alerts are only logged
+	bot.alert("campaign %s finished", "query")
+	c.Assert(client.messages, HasLen, 0)
+
+	bot.SetOwner("owner")
+	bot.alert("campaign %s finished", "query")
+	c.Assert(client.messages, DeepEquals, []string{"owner: [twbot] campaign query finished"})
+}
@@ -0,0 +1,454 @@
+package twbot
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// apiClient wraps the calls to the twitter API of the bot: the calls are
+// suspended by the circuit breaker on repeated failures, see SetCircuitBreaker,
+// delayed when the rate limit of their endpoint family is nearly exhausted,
+// the reads failing with a transient error are retried following the retry
+// policy, see SetRetryPolicy, the errors are wrapped with their sentinel
+// errors, see ErrAccountLocked, and the actions are logged as events, see Event.
+// The write calls can be vetoed by the hooks, see AddHooks, are simulated
+// in dry-run mode, see SetDryRun, and are redirected to the shadow account
+// if any, see SetShadowAccount, while some reads can use an application-only
+// token, see SetAppAuth.
+type apiClient struct {
+	// mutex guards the wrapped client and its rate limits, swapped at runtime
+	// by setClient, and is held for reading during the calls.
+	mutex sync.RWMutex
+	TwitterClient
+	bot    *TwitterBot
+	limits *rateLimits
+}
+
+// setClient swaps the wrapped client and its rate limits, then closes the
+// previous client once the in-flight calls are done with it.
+func (c *apiClient) setClient(client TwitterClient, limits *rateLimits) {
+	c.mutex.Lock()
+	previous := c.TwitterClient
+	c.TwitterClient, c.limits = client, limits
+	c.mutex.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+}
+
+// inner runs the given 'run' callback with the wrapped client, bypassing
+// the wrapping, the client not being swapped meanwhile, see setClient.
+func (c *apiClient) inner(run func(client TwitterClient) error) error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return run(c.TwitterClient)
+}
+
+// getLimits returns the rate limits of the wrapped client.
+func (c *apiClient) getLimits() *rateLimits {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.limits
+}
+
+// call runs the given twitter API read call of the given endpoint 'family',
+// waiting first for the circuit breaker to let it through and for the
+// rate limit of the family if nearly exhausted.
+func (c *apiClient) call(family string, run func() error) error {
+	return c.do(family, isTransientError, run)
+}
+
+// callWrite runs the given twitter API write call like call but retries
+// it only if the request never reached twitter, since writes like tweets
+// or direct messages could be done twice otherwise.
+func (c *apiClient) callWrite(family string, run func() error) error {
+	return c.do(family, isUnsentError, run)
+}
+
+func (c *apiClient) do(family string, retryable func(err error) bool, run func() error) error {
+	return wrapAPIError(c.bot.retry(retryable, func() error {
+		if !c.bot.waitResume() || !c.waitCircuit() || !c.waitRateLimit(family) {
+			return c.bot.botContext().Err()
+		}
+		err := c.inner(func(TwitterClient) error {
+			return run()
+		})
+		c.recordCall(err)
+		return err
+	}))
+}
+
+// Close closes the wrapped client once the in-flight calls are done.
+func (c *apiClient) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.TwitterClient.Close()
+}
+
+func (c *apiClient) AccountUpdateProfileBanner(img string, v url.Values) error {
+	if c.simulate("", Event{}) {
+		return nil
+	}
+	return c.callWrite("account/update_profile_banner", func() error {
+		return c.writer().AccountUpdateProfileBanner(img, v)
+	})
+}
+
+func (c *apiClient) BlockUserId(id int64, v url.Values) (result anaconda.User, err error) {
+	if err = c.hook(ActionBlock, Event{UserID: id}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionBlock, Event{UserID: id}) {
+		return anaconda.User{Id: id}, nil
+	}
+	err = c.callWrite("blocks/create", func() error {
+		result, err = c.writer().BlockUserId(id, v)
+		return err
+	})
+	c.report(ActionBlock, Event{UserID: id, ScreenName: result.ScreenName}, err)
+	return result, err
+}
+
+func (c *apiClient) UnblockUserId(id int64, v url.Values) (result anaconda.User, err error) {
+	if err = c.hook(ActionUnblock, Event{UserID: id}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionUnblock, Event{UserID: id}) {
+		return anaconda.User{Id: id}, nil
+	}
+	err = c.callWrite("blocks/destroy", func() error {
+		result, err = c.writer().UnblockUserId(id, v)
+		return err
+	})
+	c.report(ActionUnblock, Event{UserID: id, ScreenName: result.ScreenName}, err)
+	return result, err
+}
+
+func (c *apiClient) MuteUserId(id int64, v url.Values) (result anaconda.User, err error) {
+	if err = c.hook(ActionMute, Event{UserID: id}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionMute, Event{UserID: id}) {
+		return anaconda.User{Id: id}, nil
+	}
+	err = c.callWrite("mutes/users/create", func() error {
+		result, err = c.writer().MuteUserId(id, v)
+		return err
+	})
+	c.report(ActionMute, Event{UserID: id, ScreenName: result.ScreenName}, err)
+	return result, err
+}
+
+func (c *apiClient) UnmuteUserId(id int64, v url.Values) (result anaconda.User, err error) {
+	if err = c.hook(ActionUnmute, Event{UserID: id}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionUnmute, Event{UserID: id}) {
+		return anaconda.User{Id: id}, nil
+	}
+	err = c.callWrite("mutes/users/destroy", func() error {
+		result, err = c.writer().UnmuteUserId(id, v)
+		return err
+	})
+	c.report(ActionUnmute, Event{UserID: id, ScreenName: result.ScreenName}, err)
+	return result, err
+}
+
+func (c *apiClient) Favorite(id int64) (result anaconda.Tweet, err error) {
+	if err = c.hook(ActionLike, Event{TweetID: id}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionLike, Event{TweetID: id}) {
+		return anaconda.Tweet{Id: id, Favorited: true}, nil
+	}
+	err = c.callWrite("favorites/create", func() error {
+		result, err = c.writer().Favorite(id)
+		return err
+	})
+	c.report(ActionLike, Event{TweetID: id}, err)
+	return result, err
+}
+
+func (c *apiClient) Unfavorite(id int64) (result anaconda.Tweet, err error) {
+	if err = c.hook(ActionUnlike, Event{TweetID: id}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionUnlike, Event{TweetID: id}) {
+		return anaconda.Tweet{Id: id}, nil
+	}
+	err = c.callWrite("favorites/destroy", func() error {
+		result, err = c.writer().Unfavorite(id)
+		return err
+	})
+	c.report(ActionUnlike, Event{TweetID: id}, err)
+	return result, err
+}
+
+func (c *apiClient) FollowUserId(userID int64, v url.Values) (result anaconda.User, err error) {
+	if err = c.hook(ActionFollow, Event{UserID: userID}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionFollow, Event{UserID: userID}) {
+		return anaconda.User{Id: userID}, nil
+	}
+	err = c.callWrite("friendships/create", func() error {
+		result, err = c.writer().FollowUserId(userID, v)
+		return err
+	})
+	c.report(ActionFollow, Event{UserID: userID, ScreenName: result.ScreenName}, err)
+	return result, err
+}
+
+func (c *apiClient) UnfollowUserId(userID int64) (result anaconda.User, err error) {
+	if err = c.hook(ActionUnfollow, Event{UserID: userID}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionUnfollow, Event{UserID: userID}) {
+		return anaconda.User{Id: userID}, nil
+	}
+	err = c.callWrite("friendships/destroy", func() error {
+		result, err = c.writer().UnfollowUserId(userID)
+		return err
+	})
+	c.report(ActionUnfollow, Event{UserID: userID, ScreenName: result.ScreenName}, err)
+	return result, err
+}
+
+func (c *apiClient) GetDirectMessages(v url.Values) (result []anaconda.DirectMessage, err error) {
+	err = c.call("direct_messages/events/list", func() error {
+		result, err = c.TwitterClient.GetDirectMessages(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetFavorites(v url.Values) (result []anaconda.Tweet, err error) {
+	err = c.call("favorites/list", func() error {
+		result, err = c.TwitterClient.GetFavorites(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetSuggestedCategories(v url.Values) (result []SuggestedCategory, err error) {
+	err = c.call("users/suggestions", func() error {
+		result, err = c.TwitterClient.GetSuggestedCategories(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetSuggestedUsers(slug string, v url.Values) (result []anaconda.User, err error) {
+	err = c.call("users/suggestions/"+slug, func() error {
+		result, err = c.TwitterClient.GetSuggestedUsers(slug, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetFollowersIds(v url.Values) (result anaconda.Cursor, err error) {
+	err = c.call("followers/ids", func() error {
+		result, err = c.TwitterClient.GetFollowersIds(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetFriendsIds(v url.Values) (result anaconda.Cursor, err error) {
+	err = c.call("friends/ids", func() error {
+		result, err = c.TwitterClient.GetFriendsIds(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetFollowersUser(id int64, v url.Values) (result anaconda.Cursor, err error) {
+	err = c.read("followers/ids", func(client appReader) error {
+		result, err = client.GetFollowersUser(id, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetFriendshipsOutgoing(v url.Values) (result anaconda.Cursor, err error) {
+	err = c.call("friendships/outgoing", func() error {
+		result, err = c.TwitterClient.GetFriendshipsOutgoing(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetSelf(v url.Values) (result anaconda.User, err error) {
+	err = c.call("account/verify_credentials", func() error {
+		result, err = c.TwitterClient.GetSelf(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetMentionsTimeline(v url.Values) (result []anaconda.Tweet, err error) {
+	err = c.call("statuses/mentions_timeline", func() error {
+		result, err = c.TwitterClient.GetMentionsTimeline(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetUserTimeline(v url.Values) (result []anaconda.Tweet, err error) {
+	err = c.call("statuses/user_timeline", func() error {
+		result, err = c.TwitterClient.GetUserTimeline(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetRetweets(id int64, v url.Values) (result []anaconda.Tweet, err error) {
+	err = c.call("statuses/retweets", func() error {
+		result, err = c.TwitterClient.GetRetweets(id, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetRetweetsOfMe(v url.Values) (result []anaconda.Tweet, err error) {
+	err = c.call("statuses/retweets_of_me", func() error {
+		result, err = c.TwitterClient.GetRetweetsOfMe(v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetSearch(queryString string, v url.Values) (result anaconda.SearchResponse, err error) {
+	err = c.read("search/tweets", func(client appReader) error {
+		result, err = client.GetSearch(queryString, v)
+		return err
+	})
+	c.report(ActionSearch, Event{Query: queryString}, err)
+	return result, err
+}
+
+func (c *apiClient) GetUserSearch(searchTerm string, v url.Values) (result []anaconda.User, err error) {
+	err = c.call("users/search", func() error {
+		result, err = c.TwitterClient.GetUserSearch(searchTerm, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetUsersLookup(usernames string, v url.Values) (result []anaconda.User, err error) {
+	err = c.read("users/lookup", func(client appReader) error {
+		result, err = client.GetUsersLookup(usernames, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetUsersLookupByIds(ids []int64, v url.Values) (result []anaconda.User, err error) {
+	err = c.read("users/lookup", func(client appReader) error {
+		result, err = client.GetUsersLookupByIds(ids, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) GetUsersShow(username string, v url.Values) (result anaconda.User, err error) {
+	err = c.read("users/show", func(client appReader) error {
+		result, err = client.GetUsersShow(username, v)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) PostDMToScreenName(text, screenName string) (result anaconda.DirectMessage, err error) {
+	if err = c.hook(ActionDirectMessage, Event{ScreenName: screenName, Text: text}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionDirectMessage, Event{ScreenName: screenName, Text: text}) {
+		return anaconda.DirectMessage{Id: nextSimulatedID(), Text: text, RecipientScreenName: screenName}, nil
+	}
+	err = c.callWrite("direct_messages/events/new", func() error {
+		result, err = c.writer().PostDMToScreenName(text, screenName)
+		return err
+	})
+	c.report(ActionDirectMessage, Event{ScreenName: screenName, Text: text}, err)
+	return result, err
+}
+
+func (c *apiClient) PostDMToUserId(text string, userID int64) (result anaconda.DirectMessage, err error) {
+	if err = c.hook(ActionDirectMessage, Event{UserID: userID, Text: text}); err != nil {
+		return result, err
+	}
+	if c.simulate(ActionDirectMessage, Event{UserID: userID, Text: text}) {
+		return anaconda.DirectMessage{Id: nextSimulatedID(), Text: text, RecipientId: userID}, nil
+	}
+	err = c.callWrite("direct_messages/events/new", func() error {
+		result, err = c.writer().PostDMToUserId(text, userID)
+		return err
+	})
+	c.report(ActionDirectMessage, Event{UserID: userID, Text: text}, err)
+	return result, err
+}
+
+func (c *apiClient) PostTweet(status string, v url.Values) (result anaconda.Tweet, err error) {
+	if err = c.hook(ActionTweet, Event{Text: status}); err != nil {
+		return result, err
+	}
+	if c.bot.isDryRun() {
+		result = simulatedTweet(status)
+		c.simulate(ActionTweet, Event{TweetID: result.Id, Text: status})
+		return result, nil
+	}
+	err = c.callWrite("statuses/update", func() error {
+		result, err = c.writer().PostTweet(status, v)
+		return err
+	})
+	c.report(ActionTweet, Event{TweetID: result.Id, Text: status}, err)
+	return result, err
+}
+
+func (c *apiClient) Retweet(id int64, trimUser bool) (result anaconda.Tweet, err error) {
+	if err = c.hook(ActionRetweet, Event{TweetID: id}); err != nil {
+		return result, err
+	}
+	if c.bot.isDryRun() {
+		result = simulatedTweet("")
+		result.RetweetedStatus = &anaconda.Tweet{Id: id}
+		c.simulate(ActionRetweet, Event{TweetID: id})
+		return result, nil
+	}
+	err = c.callWrite("statuses/retweet", func() error {
+		result, err = c.writer().Retweet(id, trimUser)
+		return err
+	})
+	c.report(ActionRetweet, Event{TweetID: id}, err)
+	return result, err
+}
+
+func (c *apiClient) UploadMedia(base64String string) (result anaconda.Media, err error) {
+	if c.simulate("", Event{}) {
+		return anaconda.Media{MediaID: nextSimulatedID()}, nil
+	}
+	err = c.callWrite("media/upload", func() error {
+		result, err = c.writer().UploadMedia(base64String)
+		return err
+	})
+	return result, err
+}
+
+func (c *apiClient) PublicStreamFilter(v url.Values) (stream *anaconda.Stream) {
+	c.inner(func(client TwitterClient) error {
+		stream = client.PublicStreamFilter(v)
+		return nil
+	})
+	return stream
+}
+
+func (c *apiClient) UserStream(v url.Values) (stream *anaconda.Stream) {
+	c.inner(func(client TwitterClient) error {
+		stream = client.UserStream(v)
+		return nil
+	})
+	return stream
+}
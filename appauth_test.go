@@ -0,0 +1,61 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAppAuth(c *C) {
+	requests := []*http.Request{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		body := `{"id": 42, "screen_name": "someone"}`
+		if req.URL.Path == "/oauth2/token" {
+			body = `{"token_type": "bearer", "access_token": "bearer token"}`
+		}
+		header := http.Header{}
+		header.Set("X-Rate-Limit-Remaining", "0")
+		header.Set("X-Rate-Limit-Reset", "4102444800")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+	app, err := newAppClient(&http.Client{Transport: transport}, "key", "secret")
+	c.Assert(err, IsNil)
+	c.Assert(requests, HasLen, 1)
+	c.Assert(requests[0].Method, Equals, http.MethodPost)
+	username, password, _ := requests[0].BasicAuth()
+	c.Assert(username+":"+password, Equals, "key:secret")
+
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.setAppClient(app)
+	user, err := bot.twitterClient.GetUsersShow("someone", nil)
+	c.Assert(err, IsNil)
+	c.Assert(user.Id, Equals, int64(42))
+	c.Assert(requests, HasLen, 2)
+	c.Assert(requests[1].URL.Path, Equals, "/1.1/users/show.json")
+	c.Assert(requests[1].URL.Query().Get("screen_name"), Equals, "someone")
+	c.Assert(requests[1].Header.Get("Authorization"), Equals, "Bearer bearer token")
+	// the application rate limits are tracked separately
+	c.Assert(app.limits.delay("users/show", timeNow()) > 0, Equals, true)
+
+	// the other reads use the user token
+	_, err = bot.twitterClient.GetMentionsTimeline(nil)
+	c.Assert(err, IsNil)
+	c.Assert(requests, HasLen, 2)
+
+	bot.setAppClient(nil)
+	client.users = map[string]anaconda.User{"someone": {Id: 7, ScreenName: "someone"}}
+	user, err = bot.twitterClient.GetUsersShow("someone", nil)
+	c.Assert(err, IsNil)
+	c.Assert(user.Id, Equals, int64(7))
+	c.Assert(requests, HasLen, 2)
+}
@@ -0,0 +1,194 @@
+package twbot
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+type archiveAccount struct {
+	AccountID string `json:"accountId"`
+}
+
+type archiveTweet struct {
+	IDStr    string `json:"id_str"`
+	FullText string `json:"full_text"`
+	Text     string `json:"text"`
+}
+
+type archiveEntry struct {
+	Following *archiveAccount `json:"following"`
+	Follower  *archiveAccount `json:"follower"`
+	Tweet     *archiveTweet   `json:"tweet"`
+}
+
+// archive holds the data of a twitter archive used to seed the databases.
+type archive struct {
+	following []int64
+	followers []int64
+	tweets    []anaconda.Tweet
+}
+
+// parseArchiveFile parses a data file of a twitter archive, a javascript
+// assignment like "window.YTD.follower.part0 = [...]" of a JSON array.
+func parseArchiveFile(data []byte) ([]archiveEntry, error) {
+	start := bytes.IndexByte(data, '[')
+	if start < 0 {
+		return nil, fmt.Errorf("[twitter] invalid archive file, no array found")
+	}
+	entries := []archiveEntry{}
+	err := json.Unmarshal(data[start:], &entries)
+	return entries, err
+}
+
+func parseAccountIDs(accounts []*archiveAccount) []int64 {
+	ids := []int64{}
+	for _, account := range accounts {
+		if account == nil {
+			continue
+		}
+		id, err := strconv.ParseInt(account.AccountID, 10, 64)
+		if err != nil {
+			logError("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// readArchive reads the following, followers and tweets
+// of the twitter archive zip file 'filename'.
+func readArchive(filename string) (*archive, error) {
+	reader, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	result := &archive{}
+	for _, file := range reader.File {
+		name := path.Base(file.Name)
+		if path.Ext(name) != ".js" {
+			continue
+		}
+		// tweets may be split into several parts: tweets.js, tweets-part1.js...
+		base := strings.SplitN(strings.TrimSuffix(name, ".js"), "-", 2)[0]
+		if base != "following" && base != "follower" && base != "tweet" && base != "tweets" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := parseArchiveFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("[twitter] failed to parse archive file %s: %v", file.Name, err)
+		}
+		following, followers := []*archiveAccount{}, []*archiveAccount{}
+		for _, entry := range entries {
+			following = append(following, entry.Following)
+			followers = append(followers, entry.Follower)
+			if entry.Tweet == nil {
+				continue
+			}
+			id, err := strconv.ParseInt(entry.Tweet.IDStr, 10, 64)
+			if err != nil {
+				logError("%v", err)
+				continue
+			}
+			text := entry.Tweet.FullText
+			if text == "" {
+				text = entry.Tweet.Text
+			}
+			result.tweets = append(result.tweets, anaconda.Tweet{Id: id, IdStr: entry.Tweet.IDStr, Text: text})
+		}
+		result.following = append(result.following, parseAccountIDs(following)...)
+		result.followers = append(result.followers, parseAccountIDs(followers)...)
+	}
+	return result, nil
+}
+
+// seedUsers adds the users of the given ids missing from the database and
+// returns the number of added users. It must be called with the bot mutex locked.
+func seedUsers(users *twitterUsers, ids []int64) int {
+	count := 0
+	for _, id := range ids {
+		strID := strconv.FormatInt(id, 10)
+		if _, ok := users.Ids[strID]; ok {
+			continue
+		}
+		users.Ids[strID] = &twitterUser{
+			Timestamp: timeNow().UnixNano(),
+			Follow:    true,
+		}
+		count++
+	}
+	return count
+}
+
+// mergeTweets returns the tweets of the archive missing from the
+// tweets database, the oldest first, followed by the database tweets.
+func mergeTweets(previous, archived []anaconda.Tweet) []anaconda.Tweet {
+	known := map[int64]bool{}
+	for _, tweet := range previous {
+		known[tweet.Id] = true
+	}
+	merged := []anaconda.Tweet{}
+	for _, tweet := range archived {
+		if !known[tweet.Id] {
+			known[tweet.Id] = true
+			merged = append(merged, tweet)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Id < merged[j].Id })
+	return append(merged, previous...)
+}
+
+// ImportArchive seeds the followers, friends and tweets databases with the
+// official twitter data archive zip file 'filename' of the bot account, made
+// of the following.js, follower.js and tweet.js files, so that the history
+// of an existing account is known without paginating the twitter API.
+// Users already in database are left untouched and imported users are
+// timestamped with the import time since the archive has no follow date.
+func (t *TwitterBot) ImportArchive(filename string) error {
+	data, err := readArchive(filename)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	followers := seedUsers(t.followers, data.followers)
+	friends := seedUsers(t.friends, data.following)
+	err = t.store.Save(t.followersPath, t.followers)
+	if err == nil {
+		err = t.store.Save(t.friendsPath, t.friends)
+	}
+	t.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	previous, err := t.loadTweets()
+	if err != nil {
+		return err
+	}
+	tweets := mergeTweets(previous, data.tweets)
+	err = t.store.Save(t.tweetsPath, tweets)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] imported archive %s -> followers: %d, friends: %d, tweets: %d",
+		filename, followers, friends, len(tweets)-len(previous))
+	return nil
+}
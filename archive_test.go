@@ -0,0 +1,67 @@
+package twbot
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func writeArchive(c *C, filename string, files map[string]string) {
+	file, err := os.Create(filename)
+	c.Assert(err, IsNil)
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		c.Assert(err, IsNil)
+		_, err = w.Write([]byte(content))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(writer.Close(), IsNil)
+}
+
+func (s *MySuite) TestImportArchive(c *C) {
+	dir := c.MkDir()
+	filename := filepath.Join(dir, "archive.zip")
+	writeArchive(c, filename, map[string]string{
+		"data/follower.js": `window.YTD.follower.part0 = [
+			{"follower": {"accountId": "1", "userLink": "https://twitter.com/intent/user?user_id=1"}},
+			{"follower": {"accountId": "2", "userLink": "https://twitter.com/intent/user?user_id=2"}}
+		]`,
+		"data/following.js": `window.YTD.following.part0 = [
+			{"following": {"accountId": "3", "userLink": "https://twitter.com/intent/user?user_id=3"}}
+		]`,
+		"data/tweets.js": `window.YTD.tweets.part0 = [
+			{"tweet": {"id_str": "20", "full_text": "RT @gopher: hello"}}
+		]`,
+		"data/tweets-part1.js": `window.YTD.tweets.part1 = [
+			{"tweet": {"id_str": "10", "full_text": "first tweet"}}
+		]`,
+		"data/like.js": `window.YTD.like.part0 = [{"like": {"tweetId": "30"}}]`,
+	})
+	bot := makeFakeBot(&fakeClient{})
+	bot.followersPath = filepath.Join(dir, "followers.json")
+	bot.friendsPath = filepath.Join(dir, "friends.json")
+	bot.tweetsPath = filepath.Join(dir, "tweets.json")
+	bot.followers.Ids["1"] = &twitterUser{Timestamp: 42, Follow: true, FollowedBack: true}
+	c.Assert(bot.ImportArchive(filename), IsNil)
+
+	c.Assert(bot.followers.Ids, HasLen, 2)
+	c.Assert(bot.followers.Ids["1"], DeepEquals, &twitterUser{Timestamp: 42, Follow: true, FollowedBack: true})
+	c.Assert(bot.followers.Ids["2"].Follow, Equals, true)
+	c.Assert(bot.friends.Ids, HasLen, 1)
+	c.Assert(bot.friends.Ids["3"].Follow, Equals, true)
+	tweets, err := bot.loadTweets()
+	c.Assert(err, IsNil)
+	c.Assert(tweets, HasLen, 2)
+	c.Assert(tweets[0].Text, Equals, "first tweet")
+	c.Assert(tweets[1].Text, Equals, "RT @gopher: hello")
+
+	// importing twice does not duplicate tweets
+	c.Assert(bot.ImportArchive(filename), IsNil)
+	tweets, err = bot.loadTweets()
+	c.Assert(err, IsNil)
+	c.Assert(tweets, HasLen, 2)
+}
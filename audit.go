@@ -0,0 +1,143 @@
+package twbot
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of the audited actions, see AuditEntry.
+const (
+	AuditSuccess   = "success"
+	AuditFailed    = "failed"
+	AuditSimulated = "simulated"
+)
+
+// AuditEntry is a write action of the bot recorded in the audit log,
+// see SetAuditPath.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Action is the type of the action, ActionFollow for instance.
+	Action string `json:"action"`
+	// TargetID is the id of the tweet or of the user targeted by the action.
+	TargetID int64  `json:"target_id,omitempty"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// SetAuditPath sets the path of the audit log, an append-only file where
+// every write action of the bot is recorded as a JSON line, see AuditLog.
+// The audit log does not go through the store of the bot.
+func (t *TwitterBot) SetAuditPath(auditPath string) error {
+	logInfo("[twitter] setting audit log -> path: %s", auditPath)
+	file, err := os.OpenFile(auditPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	err = endLine(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	t.audit.mutex.Lock()
+	defer t.audit.mutex.Unlock()
+	t.audit.path = auditPath
+	return file.Close()
+}
+
+// endLine ends the last line of the given audit log file if it was
+// truncated by a crash, so that the next entries are not appended to it.
+func endLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	_, err = file.ReadAt(last, info.Size()-1)
+	if err != nil || last[0] == '\n' {
+		return err
+	}
+	_, err = file.Write([]byte{'\n'})
+	return err
+}
+
+// AuditLog returns the write actions of the bot recorded in the audit log
+// since the given time, in chronological order, see SetAuditPath.
+// The invalid entries, like a line truncated by a crash, are skipped.
+func (t *TwitterBot) AuditLog(since time.Time) ([]AuditEntry, error) {
+	t.audit.mutex.Lock()
+	defer t.audit.mutex.Unlock()
+	if t.audit.path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(t.audit.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := AuditEntry{}
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			// a crash while appending an entry leaves a truncated line
+			logWarn("[twitter] skipping invalid audit log entry %q: %v", scanner.Text(), err)
+			continue
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// record appends the given write 'action' reported by the given event
+// to the audit log, if any.
+func (a *auditLog) record(action string, event Event) {
+	if action == ActionSearch {
+		return
+	}
+	entry := AuditEntry{
+		Time:     event.Time,
+		Action:   action,
+		TargetID: event.TweetID,
+		Outcome:  AuditSuccess,
+		Error:    event.Error,
+	}
+	if entry.TargetID == 0 {
+		entry.TargetID = event.UserID
+	}
+	if event.Simulated {
+		entry.Outcome = AuditSimulated
+	} else if event.Failed() {
+		entry.Outcome = AuditFailed
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logError("%v", err)
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.path == "" {
+		return
+	}
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err == nil {
+		_, err = file.Write(append(data, '\n'))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		SubsystemStore.error("[twitter] failed to record action in audit log %s: %v", a.path, err)
+	}
+}
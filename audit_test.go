@@ -0,0 +1,66 @@
+package twbot
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAuditLog(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(403, twitterErrorTweetTooLong),
+		failures:   1,
+	}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	entries, err := bot.AuditLog(time.Time{})
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 0)
+
+	path := filepath.Join(c.MkDir(), "audit.jsonl")
+	c.Assert(bot.SetAuditPath(path), IsNil)
+	start := time.Now()
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), NotNil)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	bot.SetDryRun(true)
+	_, err = bot.twitterClient.FollowUserId(42, nil)
+	c.Assert(err, IsNil)
+
+	entries, err = bot.AuditLog(start)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+	c.Assert(entries[0].Action, Equals, ActionTweet)
+	c.Assert(entries[0].Outcome, Equals, AuditFailed)
+	c.Assert(entries[0].Error, Not(Equals), "")
+	c.Assert(entries[1].Outcome, Equals, AuditSuccess)
+	c.Assert(entries[1].TargetID, Equals, int64(1))
+	c.Assert(entries[2].Action, Equals, ActionFollow)
+	c.Assert(entries[2].TargetID, Equals, int64(42))
+	c.Assert(entries[2].Outcome, Equals, AuditSimulated)
+
+	// the audit log is append-only
+	c.Assert(bot.SetAuditPath(path), IsNil)
+	entries, err = bot.AuditLog(time.Now())
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 0)
+	entries, err = bot.AuditLog(start)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 3)
+
+	// a line truncated by a crash is skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	c.Assert(err, IsNil)
+	_, err = file.WriteString(`{"time":"20`)
+	c.Assert(err, IsNil)
+	c.Assert(file.Close(), IsNil)
+	c.Assert(bot.SetAuditPath(path), IsNil)
+	_, err = bot.twitterClient.FollowUserId(43, nil)
+	c.Assert(err, IsNil)
+	entries, err = bot.AuditLog(start)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 4)
+	c.Assert(entries[3].TargetID, Equals, int64(43))
+}
@@ -0,0 +1,70 @@
+package twbot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+const (
+	twitterRequestTokenURL = "https://api.twitter.com/oauth/request_token"
+	twitterAuthorizeURL    = "https://api.twitter.com/oauth/authorize"
+	twitterAccessTokenURL  = "https://api.twitter.com/oauth/access_token"
+)
+
+// AuthorizeInteractive obtains the access token and secret of an account for
+// the twitter app of the given consumer keys with the PIN-based flow: it
+// prints the URL of the authorization page of the app on the standard
+// output, the user opens it while logged in on the account of the bot,
+// authorizes the app and enters the displayed PIN on the standard input.
+// The returned keys are typically saved in the environment or in a profile,
+// see Profiles, so that the authorization is done once:
+//
+//  token, secret, err := twbot.AuthorizeInteractive(consumerKey, consumerSecret)
+//  ...
+//  bot := twbot.MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath,
+//      consumerKey, consumerSecret, token, secret, false)
+func AuthorizeInteractive(consumerKey, consumerSecret string) (accessToken, accessSecret string, err error) {
+	return authorizeInteractive(http.DefaultClient, consumerKey, consumerSecret, os.Stdin, os.Stdout)
+}
+
+// authorizeInteractive runs the PIN-based flow of AuthorizeInteractive
+// through the given http client, reading the PIN from 'in' and printing
+// the instructions to 'out'.
+func authorizeInteractive(httpClient *http.Client, consumerKey, consumerSecret string, in io.Reader, out io.Writer) (string, string, error) {
+	client := oauth.Client{
+		Credentials: oauth.Credentials{
+			Token:  consumerKey,
+			Secret: consumerSecret,
+		},
+		TemporaryCredentialRequestURI: twitterRequestTokenURL,
+		ResourceOwnerAuthorizationURI: twitterAuthorizeURL,
+		TokenRequestURI:               twitterAccessTokenURL,
+	}
+	// "oob" (out-of-band) makes twitter display a PIN instead of redirecting
+	temporary, err := client.RequestTemporaryCredentials(httpClient, "oob", nil)
+	if err != nil {
+		return "", "", fmt.Errorf("[twitter] failed to request authorization: %v", err)
+	}
+	fmt.Fprintf(out, "Open the following URL, authorize the app and enter the PIN:\n%s\nPIN: ",
+		client.AuthorizationURL(temporary, nil))
+	scanner := bufio.NewScanner(in)
+	pin := ""
+	if scanner.Scan() {
+		pin = strings.TrimSpace(scanner.Text())
+	}
+	if pin == "" {
+		return "", "", fmt.Errorf("[twitter] no PIN entered")
+	}
+	credentials, values, err := client.RequestToken(httpClient, temporary, pin)
+	if err != nil {
+		return "", "", fmt.Errorf("[twitter] failed to get access token: %v", err)
+	}
+	logInfo("[twitter] authorized account %s", values.Get("screen_name"))
+	return credentials.Token, credentials.Secret, nil
+}
@@ -0,0 +1,40 @@
+package twbot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAuthorizeInteractive(c *C) {
+	verifiers := []string{}
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			c.Assert(req.ParseForm(), IsNil)
+			body := "oauth_token=temporary&oauth_token_secret=temporary_secret"
+			if req.URL.String() == twitterAccessTokenURL {
+				verifiers = append(verifiers, req.PostForm.Get("oauth_verifier"))
+				body = "oauth_token=token&oauth_token_secret=secret&screen_name=bot"
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	out := &bytes.Buffer{}
+	token, secret, err := authorizeInteractive(httpClient, "key", "secret", strings.NewReader(" 1234567\n"), out)
+	c.Assert(err, IsNil)
+	c.Assert(token, Equals, "token")
+	c.Assert(secret, Equals, "secret")
+	c.Assert(verifiers, DeepEquals, []string{"1234567"})
+	c.Assert(strings.Contains(out.String(), twitterAuthorizeURL+"?oauth_token=temporary"), Equals, true)
+
+	_, _, err = authorizeInteractive(httpClient, "key", "secret", strings.NewReader("\n"), out)
+	c.Assert(err, ErrorMatches, `\[twitter\] no PIN entered`)
+	c.Assert(verifiers, HasLen, 1)
+}
@@ -0,0 +1,153 @@
+package twbot
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// batchStore is a write-behind store batching the saves of another store:
+// databases are saved every 'maxPending' saves or 'maxDelay' after the
+// first pending save, whichever comes first, and on close.
+type batchStore struct {
+	store      Store
+	maxPending int
+	maxDelay   time.Duration
+	mutex      sync.Mutex
+	pending    map[string]json.RawMessage // map path -> database to save
+	count      int
+	timer      *time.Timer
+}
+
+func newBatchStore(store Store, maxPending int, maxDelay time.Duration) *batchStore {
+	return &batchStore{
+		store:      store,
+		maxPending: maxPending,
+		maxDelay:   maxDelay,
+		pending:    make(map[string]json.RawMessage),
+	}
+}
+
+func (s *batchStore) Exists(path string) (bool, error) {
+	s.mutex.Lock()
+	_, ok := s.pending[path]
+	s.mutex.Unlock()
+	if ok {
+		return true, nil
+	}
+	return s.store.Exists(path)
+}
+
+func (s *batchStore) Load(path string, v interface{}) error {
+	s.mutex.Lock()
+	data, ok := s.pending[path]
+	s.mutex.Unlock()
+	if ok {
+		return json.Unmarshal(data, v)
+	}
+	return s.store.Load(path, v)
+}
+
+// Save snapshots the database so that it can be modified
+// while waiting to be saved.
+func (s *batchStore) Save(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending[path] = data
+	s.count++
+	if s.maxPending > 0 && s.count >= s.maxPending {
+		return s.flush()
+	}
+	if s.timer == nil && s.maxDelay > 0 {
+		s.timer = time.AfterFunc(s.maxDelay, func() {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			err := s.flush()
+			if err != nil {
+				SubsystemStore.error("%v", err)
+			}
+		})
+	}
+	return nil
+}
+
+// flush saves the pending databases. It must be called with the store mutex locked.
+func (s *batchStore) flush() error {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	var last error
+	for path, data := range s.pending {
+		err := s.store.Save(path, data)
+		if err != nil {
+			last = err
+			continue
+		}
+		delete(s.pending, path)
+	}
+	s.count = 0
+	return last
+}
+
+// pendingCount returns the number of databases waiting to be saved.
+func (s *batchStore) pendingCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.pending)
+}
+
+// Flush saves the pending databases.
+func (s *batchStore) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.flush()
+}
+
+func (s *batchStore) Close() error {
+	err := s.Flush()
+	if err != nil {
+		s.store.Close()
+		return err
+	}
+	return s.store.Close()
+}
+
+// SetSaveBatching batches the saves of the databases in order to avoid
+// rewriting a whole database on every single change during the campaigns
+// following or unfollowing thousands of users. Databases are saved every
+// 'maxPending' changes or 'maxDelay' after the first unsaved change, 0
+// meaning no limit, and when the bot is closed, see Close. Changes not saved
+// yet are lost if the bot crashes. A zero 'maxPending' and 'maxDelay', the
+// default, saves the databases on every change.
+func (t *TwitterBot) SetSaveBatching(maxPending int, maxDelay time.Duration) error {
+	logInfo("[twitter] setting save batching -> maxPending: %d, maxDelay: %s", maxPending, maxDelay)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	store := t.store
+	// the dry-run store stays on top of the batches
+	dryRun, isDryRun := store.(*dryRunStore)
+	if isDryRun {
+		store = dryRun.Store
+	}
+	if batch, ok := store.(*batchStore); ok {
+		err := batch.Flush()
+		if err != nil {
+			return err
+		}
+		store = batch.store
+	}
+	if maxPending > 0 || maxDelay > 0 {
+		store = newBatchStore(store, maxPending, maxDelay)
+	}
+	if isDryRun {
+		dryRun.Store = store
+		store = dryRun
+	}
+	t.store = store
+	return nil
+}
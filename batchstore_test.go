@@ -0,0 +1,73 @@
+package twbot
+
+import (
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestBatchStore(c *C) {
+	path := filepath.Join(c.MkDir(), "friends.json")
+	store := newBatchStore(jsonStore{}, 3, 0)
+	friends := &twitterUsers{
+		Ids: map[string]*twitterUser{},
+	}
+	saved := func() bool {
+		ok, err := jsonStore{}.Exists(path)
+		c.Assert(err, IsNil)
+		return ok
+	}
+	friends.Ids["1"] = &twitterUser{Timestamp: 1, Follow: true}
+	c.Assert(store.Save(path, friends), IsNil)
+	friends.Ids["2"] = &twitterUser{Timestamp: 2, Follow: true}
+	c.Assert(store.Save(path, friends), IsNil)
+	c.Assert(saved(), Equals, false)
+
+	// pending databases are visible before being saved
+	ok, err := store.Exists(path)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	loaded := &twitterUsers{}
+	c.Assert(store.Load(path, loaded), IsNil)
+	c.Assert(loaded, DeepEquals, friends)
+
+	friends.Ids["3"] = &twitterUser{Timestamp: 3, Follow: true}
+	c.Assert(store.Save(path, friends), IsNil)
+	c.Assert(saved(), Equals, true)
+	loaded = &twitterUsers{}
+	c.Assert(jsonStore{}.Load(path, loaded), IsNil)
+	c.Assert(loaded, DeepEquals, friends)
+
+	// pending databases are saved on close
+	friends.Ids["4"] = &twitterUser{Timestamp: 4, Follow: true}
+	c.Assert(store.Save(path, friends), IsNil)
+	c.Assert(store.Close(), IsNil)
+	loaded = &twitterUsers{}
+	c.Assert(jsonStore{}.Load(path, loaded), IsNil)
+	c.Assert(loaded.Ids, HasLen, 4)
+}
+
+func (s *MySuite) TestBatchStoreDelay(c *C) {
+	path := filepath.Join(c.MkDir(), "state.json")
+	store := newBatchStore(jsonStore{}, 0, time.Millisecond)
+	c.Assert(store.Save(path, &twitterState{}), IsNil)
+	for i := 0; i < 100; i++ {
+		if ok, _ := (jsonStore{}).Exists(path); ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatal("pending database not saved after the delay")
+}
+
+func (s *MySuite) TestSetSaveBatching(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	store := bot.store
+	c.Assert(bot.SetSaveBatching(10, time.Minute), IsNil)
+	batch, ok := bot.store.(*batchStore)
+	c.Assert(ok, Equals, true)
+	c.Assert(batch.maxPending, Equals, 10)
+	c.Assert(bot.SetSaveBatching(0, 0), IsNil)
+	c.Assert(bot.store, Equals, store)
+}
@@ -0,0 +1,321 @@
+package twbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	"github.com/dns-gh/tojson"
+)
+
+type twitterBlocks struct {
+	// note: we cannot use integers as keys in encode/json so use string instead
+	Blocked map[string]int64 `json:"blocked"` // map id -> timestamp of the block
+	Muted   map[string]int64 `json:"muted"`   // map id -> timestamp of the mute
+}
+
+// SetBlocksPath sets the path of the blocks database, keeping track of the
+// users blocked or muted by the bot. If no path is set, blocked and muted
+// users are only remembered in memory.
+func (t *TwitterBot) SetBlocksPath(blocksPath string) error {
+	blocks := &twitterBlocks{
+		Blocked: make(map[string]int64),
+		Muted:   make(map[string]int64),
+	}
+	err := t.loadOrCreate(blocksPath, blocks)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.blocksPath = blocksPath
+	t.blocks = blocks
+	return nil
+}
+
+// saveBlocks must be called with the bot mutex locked.
+func (t *TwitterBot) saveBlocks() error {
+	if t.blocksPath == "" {
+		return nil
+	}
+	return t.store.Save(t.blocksPath, t.blocks)
+}
+
+// getBlocks must be called with the bot mutex locked.
+func (t *TwitterBot) getBlocks(muted bool) map[string]int64 {
+	if muted {
+		return t.blocks.Muted
+	}
+	return t.blocks.Blocked
+}
+
+func (t *TwitterBot) setBlocked(id int64, muted, blocked bool) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := t.getBlocks(muted)
+	strID := strconv.FormatInt(id, 10)
+	if blocked {
+		ids[strID] = timeNow().UnixNano()
+	} else {
+		delete(ids, strID)
+	}
+	return t.saveBlocks()
+}
+
+// BlockUser blocks the user of the given id.
+func (t *TwitterBot) BlockUser(id int64) error {
+	_, err := t.twitterClient.BlockUserId(id, nil)
+	if err != nil {
+		return err
+	}
+	return t.setBlocked(id, false, true)
+}
+
+// UnblockUser unblocks the user of the given id.
+func (t *TwitterBot) UnblockUser(id int64) error {
+	_, err := t.twitterClient.UnblockUserId(id, nil)
+	if err != nil {
+		return err
+	}
+	return t.setBlocked(id, false, false)
+}
+
+// MuteUser mutes the user of the given id.
+func (t *TwitterBot) MuteUser(id int64) error {
+	_, err := t.twitterClient.MuteUserId(id, nil)
+	if err != nil {
+		return err
+	}
+	return t.setBlocked(id, true, true)
+}
+
+// UnmuteUser unmutes the user of the given id.
+func (t *TwitterBot) UnmuteUser(id int64) error {
+	_, err := t.twitterClient.UnmuteUserId(id, nil)
+	if err != nil {
+		return err
+	}
+	return t.setBlocked(id, true, false)
+}
+
+func (t *TwitterBot) getBlockedIDs(muted bool) []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	sorted := []int64{}
+	for strID := range t.getBlocks(muted) {
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			logError("%v", err)
+			continue
+		}
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// GetBlockedIDs returns the ids of the users blocked by the bot, sorted by id.
+func (t *TwitterBot) GetBlockedIDs() []int64 {
+	return t.getBlockedIDs(false)
+}
+
+// GetMutedIDs returns the ids of the users muted by the bot, sorted by id.
+func (t *TwitterBot) GetMutedIDs() []int64 {
+	return t.getBlockedIDs(true)
+}
+
+// isBlockedOrMuted returns true if the user of the given id is blocked or muted.
+func (t *TwitterBot) isBlockedOrMuted(id int64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	strID := strconv.FormatInt(id, 10)
+	_, blocked := t.blocks.Blocked[strID]
+	_, muted := t.blocks.Muted[strID]
+	return blocked || muted
+}
+
+// SetAutoMute enables the auto mute of the authors whose tweets are removed
+// by the banned queries of the retweet and like methods at least 'threshold'
+// times. Tweets of blocked or muted users are always removed by the banned
+// queries. A zero 'threshold', the default, disables the auto mute.
+func (t *TwitterBot) SetAutoMute(threshold int) {
+	logInfo("[twitter] setting auto mute -> threshold: %d", threshold)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.autoMuteThreshold = threshold
+}
+
+// SetBannedUsers sets the screen names of the users whose tweets are
+// always removed by the banned queries of the retweet and like methods,
+// replacing the previous ones. Screen names are case insensitive.
+func (t *TwitterBot) SetBannedUsers(screenNames ...string) {
+	logInfo("[twitter] setting banned users -> users: %v", screenNames)
+	bannedUsers := make(map[string]struct{})
+	for _, screenName := range screenNames {
+		bannedUsers[strings.ToLower(screenName)] = struct{}{}
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.bannedUsers = bannedUsers
+}
+
+// isBannedUser returns true if the user of the given screen name is banned.
+func (t *TwitterBot) isBannedUser(screenName string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, banned := t.bannedUsers[strings.ToLower(screenName)]
+	return banned
+}
+
+// hitBan counts a banned tweet of the given author
+// and mutes the author if the auto mute threshold is reached.
+func (t *TwitterBot) hitBan(user *anaconda.User) {
+	t.mutex.Lock()
+	if t.autoMuteThreshold <= 0 {
+		t.mutex.Unlock()
+		return
+	}
+	t.banHits[user.Id]++
+	_, muted := t.blocks.Muted[strconv.FormatInt(user.Id, 10)]
+	mute := !muted && t.banHits[user.Id] >= t.autoMuteThreshold
+	t.mutex.Unlock()
+	if !mute {
+		return
+	}
+	err := t.MuteUser(user.Id)
+	if err != nil {
+		print(t, fmt.Sprintf("[twitter] failed to auto mute user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
+	}
+}
+
+// readBlockList reads the block list at the given 'source', either a file
+// path or an http(s) url.
+func readBlockList(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return ioutil.ReadFile(source)
+	}
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("[twitter] failed to fetch block list %s: %s", source, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// parseBlockList parses a block list made of a JSON array of user ids if
+// 'source' has a .json extension, or a CSV file whose first column holds
+// user ids otherwise. CSV lines not starting with an id, like headers,
+// are ignored.
+func parseBlockList(source string, data []byte) ([]int64, error) {
+	ids := []int64{}
+	if strings.EqualFold(path.Ext(source), ".json") {
+		err := json.Unmarshal(data, &ids)
+		return ids, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		id, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ImportBlockList blocks, or mutes if 'mute' is true, the users of the block
+// list at the given 'source', either a file path or an http(s) url, so that
+// shared community block lists can be used. The block list is either a JSON
+// array of user ids if 'source' has a .json extension, or a CSV file whose
+// first column holds user ids. Users already blocked or muted are skipped.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) ImportBlockList(source string, mute bool, sleepPolicy SleepPolicy) error {
+	data, err := readBlockList(source)
+	if err != nil {
+		return err
+	}
+	ids, err := parseBlockList(source, data)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] importing %d user(s) from block list %s...", len(ids), source)
+	for _, id := range ids {
+		if t.isBlockedOrMuted(id) {
+			continue
+		}
+		if mute {
+			err = t.MuteUser(id)
+		} else {
+			err = t.BlockUser(id)
+		}
+		if err != nil {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to import user (id:%d), error: %v\n", id, err))
+			continue
+		}
+		t.controlledSleep(&sleepPolicy)
+	}
+	return nil
+}
+
+// ImportBlockListPeriodically imports periodically the block list at the given
+// 'source' in order to subscribe to a shared block list, see ImportBlockList.
+// The import frequency is set up by the given 'freq' input parameter.
+// It logs errors if the import failed.
+func (t *TwitterBot) ImportBlockListPeriodically(source string, mute bool, sleepPolicy SleepPolicy, freq time.Duration) {
+	t.newJob("ImportBlockListPeriodically").runPeriodically(freq, func() error {
+		return t.ImportBlockList(source, mute, sleepPolicy)
+	})
+}
+
+// ImportBlockListPeriodicallyAsync imports asynchronously and periodically the
+// block list at the given 'source', see ImportBlockListPeriodically.
+func (t *TwitterBot) ImportBlockListPeriodicallyAsync(source string, mute bool, sleepPolicy *SleepPolicy, freq time.Duration) *Job {
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	return t.runPeriodicallyAsync("ImportBlockListPeriodically", freq, func(ctx context.Context) error {
+		return t.ImportBlockList(source, mute, sleepPolicyCopy)
+	})
+}
+
+// ExportBlockList exports the users blocked, or muted if 'mute' is true, by the
+// bot to the given file 'filename', either as a JSON array of user ids if it
+// has a .json extension or as a CSV file with one user id per line otherwise.
+func (t *TwitterBot) ExportBlockList(filename string, mute bool) error {
+	ids := t.getBlockedIDs(mute)
+	if strings.EqualFold(path.Ext(filename), ".json") {
+		return tojson.Save(filename, ids)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	for _, id := range ids {
+		err = writer.Write([]string{strconv.FormatInt(id, 10)})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
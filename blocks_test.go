@@ -0,0 +1,76 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAutoMute(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.blocks = &twitterBlocks{
+		Blocked: map[string]int64{},
+		Muted:   map[string]int64{},
+	}
+	bot.banHits = map[int64]int{}
+	bot.SetAutoMute(2)
+
+	spammer := anaconda.User{Id: 1}
+	tweets := []anaconda.Tweet{
+		{Id: 10, Text: "buy now", User: spammer},
+		{Id: 11, Text: "hello", User: anaconda.User{Id: 2}},
+	}
+	c.Assert(bot.removeBanned(tweets, []string{"buy"}), HasLen, 1)
+	c.Assert(client.muted, HasLen, 0)
+	c.Assert(bot.removeBanned(tweets, []string{"buy"}), HasLen, 1)
+	c.Assert(client.muted, DeepEquals, []int64{1})
+	c.Assert(bot.GetMutedIDs(), DeepEquals, []int64{1})
+
+	// tweets of muted users are removed even without banned queries
+	allowed := bot.removeBanned(tweets, nil)
+	c.Assert(allowed, HasLen, 1)
+	c.Assert(allowed[0].Id, Equals, int64(11))
+	bot.removeBanned(tweets, []string{"buy"})
+	c.Assert(client.muted, HasLen, 1)
+}
+
+func (s *MySuite) TestParseBlockList(c *C) {
+	ids, err := parseBlockList("list.json", []byte("[1, 2, 3]"))
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 2, 3})
+
+	ids, err = parseBlockList("list.csv", []byte("id,name\n1,spammer\n 2 ,troll\n"))
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 2})
+
+	_, err = parseBlockList("list.json", []byte("not json"))
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestImportExportBlockList(c *C) {
+	dir := c.MkDir()
+	source := filepath.Join(dir, "import.csv")
+	c.Assert(ioutil.WriteFile(source, []byte("3\n1\n"), 0644), IsNil)
+
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.noSleep = true // no sleep between requests
+	bot.blocks = &twitterBlocks{
+		Blocked: map[string]int64{"1": 0},
+		Muted:   map[string]int64{},
+	}
+	c.Assert(bot.ImportBlockList(source, false, SleepPolicy{}), IsNil)
+	c.Assert(client.blocked, DeepEquals, []int64{3})
+
+	exported := filepath.Join(dir, "export.json")
+	c.Assert(bot.ExportBlockList(exported, false), IsNil)
+	data, err := ioutil.ReadFile(exported)
+	c.Assert(err, IsNil)
+	ids, err := parseBlockList(exported, data)
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 3})
+}
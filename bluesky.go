@@ -0,0 +1,234 @@
+package twbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	blueskyServer  = "https://bsky.social"
+	blueskyMaxSize = 300 // maximum characters of a post
+)
+
+// blueskySession is the authentication of a Bluesky account.
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	Did       string `json:"did"`
+}
+
+// blueskyPoster mirrors the posts of the bot to a Bluesky account.
+type blueskyPoster struct {
+	server   string
+	handle   string
+	password string
+	client   *http.Client
+	mutex    sync.Mutex
+	session  *blueskySession
+}
+
+// NewBlueskyCrossPoster returns a cross-poster publishing the posts of the bot
+// to the Bluesky account of the given handle, "bot.bsky.social" for instance,
+// authenticated by the given app password, created in the settings of the
+// account. The server is the one of the account, https://bsky.social if empty.
+// The image of a post is attached to it, otherwise its link is displayed as
+// a link card. The text is truncated to fit the 300 characters of a post.
+func NewBlueskyCrossPoster(server, handle, appPassword string) CrossPoster {
+	if server == "" {
+		server = blueskyServer
+	}
+	return &blueskyPoster{
+		server:   strings.TrimSuffix(server, "/"),
+		handle:   strings.TrimPrefix(handle, "@"),
+		password: appPassword,
+		client:   &http.Client{Timeout: crossPosterTimeout},
+	}
+}
+
+// blueskyError is the failure of a request to a Bluesky server.
+type blueskyError struct {
+	method  string
+	status  string
+	code    string
+	message string
+}
+
+func (e *blueskyError) Error() string {
+	return fmt.Sprintf("[twitter] bluesky request %s failed: %s %s %s", e.method, e.status, e.code, e.message)
+}
+
+// expired returns true if the session of the request expired or was revoked.
+func (e *blueskyError) expired() bool {
+	return e.code == "ExpiredToken" || e.code == "InvalidToken"
+}
+
+// CrossPost publishes the given post, creating a new session
+// and trying again once if the current one expired.
+func (b *blueskyPoster) setHTTPClient(client *http.Client) {
+	b.client = client
+}
+
+func (b *blueskyPoster) CrossPost(post Post) error {
+	session, err := b.getSession()
+	if err != nil {
+		return err
+	}
+	err = b.crossPost(session, post)
+	failure := &blueskyError{}
+	if !errors.As(err, &failure) || !failure.expired() {
+		return err
+	}
+	logWarn("[twitter] bluesky session expired, logging in again")
+	session, err = b.getSession()
+	if err != nil {
+		return err
+	}
+	return b.crossPost(session, post)
+}
+
+func (b *blueskyPoster) crossPost(session *blueskySession, post Post) error {
+	text := fitPost(post, blueskyMaxSize, 0)
+	record := map[string]interface{}{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": timeNow().UTC().Format(time.RFC3339),
+	}
+	if post.Link != "" && strings.HasSuffix(text, post.Link) {
+		// links are only clickable if declared as facets of byte offsets
+		record["facets"] = []interface{}{map[string]interface{}{
+			"index": map[string]int{
+				"byteStart": len(text) - len(post.Link),
+				"byteEnd":   len(text),
+			},
+			"features": []interface{}{map[string]string{
+				"$type": "app.bsky.richtext.facet#link",
+				"uri":   post.Link,
+			}},
+		}}
+	}
+	if len(post.Image) > 0 {
+		blob, err := b.uploadBlob(session, post.Image)
+		if err != nil {
+			return err
+		}
+		record["embed"] = map[string]interface{}{
+			"$type": "app.bsky.embed.images",
+			"images": []interface{}{map[string]interface{}{
+				"alt":   post.Text,
+				"image": blob,
+			}},
+		}
+	} else if post.Link != "" {
+		title := post.Link
+		if u, err := url.Parse(post.Link); err == nil && u.Host != "" {
+			title = u.Host
+		}
+		record["embed"] = map[string]interface{}{
+			"$type": "app.bsky.embed.external",
+			"external": map[string]string{
+				"uri":         post.Link,
+				"title":       title,
+				"description": post.Text,
+			},
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"repo":       session.Did,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	})
+	if err != nil {
+		return err
+	}
+	created := struct {
+		URI string `json:"uri"`
+	}{}
+	err = b.do("com.atproto.repo.createRecord", session, "application/json", data, &created)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] cross-posted to bluesky (uri: %s)", created.URI)
+	return nil
+}
+
+// getSession returns the session of the account, created at the first call
+// and after the expiration of the previous one.
+func (b *blueskyPoster) getSession() (*blueskySession, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.session != nil {
+		return b.session, nil
+	}
+	data, err := json.Marshal(map[string]string{
+		"identifier": b.handle,
+		"password":   b.password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	session := &blueskySession{}
+	err = b.do("com.atproto.server.createSession", nil, "application/json", data, session)
+	if err != nil {
+		return nil, err
+	}
+	b.session = session
+	return session, nil
+}
+
+// uploadBlob uploads the given image and returns its blob reference.
+func (b *blueskyPoster) uploadBlob(session *blueskySession, image []byte) (json.RawMessage, error) {
+	uploaded := struct {
+		Blob json.RawMessage `json:"blob"`
+	}{}
+	err := b.do("com.atproto.repo.uploadBlob", session, http.DetectContentType(image), image, &uploaded)
+	return uploaded.Blob, err
+}
+
+// do posts the given body to the given XRPC 'method' of the server,
+// authenticated by the given session if not nil, and decodes the JSON
+// response in 'data'. The session is dropped once expired, so that the
+// next call to getSession creates a new one.
+func (b *blueskyPoster) do(method string, session *blueskySession, contentType string, body []byte, data interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, b.server+"/xrpc/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		req.Header.Set("Authorization", "Bearer "+session.AccessJwt)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		failure := struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}{}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+		err := &blueskyError{
+			method:  method,
+			status:  resp.Status,
+			code:    failure.Error,
+			message: failure.Message,
+		}
+		if session != nil && err.expired() {
+			b.mutex.Lock()
+			if b.session == session {
+				b.session = nil
+			}
+			b.mutex.Unlock()
+		}
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(data)
+}
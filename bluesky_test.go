@@ -0,0 +1,98 @@
+package twbot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestBlueskyCrossPoster(c *C) {
+	sessions := 0
+	expired := false
+	records := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.server.createSession" && r.Header.Get("Authorization") != "Bearer jwt" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			login := map[string]string{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&login), IsNil)
+			c.Check(login, DeepEquals, map[string]string{"identifier": "bot.bsky.social", "password": "password"})
+			sessions++
+			w.Write([]byte(`{"accessJwt": "jwt", "did": "did:plc:bot"}`))
+		case "/xrpc/com.atproto.repo.uploadBlob":
+			data, err := ioutil.ReadAll(r.Body)
+			c.Assert(err, IsNil)
+			c.Check(string(data), Equals, "\x89PNG\r\n\x1a\n")
+			c.Check(r.Header.Get("Content-Type"), Equals, "image/png")
+			w.Write([]byte(`{"blob": {"$type": "blob", "ref": {"$link": "cid"}}}`))
+		case "/xrpc/com.atproto.repo.createRecord":
+			if expired {
+				expired = false
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "ExpiredToken", "message": "token has expired"}`))
+				return
+			}
+			request := map[string]interface{}{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&request), IsNil)
+			c.Check(request["repo"], Equals, "did:plc:bot")
+			records = append(records, request["record"].(map[string]interface{}))
+			w.Write([]byte(`{"uri": "at://did:plc:bot/app.bsky.feed.post/1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	poster := NewBlueskyCrossPoster(server.URL, "@bot.bsky.social", "password")
+	c.Assert(poster.CrossPost(Post{Text: "hello", Link: "https://example.com/a", Image: []byte("\x89PNG\r\n\x1a\n")}), IsNil)
+	c.Assert(poster.CrossPost(Post{Text: strings.Repeat("é", 400), Link: "https://example.com/b"}), IsNil)
+	c.Assert(sessions, Equals, 1)
+	c.Assert(records, HasLen, 2)
+
+	// the image is embedded and the link is a facet
+	c.Assert(records[0]["text"], Equals, "hello https://example.com/a")
+	embed := records[0]["embed"].(map[string]interface{})
+	c.Assert(embed["$type"], Equals, "app.bsky.embed.images")
+	facet := records[0]["facets"].([]interface{})[0].(map[string]interface{})
+	c.Assert(facet["index"], DeepEquals, map[string]interface{}{"byteStart": 6.0, "byteEnd": 27.0})
+
+	// the text is truncated and the link displayed as a card
+	text := records[1]["text"].(string)
+	c.Assert(len([]rune(text)), Equals, blueskyMaxSize)
+	c.Assert(strings.HasSuffix(text, "é… https://example.com/b"), Equals, true)
+	embed = records[1]["embed"].(map[string]interface{})
+	c.Assert(embed["$type"], Equals, "app.bsky.embed.external")
+	c.Assert(embed["external"].(map[string]interface{})["title"], Equals, "example.com")
+	facet = records[1]["facets"].([]interface{})[0].(map[string]interface{})
+	c.Assert(facet["index"].(map[string]interface{})["byteStart"], Equals, float64(len(text)-len("https://example.com/b")))
+
+	// an expired session is created again and the cross-post tried again
+	expired = true
+	c.Assert(poster.CrossPost(Post{Text: "renewed"}), IsNil)
+	c.Assert(sessions, Equals, 2)
+	c.Assert(records, HasLen, 3)
+	c.Assert(records[2]["text"], Equals, "renewed")
+
+	// the cross-post is tried again only once
+	expiredAgain := 2
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/xrpc/com.atproto.server.createSession" {
+			sessions++
+			w.Write([]byte(`{"accessJwt": "jwt", "did": "did:plc:bot"}`))
+			return
+		}
+		expiredAgain--
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "ExpiredToken", "message": "token has expired"}`))
+	})
+	c.Assert(poster.CrossPost(Post{Text: "expired"}), ErrorMatches, `.*ExpiredToken token has expired`)
+	c.Assert(expiredAgain, Equals, 0)
+	c.Assert(sessions, Equals, 3)
+}
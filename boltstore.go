@@ -0,0 +1,68 @@
+package twbot
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("twbot")
+
+// boltStore saves the databases in a single bbolt file, each database
+// being a JSON document of the same bucket keyed by its path.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens, or creates, the bbolt database file 'filename' as a
+// store of the bot, see SetStore. All the databases of the bot are kept in
+// this single embedded file, each save being a transactional update.
+func OpenBoltStore(filename string) (Store, error) {
+	db, err := bolt.Open(filename, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Exists(path string) (bool, error) {
+	exists := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket(boltBucket).Get([]byte(path)) != nil
+		return nil
+	})
+	return exists, err
+}
+
+func (s *boltStore) Load(path string, v interface{}) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get([]byte(path))
+		if data == nil {
+			return fmt.Errorf("[twitter] database %s not found in bolt store", path)
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+func (s *boltStore) Save(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(path), data)
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,126 @@
+package twbot
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxLastErrors is the number of failed actions kept by the bot stats.
+const maxLastErrors = 10
+
+// BotStats represents the live status of the bot, see Stats.
+type BotStats struct {
+	Time time.Time
+	// ActionsToday counts the actions done since midnight by action,
+	// ActionFollow for instance. FailuresToday counts the failed ones.
+	ActionsToday  map[string]int
+	FailuresToday map[string]int
+	// LastErrors holds the last failed actions, the most recent last.
+	LastErrors []Event
+	Followers  int
+	Friends    int
+	// PendingSaves is the number of databases waiting to be saved,
+	// see SetSaveBatching.
+	PendingSaves int
+	// Jobs holds the statistics of the running asynchronous jobs sorted
+	// by start time, with their next run and their queued items.
+	Jobs        []JobStats
+	CircuitOpen bool
+}
+
+// actionStats counts the actions of the day and keeps the last failed ones.
+type actionStats struct {
+	mutex      sync.Mutex
+	day        time.Time
+	actions    map[string]int
+	failures   map[string]int
+	lastErrors []Event
+}
+
+// reset resets the counters on a new day.
+// It must be called with the mutex locked.
+func (s *actionStats) reset(now time.Time) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	if s.actions != nil && today.Equal(s.day) {
+		return
+	}
+	s.day = today
+	s.actions = map[string]int{}
+	s.failures = map[string]int{}
+}
+
+// record counts the given 'action' reported by the given event.
+func (s *actionStats) record(action string, event Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reset(timeNow())
+	if !event.Failed() {
+		s.actions[action]++
+		return
+	}
+	s.failures[action]++
+	s.lastErrors = append(s.lastErrors, event)
+	if len(s.lastErrors) > maxLastErrors {
+		s.lastErrors = s.lastErrors[len(s.lastErrors)-maxLastErrors:]
+	}
+}
+
+func (t *TwitterBot) addJob(job *Job) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.jobs == nil {
+		t.jobs = map[*Job]struct{}{}
+	}
+	t.jobs[job] = struct{}{}
+}
+
+func (t *TwitterBot) removeJob(job *Job) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.jobs, job)
+}
+
+// Stats returns the live status of the bot: the actions of the day, the
+// last errors, the number of followers and friends, the pending saves and
+// the running jobs, suitable for health pages and reports.
+func (t *TwitterBot) Stats() BotStats {
+	stats := BotStats{
+		Time:          timeNow(),
+		ActionsToday:  map[string]int{},
+		FailuresToday: map[string]int{},
+		CircuitOpen:   t.CircuitOpen(),
+	}
+	t.actions.mutex.Lock()
+	t.actions.reset(stats.Time)
+	for action, count := range t.actions.actions {
+		stats.ActionsToday[action] = count
+	}
+	for action, count := range t.actions.failures {
+		stats.FailuresToday[action] = count
+	}
+	stats.LastErrors = append(stats.LastErrors, t.actions.lastErrors...)
+	t.actions.mutex.Unlock()
+
+	t.mutex.Lock()
+	stats.Followers = countFollowing(t.followers)
+	stats.Friends = countFollowing(t.friends)
+	store := t.store
+	jobs := make([]*Job, 0, len(t.jobs))
+	for job := range t.jobs {
+		jobs = append(jobs, job)
+	}
+	t.mutex.Unlock()
+
+	if batch, ok := store.(*batchStore); ok {
+		stats.PendingSaves = batch.pendingCount()
+	}
+	for _, job := range jobs {
+		stats.Jobs = append(stats.Jobs, job.Stats())
+	}
+	sort.Slice(stats.Jobs, func(i, j int) bool {
+		return stats.Jobs[i].Started.Before(stats.Jobs[j].Started)
+	})
+	return stats
+}
@@ -0,0 +1,35 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestBotStats(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(403, twitterErrorTweetTooLong),
+		failures:   1,
+	}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.friends.Ids["1"] = &twitterUser{Follow: true}
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), NotNil)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	job := bot.TweetPeriodicallyAsync(func() (string, error) { return "hello", nil }, time.Hour)
+
+	stats := bot.Stats()
+	c.Assert(stats.ActionsToday, DeepEquals, map[string]int{ActionTweet: 1})
+	c.Assert(stats.FailuresToday, DeepEquals, map[string]int{ActionTweet: 1})
+	c.Assert(stats.LastErrors, HasLen, 1)
+	c.Assert(stats.LastErrors[0].Action, Equals, "tweet_failed")
+	c.Assert(stats.Friends, Equals, 1)
+	c.Assert(stats.Followers, Equals, 0)
+	c.Assert(stats.Jobs, HasLen, 1)
+	c.Assert(stats.Jobs[0].Name, Equals, "TweetPeriodically")
+
+	job.Stop()
+	<-job.Done()
+	c.Assert(bot.Stats().Jobs, HasLen, 0)
+}
@@ -0,0 +1,148 @@
+package twbot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerMaxFailures = 5
+	defaultBreakerCooldown    = 15 * time.Minute
+	breakerProbeWait          = time.Second // wait of the calls while the recovery is probed
+)
+
+type circuitBreaker struct {
+	mutex       sync.Mutex
+	maxFailures int
+	cooldown    time.Duration
+	failures    int       // consecutive failures
+	openUntil   time.Time // calls are suspended until then
+	open        bool
+	probing     bool // a call is probing the recovery
+}
+
+// SetCircuitBreaker sets the circuit breaker protecting the account while
+// something is wrong: after 'maxFailures' consecutive failed calls to the
+// twitter API, like server errors, timeouts or rate limits, or as soon as
+// the account is locked or the token expired, all the calls are suspended
+// for the 'cooldown' duration. A single call then probes the recovery:
+// the calls resume if it succeeds, otherwise they are suspended again.
+// The owner is alerted when the calls are suspended and resumed, see SetOwner.
+// A zero 'maxFailures' disables the circuit breaker, resuming the suspended
+// calls if any. By default, the calls are suspended for 15 minutes after
+// 5 consecutive failures.
+func (t *TwitterBot) SetCircuitBreaker(maxFailures int, cooldown time.Duration) {
+	logInfo("[twitter] setting circuit breaker -> maxFailures: %d, cooldown: %s", maxFailures, cooldown)
+	t.breaker.mutex.Lock()
+	defer t.breaker.mutex.Unlock()
+	t.breaker.maxFailures = maxFailures
+	t.breaker.cooldown = cooldown
+	if maxFailures <= 0 {
+		// resume the calls suspended by the disabled breaker
+		t.breaker.failures = 0
+		t.breaker.open = false
+		t.breaker.probing = false
+	}
+}
+
+// CircuitOpen returns true if the calls to the twitter API
+// are suspended by the circuit breaker, see SetCircuitBreaker.
+func (t *TwitterBot) CircuitOpen() bool {
+	t.breaker.mutex.Lock()
+	defer t.breaker.mutex.Unlock()
+	return t.breaker.open
+}
+
+// isBreakerFailure returns true if the given error
+// is counted as a failure by the circuit breaker.
+func isBreakerFailure(err error) bool {
+	return isTransientError(err) ||
+		errors.Is(wrapAPIError(err), ErrRateLimited) ||
+		isBreakerTrip(err)
+}
+
+// isBreakerTrip returns true if the given error opens the circuit breaker at once.
+func isBreakerTrip(err error) bool {
+	err = wrapAPIError(err)
+	return errors.Is(err, ErrAccountLocked) || errors.Is(err, ErrTokenExpired)
+}
+
+// delay returns how long to wait before the next call, 0 if it can be sent
+// right away. Once the cooldown is over, the first call probes the recovery.
+func (b *circuitBreaker) delay(now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.maxFailures <= 0 || !b.open {
+		return 0
+	}
+	if now.Before(b.openUntil) {
+		return b.openUntil.Sub(now)
+	}
+	if b.probing {
+		return breakerProbeWait
+	}
+	b.probing = true
+	return 0
+}
+
+// record records the result of a call. It returns true as first value if
+// the calls are suspended by this result and as second value if they resume.
+func (b *circuitBreaker) record(err error, now time.Time) (bool, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.maxFailures <= 0 {
+		return false, false
+	}
+	wasOpen := b.open
+	b.probing = false
+	if err == nil || !isBreakerFailure(err) {
+		b.failures = 0
+		b.open = false
+		return false, wasOpen
+	}
+	b.failures++
+	if b.failures < b.maxFailures && !isBreakerTrip(err) && !wasOpen {
+		return false, false
+	}
+	b.open = true
+	b.openUntil = now.Add(b.cooldown)
+	return !wasOpen, false
+}
+
+// waitCircuit waits while the calls are suspended by the circuit breaker.
+// It returns false if the bot is stopped.
+func (c *apiClient) waitCircuit() bool {
+	for {
+		delay := c.bot.breaker.delay(timeNow())
+		if delay <= 0 {
+			return true
+		}
+		if !sleepContext(c.bot.botContext(), delay) {
+			return false
+		}
+	}
+}
+
+// recordCall records the result of a call in the circuit breaker
+// and in the health of the bot.
+func (c *apiClient) recordCall(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	c.bot.health.record(err, timeNow())
+	opened, closed := c.bot.breaker.record(err, timeNow())
+	if opened {
+		c.bot.alert("calls to the twitter API suspended for %s after repeated failures: %v", c.bot.getBreakerCooldown(), err)
+	}
+	if closed {
+		c.bot.alert("calls to the twitter API resumed")
+	}
+}
+
+func (t *TwitterBot) getBreakerCooldown() time.Duration {
+	t.breaker.mutex.Lock()
+	defer t.breaker.mutex.Unlock()
+	return t.breaker.cooldown
+}
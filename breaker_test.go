@@ -0,0 +1,88 @@
+package twbot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestCircuitBreaker(c *C) {
+	breaker := &circuitBreaker{maxFailures: 2, cooldown: time.Minute}
+	now := time.Now()
+	failure := makeAPIError(503, 0)
+
+	// errors which are not failures reset the count
+	c.Assert(isBreakerFailure(errors.New("failed")), Equals, false)
+	opened, _ := breaker.record(failure, now)
+	c.Assert(opened, Equals, false)
+	breaker.record(makeAPIError(403, anaconda.TwitterErrorStatusIsADuplicate), now)
+	opened, _ = breaker.record(failure, now)
+	c.Assert(opened, Equals, false)
+	c.Assert(breaker.delay(now), Equals, time.Duration(0))
+
+	// consecutive failures suspend the calls for the cooldown
+	opened, _ = breaker.record(failure, now)
+	c.Assert(opened, Equals, true)
+	c.Assert(breaker.delay(now), Equals, time.Minute)
+
+	// a single call probes the recovery, the others wait
+	later := now.Add(time.Minute)
+	c.Assert(breaker.delay(later), Equals, time.Duration(0))
+	c.Assert(breaker.delay(later), Equals, breakerProbeWait)
+	opened, closed := breaker.record(failure, later)
+	c.Assert(opened, Equals, false)
+	c.Assert(closed, Equals, false)
+	c.Assert(breaker.delay(later), Equals, time.Minute)
+
+	later = later.Add(time.Minute)
+	c.Assert(breaker.delay(later), Equals, time.Duration(0))
+	_, closed = breaker.record(nil, later)
+	c.Assert(closed, Equals, true)
+	c.Assert(breaker.delay(later), Equals, time.Duration(0))
+
+	// a locked account suspends the calls at once
+	opened, _ = breaker.record(makeAPIError(403, twitterErrorAccountLocked), later)
+	c.Assert(opened, Equals, true)
+}
+
+func (s *MySuite) TestCircuitBreakerAlerts(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(403, twitterErrorAccountLocked),
+		failures:   1,
+	}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.SetOwner("owner")
+	bot.SetCircuitBreaker(3, time.Millisecond)
+
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), NotNil)
+	c.Assert(bot.CircuitOpen(), Equals, true)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(bot.CircuitOpen(), Equals, false)
+	// the alerts are sent even while the calls are suspended
+	c.Assert(client.messages, HasLen, 2)
+}
+
+func (s *MySuite) TestCircuitBreakerDisabled(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(403, twitterErrorAccountLocked),
+		failures:   1,
+	}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.SetCircuitBreaker(3, time.Hour)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), NotNil)
+	c.Assert(bot.CircuitOpen(), Equals, true)
+
+	// disabling an open breaker, even while probing, resumes the calls
+	bot.breaker.probing = true
+	bot.SetCircuitBreaker(0, time.Hour)
+	c.Assert(bot.CircuitOpen(), Equals, false)
+	c.Assert(bot.breaker.delay(timeNow()), Equals, time.Duration(0))
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"hello"})
+}
@@ -0,0 +1,243 @@
+package twbot
+
+import (
+	"context"
+	"sync"
+)
+
+const campaignSaveEvery = 20 // candidates processed between two saves of the campaign progress
+
+type campaignProgress struct {
+	Ids      []int64 `json:"ids"`      // users to follow
+	Position int     `json:"position"` // index of the next user to follow
+}
+
+type twitterCampaigns struct {
+	Campaigns map[string]*campaignProgress `json:"campaigns"` // map campaign key -> progress
+}
+
+// Campaign represents a running follow or unfollow campaign. It allows
+// to pause, resume or stop the campaign and to follow its progress.
+// A stopped campaign cannot be resumed.
+type Campaign struct {
+	*Job
+	key       string // identifies the campaign in database, if persisted
+	source    string // origin of the followed users, see CampaignStats
+	mutex     sync.Mutex
+	paused    bool
+	resume    chan struct{}
+	processed int
+	remaining int
+}
+
+// newCampaign creates a campaign stopped when the bot is stopped, see Stop.
+func (t *TwitterBot) newCampaign(name string) *Campaign {
+	return newCampaignContext(t.botContext(), name)
+}
+
+// newCampaignContext creates a campaign stopped when the given context is done.
+func newCampaignContext(ctx context.Context, name string) *Campaign {
+	campaign := &Campaign{
+		Job: newJob(ctx, name),
+	}
+	campaign.queue = campaign.Remaining
+	return campaign
+}
+
+// Pause pauses the campaign after the current request.
+func (c *Campaign) Pause() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.paused || c.Stopped() {
+		return
+	}
+	c.paused = true
+	c.resume = make(chan struct{})
+}
+
+// Resume resumes a paused campaign.
+func (c *Campaign) Resume() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	close(c.resume)
+}
+
+// Paused returns true if the campaign is paused.
+func (c *Campaign) Paused() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.paused
+}
+
+// Source returns the origin of the users followed by the campaign,
+// as reported by CampaignStats.
+func (c *Campaign) Source() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.source
+}
+
+func (c *Campaign) setSource(source string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.source = source
+}
+
+// Processed returns the number of users followed or unfollowed so far.
+func (c *Campaign) Processed() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.processed
+}
+
+// Remaining returns the number of users remaining to be followed.
+// It is always 0 for unfollow campaigns since friends to unfollow
+// are discovered on the fly.
+func (c *Campaign) Remaining() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.remaining
+}
+
+func (c *Campaign) setRemaining(remaining int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.remaining = remaining
+}
+
+func (c *Campaign) done() {
+	c.mutex.Lock()
+	c.processed++
+	c.mutex.Unlock()
+	c.record(nil)
+}
+
+// wait blocks while the campaign is paused. It returns
+// false if the campaign is stopped, true otherwise.
+func (c *Campaign) wait() bool {
+	for {
+		c.mutex.Lock()
+		if c.Stopped() {
+			c.mutex.Unlock()
+			return false
+		}
+		if !c.paused {
+			c.mutex.Unlock()
+			return true
+		}
+		resume := c.resume
+		c.mutex.Unlock()
+		select {
+		case <-resume:
+		case <-c.ctx.Done():
+		}
+	}
+}
+
+// SetCampaignsPath sets the path of the campaigns database, where the users
+// to follow and the progress of the large follow campaigns (followers of users)
+// are saved so that a restarted bot resumes the campaigns where it left off.
+// If no path is set, the campaigns progress is not persisted.
+func (t *TwitterBot) SetCampaignsPath(campaignsPath string) error {
+	campaigns := &twitterCampaigns{
+		Campaigns: make(map[string]*campaignProgress),
+	}
+	err := t.loadOrCreate(campaignsPath, campaigns)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.campaignsPath = campaignsPath
+	t.campaigns = campaigns
+	return nil
+}
+
+// saveCampaigns must be called with the bot mutex locked.
+func (t *TwitterBot) saveCampaigns() {
+	err := t.store.Save(t.campaignsPath, t.campaigns)
+	if err != nil {
+		SubsystemStore.error("%v", err)
+	}
+}
+
+func (t *TwitterBot) isPersisted(campaign *Campaign) bool {
+	return campaign.key != "" && t.campaignsPath != ""
+}
+
+// startProgress saves the users to follow of the campaign.
+func (t *TwitterBot) startProgress(campaign *Campaign, ids []int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.isPersisted(campaign) {
+		return
+	}
+	t.campaigns.Campaigns[campaign.key] = &campaignProgress{
+		Ids: ids,
+	}
+	t.saveCampaigns()
+}
+
+// saveProgress updates the position of the next user to follow of the campaign.
+// The campaigns database is only saved every 'campaignSaveEvery' users, a
+// resumed campaign skipping the users already followed anyway.
+func (t *TwitterBot) saveProgress(campaign *Campaign, position int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.isPersisted(campaign) {
+		return
+	}
+	progress, ok := t.campaigns.Campaigns[campaign.key]
+	if !ok {
+		return
+	}
+	progress.Position = position
+	if position%campaignSaveEvery == 0 {
+		t.saveCampaigns()
+	}
+}
+
+// flushProgress saves the position of the next user to follow of the
+// interrupted campaign.
+func (t *TwitterBot) flushProgress(campaign *Campaign) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.isPersisted(campaign) {
+		return
+	}
+	if _, ok := t.campaigns.Campaigns[campaign.key]; ok {
+		t.saveCampaigns()
+	}
+}
+
+// endProgress removes the finished campaign from database.
+func (t *TwitterBot) endProgress(campaign *Campaign) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.isPersisted(campaign) {
+		return
+	}
+	delete(t.campaigns.Campaigns, campaign.key)
+	t.saveCampaigns()
+}
+
+// resumeProgress resumes the campaign from database if any.
+// It returns false if there is no campaign to resume.
+func (t *TwitterBot) resumeProgress(sleepPolicy *SleepPolicy, campaign *Campaign) bool {
+	t.mutex.Lock()
+	var progress *campaignProgress
+	if t.isPersisted(campaign) {
+		progress = t.campaigns.Campaigns[campaign.key]
+	}
+	t.mutex.Unlock()
+	if progress == nil {
+		return false
+	}
+	logInfo("[twitter] resuming campaign '%s' at %d/%d", campaign.key, progress.Position, len(progress.Ids))
+	t.followCandidates(progress.Ids, progress.Position, sleepPolicy, campaign)
+	return true
+}
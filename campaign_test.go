@@ -0,0 +1,66 @@
+package twbot
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestCampaign(c *C) {
+	campaign := newCampaignContext(context.Background(), "test")
+	c.Assert(campaign.wait(), Equals, true)
+
+	campaign.Pause()
+	c.Assert(campaign.Paused(), Equals, true)
+	resumed := make(chan bool)
+	go func() {
+		resumed <- campaign.wait()
+	}()
+	select {
+	case <-resumed:
+		c.Fatal("paused campaign should block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	campaign.Resume()
+	c.Assert(<-resumed, Equals, true)
+
+	campaign.Pause()
+	go func() {
+		resumed <- campaign.wait()
+	}()
+	campaign.Stop()
+	c.Assert(<-resumed, Equals, false)
+	c.Assert(campaign.Stopped(), Equals, true)
+	c.Assert(campaign.wait(), Equals, false)
+}
+
+func (s *MySuite) TestCampaignProgress(c *C) {
+	path := filepath.Join(c.MkDir(), "campaigns.json")
+	bot := makeFakeBot(&fakeClient{})
+	c.Assert(bot.SetCampaignsPath(path), IsNil)
+
+	campaign := newCampaignContext(context.Background(), "test")
+	campaign.key = "followers:golang:1"
+	bot.startProgress(campaign, []int64{1, 2, 3})
+	bot.saveProgress(campaign, 2)
+
+	// the progress is saved by batch
+	restarted := makeFakeBot(&fakeClient{})
+	restarted.store = bot.store
+	c.Assert(restarted.SetCampaignsPath(path), IsNil)
+	c.Assert(restarted.campaigns.Campaigns[campaign.key].Position, Equals, 0)
+	bot.flushProgress(campaign)
+
+	// a restarted bot loads the saved progress
+	c.Assert(restarted.SetCampaignsPath(path), IsNil)
+	progress, ok := restarted.campaigns.Campaigns[campaign.key]
+	c.Assert(ok, Equals, true)
+	c.Assert(progress.Ids, DeepEquals, []int64{1, 2, 3})
+	c.Assert(progress.Position, Equals, 2)
+
+	restarted.endProgress(campaign)
+	c.Assert(restarted.SetCampaignsPath(path), IsNil)
+	c.Assert(restarted.campaigns.Campaigns, HasLen, 0)
+}
@@ -0,0 +1,301 @@
+package twbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	"github.com/garyburd/go-oauth/oauth"
+)
+
+const (
+	twitterAPIURL    = "https://api.twitter.com/1.1"
+	maxDMEventsCount = 50 // twitter API limit of the direct message events pages
+)
+
+// TwitterClient is the subset of the anaconda twitter API used by the bot.
+// It allows to test the bot, and the applications built on it, against a
+// fake implementation, see MakeTwitterBotWithClient and SetTwitterClient.
+// A fake can embed the interface and implement only the methods it needs.
+type TwitterClient interface {
+	Close()
+	AccountUpdateProfileBanner(img string, v url.Values) error
+	BlockUserId(id int64, v url.Values) (anaconda.User, error)
+	UnblockUserId(id int64, v url.Values) (anaconda.User, error)
+	MuteUserId(id int64, v url.Values) (anaconda.User, error)
+	UnmuteUserId(id int64, v url.Values) (anaconda.User, error)
+	Favorite(id int64) (anaconda.Tweet, error)
+	Unfavorite(id int64) (anaconda.Tweet, error)
+	FollowUserId(userID int64, v url.Values) (anaconda.User, error)
+	UnfollowUserId(userID int64) (anaconda.User, error)
+	GetDirectMessages(v url.Values) ([]anaconda.DirectMessage, error)
+	GetFavorites(v url.Values) ([]anaconda.Tweet, error)
+	GetFollowersIds(v url.Values) (anaconda.Cursor, error)
+	GetFriendsIds(v url.Values) (anaconda.Cursor, error)
+	GetSuggestedCategories(v url.Values) ([]SuggestedCategory, error)
+	GetSuggestedUsers(slug string, v url.Values) ([]anaconda.User, error)
+	GetFollowersUser(id int64, v url.Values) (anaconda.Cursor, error)
+	GetFriendshipsOutgoing(v url.Values) (anaconda.Cursor, error)
+	GetSelf(v url.Values) (anaconda.User, error)
+	GetMentionsTimeline(v url.Values) ([]anaconda.Tweet, error)
+	GetRetweets(id int64, v url.Values) ([]anaconda.Tweet, error)
+	GetRetweetsOfMe(v url.Values) ([]anaconda.Tweet, error)
+	GetSearch(queryString string, v url.Values) (anaconda.SearchResponse, error)
+	GetUserSearch(searchTerm string, v url.Values) ([]anaconda.User, error)
+	GetUsersLookup(usernames string, v url.Values) ([]anaconda.User, error)
+	GetUsersLookupByIds(ids []int64, v url.Values) ([]anaconda.User, error)
+	GetUsersShow(username string, v url.Values) (anaconda.User, error)
+	GetUserTimeline(v url.Values) ([]anaconda.Tweet, error)
+	PostDMToScreenName(text, screenName string) (anaconda.DirectMessage, error)
+	PostDMToUserId(text string, userID int64) (anaconda.DirectMessage, error)
+	PostTweet(status string, v url.Values) (anaconda.Tweet, error)
+	PublicStreamFilter(v url.Values) *anaconda.Stream
+	Retweet(id int64, trimUser bool) (anaconda.Tweet, error)
+	UploadMedia(base64String string) (anaconda.Media, error)
+	UserStream(v url.Values) *anaconda.Stream
+}
+
+// SetTwitterClient sets the client calling the twitter API, a fake for
+// instance in unit tests, and closes the previous one. The calls go through
+// the retry policy, the circuit breaker, the hooks and the dry-run mode of
+// the bot like with the default anaconda client, without its rate limit
+// tracking. The in-flight calls are done with the previous client, closed
+// once they return.
+func (t *TwitterBot) SetTwitterClient(client TwitterClient) {
+	logInfo("[twitter] setting twitter client")
+	t.setClient(client, nil)
+}
+
+// SuggestedCategory represents a category of users suggested by twitter.
+type SuggestedCategory struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Size int    `json:"size"`
+}
+
+type suggestedMembers struct {
+	Users []anaconda.User `json:"users"`
+}
+
+// anacondaClient extends the anaconda twitter API with the endpoints
+// it does not provide, whose requests are signed by the bot itself, so
+// that the bot builds against the upstream anaconda package.
+type anacondaClient struct {
+	*anaconda.TwitterApi
+	oauthClient oauth.Client
+	mutex       sync.Mutex
+	selfID      int64 // id of the authenticated user, once known
+}
+
+func newAnacondaClient(consumerKey, consumerSecret, accessToken, accessSecret string) *anacondaClient {
+	return &anacondaClient{
+		TwitterApi: anaconda.NewTwitterApiWithCredentials(accessToken, accessSecret, consumerKey, consumerSecret),
+		oauthClient: oauth.Client{
+			Credentials: oauth.Credentials{
+				Token:  consumerKey,
+				Secret: consumerSecret,
+			},
+		},
+	}
+}
+
+// get sends a signed GET request to the given twitter API 'path'
+// and decodes the JSON response in 'data'.
+func (c *anacondaClient) get(path string, v url.Values, data interface{}) error {
+	resp, err := c.oauthClient.Get(c.HttpClient, c.Credentials, twitterAPIURL+path, v)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+// AccountUpdateProfileBanner updates the profile banner
+// of the account with the given base64 encoded image.
+func (c *anacondaClient) AccountUpdateProfileBanner(img string, v url.Values) error {
+	resp, err := c.oauthClient.Post(c.HttpClient, c.Credentials, twitterAPIURL+"/account/update_profile_banner.json",
+		withValue(v, "banner", img))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	return nil
+}
+
+// GetSuggestedCategories returns the categories of suggested users.
+func (c *anacondaClient) GetSuggestedCategories(v url.Values) ([]SuggestedCategory, error) {
+	categories := []SuggestedCategory{}
+	err := c.get("/users/suggestions.json", v, &categories)
+	return categories, err
+}
+
+// GetSuggestedUsers returns the users suggested in the category of the given 'slug'.
+func (c *anacondaClient) GetSuggestedUsers(slug string, v url.Values) ([]anaconda.User, error) {
+	members := suggestedMembers{}
+	err := c.get("/users/suggestions/"+url.PathEscape(slug)+".json", v, &members)
+	return members.Users, err
+}
+
+// post sends a signed POST request with the given JSON 'body' to the
+// given twitter API 'path' and decodes the JSON response in 'data'.
+func (c *anacondaClient) post(path string, body, data interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, twitterAPIURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	err = c.oauthClient.SetAuthorizationHeader(req.Header, c.Credentials, req.Method, req.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+// dmEvent represents a direct message event of the twitter API.
+type dmEvent struct {
+	Type             string `json:"type"`
+	ID               string `json:"id,omitempty"`
+	CreatedTimestamp string `json:"created_timestamp,omitempty"` // in milliseconds
+	MessageCreate    struct {
+		Target struct {
+			RecipientID string `json:"recipient_id"`
+		} `json:"target"`
+		SenderID    string `json:"sender_id,omitempty"`
+		MessageData struct {
+			Text string `json:"text"`
+		} `json:"message_data"`
+	} `json:"message_create"`
+}
+
+// directMessage converts the event to the direct message
+// returned by the legacy direct messages API.
+func (e *dmEvent) directMessage() anaconda.DirectMessage {
+	dm := anaconda.DirectMessage{
+		IdStr: e.ID,
+		Text:  e.MessageCreate.MessageData.Text,
+	}
+	dm.Id, _ = strconv.ParseInt(e.ID, 10, 64)
+	dm.SenderId, _ = strconv.ParseInt(e.MessageCreate.SenderID, 10, 64)
+	dm.RecipientId, _ = strconv.ParseInt(e.MessageCreate.Target.RecipientID, 10, 64)
+	timestamp, err := strconv.ParseInt(e.CreatedTimestamp, 10, 64)
+	if err == nil {
+		dm.CreatedAt = time.Unix(0, timestamp*int64(time.Millisecond)).UTC().Format(time.RubyDate)
+	}
+	return dm
+}
+
+// PostDMToUserId sends a direct message to the user of the given id
+// through the direct message events API.
+func (c *anacondaClient) PostDMToUserId(text string, userID int64) (anaconda.DirectMessage, error) {
+	request := struct {
+		Event dmEvent `json:"event"`
+	}{}
+	request.Event.Type = "message_create"
+	request.Event.MessageCreate.Target.RecipientID = strconv.FormatInt(userID, 10)
+	request.Event.MessageCreate.MessageData.Text = text
+	response := struct {
+		Event dmEvent `json:"event"`
+	}{}
+	err := c.post("/direct_messages/events/new.json", &request, &response)
+	if err != nil {
+		return anaconda.DirectMessage{}, err
+	}
+	return response.Event.directMessage(), nil
+}
+
+// PostDMToScreenName sends a direct message to the user of the given
+// screen name through the direct message events API.
+func (c *anacondaClient) PostDMToScreenName(text, screenName string) (anaconda.DirectMessage, error) {
+	user, err := c.GetUsersShow(screenName, nil)
+	if err != nil {
+		return anaconda.DirectMessage{}, err
+	}
+	dm, err := c.PostDMToUserId(text, user.Id)
+	dm.RecipientScreenName = user.ScreenName
+	return dm, err
+}
+
+// getSelfID returns the id of the authenticated user.
+func (c *anacondaClient) getSelfID() (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.selfID != 0 {
+		return c.selfID, nil
+	}
+	self, err := c.GetSelf(nil)
+	if err != nil {
+		return 0, err
+	}
+	c.selfID = self.Id
+	return c.selfID, nil
+}
+
+// GetDirectMessages returns the direct messages received by the authenticated
+// user, the most recent first, through the direct message events API. Like the
+// legacy direct messages API, it honours the "count" and "since_id" values,
+// the events of the last 30 days only being available.
+func (c *anacondaClient) GetDirectMessages(v url.Values) ([]anaconda.DirectMessage, error) {
+	selfID, err := c.getSelfID()
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(v.Get("count"))
+	sinceID, _ := strconv.ParseInt(v.Get("since_id"), 10, 64)
+	messages := []anaconda.DirectMessage{}
+	values := url.Values{}
+	values.Set("count", strconv.Itoa(maxDMEventsCount))
+	for {
+		page := struct {
+			Events     []dmEvent `json:"events"`
+			NextCursor string    `json:"next_cursor"`
+		}{}
+		err := c.get("/direct_messages/events/list.json", values, &page)
+		if err != nil {
+			return messages, err
+		}
+		for _, event := range page.Events {
+			if event.Type != "message_create" {
+				continue
+			}
+			dm := event.directMessage()
+			if dm.Id <= sinceID {
+				return messages, nil
+			}
+			// the events include the direct messages sent by the user
+			if dm.SenderId == selfID {
+				continue
+			}
+			messages = append(messages, dm)
+			if count > 0 && len(messages) >= count {
+				return messages, nil
+			}
+		}
+		if page.NextCursor == "" {
+			return messages, nil
+		}
+		values.Set("cursor", page.NextCursor)
+	}
+}
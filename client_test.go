@@ -0,0 +1,306 @@
+package twbot
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// fakeClient is a fake twitter API client. Calling a method that is not
+// overridden panics since the embedded interface is nil.
+type fakeClient struct {
+	TwitterClient
+	users       map[string]anaconda.User
+	followers   map[int64][]int64 // map user id -> followers ids
+	pageSize    int
+	failAt      string // cursor at which GetFollowersUser fails
+	cursors     []string
+	muted       []int64
+	blocked     []int64
+	lookups     []int // sizes of the users lookup batches
+	myFollowers []int64
+	myFriends   []int64
+	idsErr      error // error returned by the ids pages
+	geocode     string
+	messages    []string // direct messages sent
+	received    []anaconda.DirectMessage
+	mentions    []anaconda.Tweet
+	tweets      []string          // tweets posted
+	replies     map[string]string // map tweet id -> in_reply_to_status_id
+	retweeted   []int64
+	retweets    map[int64][]anaconda.Tweet // map tweet id -> retweets
+	followed    []int64
+	followErr   error            // error returned by the follows
+	found       []anaconda.Tweet // search results, if any
+	liked       []int64
+	favorites   []anaconda.Tweet // sorted from the most recent to the oldest one
+	favsErr     error            // error returned by the favorites pages but the first one
+	unliked     []int64
+	unfollowed  []int64
+	retweetedMe []anaconda.Tweet // tweets of the bot retweeted by others
+}
+
+// idsPage returns the page of the given ids at the cursor of 'v', the
+// first half of the ids then the second half, failing with 'idsErr' at
+// the second page if any.
+func (f *fakeClient) idsPage(ids []int64, v url.Values) (anaconda.Cursor, error) {
+	if v.Get("cursor") == "-1" {
+		return anaconda.Cursor{Ids: ids[:len(ids)/2], Next_cursor_str: "1"}, nil
+	}
+	if f.idsErr != nil {
+		return anaconda.Cursor{}, f.idsErr
+	}
+	return anaconda.Cursor{Ids: ids[len(ids)/2:], Next_cursor_str: "0"}, nil
+}
+
+func (f *fakeClient) GetFollowersIds(v url.Values) (anaconda.Cursor, error) {
+	return f.idsPage(f.myFollowers, v)
+}
+
+func (f *fakeClient) GetFriendsIds(v url.Values) (anaconda.Cursor, error) {
+	return f.idsPage(f.myFriends, v)
+}
+
+// GetUsersLookupByIds returns a user named after its id
+// for each id, except for negative ids.
+func (f *fakeClient) GetUsersLookupByIds(ids []int64, v url.Values) ([]anaconda.User, error) {
+	f.lookups = append(f.lookups, len(ids))
+	users := []anaconda.User{}
+	for _, id := range ids {
+		if id >= 0 {
+			users = append(users, anaconda.User{Id: id, ScreenName: fmt.Sprintf("user%d", id)})
+		}
+	}
+	return users, nil
+}
+
+// GetUsersLookup returns the known users among the comma separated screen names.
+func (f *fakeClient) GetUsersLookup(usernames string, v url.Values) ([]anaconda.User, error) {
+	users := []anaconda.User{}
+	for _, username := range strings.Split(usernames, ",") {
+		if user, ok := f.users[username]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeClient) BlockUserId(id int64, v url.Values) (anaconda.User, error) {
+	f.blocked = append(f.blocked, id)
+	return anaconda.User{Id: id}, nil
+}
+
+func (f *fakeClient) MuteUserId(id int64, v url.Values) (anaconda.User, error) {
+	f.muted = append(f.muted, id)
+	return anaconda.User{Id: id}, nil
+}
+
+func (f *fakeClient) GetUsersShow(username string, v url.Values) (anaconda.User, error) {
+	user, ok := f.users[username]
+	if !ok {
+		return user, fmt.Errorf("user %s not found", username)
+	}
+	return user, nil
+}
+
+// GetUserSearch returns the user of the searched screen name if known.
+func (f *fakeClient) GetUserSearch(searchTerm string, v url.Values) ([]anaconda.User, error) {
+	users := []anaconda.User{}
+	if user, ok := f.users[searchTerm]; ok {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// GetFollowersUser returns pages of 'pageSize' ids where the cursor
+// is the index of the first id of the page.
+func (f *fakeClient) GetFollowersUser(id int64, v url.Values) (anaconda.Cursor, error) {
+	cursor := v.Get("cursor")
+	f.cursors = append(f.cursors, cursor)
+	if cursor == f.failAt {
+		return anaconda.Cursor{}, fmt.Errorf("failed at cursor %s", cursor)
+	}
+	start := 0
+	if cursor != "-1" {
+		fmt.Sscanf(cursor, "%d", &start)
+	}
+	ids := f.followers[id]
+	end := start + f.pageSize
+	next := fmt.Sprintf("%d", end)
+	if end >= len(ids) {
+		end = len(ids)
+		next = "0"
+	}
+	return anaconda.Cursor{
+		Ids:             ids[start:end],
+		Next_cursor_str: next,
+	}, nil
+}
+
+func (f *fakeClient) GetSuggestedCategories(v url.Values) ([]SuggestedCategory, error) {
+	return []SuggestedCategory{{Name: "Sports", Slug: "sports"}, {Name: "Music", Slug: "music"}}, nil
+}
+
+func (f *fakeClient) GetSuggestedUsers(slug string, v url.Values) ([]anaconda.User, error) {
+	if slug != "sports" {
+		return nil, fmt.Errorf("unknown slug %s", slug)
+	}
+	return []anaconda.User{{Id: 1, FollowersCount: 10}, {Id: 2, FollowersCount: 1000}}, nil
+}
+
+// GetSearch returns the found tweets if any. Otherwise it returns a tweet
+// of the user of id 1, and of the user of id 2 if searching around a geocode.
+func (f *fakeClient) GetSearch(queryString string, v url.Values) (anaconda.SearchResponse, error) {
+	f.geocode = v.Get("geocode")
+	if f.found != nil {
+		return anaconda.SearchResponse{Statuses: f.found}, nil
+	}
+	results := anaconda.SearchResponse{
+		Statuses: []anaconda.Tweet{{User: anaconda.User{Id: 1}}},
+	}
+	if f.geocode != "" {
+		results.Statuses = append(results.Statuses, anaconda.Tweet{User: anaconda.User{Id: 2}})
+	}
+	return results, nil
+}
+
+// PostDMToUserId fails for the users of negative ids as if
+// they were not following the bot.
+func (f *fakeClient) PostDMToUserId(text string, userID int64) (anaconda.DirectMessage, error) {
+	if userID < 0 {
+		apiErr := &anaconda.ApiError{StatusCode: 403}
+		apiErr.Decoded.Errors = []anaconda.TwitterError{{Code: twitterErrorNotFollowingYou}}
+		return anaconda.DirectMessage{}, apiErr
+	}
+	f.messages = append(f.messages, text)
+	return anaconda.DirectMessage{Id: int64(len(f.messages)), Text: text}, nil
+}
+
+// PostDMToScreenName records the direct message prefixed by the screen name.
+func (f *fakeClient) PostDMToScreenName(text, screenName string) (anaconda.DirectMessage, error) {
+	f.messages = append(f.messages, screenName+": "+text)
+	return anaconda.DirectMessage{Id: int64(len(f.messages)), Text: text}, nil
+}
+
+// GetDirectMessages returns the received direct messages
+// newer than the since id, the most recent first.
+func (f *fakeClient) GetDirectMessages(v url.Values) ([]anaconda.DirectMessage, error) {
+	sinceID, _ := strconv.ParseInt(v.Get("since_id"), 10, 64)
+	messages := []anaconda.DirectMessage{}
+	for i := len(f.received) - 1; i >= 0; i-- {
+		if f.received[i].Id > sinceID {
+			messages = append(messages, f.received[i])
+		}
+	}
+	return messages, nil
+}
+
+// GetMentionsTimeline returns the mentions
+// newer than the since id, the most recent first.
+func (f *fakeClient) GetMentionsTimeline(v url.Values) ([]anaconda.Tweet, error) {
+	sinceID, _ := strconv.ParseInt(v.Get("since_id"), 10, 64)
+	mentions := []anaconda.Tweet{}
+	for i := len(f.mentions) - 1; i >= 0; i-- {
+		if f.mentions[i].Id > sinceID {
+			mentions = append(mentions, f.mentions[i])
+		}
+	}
+	return mentions, nil
+}
+
+func (f *fakeClient) PostTweet(status string, v url.Values) (anaconda.Tweet, error) {
+	f.tweets = append(f.tweets, status)
+	tweet := anaconda.Tweet{Id: int64(len(f.tweets)), Text: status}
+	tweet.IdStr = strconv.FormatInt(tweet.Id, 10)
+	if v.Get("in_reply_to_status_id") != "" {
+		if f.replies == nil {
+			f.replies = map[string]string{}
+		}
+		f.replies[tweet.IdStr] = v.Get("in_reply_to_status_id")
+	}
+	return tweet, nil
+}
+
+func (f *fakeClient) Retweet(id int64, trimUser bool) (anaconda.Tweet, error) {
+	f.retweeted = append(f.retweeted, id)
+	return anaconda.Tweet{Id: id + 1000}, nil
+}
+
+// GetRetweets returns the retweets of the tweet, at most 'count' of them.
+func (f *fakeClient) GetRetweets(id int64, v url.Values) ([]anaconda.Tweet, error) {
+	retweets := f.retweets[id]
+	count, _ := strconv.Atoi(v.Get("count"))
+	if count > 0 && count < len(retweets) {
+		retweets = retweets[:count]
+	}
+	return retweets, nil
+}
+
+// GetRetweetsOfMe returns the retweeted tweets of the bot, at most 'count' of them.
+func (f *fakeClient) GetRetweetsOfMe(v url.Values) ([]anaconda.Tweet, error) {
+	tweets := f.retweetedMe
+	count, _ := strconv.Atoi(v.Get("count"))
+	if count > 0 && count < len(tweets) {
+		tweets = tweets[:count]
+	}
+	return tweets, nil
+}
+
+func (f *fakeClient) FollowUserId(userID int64, v url.Values) (anaconda.User, error) {
+	if f.followErr != nil {
+		return anaconda.User{}, f.followErr
+	}
+	f.followed = append(f.followed, userID)
+	return anaconda.User{Id: userID}, nil
+}
+
+func (f *fakeClient) Favorite(id int64) (anaconda.Tweet, error) {
+	f.liked = append(f.liked, id)
+	return anaconda.Tweet{Id: id, Favorited: true}, nil
+}
+
+// GetFavorites returns pages of 'pageSize' favorites
+// older than the max id, the most recent first.
+func (f *fakeClient) GetFavorites(v url.Values) ([]anaconda.Tweet, error) {
+	maxID, _ := strconv.ParseInt(v.Get("max_id"), 10, 64)
+	if maxID > 0 && f.favsErr != nil {
+		return nil, f.favsErr
+	}
+	favorites := []anaconda.Tweet{}
+	for _, tweet := range f.favorites {
+		if len(favorites) == f.pageSize {
+			break
+		}
+		if maxID == 0 || tweet.Id <= maxID {
+			favorites = append(favorites, tweet)
+		}
+	}
+	return favorites, nil
+}
+
+func (f *fakeClient) Unfavorite(id int64) (anaconda.Tweet, error) {
+	f.unliked = append(f.unliked, id)
+	return anaconda.Tweet{Id: id}, nil
+}
+
+func (f *fakeClient) UnfollowUserId(userID int64) (anaconda.User, error) {
+	f.unfollowed = append(f.unfollowed, userID)
+	return anaconda.User{Id: userID}, nil
+}
+
+func makeFakeBot(client *fakeClient) *TwitterBot {
+	return &TwitterBot{
+		twitterClient: client,
+		store:         NewMemStore(),
+		followers: &twitterUsers{
+			Ids: map[string]*twitterUser{},
+		},
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{},
+		},
+		content: &contentFilter{},
+	}
+}
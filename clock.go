@@ -0,0 +1,251 @@
+package twbot
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of the time of the bots, see SetClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the given duration to elapse
+	// and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer returns a timer sending the current time on its channel
+	// once the given duration elapsed, unless it is stopped before.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a stoppable wait of a clock, see Clock.NewTimer.
+type Timer interface {
+	// C returns the channel on which the time is sent.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false
+	// if the timer already fired or was already stopped.
+	Stop() bool
+}
+
+// Rand is the source of the randomness of the bots, see SetRand.
+// A *rand.Rand satisfies it.
+type Rand interface {
+	// Intn returns a non-negative pseudo-random number in [0,n).
+	Intn(n int) int
+	// Float64 returns a pseudo-random number in [0.0,1.0).
+	Float64() float64
+}
+
+// systemClock is the clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTimer(d time.Duration) Timer         { return systemTimer{time.NewTimer(d)} }
+
+// systemTimer is a timer of the clock of the system.
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.timer.C }
+func (t systemTimer) Stop() bool          { return t.timer.Stop() }
+
+// systemRand is the default source of the math/rand package.
+type systemRand struct{}
+
+func (systemRand) Intn(n int) int   { return rand.Intn(n) }
+func (systemRand) Float64() float64 { return rand.Float64() }
+
+var (
+	clockMutex sync.RWMutex
+	clock      Clock = systemClock{}
+	random     Rand  = systemRand{}
+)
+
+// SetClock sets the clock of the bots: the ages of the friends and of the
+// likes, the daily limits, the schedules and all the sleeps and waits of the
+// bots are measured with it, so that they can be tested deterministically and
+// fast-forwarded, see FakeClock. A nil 'c' restores the clock of the system.
+func SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+	clock = c
+}
+
+// SetRand sets the source of the randomness of the bots: the random sleeps,
+// the like probability, the choice of the search queries and the jitter of
+// the retries, rand.New(rand.NewSource(1)) for instance to make them
+// reproducible. A *rand.Rand is not safe for concurrent use: it must not be
+// shared by asynchronous jobs. A nil 'r' restores the default source of
+// math/rand.
+func SetRand(r Rand) {
+	if r == nil {
+		r = systemRand{}
+	}
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+	random = r
+}
+
+func getClock() Clock {
+	clockMutex.RLock()
+	defer clockMutex.RUnlock()
+	return clock
+}
+
+func getRand() Rand {
+	clockMutex.RLock()
+	defer clockMutex.RUnlock()
+	return random
+}
+
+// timeNow returns the current time of the clock of the bots.
+func timeNow() time.Time {
+	return getClock().Now()
+}
+
+// stopTimer stops the given timer if not nil.
+func stopTimer(timer Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// ticker ticks every 'freq' according to the clock of the bots, see
+// SetClock. Like a time.Ticker, it runs at a fixed rate: a tick late
+// because of a slow run fires right away and the missed ones are dropped.
+type ticker struct {
+	next time.Time
+	freq time.Duration
+}
+
+func newTicker(freq time.Duration) *ticker {
+	return &ticker{next: timeNow().Add(freq), freq: freq}
+}
+
+// wait waits for the next tick. It returns false if the context
+// is done before.
+func (t *ticker) wait(ctx context.Context) bool {
+	if !sleepContext(ctx, t.next.Sub(timeNow())) {
+		return false
+	}
+	now := timeNow()
+	t.next = t.next.Add(t.freq)
+	if !t.next.After(now) {
+		t.next = t.next.Add((now.Sub(t.next)/t.freq + 1) * t.freq)
+	}
+	return true
+}
+
+// timeSince returns the time elapsed since 't' according to the clock of the bots.
+func timeSince(t time.Time) time.Duration {
+	return timeNow().Sub(t)
+}
+
+// randomElement returns a random element of the given list, "" if empty.
+func randomElement(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[getRand().Intn(len(list))]
+}
+
+// FakeClock is a clock whose time only advances when told to, see SetClock.
+type FakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// fakeTimer is a timer of a fake clock, see FakeClock.NewTimer.
+type fakeTimer struct {
+	clock *FakeClock
+	c     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// Stop removes the timer from the waiters of its fake clock.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, waiter := range t.clock.waiters {
+		if waiter.c == t.c {
+			t.clock.waiters = append(t.clock.waiters[:i], t.clock.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// NewFakeClock returns a fake clock set to the given time.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock
+// is advanced by the given duration, see Advance.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer returns a timer firing once the clock is advanced
+// by the given duration, see Advance.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timer := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), c: timer.c})
+	return timer
+}
+
+// Advance advances the fake clock by the given duration,
+// waking up the sleeps and waits over by then.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+	waiters := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(c.now) {
+			waiters = append(waiters, waiter)
+			continue
+		}
+		waiter.c <- c.now
+	}
+	c.waiters = waiters
+}
+
+// Waiters returns the number of sleeps and waits neither over nor
+// interrupted yet, so that tests can advance the clock once a bot
+// is waiting.
+func (c *FakeClock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.waiters)
+}
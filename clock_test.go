@@ -0,0 +1,125 @@
+package twbot
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestFakeClock(c *C) {
+	start := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	SetClock(clock)
+	defer SetClock(nil)
+	c.Assert(timeNow(), Equals, start)
+
+	slept := make(chan bool)
+	go func() {
+		slept <- sleepContext(context.Background(), time.Hour)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(30 * time.Minute)
+	select {
+	case <-slept:
+		c.Fatal("sleep over too early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(30 * time.Minute)
+	c.Assert(<-slept, Equals, true)
+	c.Assert(timeSince(start), Equals, time.Hour)
+}
+
+func (s *MySuite) TestFakeClockStoppedTimer(c *C) {
+	clock := NewFakeClock(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(clock)
+	defer SetClock(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	slept := make(chan bool)
+	go func() {
+		slept <- sleepContext(ctx, time.Hour)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	c.Assert(<-slept, Equals, false)
+	c.Assert(clock.Waiters(), Equals, 0)
+
+	timer := clock.NewTimer(time.Hour)
+	clock.Advance(time.Hour)
+	c.Assert(timer.Stop(), Equals, false)
+	<-timer.C()
+}
+
+func (s *MySuite) TestTicker(c *C) {
+	start := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	SetClock(clock)
+	defer SetClock(nil)
+	ticker := newTicker(time.Hour)
+	ticked := make(chan bool)
+	wait := func() {
+		go func() {
+			ticked <- ticker.wait(context.Background())
+		}()
+	}
+	wait()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// a run of 10 minutes does not delay the next tick
+	clock.Advance(time.Hour)
+	c.Assert(<-ticked, Equals, true)
+	clock.Advance(10 * time.Minute)
+	c.Assert(ticker.next, Equals, start.Add(2*time.Hour))
+	// a run of 3 hours makes the next tick fire right away
+	clock.Advance(3 * time.Hour)
+	wait()
+	c.Assert(<-ticked, Equals, true)
+	c.Assert(ticker.next, Equals, start.Add(5*time.Hour))
+}
+
+func (s *MySuite) TestFakeClockUnfollowAge(c *C) {
+	start := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	SetClock(clock)
+	defer SetClock(nil)
+	bot := &TwitterBot{
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				"1": {Timestamp: start.UnixNano(), Follow: true},
+			},
+		},
+		whitelist: &twitterWhitelist{
+			Ids: map[string]string{},
+		},
+		unfollowPolicy: &unfollowPolicy{
+			minAge: 24 * time.Hour,
+		},
+	}
+	_, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, false)
+	clock.Advance(24 * time.Hour)
+	id, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(id, Equals, int64(1))
+}
+
+func (s *MySuite) TestSetRand(c *C) {
+	defer SetRand(nil)
+	queries := []string{"a", "b", "c", "d", "e", "f"}
+	pick := func() []string {
+		SetRand(rand.New(rand.NewSource(1)))
+		picked := []string{}
+		for i := 0; i < 10; i++ {
+			picked = append(picked, randomElement(queries))
+		}
+		return picked
+	}
+	c.Assert(pick(), DeepEquals, pick())
+	c.Assert(randomElement(nil), Equals, "")
+}
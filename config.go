@@ -0,0 +1,1005 @@
+package twbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a duration read from configuration files
+// as a string like "1h30m", see time.ParseDuration.
+type Duration time.Duration
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config describes a bot declaratively, see LoadConfig and NewFromConfig.
+type Config struct {
+	// Debug creates more logs and removes all sleeps between API twitter calls.
+	// It enables both Verbose and NoSleep.
+	Debug bool `json:"debug" yaml:"debug" toml:"debug"`
+	// Verbose creates more logs, see SetVerbose.
+	Verbose bool `json:"verbose" yaml:"verbose" toml:"verbose"`
+	// NoSleep removes all sleeps between API twitter calls, see SetNoSleep.
+	NoSleep bool `json:"no_sleep" yaml:"no_sleep" toml:"no_sleep"`
+	// DryRun simulates all the write calls to the twitter API, see SetDryRun.
+	DryRun bool `json:"dry_run" yaml:"dry_run" toml:"dry_run"`
+	// AppAuth reads with an application-only token of the consumer keys, see SetAppAuth.
+	AppAuth bool `json:"app_auth" yaml:"app_auth" toml:"app_auth"`
+	// Owner is the screen name of the user alerted on critical events, see SetOwner.
+	Owner       string            `json:"owner" yaml:"owner" toml:"owner"`
+	Credentials CredentialsConfig `json:"credentials" yaml:"credentials" toml:"credentials"`
+	// Profile replaces the credentials by the ones of the named profile,
+	// defined in the ProfilesFile if any or in the environment, see Profiles.
+	Profile      string `json:"profile" yaml:"profile" toml:"profile"`
+	ProfilesFile string `json:"profiles_file" yaml:"profiles_file" toml:"profiles_file"`
+	// Shadow holds the keys of the account receiving the write actions of the
+	// bot, see SetShadowAccount. Empty consumer keys are the ones of the bot.
+	Shadow    *CredentialsConfig `json:"shadow" yaml:"shadow" toml:"shadow"`
+	Store     StoreConfig        `json:"store" yaml:"store" toml:"store"`
+	HTTP      HTTPConfig         `json:"http" yaml:"http" toml:"http"`
+	Paths     PathsConfig        `json:"paths" yaml:"paths" toml:"paths"`
+	Policies  PoliciesConfig     `json:"policies" yaml:"policies" toml:"policies"`
+	Queries   QueriesConfig      `json:"queries" yaml:"queries" toml:"queries"`
+	Banned    BannedConfig       `json:"banned" yaml:"banned" toml:"banned"`
+	Schedules SchedulesConfig    `json:"schedules" yaml:"schedules" toml:"schedules"`
+	Admin     AdminConfig        `json:"admin" yaml:"admin" toml:"admin"`
+	Notify    NotifyConfig       `json:"notify" yaml:"notify" toml:"notify"`
+	CrossPost CrossPostConfig    `json:"cross_post" yaml:"cross_post" toml:"cross_post"`
+	Generator *GeneratorConfig   `json:"generator" yaml:"generator" toml:"generator"`
+}
+
+// GeneratorConfig configures the generation of the replies and the quote
+// comments, see SetTextGenerator, by an OpenAI compatible API, see
+// NewOpenAIGenerator. An empty key is read from the environment variable
+// TWBOT_OPENAI_KEY.
+type GeneratorConfig struct {
+	URL         string `json:"url" yaml:"url" toml:"url"`
+	Key         string `json:"key" yaml:"key" toml:"key"`
+	Model       string `json:"model" yaml:"model" toml:"model"`
+	ReplyPrompt string `json:"reply_prompt" yaml:"reply_prompt" toml:"reply_prompt"`
+	QuotePrompt string `json:"quote_prompt" yaml:"quote_prompt" toml:"quote_prompt"`
+}
+
+// AdminConfig enables the administration of the bot on the given address,
+// see ServeAdminAsync. The token is read from the environment variable
+// TWBOT_ADMIN_TOKEN if empty.
+type AdminConfig struct {
+	Addr  string `json:"addr" yaml:"addr" toml:"addr"`
+	Token string `json:"token" yaml:"token" toml:"token"`
+	// HealthMaxIdle sets the health check of the bot, see SetHealthMaxIdle.
+	HealthMaxIdle Duration `json:"health_max_idle" yaml:"health_max_idle" toml:"health_max_idle"`
+}
+
+// NotifyConfig holds the notifiers of the alerts of the bot, see AddNotifier.
+type NotifyConfig struct {
+	// Slack and Discord are the URLs of the webhooks of the chat notifiers.
+	Slack   string      `json:"slack" yaml:"slack" toml:"slack"`
+	Discord string      `json:"discord" yaml:"discord" toml:"discord"`
+	SMTP    *SMTPConfig `json:"smtp" yaml:"smtp" toml:"smtp"`
+	// FollowersMilestones are alerted once reached, see SetFollowersMilestones.
+	FollowersMilestones []int `json:"followers_milestones" yaml:"followers_milestones" toml:"followers_milestones"`
+}
+
+// CrossPostConfig holds the accounts mirroring the posts of the bot, see AddCrossPoster.
+type CrossPostConfig struct {
+	Mastodon *MastodonConfig `json:"mastodon" yaml:"mastodon" toml:"mastodon"`
+	Bluesky  *BlueskyConfig  `json:"bluesky" yaml:"bluesky" toml:"bluesky"`
+}
+
+// MastodonConfig configures the Mastodon cross-poster, see NewMastodonCrossPoster.
+// An empty token is read from the environment variable TWBOT_MASTODON_TOKEN.
+type MastodonConfig struct {
+	Server string `json:"server" yaml:"server" toml:"server"`
+	Token  string `json:"token" yaml:"token" toml:"token"`
+}
+
+// BlueskyConfig configures the Bluesky cross-poster, see NewBlueskyCrossPoster.
+// An empty app password is read from the environment variable TWBOT_BLUESKY_PASSWORD.
+type BlueskyConfig struct {
+	Server   string `json:"server" yaml:"server" toml:"server"`
+	Handle   string `json:"handle" yaml:"handle" toml:"handle"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+}
+
+// crossPosters returns the cross-posters of the configuration.
+func (c *CrossPostConfig) crossPosters() []CrossPoster {
+	posters := []CrossPoster{}
+	if c.Mastodon != nil {
+		token := c.Mastodon.Token
+		if token == "" {
+			token = os.Getenv("TWBOT_MASTODON_TOKEN")
+		}
+		posters = append(posters, NewMastodonCrossPoster(c.Mastodon.Server, token))
+	}
+	if c.Bluesky != nil {
+		password := c.Bluesky.Password
+		if password == "" {
+			password = os.Getenv("TWBOT_BLUESKY_PASSWORD")
+		}
+		posters = append(posters, NewBlueskyCrossPoster(c.Bluesky.Server, c.Bluesky.Handle, password))
+	}
+	return posters
+}
+
+// SMTPConfig configures the email notifier, see NewSMTPNotifier.
+// An empty password is read from the environment variable TWBOT_SMTP_PASSWORD.
+type SMTPConfig struct {
+	Addr     string   `json:"addr" yaml:"addr" toml:"addr"`
+	Username string   `json:"username" yaml:"username" toml:"username"`
+	Password string   `json:"password" yaml:"password" toml:"password"`
+	From     string   `json:"from" yaml:"from" toml:"from"`
+	To       []string `json:"to" yaml:"to" toml:"to"`
+}
+
+// notifiers returns the notifiers of the configuration.
+func (n *NotifyConfig) notifiers() []Notifier {
+	notifiers := []Notifier{}
+	if n.Slack != "" {
+		notifiers = append(notifiers, NewSlackNotifier(n.Slack))
+	}
+	if n.Discord != "" {
+		notifiers = append(notifiers, NewDiscordNotifier(n.Discord))
+	}
+	if n.SMTP != nil {
+		password := n.SMTP.Password
+		if password == "" {
+			password = os.Getenv("TWBOT_SMTP_PASSWORD")
+		}
+		notifiers = append(notifiers, NewSMTPNotifier(n.SMTP.Addr, n.SMTP.Username, password, n.SMTP.From, n.SMTP.To...))
+	}
+	return notifiers
+}
+
+// CredentialsConfig holds the twitter keys of the bot. Empty keys are read
+// from the environment variables TWITTER_CONSUMER_KEY, TWITTER_CONSUMER_SECRET,
+// TWITTER_ACCESS_TOKEN and TWITTER_ACCESS_SECRET, so that secrets can be kept
+// out of the configuration file.
+type CredentialsConfig struct {
+	ConsumerKey    string `json:"consumer_key" yaml:"consumer_key" toml:"consumer_key"`
+	ConsumerSecret string `json:"consumer_secret" yaml:"consumer_secret" toml:"consumer_secret"`
+	AccessToken    string `json:"access_token" yaml:"access_token" toml:"access_token"`
+	AccessSecret   string `json:"access_secret" yaml:"access_secret" toml:"access_secret"`
+}
+
+// HTTPConfig configures the http client of the bot: the URL of its HTTP or
+// SOCKS5 proxy, see WithProxy, and the timeout of its requests, see WithTimeout.
+type HTTPConfig struct {
+	Proxy   string   `json:"proxy" yaml:"proxy" toml:"proxy"`
+	Timeout Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// options returns the options of the http client of the configuration.
+func (c *HTTPConfig) options() ([]Option, error) {
+	opts := []Option{}
+	if c.Proxy != "" {
+		proxyURL, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("[twitter] invalid proxy: %v", err)
+		}
+		opts = append(opts, WithProxy(proxyURL))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, WithTimeout(time.Duration(c.Timeout)))
+	}
+	return opts, nil
+}
+
+// StoreConfig describes the store persisting the databases, see Store.
+type StoreConfig struct {
+	// Type is either "json", the default, "bolt" or "memory".
+	Type string `json:"type" yaml:"type" toml:"type"`
+	// File is the bbolt file of the "bolt" store.
+	File string `json:"file" yaml:"file" toml:"file"`
+	// Encrypt encrypts the databases with the key read by LoadEncryptionKey
+	// from the environment or from KeyFile, see NewEncryptedStore.
+	Encrypt bool   `json:"encrypt" yaml:"encrypt" toml:"encrypt"`
+	KeyFile string `json:"key_file" yaml:"key_file" toml:"key_file"`
+	// BatchMaxPending and BatchMaxDelay batch the saves, see SetSaveBatching.
+	BatchMaxPending int      `json:"batch_max_pending" yaml:"batch_max_pending" toml:"batch_max_pending"`
+	BatchMaxDelay   Duration `json:"batch_max_delay" yaml:"batch_max_delay" toml:"batch_max_delay"`
+}
+
+// PathsConfig holds the paths of the databases. Only the followers, friends
+// and tweets databases are mandatory, the others are enabled by their path.
+type PathsConfig struct {
+	Followers  string `json:"followers" yaml:"followers" toml:"followers"`
+	Friends    string `json:"friends" yaml:"friends" toml:"friends"`
+	Tweets     string `json:"tweets" yaml:"tweets" toml:"tweets"`
+	Likes      string `json:"likes" yaml:"likes" toml:"likes"`
+	Whitelist  string `json:"whitelist" yaml:"whitelist" toml:"whitelist"`
+	State      string `json:"state" yaml:"state" toml:"state"`
+	Growth     string `json:"growth" yaml:"growth" toml:"growth"`
+	Blocks     string `json:"blocks" yaml:"blocks" toml:"blocks"`
+	Campaigns  string `json:"campaigns" yaml:"campaigns" toml:"campaigns"`
+	Audit      string `json:"audit" yaml:"audit" toml:"audit"`
+	Engagement string `json:"engagement" yaml:"engagement" toml:"engagement"`
+	Queue      string `json:"queue" yaml:"queue" toml:"queue"`
+}
+
+// PoliciesConfig holds the policies of the bot. Missing policies keep their default.
+type PoliciesConfig struct {
+	Like        *LikePolicyConfig     `json:"like" yaml:"like" toml:"like"`
+	Retweet     *RetweetPolicyConfig  `json:"retweet" yaml:"retweet" toml:"retweet"`
+	Unfollow    *UnfollowPolicyConfig `json:"unfollow" yaml:"unfollow" toml:"unfollow"`
+	Sleep       *SleepPolicyConfig    `json:"sleep" yaml:"sleep" toml:"sleep"`
+	Retention   *RetentionConfig      `json:"retention" yaml:"retention" toml:"retention"`
+	FollowQuota *FollowQuotaConfig    `json:"follow_quota" yaml:"follow_quota" toml:"follow_quota"`
+	Retry       *RetryPolicyConfig    `json:"retry" yaml:"retry" toml:"retry"`
+	Breaker     *BreakerConfig        `json:"breaker" yaml:"breaker" toml:"breaker"`
+	Timing      *TimingConfig         `json:"timing" yaml:"timing" toml:"timing"`
+	// Error is either "fail_fast", the default, "retry" or "skip", see ErrorPolicy.
+	Error string `json:"error" yaml:"error" toml:"error"`
+	// FollowFilter filters the users followed by the follow schedules.
+	FollowFilter FollowFilterConfig `json:"follow_filter" yaml:"follow_filter" toml:"follow_filter"`
+	// LikeFilter filters the tweets liked by the like schedule.
+	LikeFilter LikeFilterConfig `json:"like_filter" yaml:"like_filter" toml:"like_filter"`
+	// Sentiment filters the tweets retweeted by the retweet schedule.
+	Sentiment *SentimentConfig `json:"sentiment" yaml:"sentiment" toml:"sentiment"`
+	// Content blocks the retweets, replies and queued tweets containing
+	// profanities or the terms of its categories.
+	Content *ContentConfig `json:"content" yaml:"content" toml:"content"`
+}
+
+// ContentConfig configures the content filter with the built-in profanity
+// lists of the given languages, see SetContentFilter, and the given terms by
+// category, see AddContentCategory, replacing the categories of the previous
+// configuration on reload.
+type ContentConfig struct {
+	Languages  []string            `json:"languages" yaml:"languages" toml:"languages"`
+	Categories map[string][]string `json:"categories" yaml:"categories" toml:"categories"`
+}
+
+// SentimentConfig configures the sentiment filter, see SetSentimentFilter,
+// with the lexicon scorer of the given words, see NewLexiconScorer.
+// Zero scores keep their default, -1 for Min and 1 for Max.
+type SentimentConfig struct {
+	Min      float64  `json:"min" yaml:"min" toml:"min"`
+	Max      float64  `json:"max" yaml:"max" toml:"max"`
+	Positive []string `json:"positive" yaml:"positive" toml:"positive"`
+	Negative []string `json:"negative" yaml:"negative" toml:"negative"`
+}
+
+// scores returns the scores of the configuration.
+func (c *SentimentConfig) scores() (float64, float64) {
+	min, max := c.Min, c.Max
+	if min == 0 {
+		min = -1
+	}
+	if max == 0 {
+		max = 1
+	}
+	return min, max
+}
+
+// LikePolicyConfig configures the like policy, see SetLikePolicy.
+type LikePolicyConfig struct {
+	Auto        bool    `json:"auto" yaml:"auto" toml:"auto"`
+	Threshold   int     `json:"threshold" yaml:"threshold" toml:"threshold"`
+	Probability float64 `json:"probability" yaml:"probability" toml:"probability"`
+	MaxPerDay   int     `json:"max_per_day" yaml:"max_per_day" toml:"max_per_day"`
+}
+
+// RetweetPolicyConfig configures the retweet policy, see SetRetweetPolicy and SetQuotePolicy.
+type RetweetPolicyConfig struct {
+	MaxTry        int    `json:"max_try" yaml:"max_try" toml:"max_try"`
+	Like          bool   `json:"like" yaml:"like" toml:"like"`
+	QuoteEvery    int    `json:"quote_every" yaml:"quote_every" toml:"quote_every"`
+	QuoteTemplate string `json:"quote_template" yaml:"quote_template" toml:"quote_template"`
+}
+
+// UnfollowPolicyConfig configures the unfollow policy, see SetUnfollowPolicy,
+// SetUnfollowOrder, SetUnfollowIdleWait and SetRefollowCooldown.
+// Zero durations keep their default.
+type UnfollowPolicyConfig struct {
+	MinAge        Duration `json:"min_age" yaml:"min_age" toml:"min_age"`
+	KeepFollowers bool     `json:"keep_followers" yaml:"keep_followers" toml:"keep_followers"`
+	// Order is either "any", the default, "oldest_first" or "non_followers_first".
+	Order            string   `json:"order" yaml:"order" toml:"order"`
+	MaxPerRun        int      `json:"max_per_run" yaml:"max_per_run" toml:"max_per_run"`
+	IdleWait         Duration `json:"idle_wait" yaml:"idle_wait" toml:"idle_wait"`
+	RefollowCooldown Duration `json:"refollow_cooldown" yaml:"refollow_cooldown" toml:"refollow_cooldown"`
+}
+
+// SleepPolicyConfig configures the default sleep policy, see SleepPolicy.
+type SleepPolicyConfig struct {
+	MaxRand               int `json:"max_rand" yaml:"max_rand" toml:"max_rand"`
+	MaybeSleepChance      int `json:"maybe_sleep_chance" yaml:"maybe_sleep_chance" toml:"maybe_sleep_chance"`
+	MaybeSleepTotalChance int `json:"maybe_sleep_total_chance" yaml:"maybe_sleep_total_chance" toml:"maybe_sleep_total_chance"`
+	MaybeSleepMin         int `json:"maybe_sleep_min" yaml:"maybe_sleep_min" toml:"maybe_sleep_min"`
+	MaybeSleepMax         int `json:"maybe_sleep_max" yaml:"maybe_sleep_max" toml:"maybe_sleep_max"`
+}
+
+func (c *SleepPolicyConfig) sleepPolicy() *SleepPolicy {
+	return &SleepPolicy{
+		MaxRand:               c.MaxRand,
+		MaybeSleepChance:      c.MaybeSleepChance,
+		MaybeSleepTotalChance: c.MaybeSleepTotalChance,
+		MaybeSleepMin:         c.MaybeSleepMin,
+		MaybeSleepMax:         c.MaybeSleepMax,
+	}
+}
+
+// RetentionConfig configures the retention policy, see SetRetention.
+type RetentionConfig struct {
+	MaxTweets int      `json:"max_tweets" yaml:"max_tweets" toml:"max_tweets"`
+	MaxAge    Duration `json:"max_age" yaml:"max_age" toml:"max_age"`
+}
+
+// FollowQuotaConfig configures the follow quota, see SetFollowQuota.
+type FollowQuotaConfig struct {
+	MaxFollowsPerDay   int `json:"max_follows_per_day" yaml:"max_follows_per_day" toml:"max_follows_per_day"`
+	MaxUnfollowsPerDay int `json:"max_unfollows_per_day" yaml:"max_unfollows_per_day" toml:"max_unfollows_per_day"`
+}
+
+// RetryPolicyConfig configures the retry policy, see SetRetryPolicy.
+type RetryPolicyConfig struct {
+	MaxAttempts int      `json:"max_attempts" yaml:"max_attempts" toml:"max_attempts"`
+	BaseDelay   Duration `json:"base_delay" yaml:"base_delay" toml:"base_delay"`
+	MaxDelay    Duration `json:"max_delay" yaml:"max_delay" toml:"max_delay"`
+	Jitter      float64  `json:"jitter" yaml:"jitter" toml:"jitter"`
+}
+
+// BreakerConfig configures the circuit breaker, see SetCircuitBreaker.
+type BreakerConfig struct {
+	MaxFailures int      `json:"max_failures" yaml:"max_failures" toml:"max_failures"`
+	Cooldown    Duration `json:"cooldown" yaml:"cooldown" toml:"cooldown"`
+}
+
+// TimingConfig configures the waits of the bot, see SetTiming.
+// Zero durations keep their default.
+type TimingConfig struct {
+	UnableToFollowWait Duration `json:"unable_to_follow_wait" yaml:"unable_to_follow_wait" toml:"unable_to_follow_wait"`
+	QuotaWait          Duration `json:"quota_wait" yaml:"quota_wait" toml:"quota_wait"`
+	FollowBackWait     Duration `json:"follow_back_wait" yaml:"follow_back_wait" toml:"follow_back_wait"`
+	UnfollowIdleWait   Duration `json:"unfollow_idle_wait" yaml:"unfollow_idle_wait" toml:"unfollow_idle_wait"`
+	FollowUnfollowWait Duration `json:"follow_unfollow_wait" yaml:"follow_unfollow_wait" toml:"follow_unfollow_wait"`
+}
+
+// FollowFilterConfig configures a follow filter, see FollowFilter.
+type FollowFilterConfig struct {
+	MinFollowersCount   int      `json:"min_followers_count" yaml:"min_followers_count" toml:"min_followers_count"`
+	MaxFollowersCount   int      `json:"max_followers_count" yaml:"max_followers_count" toml:"max_followers_count"`
+	MinAccountAge       Duration `json:"min_account_age" yaml:"min_account_age" toml:"min_account_age"`
+	RequireProfileImage bool     `json:"require_profile_image" yaml:"require_profile_image" toml:"require_profile_image"`
+	IncludeKeywords     []string `json:"include_keywords" yaml:"include_keywords" toml:"include_keywords"`
+	ExcludeKeywords     []string `json:"exclude_keywords" yaml:"exclude_keywords" toml:"exclude_keywords"`
+	MinRatio            float64  `json:"min_ratio" yaml:"min_ratio" toml:"min_ratio"`
+	MaxRatio            float64  `json:"max_ratio" yaml:"max_ratio" toml:"max_ratio"`
+	Languages           []string `json:"languages" yaml:"languages" toml:"languages"`
+	SkipProtected       bool     `json:"skip_protected" yaml:"skip_protected" toml:"skip_protected"`
+}
+
+func (c *FollowFilterConfig) filter() FollowFilter {
+	return copyFollowFilter(FollowFilter{
+		MinFollowersCount:   c.MinFollowersCount,
+		MaxFollowersCount:   c.MaxFollowersCount,
+		MinAccountAge:       time.Duration(c.MinAccountAge),
+		RequireProfileImage: c.RequireProfileImage,
+		IncludeKeywords:     c.IncludeKeywords,
+		ExcludeKeywords:     c.ExcludeKeywords,
+		MinRatio:            c.MinRatio,
+		MaxRatio:            c.MaxRatio,
+		Languages:           c.Languages,
+		SkipProtected:       c.SkipProtected,
+	})
+}
+
+// LikeFilterConfig configures the like filter, see LikeFilter.
+// The banned queries of the configuration are used as banned queries.
+type LikeFilterConfig struct {
+	MinFavoriteCount int  `json:"min_favorite_count" yaml:"min_favorite_count" toml:"min_favorite_count"`
+	MinRetweetCount  int  `json:"min_retweet_count" yaml:"min_retweet_count" toml:"min_retweet_count"`
+	SkipRetweets     bool `json:"skip_retweets" yaml:"skip_retweets" toml:"skip_retweets"`
+	MaxLikes         int  `json:"max_likes" yaml:"max_likes" toml:"max_likes"`
+}
+
+func (c *Config) likeFilter() LikeFilter {
+	return LikeFilter{
+		BannedQueries:    c.Banned.Queries,
+		MinFavoriteCount: c.Policies.LikeFilter.MinFavoriteCount,
+		MinRetweetCount:  c.Policies.LikeFilter.MinRetweetCount,
+		SkipRetweets:     c.Policies.LikeFilter.SkipRetweets,
+		MaxLikes:         c.Policies.LikeFilter.MaxLikes,
+	}
+}
+
+// QueriesConfig holds the search queries of the schedules.
+type QueriesConfig struct {
+	// Retweet and Like are the queries of the retweet and like schedules.
+	Retweet []string `json:"retweet" yaml:"retweet" toml:"retweet"`
+	Like    []string `json:"like" yaml:"like" toml:"like"`
+	// FollowFollowersOf are the screen names whose followers are followed
+	// over FollowMaxPage pages, see AutoFollowFollowersOf.
+	FollowFollowersOf []string `json:"follow_followers_of" yaml:"follow_followers_of" toml:"follow_followers_of"`
+	FollowMaxPage     int      `json:"follow_max_page" yaml:"follow_max_page" toml:"follow_max_page"`
+	// FollowByQuery are the queries whose tweet authors are followed, see AutoFollowByQuery.
+	FollowByQuery []string `json:"follow_by_query" yaml:"follow_by_query" toml:"follow_by_query"`
+}
+
+// BannedConfig holds the banned queries and block lists.
+type BannedConfig struct {
+	// Queries are the banned queries of the retweet and like schedules.
+	Queries []string `json:"queries" yaml:"queries" toml:"queries"`
+	// Users are the screen names of the users whose tweets are never
+	// retweeted or liked, see SetBannedUsers.
+	Users []string `json:"users" yaml:"users" toml:"users"`
+	// BlockLists are the block lists imported by the block lists
+	// schedule, muting the users if Mute is true, see ImportBlockList.
+	BlockLists []string `json:"block_lists" yaml:"block_lists" toml:"block_lists"`
+	Mute       bool     `json:"mute" yaml:"mute" toml:"mute"`
+}
+
+// SchedulesConfig holds the frequencies of the periodic tasks of the bot,
+// a zero frequency disabling the task, and the campaigns to launch.
+type SchedulesConfig struct {
+	Retweet    Duration `json:"retweet" yaml:"retweet" toml:"retweet"`
+	Like       Duration `json:"like" yaml:"like" toml:"like"`
+	Sync       Duration `json:"sync" yaml:"sync" toml:"sync"`
+	Compact    Duration `json:"compact" yaml:"compact" toml:"compact"`
+	BlockLists Duration `json:"block_lists" yaml:"block_lists" toml:"block_lists"`
+	// Engagement tracks the engagement of the tweets, see TrackEngagementAsync.
+	Engagement Duration `json:"engagement" yaml:"engagement" toml:"engagement"`
+	// Summary is the time of day, as a duration since midnight like
+	// "23h55m", at which the daily summary is sent to the owner, or
+	// tweeted if SummaryPublic is set, see SendDailySummaryAtAsync.
+	Summary       Duration `json:"summary" yaml:"summary" toml:"summary"`
+	SummaryPublic bool     `json:"summary_public" yaml:"summary_public" toml:"summary_public"`
+	// Follow launches the follow campaigns of the follow queries.
+	Follow bool `json:"follow" yaml:"follow" toml:"follow"`
+	// Unfollow launches the auto unfollow, see AutoUnfollowFriendsAsync.
+	Unfollow bool `json:"unfollow" yaml:"unfollow" toml:"unfollow"`
+	// Queue tweets the queued tweets, see TweetFromQueuePeriodicallyAsync.
+	Queue Duration `json:"queue" yaml:"queue" toml:"queue"`
+	// Feeds are the RSS or Atom feeds whose new entries are tweeted.
+	Feeds []FeedConfig `json:"feeds" yaml:"feeds" toml:"feeds"`
+	// Sources are the sources whose items are tweeted, see Source.
+	Sources []SourceConfig `json:"sources" yaml:"sources" toml:"sources"`
+}
+
+// FeedConfig tweets the new entries of the feed of the given URL, rendered by
+// the given template, at the given frequency, see TweetFromFeedPeriodicallyAsync.
+type FeedConfig struct {
+	URL      string   `json:"url" yaml:"url" toml:"url"`
+	Template string   `json:"template" yaml:"template" toml:"template"`
+	Freq     Duration `json:"freq" yaml:"freq" toml:"freq"`
+}
+
+// SourceConfig tweets the items of a source at the given frequency,
+// see TweetFromSourcePeriodicallyAsync.
+type SourceConfig struct {
+	// Name keys the tweeted items of the source in the state database,
+	// the URL or the path of the source if empty.
+	Name string `json:"name" yaml:"name" toml:"name"`
+	// Type is either "feed", "directory", "csv" or "rotation", see
+	// NewFeedSource, NewDirectorySource, NewCSVSource and NewRotationSource.
+	Type string `json:"type" yaml:"type" toml:"type"`
+	// URL and Template describe a feed source.
+	URL      string `json:"url" yaml:"url" toml:"url"`
+	Template string `json:"template" yaml:"template" toml:"template"`
+	// Path is the directory of a directory source or the file of a CSV source.
+	Path string `json:"path" yaml:"path" toml:"path"`
+	// Texts are the texts of a rotation source.
+	Texts []string `json:"texts" yaml:"texts" toml:"texts"`
+	Freq  Duration `json:"freq" yaml:"freq" toml:"freq"`
+}
+
+// name returns the name of the source of the configuration.
+func (c *SourceConfig) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if c.URL != "" {
+		return c.URL
+	}
+	if c.Path != "" {
+		return c.Path
+	}
+	return c.Type
+}
+
+// source returns the source of the configuration, or an error if its type
+// is unknown.
+func (c *SourceConfig) source(t *TwitterBot) (Source, error) {
+	switch c.Type {
+	case "feed":
+		return t.NewFeedSource(c.URL, c.Template), nil
+	case "directory":
+		return NewDirectorySource(c.Path), nil
+	case "csv":
+		return NewCSVSource(c.Path), nil
+	case "rotation":
+		return NewRotationSource(c.Texts...), nil
+	}
+	return nil, fmt.Errorf("[twitter] unknown source type: %s", c.Type)
+}
+
+// LoadConfig loads the configuration file of the given 'path', either
+// a JSON, a YAML or a TOML file depending on its extension.
+// Unknown fields are reported as errors to catch typos.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	err := decodeFile(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decodeFile decodes the JSON, YAML or TOML file of the given 'path',
+// depending on its extension, in 'data'. Unknown fields are rejected.
+func decodeFile(path string, data interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(data)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(data)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(content), data)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown fields %v", meta.Undecoded())
+		}
+	default:
+		return fmt.Errorf("[twitter] unknown configuration format %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("[twitter] failed to load configuration %s: %v", path, err)
+	}
+	return nil
+}
+
+func parseUnfollowOrder(order string) (UnfollowOrder, error) {
+	switch order {
+	case "", "any":
+		return UnfollowAnyOrder, nil
+	case "oldest_first":
+		return UnfollowOldestFirst, nil
+	case "non_followers_first":
+		return UnfollowNonFollowersFirst, nil
+	}
+	return UnfollowAnyOrder, fmt.Errorf("[twitter] unknown unfollow order %q", order)
+}
+
+func parseErrorPolicy(policy string) (ErrorPolicy, error) {
+	switch policy {
+	case "", "fail_fast":
+		return ErrorFailFast, nil
+	case "retry":
+		return ErrorRetry, nil
+	case "skip":
+		return ErrorSkip, nil
+	}
+	return ErrorFailFast, fmt.Errorf("[twitter] unknown error policy %q", policy)
+}
+
+// fromEnv reads the empty keys from the environment variables of the given
+// 'prefix', "TWITTER_" for the default ones, see CredentialsConfig.
+func (c *CredentialsConfig) fromEnv(prefix string) error {
+	errorList := []string{}
+	getEnvIfEmpty := func(value *string, key string) {
+		if *value == "" {
+			*value = os.Getenv(key)
+		}
+		if *value == "" {
+			errorList = append(errorList, fmt.Sprintf("%q is not defined", key))
+		}
+	}
+	getEnvIfEmpty(&c.ConsumerKey, prefix+"CONSUMER_KEY")
+	getEnvIfEmpty(&c.ConsumerSecret, prefix+"CONSUMER_SECRET")
+	getEnvIfEmpty(&c.AccessToken, prefix+"ACCESS_TOKEN")
+	getEnvIfEmpty(&c.AccessSecret, prefix+"ACCESS_SECRET")
+	if len(errorList) > 0 {
+		return fmt.Errorf("[twitter] missing credentials:\n%s", strings.Join(errorList, "\n"))
+	}
+	return nil
+}
+
+func (c *StoreConfig) open() (Store, error) {
+	var store Store
+	var err error
+	switch c.Type {
+	case "", "json":
+		store = NewJSONStore()
+	case "bolt":
+		store, err = OpenBoltStore(c.File)
+	case "memory":
+		store = NewMemStore()
+	default:
+		err = fmt.Errorf("[twitter] unknown store type %q", c.Type)
+	}
+	if err != nil || !c.Encrypt {
+		return store, err
+	}
+	key, err := LoadEncryptionKey(c.KeyFile)
+	if err == nil {
+		var encrypted Store
+		encrypted, err = NewEncryptedStore(store, key)
+		if err == nil {
+			return encrypted, nil
+		}
+	}
+	store.Close()
+	return nil, err
+}
+
+// validate checks the policies of the configuration, so that they can
+// all be rejected before any of them is applied.
+func (policies *PoliciesConfig) validate() error {
+	if like := policies.Like; like != nil {
+		err := validateLikePolicy(like.Threshold, like.Probability, like.MaxPerDay)
+		if err != nil {
+			return err
+		}
+	}
+	if retweet := policies.Retweet; retweet != nil {
+		err := validateRetweetPolicy(retweet.MaxTry)
+		if err != nil {
+			return err
+		}
+	}
+	if content := policies.Content; content != nil {
+		err := validateContentFilter(content.Languages)
+		if err != nil {
+			return err
+		}
+	}
+	if sentiment := policies.Sentiment; sentiment != nil {
+		err := validateSentimentFilter(sentiment.scores())
+		if err != nil {
+			return err
+		}
+	}
+	// zero idle waits keep their default
+	if unfollow := policies.Unfollow; unfollow != nil && unfollow.IdleWait != 0 {
+		err := validateUnfollowIdleWait(time.Duration(unfollow.IdleWait))
+		if err != nil {
+			return err
+		}
+	}
+	if timing := policies.Timing; timing != nil && timing.UnfollowIdleWait != 0 {
+		err := validateUnfollowIdleWait(time.Duration(timing.UnfollowIdleWait))
+		if err != nil {
+			return err
+		}
+	}
+	if sleep := policies.Sleep; sleep != nil {
+		return sleep.sleepPolicy().Validate()
+	}
+	return nil
+}
+
+// applyPolicies sets the policies of the configuration, keeping
+// the current policies when they are missing.
+func (t *TwitterBot) applyPolicies(policies *PoliciesConfig) error {
+	order := UnfollowAnyOrder
+	if policies.Unfollow != nil {
+		var err error
+		order, err = parseUnfollowOrder(policies.Unfollow.Order)
+		if err != nil {
+			return err
+		}
+	}
+	errorPolicy, err := parseErrorPolicy(policies.Error)
+	if err != nil {
+		return err
+	}
+	err = policies.validate()
+	if err != nil {
+		return err
+	}
+	if like := policies.Like; like != nil {
+		t.SetLikePolicy(like.Auto, like.Threshold, like.Probability, like.MaxPerDay)
+	}
+	if retweet := policies.Retweet; retweet != nil {
+		t.SetRetweetPolicy(retweet.MaxTry, retweet.Like)
+		template := retweet.QuoteTemplate
+		if template == "" {
+			template = defaultQuoteTemplate
+		}
+		t.SetQuotePolicy(retweet.QuoteEvery, template)
+	}
+	if unfollow := policies.Unfollow; unfollow != nil {
+		minAge := time.Duration(unfollow.MinAge)
+		if minAge == 0 {
+			minAge = defaultUnfollowMinAge
+		}
+		t.SetUnfollowPolicy(minAge, unfollow.KeepFollowers)
+		t.SetUnfollowOrder(order, unfollow.MaxPerRun)
+		if unfollow.IdleWait != 0 {
+			err := t.SetUnfollowIdleWait(time.Duration(unfollow.IdleWait))
+			if err != nil {
+				return err
+			}
+		}
+		t.SetRefollowCooldown(time.Duration(unfollow.RefollowCooldown))
+	}
+	if sleep := policies.Sleep; sleep != nil {
+		t.mutex.Lock()
+		t.defaultSleepPolicy = sleep.sleepPolicy()
+		t.mutex.Unlock()
+	}
+	if retention := policies.Retention; retention != nil {
+		t.SetRetention(retention.MaxTweets, time.Duration(retention.MaxAge))
+	}
+	if quota := policies.FollowQuota; quota != nil {
+		t.SetFollowQuota(quota.MaxFollowsPerDay, quota.MaxUnfollowsPerDay)
+	}
+	if retry := policies.Retry; retry != nil {
+		t.SetRetryPolicy(retry.MaxAttempts, time.Duration(retry.BaseDelay), time.Duration(retry.MaxDelay), retry.Jitter)
+	}
+	if breaker := policies.Breaker; breaker != nil {
+		t.SetCircuitBreaker(breaker.MaxFailures, time.Duration(breaker.Cooldown))
+	}
+	if timing := policies.Timing; timing != nil {
+		t.SetTiming(Timing{
+			UnableToFollowWait: time.Duration(timing.UnableToFollowWait),
+			QuotaWait:          time.Duration(timing.QuotaWait),
+			FollowBackWait:     time.Duration(timing.FollowBackWait),
+			UnfollowIdleWait:   time.Duration(timing.UnfollowIdleWait),
+			FollowUnfollowWait: time.Duration(timing.FollowUnfollowWait),
+		})
+	}
+	if content := policies.Content; content != nil {
+		t.SetContentFilter(content.Languages...)
+		t.setContentCategories(content.Categories)
+	}
+	if sentiment := policies.Sentiment; sentiment != nil {
+		min, max := sentiment.scores()
+		t.SetSentimentFilter(NewLexiconScorer(sentiment.Positive, sentiment.Negative), min, max)
+	}
+	t.SetErrorPolicy(errorPolicy, nil)
+	return nil
+}
+
+// setPaths sets the paths of the optional databases of the configuration.
+func (t *TwitterBot) setPaths(paths *PathsConfig) error {
+	setters := []struct {
+		path string
+		set  func(string) error
+	}{
+		{paths.Likes, t.SetLikesPath},
+		{paths.Whitelist, t.SetWhitelistPath},
+		{paths.State, t.SetStatePath},
+		{paths.Growth, t.SetGrowthPath},
+		{paths.Blocks, t.SetBlocksPath},
+		{paths.Campaigns, t.SetCampaignsPath},
+		{paths.Audit, t.SetAuditPath},
+		{paths.Engagement, t.SetEngagementPath},
+		{paths.Queue, t.SetQueuePath},
+	}
+	for _, setter := range setters {
+		if setter.path == "" {
+			continue
+		}
+		err := setter.set(setter.path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSchedules launches asynchronously the periodic tasks
+// and the campaigns of the configuration.
+func (t *TwitterBot) startSchedules(cfg *Config) {
+	if cfg.Admin.Addr != "" {
+		token := cfg.Admin.Token
+		if token == "" {
+			token = os.Getenv("TWBOT_ADMIN_TOKEN")
+		}
+		t.ServeAdminAsync(cfg.Admin.Addr, token)
+	}
+	schedules := &cfg.Schedules
+	if schedules.Retweet > 0 {
+		t.schedulePeriodicallyAsync("RetweetPeriodically", time.Duration(schedules.Retweet), func(ctx context.Context, cfg *Config) error {
+			if len(cfg.Queries.Retweet) == 0 {
+				return nil
+			}
+			return t.autoRetweet(ctx, makeBannedByQuery(cfg.Queries.Retweet, cfg.Banned.Queries))
+		})
+	}
+	if schedules.Like > 0 {
+		t.schedulePeriodicallyAsync("AutoLikePeriodically", time.Duration(schedules.Like), func(ctx context.Context, cfg *Config) error {
+			if len(cfg.Queries.Like) == 0 {
+				return nil
+			}
+			return t.autoLike(ctx, cfg.Queries.Like, cfg.likeFilter())
+		})
+	}
+	if schedules.Sync > 0 {
+		t.SyncPeriodicallyAsync(time.Duration(schedules.Sync))
+	}
+	if schedules.Compact > 0 {
+		t.CompactPeriodicallyAsync(time.Duration(schedules.Compact))
+	}
+	if schedules.Queue > 0 {
+		t.TweetFromQueuePeriodicallyAsync(time.Duration(schedules.Queue))
+	}
+	for _, feed := range schedules.Feeds {
+		if feed.Freq > 0 {
+			t.TweetFromFeedPeriodicallyAsync(feed.URL, feed.Template, time.Duration(feed.Freq))
+		}
+	}
+	for i := range schedules.Sources {
+		// the sources were checked by setUp
+		config := &schedules.Sources[i]
+		source, _ := config.source(t)
+		if config.Freq > 0 {
+			t.TweetFromSourcePeriodicallyAsync(config.name(), source, time.Duration(config.Freq))
+		}
+	}
+	if schedules.Engagement > 0 {
+		t.TrackEngagementAsync(time.Duration(schedules.Engagement))
+	}
+	if schedules.Summary > 0 {
+		t.SendDailySummaryAtAsync(time.Duration(schedules.Summary), schedules.SummaryPublic)
+	}
+	if schedules.BlockLists > 0 {
+		for _, source := range cfg.Banned.BlockLists {
+			t.ImportBlockListPeriodicallyAsync(source, cfg.Banned.Mute, nil, time.Duration(schedules.BlockLists))
+		}
+	}
+	if schedules.Follow {
+		filter := cfg.Policies.FollowFilter.filter()
+		if len(cfg.Queries.FollowFollowersOf) > 0 {
+			t.AutoFollowFollowersOfAsync(cfg.Queries.FollowFollowersOf, cfg.Queries.FollowMaxPage, filter, nil)
+		}
+		for _, query := range cfg.Queries.FollowByQuery {
+			t.AutoFollowByQueryAsync(query, filter, nil)
+		}
+	}
+	if schedules.Unfollow {
+		t.AutoUnfollowFriendsAsync(nil)
+	}
+}
+
+// NewFromConfig creates a twitter bot fully described by the given
+// configuration, see LoadConfig: the credentials are verified, see
+// VerifyCredentials, the store, the databases and the policies are set up
+// and the followers and friends databases are synchronized,
+// then the schedules are launched asynchronously, use Wait to wait for them.
+// The configuration must not be modified afterwards, see ReloadConfig.
+// Contrary to MakeTwitterBot, it returns an error instead of exiting:
+//
+//  cfg, err := twbot.LoadConfig("bot.yaml")
+//  ...
+//  bot, err := twbot.NewFromConfig(cfg)
+//  ...
+//  defer bot.Close()
+//  bot.Wait()
+func NewFromConfig(cfg *Config) (*TwitterBot, error) {
+	logInfo("[twitter] making twitter bot from configuration")
+	credentials := cfg.Credentials
+	var err error
+	if cfg.Profile != "" {
+		credentials, err = loadProfile(cfg.ProfilesFile, cfg.Profile)
+	} else {
+		err = credentials.fromEnv("TWITTER_")
+	}
+	if err != nil {
+		return nil, err
+	}
+	opts, err := cfg.HTTP.options()
+	if err != nil {
+		return nil, err
+	}
+	paths := &cfg.Paths
+	bot := newTwitterBot(paths.Followers, paths.Friends, paths.Tweets, credentials.ConsumerKey,
+		credentials.ConsumerSecret, credentials.AccessToken, credentials.AccessSecret, cfg.Debug, opts...)
+	if cfg.Verbose {
+		bot.SetVerbose(true)
+	}
+	if cfg.NoSleep {
+		bot.SetNoSleep(true)
+	}
+	if cfg.DryRun {
+		bot.SetDryRun(true)
+	}
+	if shadow := cfg.Shadow; shadow != nil {
+		consumerKey, consumerSecret := shadow.ConsumerKey, shadow.ConsumerSecret
+		if consumerKey == "" {
+			consumerKey, consumerSecret = credentials.ConsumerKey, credentials.ConsumerSecret
+		}
+		bot.SetShadowAccount(consumerKey, consumerSecret, shadow.AccessToken, shadow.AccessSecret)
+	}
+	_, err = bot.VerifyCredentials()
+	if err == nil && cfg.AppAuth {
+		err = bot.SetAppAuth(credentials.ConsumerKey, credentials.ConsumerSecret)
+	}
+	if err == nil {
+		bot.store, err = cfg.Store.open()
+	}
+	if err == nil {
+		err = bot.setUp(cfg)
+	}
+	if err != nil {
+		bot.twitterClient.Close()
+		bot.SetShadowClient(nil)
+		if bot.store != nil {
+			bot.store.Close()
+		}
+		return nil, err
+	}
+	bot.startSchedules(cfg)
+	return bot, nil
+}
+
+func (t *TwitterBot) setUp(cfg *Config) error {
+	if cfg.Store.BatchMaxPending > 0 || cfg.Store.BatchMaxDelay > 0 {
+		err := t.SetSaveBatching(cfg.Store.BatchMaxPending, time.Duration(cfg.Store.BatchMaxDelay))
+		if err != nil {
+			return err
+		}
+	}
+	err := t.applyPolicies(&cfg.Policies)
+	if err != nil {
+		return err
+	}
+	for i := range cfg.Schedules.Sources {
+		_, err = cfg.Schedules.Sources[i].source(t)
+		if err != nil {
+			return err
+		}
+	}
+	err = t.Sync()
+	if err != nil {
+		return err
+	}
+	if cfg.Owner != "" {
+		t.SetOwner(cfg.Owner)
+	}
+	for _, notifier := range cfg.Notify.notifiers() {
+		t.AddNotifier(notifier)
+	}
+	for _, poster := range cfg.CrossPost.crossPosters() {
+		t.AddCrossPoster(poster)
+	}
+	if generator := cfg.Generator; generator != nil {
+		key := generator.Key
+		if key == "" {
+			key = os.Getenv("TWBOT_OPENAI_KEY")
+		}
+		t.SetTextGenerator(NewOpenAIGenerator(generator.URL, key, generator.Model),
+			generator.ReplyPrompt, generator.QuotePrompt)
+	}
+	if len(cfg.Notify.FollowersMilestones) > 0 {
+		t.SetFollowersMilestones(cfg.Notify.FollowersMilestones...)
+	}
+	if cfg.Admin.HealthMaxIdle > 0 {
+		t.SetHealthMaxIdle(time.Duration(cfg.Admin.HealthMaxIdle))
+	}
+	t.SetBannedUsers(cfg.Banned.Users...)
+	t.setConfig(cfg)
+	return t.setPaths(&cfg.Paths)
+}
@@ -0,0 +1,198 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+const (
+	testConfigJSON = `{
+	"owner": "owner",
+	"paths": {"followers": "followers.json", "friends": "friends.json", "tweets": "tweets.json"},
+	"policies": {
+		"unfollow": {"min_age": "48h", "order": "oldest_first", "max_per_run": 10},
+		"error": "skip",
+		"follow_filter": {"min_account_age": "720h", "languages": ["en"]}
+	},
+	"queries": {"retweet": ["golang"]},
+	"banned": {"queries": ["spam"]},
+	"schedules": {"retweet": "1h", "unfollow": true}
+}`
+	testConfigYAML = `
+owner: owner
+paths:
+  followers: followers.json
+  friends: friends.json
+  tweets: tweets.json
+policies:
+  unfollow:
+    min_age: 48h
+    order: oldest_first
+    max_per_run: 10
+  error: skip
+  follow_filter:
+    min_account_age: 720h
+    languages: [en]
+queries:
+  retweet: [golang]
+banned:
+  queries: [spam]
+schedules:
+  retweet: 1h
+  unfollow: true
+`
+	testConfigTOML = `
+owner = "owner"
+
+[paths]
+followers = "followers.json"
+friends = "friends.json"
+tweets = "tweets.json"
+
+[policies]
+error = "skip"
+
+[policies.unfollow]
+min_age = "48h"
+order = "oldest_first"
+max_per_run = 10
+
+[policies.follow_filter]
+min_account_age = "720h"
+languages = ["en"]
+
+[queries]
+retweet = ["golang"]
+
+[banned]
+queries = ["spam"]
+
+[schedules]
+retweet = "1h"
+unfollow = true
+`
+)
+
+func writeConfig(c *C, name, content string) string {
+	path := filepath.Join(c.MkDir(), name)
+	c.Assert(ioutil.WriteFile(path, []byte(content), 0644), IsNil)
+	return path
+}
+
+func (s *MySuite) TestLoadConfig(c *C) {
+	expected := &Config{
+		Owner: "owner",
+		Paths: PathsConfig{
+			Followers: "followers.json",
+			Friends:   "friends.json",
+			Tweets:    "tweets.json",
+		},
+		Policies: PoliciesConfig{
+			Unfollow: &UnfollowPolicyConfig{
+				MinAge:    Duration(48 * time.Hour),
+				Order:     "oldest_first",
+				MaxPerRun: 10,
+			},
+			Error: "skip",
+			FollowFilter: FollowFilterConfig{
+				MinAccountAge: Duration(720 * time.Hour),
+				Languages:     []string{"en"},
+			},
+		},
+		Queries: QueriesConfig{
+			Retweet: []string{"golang"},
+		},
+		Banned: BannedConfig{
+			Queries: []string{"spam"},
+		},
+		Schedules: SchedulesConfig{
+			Retweet:  Duration(time.Hour),
+			Unfollow: true,
+		},
+	}
+	for name, content := range map[string]string{
+		"bot.json": testConfigJSON,
+		"bot.yaml": testConfigYAML,
+		"bot.toml": testConfigTOML,
+	} {
+		cfg, err := LoadConfig(writeConfig(c, name, content))
+		c.Assert(err, IsNil, Commentf(name))
+		c.Assert(cfg, DeepEquals, expected, Commentf(name))
+	}
+
+	// unknown fields and formats are errors
+	_, err := LoadConfig(writeConfig(c, "bot.json", `{"ownr": "owner"}`))
+	c.Assert(err, NotNil)
+	_, err = LoadConfig(writeConfig(c, "bot.yml", "ownr: owner"))
+	c.Assert(err, NotNil)
+	_, err = LoadConfig(writeConfig(c, "bot.toml", `ownr = "owner"`))
+	c.Assert(err, NotNil)
+	_, err = LoadConfig(writeConfig(c, "bot.ini", "owner=owner"))
+	c.Assert(err, ErrorMatches, `\[twitter\] unknown configuration format .*`)
+	_, err = LoadConfig(writeConfig(c, "bot.json", `{"schedules": {"retweet": "1 hour"}}`))
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestApplyPolicies(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.likePolicy = &likePolicy{}
+	bot.retweetPolicy = &retweetPolicy{}
+	bot.unfollowPolicy = &unfollowPolicy{idleWait: defaultUnfollowIdleWait}
+	bot.followGuard = &followGuard{}
+	cfg, err := LoadConfig(writeConfig(c, "bot.json", testConfigJSON))
+	c.Assert(err, IsNil)
+	c.Assert(bot.applyPolicies(&cfg.Policies), IsNil)
+	c.Assert(bot.unfollowPolicy.minAge, Equals, 48*time.Hour)
+	c.Assert(bot.unfollowPolicy.order, Equals, UnfollowOldestFirst)
+	c.Assert(bot.unfollowPolicy.maxPerRun, Equals, 10)
+	c.Assert(bot.unfollowPolicy.idleWait, Equals, defaultUnfollowIdleWait)
+	policy, _ := bot.getErrorPolicy()
+	c.Assert(policy, Equals, ErrorSkip)
+
+	cfg.Policies.Unfollow.Order = "newest_first"
+	c.Assert(bot.applyPolicies(&cfg.Policies), ErrorMatches, `\[twitter\] unknown unfollow order "newest_first"`)
+	cfg.Policies.Unfollow.Order = ""
+	cfg.Policies.Error = "panic"
+	c.Assert(bot.applyPolicies(&cfg.Policies), ErrorMatches, `\[twitter\] unknown error policy "panic"`)
+	cfg.Policies.Error = ""
+	cfg.Policies.Unfollow.IdleWait = Duration(-time.Hour)
+	c.Assert(bot.applyPolicies(&cfg.Policies), ErrorMatches, `\[twitter\] invalid unfollow idle wait: .*`)
+	cfg.Policies.Unfollow.IdleWait = 0
+	cfg.Policies.Timing = &TimingConfig{UnfollowIdleWait: Duration(-time.Hour)}
+	c.Assert(bot.applyPolicies(&cfg.Policies), ErrorMatches, `\[twitter\] invalid unfollow idle wait: .*`)
+	c.Assert(bot.unfollowPolicy.idleWait, Equals, defaultUnfollowIdleWait)
+}
+
+func (s *MySuite) TestStoreConfig(c *C) {
+	store, err := (&StoreConfig{}).open()
+	c.Assert(err, IsNil)
+	c.Assert(store, FitsTypeOf, jsonStore{})
+	store, err = (&StoreConfig{Type: "memory"}).open()
+	c.Assert(err, IsNil)
+	c.Assert(store.Close(), IsNil)
+	store, err = (&StoreConfig{Type: "bolt", File: filepath.Join(c.MkDir(), "twbot.db")}).open()
+	c.Assert(err, IsNil)
+	c.Assert(store.Close(), IsNil)
+	_, err = (&StoreConfig{Type: "sql"}).open()
+	c.Assert(err, ErrorMatches, `\[twitter\] unknown store type "sql"`)
+}
+
+func (s *MySuite) TestSourceConfig(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	config := &SourceConfig{Type: "rotation", Texts: []string{"hello"}}
+	c.Assert(config.name(), Equals, "rotation")
+	source, err := config.source(bot)
+	c.Assert(err, IsNil)
+	item, err := source.Next()
+	c.Assert(err, IsNil)
+	c.Assert(item, DeepEquals, Item{Text: "hello"})
+	config = &SourceConfig{Type: "directory", Path: "posts"}
+	c.Assert(config.name(), Equals, "posts")
+	config = &SourceConfig{Name: "news", Type: "rss", URL: "https://example.com/rss"}
+	c.Assert(config.name(), Equals, "news")
+	_, err = config.source(bot)
+	c.Assert(err, ErrorMatches, `\[twitter\] unknown source type: rss`)
+}
@@ -0,0 +1,189 @@
+package twbot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	contentMaxBlocked = 100 // blocked contents kept for the report
+)
+
+// profanities are the built-in profanity lists by language,
+// see SetContentFilter.
+var profanities = map[string][]string{
+	"en": {"asshole", "bastard", "bitch", "bullshit", "cunt", "dick", "dickhead",
+		"fuck", "fucking", "motherfucker", "shit", "slut", "whore", "wanker"},
+	"fr": {"batard", "bâtard", "bordel", "connard", "connasse", "encule", "enculé",
+		"merde", "pute", "putain", "salope", "ta gueule"},
+	"es": {"cabron", "cabrón", "coño", "gilipollas", "hijo de puta", "joder",
+		"mierda", "pendejo", "puta", "puto"},
+	"de": {"arschloch", "fotze", "hurensohn", "miststück", "scheisse", "scheiße",
+		"schlampe", "wichser"},
+}
+
+// BlockedContent is a content blocked by the content filter, see ContentReport.
+type BlockedContent struct {
+	Time time.Time `json:"time"`
+	// Action is either "retweet", "reply" or "queue".
+	Action string `json:"action"`
+	// Category and Term are the category and the term matched by the content.
+	Category string `json:"category"`
+	Term     string `json:"term"`
+	Text     string `json:"text"`
+}
+
+// contentFilter blocks the contents containing a term of its categories.
+type contentFilter struct {
+	categories map[string][]string // map category -> normalized terms
+	blocked    []BlockedContent    // the oldest first
+}
+
+// normalizeContent lowers the given text and replaces its punctuation by
+// spaces, surrounding it by spaces so that terms match whole words only.
+func normalizeContent(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return " " + strings.Join(words, " ") + " "
+}
+
+// match returns the category and the term of the first term contained
+// by the given text, in the order of the categories names.
+func (f *contentFilter) match(text string) (string, string, bool) {
+	normalized := normalizeContent(text)
+	categories := []string{}
+	for category := range f.categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		for _, term := range f.categories[category] {
+			if strings.Contains(normalized, term) {
+				return category, strings.TrimSpace(term), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func (f *contentFilter) add(category string, terms ...string) {
+	if f.categories == nil {
+		f.categories = make(map[string][]string)
+	}
+	for _, term := range terms {
+		normalized := normalizeContent(term)
+		if strings.TrimSpace(normalized) != "" {
+			f.categories[category] = append(f.categories[category], normalized)
+		}
+	}
+}
+
+func validateContentFilter(languages []string) error {
+	for _, language := range languages {
+		if _, ok := profanities[language]; !ok {
+			return fmt.Errorf("[twitter] unknown profanity language %q", language)
+		}
+	}
+	return nil
+}
+
+// SetContentFilter enables the built-in profanity lists of the given languages,
+// "en", "fr", "es" or "de", as the "profanity-<language>" categories of the
+// content filter. The content filter removes the tweets to retweet, skips the
+// mentions to reply to and the replies, and drops the queued tweets, that
+// contain a term of one of its categories, as whole words whatever their case,
+// see AddContentCategory and ContentReport.
+// It returns an error, and leaves the filter unchanged, if a language is unknown.
+func (t *TwitterBot) SetContentFilter(languages ...string) error {
+	err := validateContentFilter(languages)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] setting content filter -> languages: %v", languages)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for category := range t.content.categories {
+		if strings.HasPrefix(category, "profanity-") {
+			delete(t.content.categories, category)
+		}
+	}
+	for _, language := range languages {
+		t.content.add("profanity-"+language, profanities[language]...)
+	}
+	return nil
+}
+
+// AddContentCategory adds the given terms, words or phrases, to the given
+// category of the content filter, see SetContentFilter.
+func (t *TwitterBot) AddContentCategory(category string, terms ...string) {
+	logInfo("[twitter] adding content category -> category: %s, terms: %d", category, len(terms))
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.content.add(category, terms...)
+}
+
+// setContentCategories replaces the categories of the content filter, but
+// the profanity ones, see SetContentFilter, by the given ones.
+func (t *TwitterBot) setContentCategories(categories map[string][]string) {
+	logInfo("[twitter] setting content categories -> categories: %d", len(categories))
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for category := range t.content.categories {
+		if !strings.HasPrefix(category, "profanity-") {
+			delete(t.content.categories, category)
+		}
+	}
+	for category, terms := range categories {
+		t.content.add(category, terms...)
+	}
+}
+
+// ContentReport returns the last contents blocked by the content filter,
+// the oldest first, see SetContentFilter.
+func (t *TwitterBot) ContentReport() []BlockedContent {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	report := make([]BlockedContent, len(t.content.blocked))
+	copy(report, t.content.blocked)
+	return report
+}
+
+// allowContent returns false, and reports the content, if the given text
+// of the given action is blocked by the content filter.
+func (t *TwitterBot) allowContent(action, text string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	category, term, blocked := t.content.match(text)
+	if !blocked {
+		return true
+	}
+	SubsystemTweet.info("[twitter] blocking %s content of category %s (term: %s): %s", action, category, term, text)
+	t.content.blocked = append(t.content.blocked, BlockedContent{
+		Time:     timeNow(),
+		Action:   action,
+		Category: category,
+		Term:     term,
+		Text:     text,
+	})
+	if len(t.content.blocked) > contentMaxBlocked {
+		t.content.blocked = t.content.blocked[len(t.content.blocked)-contentMaxBlocked:]
+	}
+	return false
+}
+
+// removeByContent removes the tweets blocked by the content filter.
+func (t *TwitterBot) removeByContent(current []anaconda.Tweet) []anaconda.Tweet {
+	allowed := []anaconda.Tweet{}
+	for _, tweet := range current {
+		if t.allowContent("retweet", tweet.Text) {
+			allowed = append(allowed, tweet)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,89 @@
+package twbot
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestContentFilter(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	c.Assert(bot.allowContent("retweet", "what the fuck"), Equals, true)
+
+	c.Assert(bot.SetContentFilter("en", "xx"), ErrorMatches, `\[twitter\] unknown profanity language "xx"`)
+	c.Assert(bot.SetContentFilter("en", "es"), IsNil)
+	bot.AddContentCategory("politics", "Election", "vote for")
+	c.Assert(bot.allowContent("retweet", "What the FUCK!"), Equals, false)
+	c.Assert(bot.allowContent("retweet", "hijo de puta"), Equals, false)
+	c.Assert(bot.allowContent("reply", "the election is coming"), Equals, false)
+	c.Assert(bot.allowContent("reply", "please, vote... for me"), Equals, false)
+	// terms only match whole words
+	c.Assert(bot.allowContent("retweet", "shitake mushrooms"), Equals, true)
+	c.Assert(bot.allowContent("retweet", "elections"), Equals, true)
+	c.Assert(bot.allowContent("retweet", "merde"), Equals, true)
+
+	report := bot.ContentReport()
+	c.Assert(report, HasLen, 4)
+	c.Assert(report[0].Action, Equals, "retweet")
+	c.Assert(report[0].Category, Equals, "profanity-en")
+	c.Assert(report[0].Term, Equals, "fuck")
+	c.Assert(report[1].Category, Equals, "profanity-es")
+	c.Assert(report[1].Term, Equals, "hijo de puta")
+	c.Assert(report[3].Category, Equals, "politics")
+	c.Assert(report[3].Text, Equals, "please, vote... for me")
+
+	// languages are replaced, categories kept
+	c.Assert(bot.SetContentFilter("fr"), IsNil)
+	c.Assert(bot.allowContent("retweet", "fuck"), Equals, true)
+	c.Assert(bot.allowContent("retweet", "merde"), Equals, false)
+	c.Assert(bot.allowContent("retweet", "election"), Equals, false)
+
+	// categories are replaced, languages kept, when the configuration is applied again
+	bot.setContentCategories(map[string][]string{"sports": {"goal"}})
+	bot.setContentCategories(map[string][]string{"sports": {"goal"}})
+	c.Assert(bot.content.categories["sports"], HasLen, 1)
+	c.Assert(bot.allowContent("retweet", "election"), Equals, true)
+	c.Assert(bot.allowContent("retweet", "goal"), Equals, false)
+	c.Assert(bot.allowContent("retweet", "merde"), Equals, false)
+}
+
+func (s *MySuite) TestContentFilterActions(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.replyRules = &replyRules{
+		byUser: map[int64]int{},
+	}
+	bot.queue = &twitterQueue{}
+	c.Assert(bot.SetContentFilter("en"), IsNil)
+	bot.SetReplyPolicy(0, "hello {author}")
+
+	tweets := bot.removeByContent([]anaconda.Tweet{{Id: 1, Text: "shit"}, {Id: 2, Text: "nice"}})
+	c.Assert(tweets, HasLen, 1)
+	c.Assert(tweets[0].Id, Equals, int64(2))
+
+	_, ok := bot.replyByRules(anaconda.Tweet{Text: "@bot you bitch"})
+	c.Assert(ok, Equals, false)
+	_, ok = bot.replyByRules(anaconda.Tweet{Text: "@bot hi", User: anaconda.User{ScreenName: "user"}})
+	c.Assert(ok, Equals, true)
+	bot.AddContentCategory("names", "user")
+	_, ok = bot.replyByRules(anaconda.Tweet{Text: "@bot hi", User: anaconda.User{ScreenName: "user"}})
+	c.Assert(ok, Equals, false)
+
+	_, err := bot.Enqueue("bullshit", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(bot.TweetFromQueueOnce(), IsNil)
+	c.Assert(bot.QueueLen(), Equals, 0)
+	c.Assert(client.tweets, HasLen, 0)
+
+	admin := bot.NewAdmin("secret")
+	c.Assert(serveAdmin(admin, http.MethodGet, "/blocked", "", "").Code, Equals, http.StatusUnauthorized)
+	recorder := serveAdmin(admin, http.MethodGet, "/blocked", "secret", "")
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	report := []BlockedContent{}
+	c.Assert(json.Unmarshal(recorder.Body.Bytes(), &report), IsNil)
+	c.Assert(report, HasLen, 4)
+	c.Assert(report[2].Text, Equals, "hello user")
+	c.Assert(report[3].Action, Equals, "queue")
+}
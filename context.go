@@ -0,0 +1,136 @@
+package twbot
+
+import (
+	"context"
+	"time"
+)
+
+// sleepContext sleeps for the given duration according to the clock of the
+// bots, see SetClock. It returns false if the context is done before the end
+// of the sleep.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := getClock().NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// randSleep randomly sleeps from 0 to 'maxRand' seconds. It returns
+// false if the context is done before the end of the sleep.
+func randSleep(ctx context.Context, maxRand int) bool {
+	if maxRand <= 0 {
+		return ctx.Err() == nil
+	}
+	return sleepContext(ctx, time.Duration(getRand().Intn(maxRand+1))*time.Second)
+}
+
+// maybeSleep sleeps from 'min' to 'max' seconds with a chance of 'chance'
+// over 'totalChance'. It returns false if the context is done before
+// the end of the sleep.
+func maybeSleep(ctx context.Context, chance, totalChance, min, max int) bool {
+	if totalChance <= 0 || getRand().Intn(totalChance) >= chance {
+		return ctx.Err() == nil
+	}
+	seconds := min
+	if max > min {
+		seconds += getRand().Intn(max - min + 1)
+	}
+	return sleepContext(ctx, time.Duration(seconds)*time.Second)
+}
+
+// runPeriodically runs the given 'run' callback every 'freq' until the
+// context is done and returns the context error. It logs the run errors.
+func runPeriodically(ctx context.Context, freq time.Duration, run func() error) error {
+	newJob(ctx, "").runPeriodically(freq, run)
+	return ctx.Err()
+}
+
+// TweetOnceCtx is the same as TweetOnce but it is not run
+// if the context is already done.
+func (t *TwitterBot) TweetOnceCtx(ctx context.Context, fetch func() (string, error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.TweetOnce(fetch)
+}
+
+// TweetPeriodicallyCtx is the same as TweetPeriodically
+// but it returns the context error once the context is done.
+func (t *TwitterBot) TweetPeriodicallyCtx(ctx context.Context, fetch func() (string, error), freq time.Duration) error {
+	return runPeriodically(ctx, freq, func() error {
+		return t.TweetOnce(fetch)
+	})
+}
+
+// RetweetOnceCtx is the same as RetweetOnce but it stops trying
+// to retweet and returns the context error once the context is done.
+func (t *TwitterBot) RetweetOnceCtx(ctx context.Context, queries, bannedQueries []string) error {
+	return t.autoRetweet(ctx, makeBannedByQuery(queries, bannedQueries))
+}
+
+// RetweetPeriodicallyCtx is the same as RetweetPeriodically
+// but it returns the context error once the context is done.
+func (t *TwitterBot) RetweetPeriodicallyCtx(ctx context.Context, searchQueries, bannedQueries []string, freq time.Duration) error {
+	bannedByQuery := makeBannedByQuery(searchQueries, bannedQueries)
+	return runPeriodically(ctx, freq, func() error {
+		return t.autoRetweet(ctx, bannedByQuery)
+	})
+}
+
+// AutoLikeOnceCtx is the same as AutoLikeOnce but it stops liking
+// and returns the context error once the context is done.
+func (t *TwitterBot) AutoLikeOnceCtx(ctx context.Context, queries []string, filter LikeFilter) error {
+	return t.autoLike(ctx, queries, filter)
+}
+
+// AutoLikePeriodicallyCtx is the same as AutoLikePeriodically
+// but it returns the context error once the context is done.
+func (t *TwitterBot) AutoLikePeriodicallyCtx(ctx context.Context, queries []string, filter LikeFilter, freq time.Duration) error {
+	return runPeriodically(ctx, freq, func() error {
+		return t.autoLike(ctx, queries, filter)
+	})
+}
+
+// SyncPeriodicallyCtx is the same as SyncPeriodically
+// but it returns the context error once the context is done.
+func (t *TwitterBot) SyncPeriodicallyCtx(ctx context.Context, freq time.Duration) error {
+	return runPeriodically(ctx, freq, t.Sync)
+}
+
+// AutoFollowFollowersCtx is the same as AutoFollowFollowers but the follows,
+// the sleeps between them and the waits for the follow quota are interrupted
+// once the context is done. It returns the context error in this case.
+func (t *TwitterBot) AutoFollowFollowersCtx(ctx context.Context, query string, maxPage int, filter FollowFilter, sleepPolicy SleepPolicy) error {
+	t.autoFollowFollowers(query, maxPage, filter, sleepPolicy, newCampaignContext(ctx, "AutoFollowFollowers"))
+	return ctx.Err()
+}
+
+// AutoUnfollowFriendsCtx automatically unfollows friends, see
+// AutoUnfollowFriendsAsync, until the context is done. The unfollows, the
+// sleeps between them and the waits between runs and for the unfollow quota
+// are interrupted once the context is done. It returns the context error.
+func (t *TwitterBot) AutoUnfollowFriendsCtx(ctx context.Context, sleepPolicy SleepPolicy) error {
+	SubsystemFollow.info("[twitter] launching auto unfollow...")
+	sleepPolicy.log()
+	t.unfollowAll(&sleepPolicy, newCampaignContext(ctx, "AutoUnfollowFriends"))
+	SubsystemFollow.info("[twitter] auto unfollow disabled")
+	return ctx.Err()
+}
+
+// UnfollowInactiveCtx is the same as UnfollowInactive but the unfollows
+// are interrupted once the context is done. It returns the context error
+// in this case.
+func (t *TwitterBot) UnfollowInactiveCtx(ctx context.Context, maxInactivity time.Duration, sleepPolicy SleepPolicy) error {
+	err := t.unfollowInactive(maxInactivity, &sleepPolicy, newCampaignContext(ctx, "UnfollowInactive"))
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,62 @@
+package twbot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestSleepContext(c *C) {
+	c.Assert(sleepContext(context.Background(), time.Millisecond), Equals, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(sleepContext(ctx, time.Hour), Equals, false)
+	c.Assert(randSleep(ctx, 3600), Equals, false)
+	c.Assert(maybeSleep(ctx, 1, 1, 3600, 7200), Equals, false)
+	c.Assert(maybeSleep(context.Background(), 0, 1, 3600, 7200), Equals, true)
+}
+
+func (s *MySuite) TestRunPeriodically(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	err := runPeriodically(ctx, time.Millisecond, func() error {
+		runs++
+		if runs == 3 {
+			cancel()
+		}
+		return errors.New("failed")
+	})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(runs, Equals, 3)
+}
+
+func (s *MySuite) TestAutoUnfollowFriendsCtx(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	// the 3 hours wait between runs is interrupted by the deadline
+	bot.unfollowPolicy = &unfollowPolicy{
+		idleWait: 3 * time.Hour,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- bot.AutoUnfollowFriendsCtx(ctx, SleepPolicy{})
+	}()
+	select {
+	case err := <-done:
+		c.Assert(err, Equals, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		c.Fatal("auto unfollow should return once the context is done")
+	}
+}
+
+func (s *MySuite) TestCampaignContext(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	campaign := newCampaignContext(ctx, "test")
+	c.Assert(campaign.Stopped(), Equals, false)
+	cancel()
+	c.Assert(campaign.Stopped(), Equals, true)
+	c.Assert(campaign.wait(), Equals, false)
+}
@@ -0,0 +1,110 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// ErrInvalidConsumerKeys is the reason of the credentials errors
+// caused by consumer keys unknown to twitter, see CredentialsError.
+var ErrInvalidConsumerKeys = errors.New("[twitter] invalid consumer key or secret")
+
+// credentialsHints are the actions fixing the
+// credentials errors of the given reasons.
+var credentialsHints = map[error]string{
+	ErrInvalidConsumerKeys: "check the API key and secret of the twitter app, TWITTER_CONSUMER_KEY and TWITTER_CONSUMER_SECRET by default",
+	ErrTokenExpired:        "check or regenerate the access token and secret of the account, TWITTER_ACCESS_TOKEN and TWITTER_ACCESS_SECRET by default, see AuthorizeInteractive",
+	ErrAccountSuspended:    "the account of the bot must be reinstated by twitter",
+	ErrAccountLocked:       "log in the account of the bot on twitter to unlock it",
+}
+
+// CredentialsError is returned when the twitter keys of the bot are rejected,
+// see VerifyCredentials. Its reason is one of ErrInvalidConsumerKeys,
+// ErrTokenExpired, ErrAccountSuspended or ErrAccountLocked, matched by
+// errors.Is, and the underlying *anaconda.ApiError is in its chain.
+type CredentialsError struct {
+	Reason error
+	Err    error
+}
+
+func (e *CredentialsError) Error() string {
+	return fmt.Sprintf("%v, %s: %v", e.Reason, credentialsHints[e.Reason], e.Err)
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CredentialsError) Is(target error) bool {
+	return target == e.Reason
+}
+
+// VerifyCredentials checks that the twitter keys of the bot are accepted and
+// returns its account. It returns a *CredentialsError telling whether the
+// consumer keys or the access token are invalid or whether the account is
+// suspended or locked, or the error of the call for the other failures.
+func (t *TwitterBot) VerifyCredentials() (anaconda.User, error) {
+	user, err := t.twitterClient.GetSelf(nil)
+	if err == nil {
+		logInfo("[twitter] credentials verified for account %s", user.ScreenName)
+		return user, nil
+	}
+	var reason error
+	for _, sentinel := range []error{ErrAccountSuspended, ErrAccountLocked, ErrTokenExpired} {
+		if errors.Is(err, sentinel) {
+			reason = sentinel
+			break
+		}
+	}
+	if reason == nil && isAuthenticationError(err) {
+		// twitter does not tell apart invalid consumer keys and invalid access tokens
+		reason = ErrTokenExpired
+		if !t.validConsumerKeys() {
+			reason = ErrInvalidConsumerKeys
+		}
+	}
+	if reason == nil {
+		return user, err
+	}
+	return user, &CredentialsError{
+		Reason: reason,
+		Err:    err,
+	}
+}
+
+// isAuthenticationError returns true if the given
+// error is a twitter API authentication error.
+func isAuthenticationError(err error) bool {
+	if hasAPIErrorCode(err, anaconda.TwitterErrorCouldNotAuthenticate,
+		anaconda.TwitterErrorCouldNotAuthenticateYou, anaconda.TwitterErrorBadAuthenticationData) {
+		return true
+	}
+	apiErr, ok := asAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// validConsumerKeys returns false if the consumer keys of the bot are rejected
+// when requesting an application-only token with them, see SetAppAuth.
+// It returns true if the keys are unknown, the bot using a fake client for
+// instance, or if the request failed for another reason.
+func (t *TwitterBot) validConsumerKeys() bool {
+	api, ok := t.twitterClient.(*apiClient)
+	if !ok {
+		return true
+	}
+	var client *anacondaClient
+	api.inner(func(inner TwitterClient) error {
+		client, ok = inner.(*anacondaClient)
+		return nil
+	})
+	if !ok {
+		return true
+	}
+	consumer := client.oauthClient.Credentials
+	_, err := newAppClient(t.newHTTPClient(t.baseTransport()), consumer.Token, consumer.Secret)
+	apiErr, ok := asAPIError(err)
+	return !ok || apiErr.StatusCode != http.StatusForbidden && apiErr.StatusCode != http.StatusUnauthorized
+}
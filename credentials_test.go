@@ -0,0 +1,64 @@
+package twbot
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestVerifyCredentials(c *C) {
+	verifyStatus, verifyBody, tokenStatus := 0, "", 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := verifyStatus, verifyBody
+		if req.URL.String() == twitterTokenURL {
+			status, body = tokenStatus, `{"token_type": "bearer", "access_token": "token"}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	bot := newTwitterBot("", "", "", "key", "secret", "token", "access", false,
+		WithHTTPClient(&http.Client{Transport: transport}))
+	// the rejected credentials would suspend the calls
+	bot.SetCircuitBreaker(0, 0)
+
+	verifyStatus, verifyBody = http.StatusOK, `{"id": 42, "screen_name": "bot"}`
+	user, err := bot.VerifyCredentials()
+	c.Assert(err, IsNil)
+	c.Assert(user.ScreenName, Equals, "bot")
+
+	check := func(status int, code int, tokenOK bool, reason error) {
+		verifyStatus, verifyBody = status, `{"errors": [{"code": `+strconv.Itoa(code)+`}]}`
+		tokenStatus = http.StatusForbidden
+		if tokenOK {
+			tokenStatus = http.StatusOK
+		}
+		_, err := bot.VerifyCredentials()
+		credentialsErr, ok := err.(*CredentialsError)
+		c.Assert(ok, Equals, true, Commentf("%v", err))
+		c.Assert(credentialsErr.Reason, Equals, reason)
+		c.Assert(errors.Is(err, reason), Equals, true)
+		var apiErr *anaconda.ApiError
+		c.Assert(errors.As(err, &apiErr), Equals, true)
+		c.Assert(apiErr.StatusCode, Equals, status)
+	}
+	check(http.StatusUnauthorized, anaconda.TwitterErrorCouldNotAuthenticate, false, ErrInvalidConsumerKeys)
+	check(http.StatusUnauthorized, anaconda.TwitterErrorCouldNotAuthenticate, true, ErrTokenExpired)
+	check(http.StatusUnauthorized, anaconda.TwitterErrorInvalidToken, false, ErrTokenExpired)
+	check(http.StatusForbidden, anaconda.TwitterErrorAccountSuspended, true, ErrAccountSuspended)
+	check(http.StatusForbidden, twitterErrorAccountLocked, true, ErrAccountLocked)
+
+	// the other errors are returned as is
+	verifyStatus, verifyBody = http.StatusNotFound, `{"errors": [{"code": 34}]}`
+	_, err = bot.VerifyCredentials()
+	_, ok := err.(*CredentialsError)
+	c.Assert(ok, Equals, false)
+}
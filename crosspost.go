@@ -0,0 +1,104 @@
+package twbot
+
+import (
+	"net/http"
+	"time"
+)
+
+const crossPosterTimeout = 30 * time.Second
+
+// Post is a post of the bot mirrored to another social network, see CrossPoster.
+type Post struct {
+	// Text is the message of the post, without its link.
+	Text string
+	// Link is the URL appended to the message, if any.
+	Link string
+	// Image is the raw image of the post, if any.
+	Image []byte
+}
+
+// CrossPoster mirrors the posts of the bot to another social network,
+// see AddCrossPoster, NewMastodonCrossPoster and NewBlueskyCrossPoster.
+type CrossPoster interface {
+	// CrossPost publishes the given post.
+	CrossPost(post Post) error
+}
+
+// AddCrossPoster registers the given cross-poster, so that every post of
+// TweetOnce and TweetImageOnce, and of their periodic versions, is mirrored
+// by it once tweeted. A failed cross-post is only logged, the tweet being
+// kept. Nothing is mirrored in dry-run mode, see SetDryRun. The Mastodon
+// and Bluesky cross-posters send their requests through the transport and
+// the timeout of the bot, see WithHTTPClient, WithProxy and WithTimeout.
+func (t *TwitterBot) AddCrossPoster(poster CrossPoster) {
+	if p, ok := poster.(httpPoster); ok {
+		p.setHTTPClient(t.crossPostClient())
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.crossPosters = append(t.crossPosters, poster)
+}
+
+// httpPoster is a cross-poster sending its requests with an http client
+// of the bot, see AddCrossPoster.
+type httpPoster interface {
+	setHTTPClient(client *http.Client)
+}
+
+// crossPostClient returns the http client of the cross-posters, bounded
+// by crossPosterTimeout unless the bot has its own timeout.
+func (t *TwitterBot) crossPostClient() *http.Client {
+	client := t.newHTTPClient(t.baseTransport())
+	if t.requestTimeout() == 0 {
+		client.Timeout = crossPosterTimeout
+	}
+	return client
+}
+
+// crossPost mirrors the given post with all the cross-posters of the bot.
+func (t *TwitterBot) crossPost(post Post) {
+	t.mutex.Lock()
+	posters := t.crossPosters
+	t.mutex.Unlock()
+	if len(posters) == 0 {
+		return
+	}
+	if t.isDryRun() {
+		logInfo("[twitter] simulating cross-post: %s", post.Text)
+		return
+	}
+	for _, poster := range posters {
+		err := poster.CrossPost(post)
+		if err != nil {
+			logError("[twitter] failed to cross-post, error: %v", err)
+		}
+	}
+}
+
+// fitPost returns the text of the given post followed by its link, the text
+// being truncated with an ellipsis so that the whole fits in 'maxSize'
+// characters, the link counting as 'linkSize' characters, or as its own
+// length if 'linkSize' is 0.
+func fitPost(post Post, maxSize, linkSize int) string {
+	text := []rune(post.Text)
+	if post.Link == "" {
+		if len(text) > maxSize {
+			return string(text[:maxSize-1]) + "…"
+		}
+		return string(text)
+	}
+	if linkSize == 0 {
+		linkSize = len([]rune(post.Link))
+	}
+	available := maxSize - linkSize - 1
+	if len(text) > available {
+		if available <= 1 {
+			return post.Link
+		}
+		text = append(text[:available-1], '…')
+	}
+	if len(text) == 0 {
+		return post.Link
+	}
+	return string(text) + " " + post.Link
+}
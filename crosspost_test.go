@@ -0,0 +1,118 @@
+package twbot
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type recordPoster struct {
+	posts []Post
+	err   error
+}
+
+func (r *recordPoster) CrossPost(post Post) error {
+	r.posts = append(r.posts, post)
+	return r.err
+}
+
+func (s *MySuite) TestFitPost(c *C) {
+	c.Assert(fitPost(Post{Text: "hello"}, 10, 0), Equals, "hello")
+	c.Assert(fitPost(Post{Text: "hello world"}, 10, 0), Equals, "hello wor…")
+	c.Assert(fitPost(Post{Text: "hello", Link: "http://a.b"}, 20, 0), Equals, "hello http://a.b")
+	c.Assert(fitPost(Post{Text: "hello world", Link: "http://a.b"}, 20, 0), Equals, "hello wo… http://a.b")
+	c.Assert(fitPost(Post{Text: "hello world", Link: "http://a.b"}, 20, 5), Equals, "hello world http://a.b")
+	c.Assert(fitPost(Post{Text: "hello", Link: "http://a.b"}, 10, 0), Equals, "http://a.b")
+	c.Assert(fitPost(Post{Link: "http://a.b"}, 20, 0), Equals, "http://a.b")
+}
+
+func (s *MySuite) TestCrossPost(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	poster := &recordPoster{}
+	failing := &recordPoster{err: errors.New("unavailable")}
+	bot.AddCrossPoster(failing)
+	bot.AddCrossPoster(poster)
+
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"hello"})
+	c.Assert(poster.posts, DeepEquals, []Post{{Text: "hello"}})
+	c.Assert(failing.posts, HasLen, 1)
+
+	// nothing is mirrored if the tweet failed or in dry-run mode
+	c.Assert(bot.TweetOnce(func() (string, error) { return "", errors.New("no message") }), NotNil)
+	bot.dryRun = true
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	c.Assert(bot.TweetOnce(func() (string, error) { return "simulated", nil }), IsNil)
+	c.Assert(poster.posts, HasLen, 1)
+}
+
+func (s *MySuite) TestCrossPosterHTTPClient(c *C) {
+	hosts := []string{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id": "8"}`)),
+			Request:    req,
+		}, nil
+	})
+	bot := newTwitterBot("", "", "", "key", "secret", "token", "access", false,
+		WithHTTPClient(&http.Client{Transport: transport}))
+	defer bot.Close()
+	mastodon := NewMastodonCrossPoster("https://mastodon.example", "token")
+	bluesky := NewBlueskyCrossPoster("https://bluesky.example", "bot.bsky.social", "password")
+	bot.AddCrossPoster(mastodon)
+	bot.AddCrossPoster(bluesky)
+	// the cross-posters use the transport of the bot, bounded by their own timeout
+	c.Assert(mastodon.(*mastodonPoster).client.Timeout, Equals, crossPosterTimeout)
+	c.Assert(mastodon.CrossPost(Post{Text: "hello"}), IsNil)
+	bluesky.CrossPost(Post{Text: "hello"})
+	c.Assert(hosts[:2], DeepEquals, []string{"mastodon.example", "bluesky.example"})
+
+	bot = newTwitterBot("", "", "", "key", "secret", "token", "access", false,
+		WithHTTPClient(&http.Client{Transport: transport}), WithTimeout(time.Minute))
+	defer bot.Close()
+	mastodon = NewMastodonCrossPoster("https://mastodon.example", "token")
+	bot.AddCrossPoster(mastodon)
+	c.Assert(mastodon.(*mastodonPoster).client.Timeout, Equals, time.Duration(0))
+}
+
+func (s *MySuite) TestMastodonCrossPoster(c *C) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("Authorization"), Equals, "Bearer token")
+		switch r.URL.Path {
+		case "/api/v2/media":
+			file, _, err := r.FormFile("file")
+			c.Assert(err, IsNil)
+			data, err := ioutil.ReadAll(file)
+			c.Assert(err, IsNil)
+			requests = append(requests, "media "+string(data))
+			w.Write([]byte(`{"id": "7"}`))
+		case "/api/v1/statuses":
+			requests = append(requests, "status "+r.FormValue("status")+" "+r.FormValue("media_ids[]"))
+			w.Write([]byte(`{"id": "8"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	poster := NewMastodonCrossPoster(server.URL+"/", "token")
+	c.Assert(poster.CrossPost(Post{Text: "hello", Link: "https://example.com", Image: []byte("image")}), IsNil)
+	c.Assert(poster.CrossPost(Post{Text: strings.Repeat("a", 600)}), IsNil)
+	c.Assert(requests, HasLen, 3)
+	c.Assert(requests[0], Equals, "media image")
+	c.Assert(requests[1], Equals, "status hello https://example.com 7")
+	c.Assert(len([]rune(requests[2])), Equals, len("status  ")+mastodonMaxSize)
+
+	poster = NewMastodonCrossPoster(server.URL+"/unknown", "token")
+	c.Assert(poster.CrossPost(Post{Text: "hello"}), ErrorMatches, `\[twitter\] mastodon request /api/v1/statuses failed: 404 Not Found`)
+}
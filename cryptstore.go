@@ -0,0 +1,108 @@
+package twbot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	encryptionKeyEnv = "TWITTER_DB_KEY"
+)
+
+// encryptedDatabase is the document saved by the encrypted store: the
+// nonce followed by the AES-GCM encrypted JSON database.
+type encryptedDatabase struct {
+	Data []byte `json:"data"`
+}
+
+// cryptStore encrypts the databases saved in another store.
+type cryptStore struct {
+	store Store
+	aead  cipher.AEAD
+}
+
+// NewEncryptedStore returns a store encrypting with AES-GCM the databases
+// saved in the given 'store', so that the followers and friends databases
+// are not stored in plaintext on shared servers. The 'key' must be 16, 24
+// or 32 bytes long, see LoadEncryptionKey. Plaintext databases can be
+// encrypted with MigrateStore.
+func NewEncryptedStore(store Store, key []byte) (Store, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &cryptStore{
+		store: store,
+		aead:  aead,
+	}, nil
+}
+
+// LoadEncryptionKey returns the base64 encoded encryption key of the
+// TWITTER_DB_KEY environment variable if defined, or of the given 'keyFile'
+// otherwise, see NewEncryptedStore.
+func LoadEncryptionKey(keyFile string) ([]byte, error) {
+	encoded := os.Getenv(encryptionKeyEnv)
+	if encoded == "" {
+		data, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(data)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+}
+
+func (s *cryptStore) Exists(path string) (bool, error) {
+	return s.store.Exists(path)
+}
+
+// Load decrypts the database, authenticated by its path.
+func (s *cryptStore) Load(path string, v interface{}) error {
+	database := &encryptedDatabase{}
+	err := s.store.Load(path, database)
+	if err != nil {
+		return err
+	}
+	size := s.aead.NonceSize()
+	if len(database.Data) < size {
+		return fmt.Errorf("[twitter] database %s is not encrypted", path)
+	}
+	data, err := s.aead.Open(nil, database.Data[:size], database.Data[size:], []byte(path))
+	if err != nil {
+		return fmt.Errorf("[twitter] failed to decrypt database %s: %v", path, err)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Save encrypts the database with a random nonce, the path
+// being authenticated so that databases cannot be swapped.
+func (s *cryptStore) Save(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return err
+	}
+	return s.store.Save(path, &encryptedDatabase{
+		Data: s.aead.Seal(nonce, nonce, data, []byte(path)),
+	})
+}
+
+func (s *cryptStore) Close() error {
+	return s.store.Close()
+}
@@ -0,0 +1,53 @@
+package twbot
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestEncryptedStore(c *C) {
+	dir := c.MkDir()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	_, err := NewEncryptedStore(NewJSONStore(), key[:10])
+	c.Assert(err, NotNil)
+	store, err := NewEncryptedStore(NewJSONStore(), key)
+	c.Assert(err, IsNil)
+	path := filepath.Join(dir, "friends.json")
+	testStore(c, store, path)
+
+	// databases are not stored in plaintext
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "timestamp"), Equals, false)
+
+	// nor can they be swapped or decrypted with another key
+	other := filepath.Join(dir, "followers.json")
+	c.Assert(ioutil.WriteFile(other, data, 0644), IsNil)
+	c.Assert(store.Load(other, &twitterUsers{}), ErrorMatches, ".*failed to decrypt.*")
+	store, err = NewEncryptedStore(NewJSONStore(), []byte("fedcba9876543210fedcba9876543210"))
+	c.Assert(err, IsNil)
+	c.Assert(store.Load(path, &twitterUsers{}), ErrorMatches, ".*failed to decrypt.*")
+}
+
+func (s *MySuite) TestLoadEncryptionKey(c *C) {
+	keyFile := filepath.Join(c.MkDir(), "key")
+	encoded := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	c.Assert(ioutil.WriteFile(keyFile, []byte(encoded+"\n"), 0600), IsNil)
+	previous := os.Getenv(encryptionKeyEnv)
+	defer os.Setenv(encryptionKeyEnv, previous)
+
+	os.Setenv(encryptionKeyEnv, "")
+	key, err := LoadEncryptionKey(keyFile)
+	c.Assert(err, IsNil)
+	c.Assert(string(key), Equals, "0123456789abcdef")
+
+	os.Setenv(encryptionKeyEnv, base64.StdEncoding.EncodeToString([]byte("fedcba9876543210")))
+	key, err = LoadEncryptionKey(keyFile)
+	c.Assert(err, IsNil)
+	c.Assert(string(key), Equals, "fedcba9876543210")
+}
@@ -0,0 +1,135 @@
+package twbot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	maxDirectMessagesCount        = 200 // twitter API limit
+	twitterErrorNotFollowingYou   = 150 // cannot send messages to users who are not following you
+	twitterErrorCannotMessageUser = 349 // cannot send messages to this user
+)
+
+// isDMRefusedError returns true if the error means that the
+// recipient does not accept direct messages from the bot.
+func isDMRefusedError(err error) bool {
+	return hasAPIErrorCode(err, twitterErrorNotFollowingYou, twitterErrorCannotMessageUser)
+}
+
+func (t *TwitterBot) checkDMError(err error, recipient string) error {
+	if isDMRefusedError(err) {
+		return fmt.Errorf("[twitter] user %s does not accept direct messages from the bot: %w", recipient, err)
+	}
+	t.checkBotRestriction(err)
+	return err
+}
+
+// SendDM sends a direct message with the given text to the user of the given id.
+// It returns an error if the user does not accept direct messages from the bot,
+// when not following it for instance.
+func (t *TwitterBot) SendDM(userID int64, text string) error {
+	_, err := t.twitterClient.PostDMToUserId(text, userID)
+	if err != nil {
+		return t.checkDMError(err, strconv.FormatInt(userID, 10))
+	}
+	return nil
+}
+
+// SendDMToScreenName sends a direct message with the given text to the user
+// of the given screen name, see SendDM.
+func (t *TwitterBot) SendDMToScreenName(screenName, text string) error {
+	_, err := t.twitterClient.PostDMToScreenName(text, screenName)
+	if err != nil {
+		return t.checkDMError(err, screenName)
+	}
+	return nil
+}
+
+// DMSliceOnce sends to each user of 'userIDs' the direct message returned by
+// the 'fetch' callback for this user, for announcements for instance. Messages
+// are paced by the default sleep policy, see MakeTwitterBot. It returns the
+// result of each user by id, a nil error meaning the message was sent.
+func (t *TwitterBot) DMSliceOnce(userIDs []int64, fetch func(userID int64) (string, error)) map[int64]error {
+	results := make(map[int64]error, len(userIDs))
+	sleepPolicy := t.checkSleepPolicy(nil)
+	for i, userID := range userIDs {
+		text, err := fetch(userID)
+		if err == nil {
+			err = t.SendDM(userID, text)
+		}
+		results[userID] = err
+		if err != nil {
+			print(t, fmt.Sprintf("[twitter] failed to send direct message to user (id:%d), error: %v\n", userID, err))
+			continue
+		}
+		if i < len(userIDs)-1 {
+			t.controlledSleep(&sleepPolicy)
+		}
+	}
+	return results
+}
+
+// checkDirectMessages calls the handler on the direct messages received since
+// the last check, the oldest first, and replies with the handler reply if any.
+// The first check only records the most recent direct message.
+func (t *TwitterBot) checkDirectMessages(handler func(dm anaconda.DirectMessage) (string, bool)) error {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxDirectMessagesCount))
+	sinceID, ok := t.getSinceID(directMessagesSinceID)
+	if sinceID > 0 {
+		v.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+	messages, err := t.twitterClient.GetDirectMessages(v)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		if !ok {
+			t.setSinceID(directMessagesSinceID, 0)
+		}
+		return nil
+	}
+	if ok {
+		for i := len(messages) - 1; i >= 0; i-- {
+			dm := messages[i]
+			reply, ok := handler(dm)
+			if !ok {
+				continue
+			}
+			err := t.SendDM(dm.SenderId, reply)
+			if err != nil {
+				logError("%v", err)
+			}
+		}
+	}
+	t.setSinceID(directMessagesSinceID, messages[0].Id)
+	return nil
+}
+
+// OnDirectMessage polls the direct messages received by the bot and calls the
+// given 'handler' on each new direct message, the oldest first. If the handler
+// returns true, its reply is sent back to the sender: it allows bots to
+// implement simple command interfaces for their operators for instance.
+// Direct messages received before the first poll are ignored, see
+// SetStatePath to keep track of the handled direct messages across restarts.
+// The poll frequency is set up by the given 'freq' input parameter.
+// It logs errors if the poll or the replies failed.
+func (t *TwitterBot) OnDirectMessage(handler func(dm anaconda.DirectMessage) (string, bool), freq time.Duration) {
+	t.newJob("OnDirectMessage").runPeriodically(freq, func() error {
+		return t.checkDirectMessages(handler)
+	})
+}
+
+// OnDirectMessageAsync polls asynchronously the direct messages
+// received by the bot, see OnDirectMessage.
+func (t *TwitterBot) OnDirectMessageAsync(handler func(dm anaconda.DirectMessage) (string, bool), freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("OnDirectMessage", freq, func(ctx context.Context) error {
+		return t.checkDirectMessages(handler)
+	})
+}
@@ -0,0 +1,134 @@
+package twbot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestSendDM(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	c.Assert(bot.SendDM(1, "hello"), IsNil)
+	c.Assert(client.messages, DeepEquals, []string{"hello"})
+
+	err := bot.SendDM(-1, "hello")
+	c.Assert(err, ErrorMatches, ".*does not accept direct messages.*")
+	c.Assert(isDMRefusedError(fmt.Errorf("not an api error")), Equals, false)
+}
+
+func (s *MySuite) TestCheckDirectMessages(c *C) {
+	client := &fakeClient{
+		received: []anaconda.DirectMessage{{Id: 1, SenderId: 10, Text: "old"}},
+	}
+	bot := makeFakeBot(client)
+	bot.state = &twitterState{
+		SinceIDs: map[string]int64{},
+	}
+	handled := []string{}
+	handler := func(dm anaconda.DirectMessage) (string, bool) {
+		handled = append(handled, dm.Text)
+		return "pong", dm.Text == "ping"
+	}
+	// direct messages received before the first check are ignored
+	c.Assert(bot.checkDirectMessages(handler), IsNil)
+	c.Assert(handled, HasLen, 0)
+
+	client.received = append(client.received,
+		anaconda.DirectMessage{Id: 2, SenderId: 10, Text: "ping"},
+		anaconda.DirectMessage{Id: 3, SenderId: 10, Text: "status"})
+	c.Assert(bot.checkDirectMessages(handler), IsNil)
+	c.Assert(handled, DeepEquals, []string{"ping", "status"})
+	c.Assert(client.messages, DeepEquals, []string{"pong"})
+
+	c.Assert(bot.checkDirectMessages(handler), IsNil)
+	c.Assert(handled, HasLen, 2)
+}
+
+func (s *MySuite) TestDMSliceOnce(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.noSleep = true
+	bot.defaultSleepPolicy = &SleepPolicy{}
+	fetch := func(userID int64) (string, error) {
+		if userID == 2 {
+			return "", fmt.Errorf("no message for user %d", userID)
+		}
+		return fmt.Sprintf("hello %d", userID), nil
+	}
+	results := bot.DMSliceOnce([]int64{1, 2, -3}, fetch)
+	c.Assert(results, HasLen, 3)
+	c.Assert(results[1], IsNil)
+	c.Assert(results[2], ErrorMatches, "no message for user 2")
+	c.Assert(results[-3], ErrorMatches, ".*does not accept direct messages.*")
+	c.Assert(client.messages, DeepEquals, []string{"hello 1"})
+}
+
+func (s *MySuite) TestDirectMessageEvents(c *C) {
+	requests := []string{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := ""
+		switch req.URL.Path {
+		case "/1.1/direct_messages/events/new.json":
+			payload, err := ioutil.ReadAll(req.Body)
+			c.Assert(err, IsNil)
+			requests = append(requests, string(payload))
+			body = `{"event": {"type": "message_create", "id": "110", "created_timestamp": "1488240000000",
+				"message_create": {"target": {"recipient_id": "7"}, "sender_id": "42", "message_data": {"text": "hello"}}}}`
+		case "/1.1/direct_messages/events/list.json":
+			cursor := req.URL.Query().Get("cursor")
+			requests = append(requests, "list "+cursor)
+			body = `{"events": [
+				{"type": "message_create", "id": "105", "message_create": {"sender_id": "42", "message_data": {"text": "sent"}}},
+				{"type": "message_create", "id": "104", "message_create": {"sender_id": "7", "message_data": {"text": "ping"}}}
+			], "next_cursor": "next"}`
+			if cursor == "next" {
+				body = `{"events": [
+					{"type": "message_create", "id": "103", "message_create": {"sender_id": "7", "message_data": {"text": "status"}}},
+					{"type": "message_create", "id": "101", "message_create": {"sender_id": "7", "message_data": {"text": "handled"}}}
+				]}`
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+	client := newAnacondaClient("key", "secret", "token", "access")
+	client.HttpClient = &http.Client{Transport: transport}
+	client.selfID = 42
+
+	dm, err := client.PostDMToUserId("hello", 7)
+	c.Assert(err, IsNil)
+	c.Assert(requests, DeepEquals, []string{
+		`{"event":{"type":"message_create","message_create":{"target":{"recipient_id":"7"},"message_data":{"text":"hello"}}}}`,
+	})
+	c.Assert(dm.Id, Equals, int64(110))
+	c.Assert(dm.SenderId, Equals, int64(42))
+	c.Assert(dm.RecipientId, Equals, int64(7))
+	c.Assert(dm.Text, Equals, "hello")
+	c.Assert(dm.CreatedAt, Equals, "Tue Feb 28 00:00:00 +0000 2017")
+
+	// only the direct messages received since the since id are returned
+	requests = nil
+	v := url.Values{}
+	v.Set("since_id", "101")
+	messages, err := client.GetDirectMessages(v)
+	c.Assert(err, IsNil)
+	c.Assert(requests, DeepEquals, []string{"list ", "list next"})
+	c.Assert(messages, HasLen, 2)
+	c.Assert(messages[0].Text, Equals, "ping")
+	c.Assert(messages[1].Text, Equals, "status")
+
+	v.Set("count", "1")
+	messages, err = client.GetDirectMessages(v)
+	c.Assert(err, IsNil)
+	c.Assert(messages, HasLen, 1)
+}
@@ -0,0 +1,149 @@
+package twbot
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// SetDryRun enables or disables the dry-run mode of the bot: the tweets,
+// retweets, likes, follows, unfollows, direct messages and the other write
+// actions are logged as simulated events but no write call is made to the
+// twitter API, so that new queries and policies can be tested safely on a
+// real account. The simulated tweets and retweets are given negative ids.
+// The databases of the bot are left untouched in dry-run mode: their changes,
+// such as the simulated tweets, friends or likes, are recorded apart in the
+// simulated databases of the store, at the paths of the databases suffixed by
+// ".dryrun", so that they can be reviewed. Once the dry-run mode is disabled,
+// the databases are loaded again from the store, the next dry run starting
+// again from them.
+func (t *TwitterBot) SetDryRun(dryRun bool) {
+	logInfo("[twitter] setting dry run -> dryRun: %t", dryRun)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.dryRun = dryRun
+	store, ok := t.store.(*dryRunStore)
+	if dryRun && !ok {
+		t.store = newDryRunStore(t.store)
+	} else if !dryRun && ok {
+		t.store = store.Store
+		err := t.reloadDatabases()
+		if err != nil {
+			SubsystemStore.error("[twitter] failed to reload the databases after dry run: %v", err)
+		}
+	}
+}
+
+func (t *TwitterBot) isDryRun() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.dryRun
+}
+
+const (
+	dryRunExt = ".dryrun" // suffix of the paths of the simulated databases
+)
+
+// lastSimulatedID is the last id given to a simulated tweet, direct message
+// or media, decremented so that the simulated ids never clash with real ones.
+var lastSimulatedID int64
+
+func nextSimulatedID() int64 {
+	return atomic.AddInt64(&lastSimulatedID, -1)
+}
+
+// simulate returns true if the bot is in dry-run mode, logging in this case
+// the simulated event of the given write 'action' instead of calling the API.
+// An empty 'action' is not logged.
+func (c *apiClient) simulate(action string, event Event) bool {
+	if !c.bot.isDryRun() {
+		return false
+	}
+	if action != "" {
+		event.Simulated = true
+		c.report(action, event, nil)
+	}
+	return true
+}
+
+// simulatedTweet returns a tweet with a simulated id.
+func simulatedTweet(text string) (tweet anaconda.Tweet) {
+	tweet.Id = nextSimulatedID()
+	tweet.IdStr = strconv.FormatInt(tweet.Id, 10)
+	tweet.Text = text
+	tweet.CreatedAt = timeNow().Format(time.RubyDate)
+	return tweet
+}
+
+// dryRunStore saves the databases in dry-run mode apart from the databases
+// of the bot, in the simulated databases of the store, see dryRunExt.
+type dryRunStore struct {
+	Store // store of the bot
+	mutex sync.Mutex
+	saved map[string]bool // databases saved in dry-run mode
+}
+
+func newDryRunStore(store Store) *dryRunStore {
+	return &dryRunStore{
+		Store: store,
+		saved: make(map[string]bool),
+	}
+}
+
+func (s *dryRunStore) isSaved(path string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saved[path]
+}
+
+func (s *dryRunStore) Exists(path string) (bool, error) {
+	if s.isSaved(path) {
+		return true, nil
+	}
+	return s.Store.Exists(path)
+}
+
+func (s *dryRunStore) Load(path string, v interface{}) error {
+	if s.isSaved(path) {
+		return s.Store.Load(path+dryRunExt, v)
+	}
+	return s.Store.Load(path, v)
+}
+
+func (s *dryRunStore) Save(path string, v interface{}) error {
+	err := s.Store.Save(path+dryRunExt, v)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.saved[path] = true
+	return nil
+}
+
+// reloadDatabases loads again the databases kept in memory from the store,
+// discarding their changes. The databases without a path are kept as is.
+// It must be called with the bot mutex locked.
+func (t *TwitterBot) reloadDatabases() error {
+	for _, db := range t.databases() {
+		if db.path == "" || db.empty == nil {
+			continue
+		}
+		v := db.empty()
+		ok, err := t.store.Exists(db.path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = t.store.Load(db.path, v)
+			if err != nil {
+				return err
+			}
+		}
+		db.set(v)
+	}
+	return nil
+}
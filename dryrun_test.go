@@ -0,0 +1,68 @@
+package twbot
+
+import (
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestDryRun(c *C) {
+	logger := &eventLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	store := bot.store
+	bot.friendsPath = "friends.json"
+	bot.tweetsPath = "tweets.json"
+	bot.addFriend(&anaconda.User{Id: 1}, "test")
+	bot.SetDryRun(true)
+
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(bot.SendDM(1, "hello"), IsNil)
+	user, err := bot.twitterClient.FollowUserId(42, nil)
+	c.Assert(err, IsNil)
+	bot.addFriend(&user, "test")
+	retweet, err := bot.twitterClient.Retweet(7, false)
+	c.Assert(err, IsNil)
+	c.Assert(retweet.Id < 0, Equals, true)
+	c.Assert(retweet.RetweetedStatus.Id, Equals, int64(7))
+	c.Assert(bot.saveRetweeted(retweet), IsNil)
+	// no write call is made
+	c.Assert(client.tweets, HasLen, 0)
+	c.Assert(client.messages, HasLen, 0)
+	// the simulated friends and tweets are recorded apart
+	c.Assert(bot.friends.Ids["42"].Follow, Equals, true)
+	friends := &twitterUsers{}
+	c.Assert(store.Load("friends.json", friends), IsNil)
+	c.Assert(friends.Ids, HasLen, 1)
+	c.Assert(store.Load("friends.json"+dryRunExt, friends), IsNil)
+	c.Assert(friends.Ids, HasLen, 2)
+	c.Assert(friends.Ids["42"].Follow, Equals, true)
+	exists, err := store.Exists("tweets.json")
+	c.Assert(err, IsNil)
+	c.Assert(exists, Equals, false)
+	tweets := []anaconda.Tweet{}
+	c.Assert(store.Load("tweets.json"+dryRunExt, &tweets), IsNil)
+	c.Assert(tweets, HasLen, 1)
+	c.Assert(tweets[0].Id, Equals, retweet.Id)
+	c.Assert(logger.events, HasLen, 4)
+	for _, event := range logger.events {
+		c.Assert(event.Simulated, Equals, true)
+	}
+	c.Assert(logger.events[0].Action, Equals, "tweet_success")
+	c.Assert(logger.events[0].TweetID < 0, Equals, true)
+	c.Assert(logger.events[2].Action, Equals, "follow_success")
+
+	// and discarded from the databases of the bot once the dry run is over
+	bot.SetDryRun(false)
+	c.Assert(bot.store, Equals, store)
+	c.Assert(bot.friends.Ids, HasLen, 1)
+	c.Assert(bot.friends.Ids["1"], NotNil)
+	loaded, err := bot.loadTweets()
+	c.Assert(err, IsNil)
+	c.Assert(loaded, HasLen, 0)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"hello"})
+	c.Assert(logger.events[4].Simulated, Equals, false)
+}
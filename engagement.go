@@ -0,0 +1,166 @@
+package twbot
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	maxTimelineCount  = 200 // twitter API limit
+	engagementSinceID = "engagement"
+)
+
+// TweetEngagement represents the engagement of a tweet of the bot,
+// see TrackEngagement.
+type TweetEngagement struct {
+	ID        int64     `json:"id"`
+	Text      string    `json:"text"`
+	Created   time.Time `json:"created"`
+	Favorites int       `json:"favorites"`
+	Retweets  int       `json:"retweets"`
+	Replies   int       `json:"replies"`
+	Updated   time.Time `json:"updated"`
+}
+
+// Score returns the total engagement of the tweet, the sum of
+// its favorites, retweets and replies.
+func (e TweetEngagement) Score() int {
+	return e.Favorites + e.Retweets + e.Replies
+}
+
+type twitterEngagement struct {
+	Tweets map[string]*TweetEngagement `json:"tweets"` // map tweet id -> engagement
+}
+
+// SetEngagementPath sets the path of the engagement database, where the
+// engagement of the tweets of the bot is recorded, see TrackEngagement.
+// If no path is set, the engagement is only kept in memory.
+func (t *TwitterBot) SetEngagementPath(engagementPath string) error {
+	engagement := &twitterEngagement{
+		Tweets: make(map[string]*TweetEngagement),
+	}
+	err := t.loadOrCreate(engagementPath, engagement)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	// keep the tweets recorded before the database was set
+	for id, tweet := range t.engagement.Tweets {
+		engagement.Tweets[id] = tweet
+	}
+	t.engagementPath = engagementPath
+	t.engagement = engagement
+	return t.store.Save(t.engagementPath, t.engagement)
+}
+
+// recordEngagement records the favorites and retweets of the recent tweets
+// of the bot and counts the replies to them among the new mentions.
+func (t *TwitterBot) recordEngagement() error {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxTimelineCount))
+	v.Set("include_rts", "false")
+	timeline, err := t.twitterClient.GetUserTimeline(v)
+	if err != nil {
+		return err
+	}
+	v = url.Values{}
+	v.Set("count", strconv.Itoa(maxMentionsCount))
+	sinceID, _ := t.getSinceID(engagementSinceID)
+	if sinceID > 0 {
+		v.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+	mentions, err := t.twitterClient.GetMentionsTimeline(v)
+	if err != nil {
+		return err
+	}
+	now := timeNow()
+	t.mutex.Lock()
+	for _, tweet := range timeline {
+		if tweet.RetweetedStatus != nil {
+			continue
+		}
+		created, err := tweet.CreatedAtTime()
+		if err != nil {
+			created = now
+		}
+		id := strconv.FormatInt(tweet.Id, 10)
+		engagement, ok := t.engagement.Tweets[id]
+		if !ok {
+			engagement = &TweetEngagement{ID: tweet.Id}
+			t.engagement.Tweets[id] = engagement
+		}
+		engagement.Text = tweet.FullText
+		if engagement.Text == "" {
+			engagement.Text = tweet.Text
+		}
+		engagement.Created = created
+		engagement.Favorites = tweet.FavoriteCount
+		engagement.Retweets = tweet.RetweetCount
+		engagement.Updated = now
+	}
+	for _, mention := range mentions {
+		engagement, ok := t.engagement.Tweets[strconv.FormatInt(mention.InReplyToStatusID, 10)]
+		if ok {
+			engagement.Replies++
+			engagement.Updated = now
+		}
+	}
+	if t.engagementPath != "" {
+		err = t.store.Save(t.engagementPath, t.engagement)
+	}
+	t.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(mentions) > 0 {
+		// mentions are sorted from the most recent to the oldest one
+		t.setSinceID(engagementSinceID, mentions[0].Id)
+	}
+	logInfo("[twitter] recorded engagement of %d tweets and %d mentions", len(timeline), len(mentions))
+	return nil
+}
+
+// TrackEngagement fetches periodically the recent tweets of the bot and
+// records their favorites, retweets and replies, counted from the mentions
+// since the tracking started, so that TopTweets tells which tweets perform.
+// The fetch frequency is set up by the given 'freq' input parameter.
+// It logs errors if the fetch failed.
+func (t *TwitterBot) TrackEngagement(freq time.Duration) {
+	t.newJob("TrackEngagement").runPeriodically(freq, func() error {
+		return t.recordEngagement()
+	})
+}
+
+// TrackEngagementAsync fetches asynchronously and periodically
+// the engagement of the tweets of the bot, see TrackEngagement.
+func (t *TwitterBot) TrackEngagementAsync(freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("TrackEngagement", freq, func(ctx context.Context) error {
+		return t.recordEngagement()
+	})
+}
+
+// TopTweets returns the tweets of the bot posted over the given 'period'
+// sorted by decreasing engagement, see TweetEngagement.Score and
+// TrackEngagement. Tweets with the same score are sorted by recency.
+func (t *TwitterBot) TopTweets(period time.Duration) []TweetEngagement {
+	since := timeNow().Add(-period)
+	t.mutex.Lock()
+	tweets := []TweetEngagement{}
+	for _, tweet := range t.engagement.Tweets {
+		if !tweet.Created.Before(since) {
+			tweets = append(tweets, *tweet)
+		}
+	}
+	t.mutex.Unlock()
+	sort.Slice(tweets, func(i, j int) bool {
+		if tweets[i].Score() != tweets[j].Score() {
+			return tweets[i].Score() > tweets[j].Score()
+		}
+		return tweets[i].Created.After(tweets[j].Created)
+	})
+	return tweets
+}
@@ -0,0 +1,76 @@
+package twbot
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+// timelineClient returns the given tweets as the timeline of the bot.
+type timelineClient struct {
+	*fakeClient
+	timeline []anaconda.Tweet
+}
+
+func (f *timelineClient) GetUserTimeline(v url.Values) ([]anaconda.Tweet, error) {
+	return f.timeline, nil
+}
+
+func makeTimelineTweet(id int64, created time.Time, favorites, retweets int) anaconda.Tweet {
+	return anaconda.Tweet{
+		Id:            id,
+		Text:          "tweet",
+		CreatedAt:     created.Format(time.RubyDate),
+		FavoriteCount: favorites,
+		RetweetCount:  retweets,
+	}
+}
+
+func (s *MySuite) TestTopTweets(c *C) {
+	now := time.Now().Truncate(time.Second)
+	client := &timelineClient{
+		fakeClient: &fakeClient{
+			mentions: []anaconda.Tweet{{Id: 10, InReplyToStatusID: 2}, {Id: 11, InReplyToStatusID: 5}},
+		},
+		timeline: []anaconda.Tweet{
+			makeTimelineTweet(3, now.Add(-time.Hour), 1, 0),
+			makeTimelineTweet(2, now.Add(-2*time.Hour), 2, 1),
+			makeTimelineTweet(1, now.Add(-72*time.Hour), 10, 10),
+		},
+	}
+	client.timeline = append(client.timeline, anaconda.Tweet{Id: 4, RetweetedStatus: &anaconda.Tweet{Id: 5}})
+	bot := makeFakeBot(client.fakeClient)
+	bot.twitterClient = client
+	bot.state = &twitterState{SinceIDs: map[string]int64{}}
+	bot.engagement = &twitterEngagement{Tweets: map[string]*TweetEngagement{}}
+	c.Assert(bot.SetEngagementPath("engagement.json"), IsNil)
+
+	c.Assert(bot.recordEngagement(), IsNil)
+	tweets := bot.TopTweets(24 * time.Hour)
+	c.Assert(tweets, HasLen, 2)
+	c.Assert(tweets[0].ID, Equals, int64(2))
+	c.Assert(tweets[0].Replies, Equals, 1)
+	c.Assert(tweets[0].Score(), Equals, 4)
+	c.Assert(tweets[0].Created.Equal(now.Add(-2*time.Hour)), Equals, true)
+	c.Assert(tweets[1].ID, Equals, int64(3))
+	c.Assert(bot.TopTweets(7 * 24 * time.Hour)[0].ID, Equals, int64(1))
+
+	// the replies already counted are not counted twice
+	for id := int64(12); id < 15; id++ {
+		client.mentions = append(client.mentions, anaconda.Tweet{Id: id, InReplyToStatusID: 3})
+	}
+	c.Assert(bot.recordEngagement(), IsNil)
+	tweets = bot.TopTweets(24 * time.Hour)
+	// tweets with the same score are sorted by recency
+	c.Assert(tweets[0].ID, Equals, int64(3))
+	c.Assert(tweets[0].Replies, Equals, 3)
+	c.Assert(tweets[0].Score(), Equals, 4)
+	c.Assert(tweets[1].Replies, Equals, 1)
+
+	engagement := &twitterEngagement{}
+	c.Assert(bot.store.Load("engagement.json", engagement), IsNil)
+	c.Assert(engagement.Tweets, HasLen, 3)
+	c.Assert(engagement.Tweets["3"].Replies, Equals, 3)
+}
@@ -0,0 +1,83 @@
+package twbot
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	errorRetryMax   = 3
+	errorRetryDelay = 5 * time.Second
+)
+
+// ErrorPolicy controls how the bot handles the errors it can recover from
+// while running, like a failed database save or a locked account.
+type ErrorPolicy int
+
+const (
+	// ErrorFailFast stops the bot on the first error. It is the default.
+	ErrorFailFast ErrorPolicy = iota
+	// ErrorRetry retries the failed operation a few times when possible,
+	// then logs the error and goes on.
+	ErrorRetry
+	// ErrorSkip logs the error and goes on.
+	ErrorSkip
+	// ErrorCallback passes the error to the callback given
+	// to SetErrorPolicy and goes on.
+	ErrorCallback
+)
+
+type errorPolicy struct {
+	mutex    sync.Mutex
+	policy   ErrorPolicy
+	callback func(err error)
+}
+
+// SetErrorPolicy sets how the errors the bot can recover from are handled
+// by all the running features, see ErrorPolicy. The 'callback' is only
+// used by the ErrorCallback policy and is called from the goroutine that
+// failed, it must not be nil for this policy.
+func (t *TwitterBot) SetErrorPolicy(policy ErrorPolicy, callback func(err error)) {
+	logInfo("[twitter] setting error policy -> policy: %d", policy)
+	t.errorPolicy.mutex.Lock()
+	defer t.errorPolicy.mutex.Unlock()
+	t.errorPolicy.policy = policy
+	t.errorPolicy.callback = callback
+}
+
+func (t *TwitterBot) getErrorPolicy() (ErrorPolicy, func(err error)) {
+	t.errorPolicy.mutex.Lock()
+	defer t.errorPolicy.mutex.Unlock()
+	return t.errorPolicy.policy, t.errorPolicy.callback
+}
+
+// handleError handles the given recoverable error following the error policy.
+// The 'retry' function, if any, runs the failed operation again.
+// It must be called with the bot mutex unlocked since it may sleep before
+// retrying or call the error callback, which may use the bot.
+func (t *TwitterBot) handleError(err error, retry func() error) {
+	policy, callback := t.getErrorPolicy()
+	switch policy {
+	case ErrorRetry:
+		for i := 0; i < errorRetryMax && retry != nil && err != nil; i++ {
+			logWarn("[twitter] retrying after error (%d/%d): %v", i+1, errorRetryMax, err)
+			if !t.noSleep && !sleepContext(t.botContext(), errorRetryDelay) {
+				break
+			}
+			err = retry()
+		}
+		if err != nil {
+			logError("%v", err)
+		}
+	case ErrorSkip:
+		logError("%v", err)
+	case ErrorCallback:
+		if callback == nil {
+			logError("%v", err)
+			return
+		}
+		callback(err)
+	default:
+		logFatal("%v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package twbot
+
+import (
+	"errors"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+// failingStore fails the first 'failures' saves.
+type failingStore struct {
+	Store
+	failures int
+	saves    int
+}
+
+func (s *failingStore) Save(path string, v interface{}) error {
+	s.saves++
+	if s.saves <= s.failures {
+		return errors.New("save failed")
+	}
+	return s.Store.Save(path, v)
+}
+
+func (s *MySuite) TestErrorPolicy(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.noSleep = true
+	store := &failingStore{Store: bot.store, failures: 1}
+	bot.store = store
+	bot.friendsPath = "friends.json"
+
+	// skip: the save is not retried
+	bot.SetErrorPolicy(ErrorSkip, nil)
+	bot.addFriend(&anaconda.User{Id: 1}, "")
+	c.Assert(store.saves, Equals, 1)
+	exists, err := bot.store.Exists("friends.json")
+	c.Assert(err, IsNil)
+	c.Assert(exists, Equals, false)
+
+	// retry: the save succeeds on the second retry
+	store.saves, store.failures = 0, 2
+	bot.SetErrorPolicy(ErrorRetry, nil)
+	bot.addFriend(&anaconda.User{Id: 2}, "")
+	c.Assert(store.saves, Equals, 3)
+	exists, err = bot.store.Exists("friends.json")
+	c.Assert(err, IsNil)
+	c.Assert(exists, Equals, true)
+
+	// callback: the error is passed to the callback
+	store.saves, store.failures = 0, 1
+	errs := []error{}
+	bot.SetErrorPolicy(ErrorCallback, func(err error) {
+		// the bot mutex is released before calling the callback
+		bot.mutex.Lock()
+		defer bot.mutex.Unlock()
+		errs = append(errs, err)
+	})
+	bot.unfollowFriend(2)
+	c.Assert(errs, HasLen, 1)
+	c.Assert(errs[0], ErrorMatches, "save failed")
+
+	// account locked errors follow the error policy too
+	apiErr := &anaconda.ApiError{StatusCode: 403}
+	apiErr.Decoded.Errors = []anaconda.TwitterError{{Code: twitterErrorAccountLocked}}
+	bot.checkBotRestriction(wrapAPIError(apiErr))
+	c.Assert(errs, HasLen, 2)
+}
@@ -0,0 +1,115 @@
+package twbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	twitterErrorUnableToFollow = 161 // unable to follow more people at this time
+	twitterErrorTweetTooLong   = 186 // tweet needs to be a bit shorter
+	twitterErrorAccountLocked  = 326 // this account is temporarily locked
+)
+
+// Errors returned, wrapped, by the bot when the twitter API rejects
+// a request. Use errors.Is to check for them and errors.As to get
+// the underlying *anaconda.ApiError.
+var (
+	ErrAccountLocked    = errors.New("[twitter] account temporarily locked")
+	ErrTokenExpired     = errors.New("[twitter] invalid or expired token")
+	ErrRateLimited      = errors.New("[twitter] rate limit exceeded")
+	ErrDuplicateStatus  = errors.New("[twitter] status is a duplicate")
+	ErrUnableToFollow   = errors.New("[twitter] unable to follow more people at this time")
+	ErrAccountSuspended = errors.New("[twitter] account suspended")
+)
+
+// newAPIError returns the twitter API error of the given failed response,
+// decoding its twitter error codes if any.
+func newAPIError(resp *http.Response) *anaconda.ApiError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	apiErr := &anaconda.ApiError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}
+	if resp.Request != nil {
+		apiErr.URL = resp.Request.URL
+	}
+	json.Unmarshal(body, &apiErr.Decoded)
+	return apiErr
+}
+
+// asAPIError returns the twitter API error in the chain of the given error.
+// It returns false for the other errors, like network errors.
+func asAPIError(err error) (*anaconda.ApiError, bool) {
+	var apiErr *anaconda.ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr, apiErr != nil
+	}
+	var apiErrValue anaconda.ApiError
+	if errors.As(err, &apiErrValue) {
+		return &apiErrValue, true
+	}
+	return nil, false
+}
+
+// hasAPIErrorCode returns true if the given error is a twitter API
+// error with one of the given 'codes'.
+func hasAPIErrorCode(err error, codes ...int) bool {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return false
+	}
+	for _, e := range apiErr.Decoded.Errors {
+		for _, code := range codes {
+			if e.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apiErrorSentinel returns the sentinel error matching
+// the given twitter API error, or nil if none matches.
+func apiErrorSentinel(err error) error {
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		return nil
+	}
+	for _, e := range apiErr.Decoded.Errors {
+		switch e.Code {
+		case twitterErrorAccountLocked:
+			return ErrAccountLocked
+		case anaconda.TwitterErrorAccountSuspended:
+			return ErrAccountSuspended
+		case anaconda.TwitterErrorInvalidToken:
+			return ErrTokenExpired
+		case anaconda.TwitterErrorRateLimitExceeded:
+			return ErrRateLimited
+		case anaconda.TwitterErrorStatusIsADuplicate:
+			return ErrDuplicateStatus
+		case twitterErrorUnableToFollow:
+			return ErrUnableToFollow
+		}
+	}
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// wrapAPIError wraps the given twitter API error with its matching
+// sentinel error, if any, keeping the API error in the chain.
+func wrapAPIError(err error) error {
+	sentinel := apiErrorSentinel(err)
+	if sentinel == nil || errors.Is(err, sentinel) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
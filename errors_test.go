@@ -0,0 +1,118 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func makeAPIError(status, code int) *anaconda.ApiError {
+	apiErr := &anaconda.ApiError{StatusCode: status}
+	if code != 0 {
+		apiErr.Decoded.Errors = []anaconda.TwitterError{{Code: code}}
+	}
+	return apiErr
+}
+
+func (s *MySuite) TestWrapAPIError(c *C) {
+	c.Assert(wrapAPIError(nil), IsNil)
+	plain := errors.New("connection reset")
+	c.Assert(wrapAPIError(plain), Equals, plain)
+	unknown := makeAPIError(404, anaconda.TwitterErrorDoesNotExist)
+	c.Assert(wrapAPIError(unknown), Equals, error(unknown))
+
+	sentinels := map[error]*anaconda.ApiError{
+		ErrAccountLocked:   makeAPIError(403, twitterErrorAccountLocked),
+		ErrTokenExpired:    makeAPIError(401, anaconda.TwitterErrorInvalidToken),
+		ErrRateLimited:     makeAPIError(429, 0),
+		ErrDuplicateStatus: makeAPIError(403, anaconda.TwitterErrorStatusIsADuplicate),
+		ErrUnableToFollow:  makeAPIError(403, twitterErrorUnableToFollow),
+	}
+	for sentinel, apiErr := range sentinels {
+		err := wrapAPIError(apiErr)
+		c.Assert(errors.Is(err, sentinel), Equals, true)
+		var wrapped *anaconda.ApiError
+		c.Assert(errors.As(err, &wrapped), Equals, true)
+		c.Assert(wrapped, Equals, apiErr)
+		// errors are wrapped only once
+		c.Assert(wrapAPIError(err), Equals, err)
+	}
+}
+
+func (s *MySuite) TestWrapIdsPagesError(c *C) {
+	client := &fakeClient{
+		idsErr:      makeAPIError(429, 0),
+		myFollowers: []int64{1, 2},
+		myFriends:   []int64{3, 4},
+	}
+	bot := makeFakeBot(client)
+	// the ids of the pages fetched before the failure are not returned
+	ids, err := bot.fetchFollowersIds()
+	c.Assert(errors.Is(err, ErrRateLimited), Equals, true)
+	c.Assert(ids, IsNil)
+	ids, err = bot.fetchFriendsIds()
+	c.Assert(errors.Is(err, ErrRateLimited), Equals, true)
+	c.Assert(ids, IsNil)
+	client.idsErr = nil
+	ids, err = bot.fetchFollowersIds()
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 2})
+}
+
+func (s *MySuite) TestAPIErrorInspection(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	plain := errors.New("connection reset")
+	network := &net.OpError{Op: "dial", Net: "tcp", Err: plain}
+
+	// errors other than twitter API errors never panic
+	for _, err := range []error{nil, plain, network, fmt.Errorf("wrapped: %w", network)} {
+		_, ok := asAPIError(err)
+		c.Assert(ok, Equals, false)
+		c.Assert(bot.checkAPIError(err), Equals, err)
+		c.Assert(bot.isStatusOver140CharactersError(err), Equals, false)
+		c.Assert(isDMRefusedError(err), Equals, false)
+		c.Assert(wrapAPIError(err), Equals, err)
+	}
+
+	// successful status codes are not errors
+	c.Assert(bot.checkAPIError(makeAPIError(204, 0)), IsNil)
+	c.Assert(bot.checkAPIError(*makeAPIError(204, 0)), IsNil)
+	failed := makeAPIError(500, 0)
+	c.Assert(bot.checkAPIError(failed), Equals, error(failed))
+
+	// error codes are found in wrapped and value errors, in any position
+	tooLong := makeAPIError(403, twitterErrorTweetTooLong)
+	tooLong.Decoded.Errors = append([]anaconda.TwitterError{{Code: 1}}, tooLong.Decoded.Errors...)
+	c.Assert(bot.isStatusOver140CharactersError(fmt.Errorf("wrapped: %w", tooLong)), Equals, true)
+	c.Assert(bot.isStatusOver140CharactersError(*makeAPIError(403, twitterErrorTweetTooLong)), Equals, true)
+	c.Assert(isDMRefusedError(wrapAPIError(makeAPIError(403, twitterErrorCannotMessageUser))), Equals, true)
+}
+
+func (s *MySuite) TestNewAPIError(c *C) {
+	requests := []string{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		c.Assert(req.ParseForm(), IsNil)
+		requests = append(requests, req.URL.Path+" "+req.PostForm.Get("banner"))
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"errors": [{"code": 326, "message": "locked"}]}`)),
+			Request:    req,
+		}, nil
+	})
+	client := newAnacondaClient("key", "secret", "token", "access")
+	client.HttpClient = &http.Client{Transport: transport}
+	err := client.AccountUpdateProfileBanner("aW1n", nil)
+	c.Assert(requests, DeepEquals, []string{"/1.1/account/update_profile_banner.json aW1n"})
+	apiErr, ok := err.(*anaconda.ApiError)
+	c.Assert(ok, Equals, true)
+	c.Assert(apiErr.StatusCode, Equals, http.StatusForbidden)
+	c.Assert(apiErr.URL.Path, Equals, "/1.1/account/update_profile_banner.json")
+	c.Assert(errors.Is(wrapAPIError(err), ErrAccountLocked), Equals, true)
+}
@@ -0,0 +1,99 @@
+package twbot
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions of the bot reported by the events, see Event.
+const (
+	ActionTweet         = "tweet"
+	ActionRetweet       = "retweet"
+	ActionLike          = "like"
+	ActionUnlike        = "unlike"
+	ActionFollow        = "follow"
+	ActionUnfollow      = "unfollow"
+	ActionBlock         = "block"
+	ActionUnblock       = "unblock"
+	ActionMute          = "mute"
+	ActionUnmute        = "unmute"
+	ActionDirectMessage = "direct_message"
+	ActionSearch        = "search"
+)
+
+// actionSubsystems holds the subsystems of the actions, see SetSubsystemLogging.
+var actionSubsystems = map[string]Subsystem{
+	ActionTweet:    SubsystemTweet,
+	ActionRetweet:  SubsystemRetweet,
+	ActionFollow:   SubsystemFollow,
+	ActionUnfollow: SubsystemFollow,
+}
+
+// Event is a machine-parseable log event reported for each action of the bot
+// on twitter. Its 'Action' is the action suffixed with "_success" or "_failed",
+// "follow_failed" or "retweet_success" for instance. Failed events hold the
+// error and the twitter error code, if any.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	TweetID    int64     `json:"tweet_id,omitempty"`
+	UserID     int64     `json:"user_id,omitempty"`
+	ScreenName string    `json:"screen_name,omitempty"`
+	Query      string    `json:"query,omitempty"`
+	Text       string    `json:"text,omitempty"`
+	ErrorCode  int       `json:"error_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	// Simulated is true for the actions simulated in dry-run mode, see SetDryRun.
+	Simulated bool `json:"simulated,omitempty"`
+}
+
+// EventLogger can be implemented by the logger set with SetLogger to receive
+// the events with their fields. Otherwise the events are logged as JSON,
+// with the Error level for the failed ones and the Info level for the others.
+type EventLogger interface {
+	Event(event Event)
+}
+
+// Failed returns true if the event reports a failed action.
+func (e Event) Failed() bool {
+	return e.Error != ""
+}
+
+// String returns the event formatted as JSON.
+func (e Event) String() string {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// logEvent logs the event of the given 'action', completed with the given
+// error of the action, and returns it.
+func logEvent(action string, event Event, err error) Event {
+	event.Time = timeNow()
+	event.Action = action + "_success"
+	if err != nil {
+		event.Action = action + "_failed"
+		event.Error = err.Error()
+		if apiErr, ok := asAPIError(err); ok && len(apiErr.Decoded.Errors) > 0 {
+			event.ErrorCode = apiErr.Decoded.Errors[0].Code
+		}
+	}
+	level := LogInfo
+	if event.Failed() {
+		level = LogError
+	}
+	logger := getLogger(level, actionSubsystems[action])
+	if logger == nil {
+		return event
+	}
+	if events, ok := logger.(EventLogger); ok {
+		events.Event(event)
+	} else if event.Failed() {
+		logger.Error("%s", event)
+	} else {
+		logger.Info("%s", event)
+	}
+	return event
+}
@@ -0,0 +1,50 @@
+package twbot
+
+import (
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+type eventLogger struct {
+	recordLogger
+	events []Event
+}
+
+func (l *eventLogger) Event(event Event) {
+	l.events = append(l.events, event)
+}
+
+func (s *MySuite) TestEvents(c *C) {
+	logger := &eventLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(403, anaconda.TwitterErrorStatusIsADuplicate),
+		failures:   1,
+	}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), NotNil)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(logger.events, HasLen, 2)
+	failed := logger.events[0]
+	c.Assert(failed.Action, Equals, "tweet_failed")
+	c.Assert(failed.Failed(), Equals, true)
+	c.Assert(failed.ErrorCode, Equals, anaconda.TwitterErrorStatusIsADuplicate)
+	succeeded := logger.events[1]
+	c.Assert(succeeded.Action, Equals, "tweet_success")
+	c.Assert(succeeded.Failed(), Equals, false)
+	c.Assert(succeeded.TweetID, Equals, int64(1))
+}
+
+func (s *MySuite) TestEventJSON(c *C) {
+	logger := &recordLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	logEvent(ActionFollow, Event{UserID: 42, ScreenName: "gopher"}, makeAPIError(403, twitterErrorUnableToFollow))
+	c.Assert(logger.messages, HasLen, 1)
+	c.Assert(logger.messages[0], Matches,
+		`error \{"time":".*","action":"follow_failed","user_id":42,"screen_name":"gopher","error_code":161,"error":".*"\}`)
+}
@@ -0,0 +1,136 @@
+package twbot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+var usersCSVHeader = []string{"id", "screen_name", "timestamp", "follow",
+	"followed_back", "unfollowed", "source", "followers_count"}
+
+func formatTimestamp(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(0, timestamp).UTC().Format(time.RFC3339)
+}
+
+// copyUsers returns a copy of the users of the given database sorted by id.
+func (t *TwitterBot) copyUsers(users *twitterUsers) ([]int64, map[int64]twitterUser) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	copied := map[int64]twitterUser{}
+	for strID, user := range users.Ids {
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			logError("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+		copied[id] = *user
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, copied
+}
+
+// hydrateScreenNames fills the missing screen names of the given users.
+func (t *TwitterBot) hydrateScreenNames(users map[int64]twitterUser) error {
+	missing := []int64{}
+	for id, user := range users {
+		if user.ScreenName == "" {
+			missing = append(missing, id)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	looked, err := t.LookupUsers(missing)
+	if err != nil {
+		return err
+	}
+	for _, v := range looked {
+		user := users[v.Id]
+		user.ScreenName = v.ScreenName
+		users[v.Id] = user
+	}
+	return nil
+}
+
+func (t *TwitterBot) exportUsersCSV(w io.Writer, db *twitterUsers, hydrate bool) error {
+	ids, users := t.copyUsers(db)
+	if hydrate {
+		err := t.hydrateScreenNames(users)
+		if err != nil {
+			return err
+		}
+	}
+	writer := csv.NewWriter(w)
+	err := writer.Write(usersCSVHeader)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		user := users[id]
+		followersCount := ""
+		if user.FollowersCount > 0 {
+			followersCount = strconv.Itoa(user.FollowersCount)
+		}
+		err = writer.Write([]string{
+			strconv.FormatInt(id, 10),
+			user.ScreenName,
+			formatTimestamp(user.Timestamp),
+			strconv.FormatBool(user.Follow),
+			strconv.FormatBool(user.FollowedBack),
+			formatTimestamp(user.Unfollowed),
+			user.Source,
+			followersCount,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportFollowersCSV writes the followers database as CSV to 'w', one user per
+// line sorted by id, so that it can be analyzed in a spreadsheet for instance.
+// Timestamps are written as RFC 3339 UTC dates. If 'hydrate' is true, the
+// screen names missing from database are looked up.
+func (t *TwitterBot) ExportFollowersCSV(w io.Writer, hydrate bool) error {
+	t.mutex.Lock()
+	followers := t.followers
+	t.mutex.Unlock()
+	return t.exportUsersCSV(w, followers, hydrate)
+}
+
+// ExportFriendsCSV writes the friends database as CSV to 'w', see ExportFollowersCSV.
+func (t *TwitterBot) ExportFriendsCSV(w io.Writer, hydrate bool) error {
+	t.mutex.Lock()
+	friends := t.friends
+	t.mutex.Unlock()
+	return t.exportUsersCSV(w, friends, hydrate)
+}
+
+// ExportTweetsJSONL writes the tweets database as JSON Lines to 'w',
+// one tweet per line, the oldest first.
+func (t *TwitterBot) ExportTweetsJSONL(w io.Writer) error {
+	tweets := &[]anaconda.Tweet{}
+	err := t.loadOrCreate(t.tweetsPath, tweets)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for _, tweet := range *tweets {
+		err = encoder.Encode(tweet)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
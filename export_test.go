@@ -0,0 +1,44 @@
+package twbot
+
+import (
+	"bytes"
+	"path/filepath"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestExportUsersCSV(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	timestamp := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano()
+	bot.friends.Ids = map[string]*twitterUser{
+		"2": {Timestamp: timestamp, Follow: true, Source: "query:golang", ScreenName: "gopher", FollowersCount: 10},
+		"1": {Timestamp: timestamp, Unfollowed: timestamp},
+	}
+	buffer := &bytes.Buffer{}
+	c.Assert(bot.ExportFriendsCSV(buffer, false), IsNil)
+	c.Assert(buffer.String(), Equals,
+		"id,screen_name,timestamp,follow,followed_back,unfollowed,source,followers_count\n"+
+			"1,,2017-01-02T03:04:05Z,false,false,2017-01-02T03:04:05Z,,\n"+
+			"2,gopher,2017-01-02T03:04:05Z,true,false,,query:golang,10\n")
+
+	// missing screen names are looked up
+	buffer.Reset()
+	c.Assert(bot.ExportFriendsCSV(buffer, true), IsNil)
+	c.Assert(buffer.String(), Matches, "(?s).*\n1,user1,.*\n2,gopher,.*")
+	// without modifying the database
+	c.Assert(bot.friends.Ids["1"].ScreenName, Equals, "")
+}
+
+func (s *MySuite) TestExportTweetsJSONL(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.tweetsPath = filepath.Join(c.MkDir(), "tweets.json")
+	c.Assert(bot.store.Save(bot.tweetsPath, []anaconda.Tweet{{Id: 1}, {Id: 2}}), IsNil)
+	buffer := &bytes.Buffer{}
+	c.Assert(bot.ExportTweetsJSONL(buffer), IsNil)
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	c.Assert(lines, HasLen, 2)
+	c.Assert(string(lines[0]), Matches, `\{.*"id":1,.*\}`)
+}
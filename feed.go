@@ -0,0 +1,194 @@
+package twbot
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFeedTemplate = "{{.Title}} {{.Link}}"
+	feedTimeout         = 30 * time.Second
+)
+
+// FeedEntry is an entry of an RSS or Atom feed, see TweetFromFeedPeriodically.
+type FeedEntry struct {
+	GUID        string
+	Title       string
+	Link        string
+	Description string
+}
+
+// xmlFeed decodes the RSS 2.0, RSS 1.0 and Atom feeds.
+type xmlFeed struct {
+	Channel struct {
+		Items []xmlFeedItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []xmlFeedItem `xml:"item"`
+	Entries []struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Summary string `xml:"summary"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+type xmlFeedItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+}
+
+// parseFeed returns the entries of the given RSS or Atom feed, in the order
+// of the feed. An entry without id is identified by its link, or its title.
+func parseFeed(data []byte) ([]FeedEntry, error) {
+	feed := xmlFeed{}
+	err := xml.Unmarshal(data, &feed)
+	if err != nil {
+		return nil, err
+	}
+	entries := []FeedEntry{}
+	for _, item := range append(feed.Channel.Items, feed.Items...) {
+		entries = append(entries, FeedEntry{
+			GUID:        item.GUID,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+		})
+	}
+	for _, entry := range feed.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		entries = append(entries, FeedEntry{
+			GUID:        entry.ID,
+			Title:       entry.Title,
+			Link:        link,
+			Description: entry.Summary,
+		})
+	}
+	for i := range entries {
+		entry := &entries[i]
+		entry.Title = strings.TrimSpace(entry.Title)
+		entry.Link = strings.TrimSpace(entry.Link)
+		entry.GUID = strings.TrimSpace(entry.GUID)
+		if entry.GUID == "" {
+			entry.GUID = entry.Link
+		}
+		if entry.GUID == "" {
+			entry.GUID = entry.Title
+		}
+	}
+	return entries, nil
+}
+
+// fetchFeed downloads and parses the feed of the given URL.
+func (t *TwitterBot) fetchFeed(feedURL string) ([]FeedEntry, error) {
+	client := t.newHTTPClient(t.baseTransport())
+	if t.requestTimeout() == 0 {
+		client.Timeout = feedTimeout
+	}
+	resp, err := client.Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("[twitter] failed to fetch feed %s: %s", feedURL, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseFeed(data)
+	if err != nil {
+		return nil, fmt.Errorf("[twitter] failed to parse feed %s: %v", feedURL, err)
+	}
+	return entries, nil
+}
+
+// sourcePass is a source recording its last item and its error,
+// ErrNoItem at the end of its pass.
+type sourcePass struct {
+	source Source
+	item   Item
+	err    error
+}
+
+func (p *sourcePass) Next() (Item, error) {
+	p.item, p.err = p.source.Next()
+	return p.item, p.err
+}
+
+// TweetFromFeedOnce tweets the entries of the RSS or Atom feed of the given URL
+// not tweeted yet, the oldest first, each rendered by the given text/template
+// 'tmpl' executed on its FeedEntry, "{{.Title}} {{.Link}}" if empty, see
+// NewFeedSource. The ids of the tweeted entries are kept in the state database,
+// see SetStatePath, the feed URL being the name of its source, see
+// TweetFromSourceOnce. The first time a feed is polled, its entries are only
+// recorded, so that only the entries published afterwards are tweeted.
+// It returns an error if the feed or the template is invalid and only logs
+// errors for each failed tweet tentative, the entry being retried next time.
+func (t *TwitterBot) TweetFromFeedOnce(feedURL, tmpl string) error {
+	source := t.NewFeedSource(feedURL, tmpl)
+	if _, polled := t.getSourceKeys(feedURL); !polled {
+		keys := []string{}
+		for {
+			item, err := source.Next()
+			if errors.Is(err, ErrNoItem) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			keys = append(keys, item.Key)
+		}
+		t.addSourceKeys(feedURL, keys...)
+		SubsystemTweet.info("[twitter] recorded %d entries of new feed %s", len(keys), feedURL)
+		return nil
+	}
+	pass := &sourcePass{source: source}
+	for {
+		err := t.TweetFromSourceOnce(feedURL, pass)
+		if errors.Is(pass.err, ErrNoItem) {
+			return nil
+		}
+		if pass.err != nil {
+			return pass.err
+		}
+		if err != nil {
+			SubsystemTweet.error("[twitter] failed to tweet feed entry %s: %v", pass.item.Key, err)
+		}
+	}
+}
+
+// TweetFromFeedPeriodically polls periodically the RSS or Atom feed of the given
+// URL and tweets its new entries, see TweetFromFeedOnce.
+// The poll frequency is set up by the given 'freq' input parameter.
+// It only logs the error if the poll failed.
+func (t *TwitterBot) TweetFromFeedPeriodically(feedURL string, tmpl string, freq time.Duration) {
+	t.newJob("TweetFromFeedPeriodically").runPeriodically(freq, func() error {
+		return t.TweetFromFeedOnce(feedURL, tmpl)
+	})
+}
+
+// TweetFromFeedPeriodicallyAsync polls asynchronously and periodically the RSS
+// or Atom feed of the given URL and tweets its new entries, see TweetFromFeedOnce.
+func (t *TwitterBot) TweetFromFeedPeriodicallyAsync(feedURL string, tmpl string, freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("TweetFromFeedPeriodically", freq, func(ctx context.Context) error {
+		return t.TweetFromFeedOnce(feedURL, tmpl)
+	})
+}
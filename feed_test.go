@@ -0,0 +1,87 @@
+package twbot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+func makeRSS(items ...string) string {
+	rss := `<?xml version="1.0"?><rss version="2.0"><channel><title>news</title>`
+	for _, item := range items {
+		rss += fmt.Sprintf(`<item><title>%s</title><link>https://example.com/%s</link><guid>id-%s</guid></item>`, item, item, item)
+	}
+	return rss + `</channel></rss>`
+}
+
+func (s *MySuite) TestParseFeed(c *C) {
+	entries, err := parseFeed([]byte(makeRSS("b", "a")))
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []FeedEntry{
+		{GUID: "id-b", Title: "b", Link: "https://example.com/b"},
+		{GUID: "id-a", Title: "a", Link: "https://example.com/a"},
+	})
+	entries, err = parseFeed([]byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>urn:1</id>
+    <title> atom </title>
+    <link rel="edit" href="https://example.com/edit"/>
+    <link href="https://example.com/atom"/>
+    <summary>summary</summary>
+  </entry>
+  <entry><title>no id</title><link href="https://example.com/no-id"/></entry>
+</feed>`))
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []FeedEntry{
+		{GUID: "urn:1", Title: "atom", Link: "https://example.com/atom", Description: "summary"},
+		{GUID: "https://example.com/no-id", Title: "no id", Link: "https://example.com/no-id"},
+	})
+	_, err = parseFeed([]byte("not a feed"))
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestTweetFromFeedOnce(c *C) {
+	feed := makeRSS("a")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.state = &twitterState{SinceIDs: map[string]int64{}}
+
+	// the entries of a new feed are only recorded
+	c.Assert(bot.TweetFromFeedOnce(server.URL, "new: {{.Title}} {{.Link}}"), IsNil)
+	c.Assert(client.tweets, HasLen, 0)
+
+	feed = makeRSS("c", "b", "a")
+	c.Assert(bot.TweetFromFeedOnce(server.URL, "new: {{.Title}} {{.Link}}"), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"new: b https://example.com/b", "new: c https://example.com/c"})
+	c.Assert(bot.state.Sources[server.URL], DeepEquals, []string{"id-a", "id-b", "id-c"})
+	c.Assert(bot.TweetFromFeedOnce(server.URL, ""), IsNil)
+	c.Assert(client.tweets, HasLen, 2)
+
+	feed = makeRSS("d")
+	c.Assert(bot.TweetFromFeedOnce(server.URL, ""), IsNil)
+	c.Assert(client.tweets[2], Equals, "d https://example.com/d")
+
+	c.Assert(bot.TweetFromFeedOnce(server.URL, "{{.Unknown"), ErrorMatches, `\[twitter\] invalid feed template: .*`)
+	feed = "<html>"
+	c.Assert(bot.TweetFromFeedOnce(server.URL, ""), ErrorMatches, `\[twitter\] failed to parse feed .*`)
+
+	// the feeds of a legacy state are moved to the sources
+	legacy := &twitterState{
+		SinceIDs: map[string]int64{},
+		Feeds:    map[string][]string{server.URL: {"id-e"}},
+	}
+	c.Assert(bot.store.Save("state.json", legacy), IsNil)
+	c.Assert(bot.SetStatePath("state.json"), IsNil)
+	c.Assert(bot.state.Feeds, IsNil)
+	c.Assert(bot.state.Sources[server.URL], DeepEquals, []string{"id-e"})
+	feed = makeRSS("e")
+	c.Assert(bot.TweetFromFeedOnce(server.URL, ""), IsNil)
+	c.Assert(client.tweets, HasLen, 3)
+}
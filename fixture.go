@@ -0,0 +1,207 @@
+package twbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fixture is a twitter API response recorded by the recording transport.
+type fixture struct {
+	// Request identifies the request, see fixtureRequest.
+	Request string      `json:"request"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header,omitempty"`
+	Body    string      `json:"body"`
+}
+
+// fixtureRequest returns the method, the path and the parameters, sorted
+// and without the oauth ones, of the given request, so that a replayed
+// request matches its recorded response whatever the credentials.
+func fixtureRequest(req *http.Request) (string, error) {
+	params := req.URL.Query()
+	if req.Body != nil && req.Method != http.MethodGet {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			for key, values := range form {
+				params[key] = append(params[key], values...)
+			}
+		}
+	}
+	for key := range params {
+		if strings.HasPrefix(key, "oauth_") {
+			params.Del(key)
+		}
+	}
+	// url.Values.Encode sorts the parameters by key
+	return strings.TrimSuffix(req.Method+" "+req.URL.Path+"?"+params.Encode(), "?"), nil
+}
+
+var unsafeFixtureChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// recordTransport records the responses of another transport as fixtures.
+type recordTransport struct {
+	base  http.RoundTripper
+	dir   string
+	mutex sync.Mutex
+	count int
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := fixtureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	data, err := json.MarshalIndent(fixture{
+		Request: request,
+		Status:  resp.StatusCode,
+		Header:  resp.Header,
+		Body:    string(body),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.count++
+	name := fmt.Sprintf("%04d_%s_%s.json", t.count, req.Method,
+		strings.Trim(unsafeFixtureChars.ReplaceAllString(req.URL.Path, "_"), "_"))
+	err = ioutil.WriteFile(filepath.Join(t.dir, name), data, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replayTransport serves the responses recorded as fixtures.
+type replayTransport struct {
+	mutex    sync.Mutex
+	fixtures map[string][]fixture // map request -> recorded responses in order
+	served   map[string]int       // map request -> number of responses served
+}
+
+func loadFixtures(dir string) (map[string][]fixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	// fixtures are numbered in their recording order
+	sort.Strings(files)
+	fixtures := map[string][]fixture{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f := fixture{}
+		err = json.Unmarshal(data, &f)
+		if err != nil {
+			return nil, fmt.Errorf("[twitter] invalid fixture %s: %v", file, err)
+		}
+		fixtures[f.Request] = append(fixtures[f.Request], f)
+	}
+	return fixtures, nil
+}
+
+// RoundTrip serves the next response recorded for the request, the last
+// one once they are all served. Requests never recorded fail.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := fixtureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	fixtures := t.fixtures[request]
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("[twitter] no fixture recorded for request %s", request)
+	}
+	served := t.served[request]
+	t.served[request]++
+	if served >= len(fixtures) {
+		served = len(fixtures) - 1
+	}
+	f := fixtures[served]
+	header := http.Header{}
+	for key, values := range f.Header {
+		header[key] = values
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// NewRecordingClient returns a twitter client calling the twitter API with
+// the given twitter keys and recording each response as a JSON fixture file
+// in the given directory, to be replayed by NewReplayClient, see
+// MakeTwitterBotWithClient. The fixtures are numbered in their recording
+// order and must be reviewed before being committed since they hold the
+// real responses of the account.
+func NewRecordingClient(dir, consumerKey, consumerSecret, accessToken, accessSecret string) (TwitterClient, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	client := newAnacondaClient(consumerKey, consumerSecret, accessToken, accessSecret)
+	client.HttpClient = &http.Client{
+		Transport: &recordTransport{
+			base: http.DefaultTransport,
+			dir:  dir,
+		},
+	}
+	return client, nil
+}
+
+// NewReplayClient returns a twitter client serving the responses recorded
+// as fixtures in the given directory by NewRecordingClient, without any
+// twitter keys nor network access, for integration tests. Each request is
+// served the responses recorded for the same method, path and parameters
+// in their recording order, the last one being served again once they are
+// all served, and fails if none was recorded.
+func NewReplayClient(dir string) (TwitterClient, error) {
+	fixtures, err := loadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	client := newAnacondaClient("", "", "", "")
+	client.HttpClient = &http.Client{
+		Transport: &replayTransport{
+			fixtures: fixtures,
+			served:   map[string]int{},
+		},
+	}
+	return client, nil
+}
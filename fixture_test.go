@@ -0,0 +1,78 @@
+package twbot
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+// roundTripperFunc is an adapter to use ordinary functions as transports.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (s *MySuite) TestReplayClient(c *C) {
+	client, err := NewReplayClient(filepath.Join("testdata", "fixtures"))
+	c.Assert(err, IsNil)
+	dir := c.MkDir()
+	bot := MakeTwitterBotWithClient(filepath.Join(dir, "followers.json"), filepath.Join(dir, "friends.json"),
+		filepath.Join(dir, "tweets.json"), client, true)
+	defer bot.Close()
+	// the followers are fetched from two pages
+	c.Assert(bot.Stats().Followers, Equals, 3)
+	c.Assert(bot.Stats().Friends, Equals, 2)
+
+	tweet, err := bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(err, IsNil)
+	c.Assert(tweet.Id, Equals, int64(10))
+	_, err = bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(errors.Is(err, ErrDuplicateStatus), Equals, true)
+	_, err = bot.twitterClient.PostTweet("unknown", nil)
+	c.Assert(err, ErrorMatches, ".*no fixture recorded for request POST /1.1/statuses/update.json\\?status=unknown")
+}
+
+func (s *MySuite) TestRecordTransport(c *C) {
+	dir := c.MkDir()
+	transport := &recordTransport{
+		base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"page":"` + req.URL.Query().Get("cursor") + `"}`)),
+			}, nil
+		}),
+		dir: dir,
+	}
+	for _, cursor := range []string{"-1", "5"} {
+		req, err := http.NewRequest(http.MethodGet, "https://api.twitter.com/1.1/followers/ids.json?oauth_token=x&cursor="+cursor, nil)
+		c.Assert(err, IsNil)
+		resp, err := transport.RoundTrip(req)
+		c.Assert(err, IsNil)
+		body, err := ioutil.ReadAll(resp.Body)
+		c.Assert(err, IsNil)
+		c.Assert(string(body), Equals, `{"page":"`+cursor+`"}`)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 2)
+	c.Assert(filepath.Base(files[0]), Equals, "0001_GET_1_1_followers_ids_json.json")
+
+	// the recorded responses are replayed whatever the credentials
+	fixtures, err := loadFixtures(dir)
+	c.Assert(err, IsNil)
+	replay := &replayTransport{fixtures: fixtures, served: map[string]int{}}
+	req, err := http.NewRequest(http.MethodGet, "https://api.twitter.com/1.1/followers/ids.json?cursor=5&oauth_token=y", nil)
+	c.Assert(err, IsNil)
+	resp, err := replay.RoundTrip(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, `{"page":"5"}`)
+}
@@ -0,0 +1,706 @@
+package twbot
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	maxUsersLookupCount = 100 // twitter API limit
+	maxSearchCount      = 100 // twitter API limit
+	// followBackSinceID keeps the time of the first follow back run in the
+	// state database: the followers seen before are never followed back.
+	followBackSinceID = "follow_back"
+)
+
+// FollowFilter represents the filter applied on users before
+// following them in follow campaigns. The zero value filters nothing.
+type FollowFilter struct {
+	// MinFollowersCount and MaxFollowersCount bound the number of
+	// followers of the user, a zero maximum meaning no limit.
+	MinFollowersCount int
+	MaxFollowersCount int
+	// MinAccountAge removes users whose account is too recent.
+	MinAccountAge time.Duration
+	// RequireProfileImage removes users with the default profile image.
+	RequireProfileImage bool
+	// IncludeKeywords keeps only users whose bio contains at least one of the
+	// keywords and ExcludeKeywords removes users whose bio contains one of them.
+	// Keywords are case insensitive.
+	IncludeKeywords []string
+	ExcludeKeywords []string
+	// MinRatio and MaxRatio bound the ratio between the number of friends
+	// and the number of followers of the user, a zero maximum meaning no limit.
+	MinRatio float64
+	MaxRatio float64
+	// Languages keeps only users whose language is one of the given ones.
+	Languages []string
+	// SkipProtected removes protected users, whose follow would only
+	// create a pending follow request, see CancelPendingFollows.
+	SkipProtected bool
+}
+
+func (f *FollowFilter) empty() bool {
+	return f.MinFollowersCount == 0 && f.MaxFollowersCount == 0 &&
+		f.MinAccountAge == 0 && !f.RequireProfileImage &&
+		len(f.IncludeKeywords) == 0 && len(f.ExcludeKeywords) == 0 &&
+		f.MinRatio == 0 && f.MaxRatio == 0 && len(f.Languages) == 0 &&
+		!f.SkipProtected
+}
+
+func containsAny(text string, keywords []string) bool {
+	text = strings.ToLower(text)
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FollowFilter) match(user *anaconda.User) bool {
+	if user.FollowersCount < f.MinFollowersCount {
+		return false
+	}
+	if f.MaxFollowersCount > 0 && user.FollowersCount > f.MaxFollowersCount {
+		return false
+	}
+	if f.MinAccountAge > 0 {
+		created, err := time.Parse(time.RubyDate, user.CreatedAt)
+		if err != nil || timeSince(created) < f.MinAccountAge {
+			return false
+		}
+	}
+	if f.RequireProfileImage && user.DefaultProfileImage {
+		return false
+	}
+	if f.SkipProtected && user.Protected {
+		return false
+	}
+	if len(f.IncludeKeywords) > 0 && !containsAny(user.Description, f.IncludeKeywords) {
+		return false
+	}
+	if containsAny(user.Description, f.ExcludeKeywords) {
+		return false
+	}
+	if f.MinRatio > 0 || f.MaxRatio > 0 {
+		ratio := float64(user.FriendsCount)
+		if user.FollowersCount > 0 {
+			ratio = ratio / float64(user.FollowersCount)
+		}
+		if ratio < f.MinRatio || (f.MaxRatio > 0 && ratio > f.MaxRatio) {
+			return false
+		}
+	}
+	if len(f.Languages) > 0 {
+		found := false
+		for _, lang := range f.Languages {
+			if user.Lang == lang {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func copyFollowFilter(filter FollowFilter) FollowFilter {
+	copyStrings := func(list []string) []string {
+		copied := make([]string, len(list))
+		copy(copied, list)
+		return copied
+	}
+	filter.IncludeKeywords = copyStrings(filter.IncludeKeywords)
+	filter.ExcludeKeywords = copyStrings(filter.ExcludeKeywords)
+	filter.Languages = copyStrings(filter.Languages)
+	return filter
+}
+
+// filterUsers returns the ids of the users of the given 'ids' matching
+// the filter, using bulk users lookups. The ids are returned as is
+// if the filter is empty.
+func (t *TwitterBot) filterUsers(ids []int64, filter *FollowFilter) ([]int64, error) {
+	if filter.empty() {
+		return ids, nil
+	}
+	users, err := t.LookupUsers(ids)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []int64{}
+	for _, user := range users {
+		if !filter.match(&user) {
+			print(t, fmt.Sprintf("[twitter] filtering out user (id:%d, name:%s)\n", user.Id, user.Name))
+			continue
+		}
+		filtered = append(filtered, user.Id)
+	}
+	return filtered, nil
+}
+
+// LookupUsers returns the users of the given ids, looked up by batch of 100
+// users, the twitter API limit. It allows to enrich the raw ids stored in the
+// followers and friends databases with screen names and bios for instance.
+// Users whose account no longer exists are not returned.
+func (t *TwitterBot) LookupUsers(ids []int64) ([]anaconda.User, error) {
+	users := []anaconda.User{}
+	for start := 0; start < len(ids); start += maxUsersLookupCount {
+		end := start + maxUsersLookupCount
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch, err := t.twitterClient.GetUsersLookupByIds(ids[start:end], nil)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, batch...)
+	}
+	return users, nil
+}
+
+// fetchPendingFollows returns the ids of the protected users
+// the bot sent a follow request to that are still pending.
+func (t *TwitterBot) fetchPendingFollows() ([]int64, error) {
+	ids := []int64{}
+	v := url.Values{}
+	v.Set("cursor", "-1")
+	for {
+		page, err := t.twitterClient.GetFriendshipsOutgoing(v)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, page.Ids...)
+		if page.Next_cursor_str == "0" || page.Next_cursor_str == "" {
+			break
+		}
+		v.Set("cursor", page.Next_cursor_str)
+	}
+	return ids, nil
+}
+
+// CancelPendingFollows cancels the follow requests sent by the bot to
+// protected users at least 'minAge' ago that are still pending. Only the
+// requests sent by the follow campaigns of the bot are cancelled and
+// cancelled users are never followed again, see SetRefollowCooldown.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) CancelPendingFollows(minAge time.Duration, sleepPolicy SleepPolicy) error {
+	ids, err := t.fetchPendingFollows()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		friend, ok := t.getFriend(id)
+		if !ok || timeNow().UnixNano()-friend.Timestamp < minAge.Nanoseconds() {
+			continue
+		}
+		// cancelling a pending follow request is done by unfollowing the user
+		_, err := t.twitterClient.UnfollowUserId(id)
+		if err != nil {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to cancel follow request (id:%d), error: %v\n", id, err))
+			continue
+		}
+		t.unfollowFriend(id)
+		SubsystemFollow.info("[twitter] cancelling follow request (id:%d)", id)
+		t.controlledSleep(&sleepPolicy)
+	}
+	return nil
+}
+
+// getFollowersToFollowBack returns the ids of the followers first seen
+// after 'since' that are not friends and that were never followed back.
+func (t *TwitterBot) getFollowersToFollowBack(since int64) []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	for strID, user := range t.followers.Ids {
+		if !user.Follow || user.FollowedBack || user.Timestamp <= since {
+			continue
+		}
+		if !t.canRefollow(strID) {
+			continue
+		}
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			SubsystemFollow.error("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// markFollowedBack flags the follower as followed back
+// so that it is never followed back again.
+func (t *TwitterBot) markFollowedBack(id int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	user, ok := t.followers.Ids[strconv.FormatInt(id, 10)]
+	if !ok {
+		return
+	}
+	user.FollowedBack = true
+	err := t.store.Save(t.followersPath, t.followers)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+	}
+}
+
+func (t *TwitterBot) followBack(filter *FollowFilter, sleepPolicy *SleepPolicy, campaign *Campaign) error {
+	err := t.updateFollowers()
+	if err != nil {
+		return err
+	}
+	since, ok := t.getSinceID(followBackSinceID)
+	if !ok {
+		// the first run only records the current followers
+		// so that only the new followers are followed back
+		t.setSinceID(followBackSinceID, timeNow().UnixNano())
+		return nil
+	}
+	users, err := t.LookupUsers(t.getFollowersToFollowBack(since))
+	if err != nil {
+		return err
+	}
+	for i, user := range users {
+		campaign.setRemaining(len(users) - i)
+		if !campaign.wait() {
+			return nil
+		}
+		if !filter.match(&user) {
+			print(t, fmt.Sprintf("[twitter] filtering out follower (id:%d, name:%s)\n", user.Id, user.Name))
+			continue
+		}
+		if !t.waitFollowQuota(campaign.ctx) {
+			return nil
+		}
+		_, err := t.twitterClient.FollowUserId(user.Id, nil)
+		if err != nil {
+			if !t.checkUnableToFollowAtThisTime(campaign.ctx, err) {
+				t.checkBotRestriction(err)
+				print(t, fmt.Sprintf("[twitter] failed to follow back user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
+			}
+			continue
+		}
+		t.countFollow()
+		t.addFriend(&user, campaign.Source())
+		t.markFollowedBack(user.Id)
+		campaign.done()
+		t.controlledSleepContext(campaign.ctx, sleepPolicy)
+	}
+	campaign.setRemaining(0)
+	return nil
+}
+
+// AutoFollowBackAsync automatically asynchronously follows back the new
+// followers matching the given 'filter'. Followers are checked every hour
+// and each follower is only followed back once. The first check only records
+// the current followers, which are never followed back, see SetStatePath.
+// The sleep policy controls the type of sleep you want between requests.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowBackAsync(filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowBack")
+	campaign.setSource("follow-back")
+	filter = copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		SubsystemFollow.info("[twitter] launching auto follow back...")
+		sleepPolicyCopy.log()
+		for {
+			err := t.followBack(&filter, &sleepPolicyCopy, campaign)
+			if err != nil {
+				SubsystemFollow.error("%v", err)
+			}
+			if campaign.Stopped() {
+				break
+			}
+			wait := t.getTiming().FollowBackWait
+			SubsystemFollow.info("[twitter] no more followers to follow back, waiting %s...", wait)
+			sleepContext(campaign.ctx, wait)
+		}
+		SubsystemFollow.info("[twitter] auto follow back disabled")
+		return nil
+	})
+	return campaign
+}
+
+// getEngagers returns the ids of the users who retweeted
+// the 'maxTweets' most recent retweeted tweets of the bot.
+func (t *TwitterBot) getEngagers(maxTweets int) ([]int64, error) {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxTweets))
+	tweets, err := t.twitterClient.GetRetweetsOfMe(v)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int64{}
+	added := map[int64]struct{}{}
+	for _, tweet := range tweets {
+		users, err := t.GetRetweeters(tweet.Id)
+		if err != nil {
+			SubsystemFollow.error("%v", err)
+			continue
+		}
+		for _, user := range users {
+			if _, ok := added[user.Id]; ok {
+				continue
+			}
+			added[user.Id] = struct{}{}
+			ids = append(ids, user.Id)
+		}
+		t.sleep()
+	}
+	return ids, nil
+}
+
+// AutoFollowEngagers automatically follows the users who retweeted the
+// 'maxTweets' most recent retweeted tweets of the bot and matching the given 'filter'.
+// Note: the twitter API does not expose the users who liked a tweet,
+// so only retweeters are followed.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoFollowEngagers(maxTweets int, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowEngagers(maxTweets, filter, sleepPolicy, t.newCampaign("AutoFollowEngagers"))
+}
+
+func (t *TwitterBot) autoFollowEngagers(maxTweets int, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow of engagers over %d tweet(s)...", maxTweets)
+	sleepPolicy.log()
+	campaign.setSource("engagers")
+	ids, err := t.getEngagers(maxTweets)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+		return
+	}
+	t.followAll(ids, &filter, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
+}
+
+// AutoFollowEngagersAsync automatically asynchronously follows the users who
+// retweeted the 'maxTweets' most recent retweeted tweets of the bot and
+// matching the given 'filter'.
+// The sleep policy controls the type of sleep you want between requests.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowEngagersAsync(maxTweets int, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowEngagers")
+	filterCopy := copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		t.autoFollowEngagers(maxTweets, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
+}
+
+// GeoCode represents a geographic area: the circle of the given 'Radius',
+// like "10km" or "5mi", around the given latitude and longitude.
+type GeoCode struct {
+	Latitude  float64
+	Longitude float64
+	Radius    string
+}
+
+// String returns the geocode in the "latitude,longitude,radius"
+// format of the twitter search API.
+func (g GeoCode) String() string {
+	return fmt.Sprintf("%g,%g,%s", g.Latitude, g.Longitude, g.Radius)
+}
+
+// getAuthors returns the ids of the authors of the recent tweets
+// matching the given search query, located in the given 'geocode'
+// area if not nil, and matching the given 'filter'.
+func (t *TwitterBot) getAuthors(searchQuery string, geocode *GeoCode, filter *FollowFilter) ([]int64, error) {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxSearchCount))
+	if geocode != nil {
+		v.Set("geocode", geocode.String())
+	}
+	results, err := t.twitterClient.GetSearch(searchQuery, v)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int64{}
+	added := map[int64]struct{}{}
+	for _, tweet := range results.Statuses {
+		user := tweet.User
+		if _, ok := added[user.Id]; ok {
+			continue
+		}
+		added[user.Id] = struct{}{}
+		if !filter.match(&user) {
+			print(t, fmt.Sprintf("[twitter] filtering out user (id:%d, name:%s)\n", user.Id, user.Name))
+			continue
+		}
+		ids = append(ids, user.Id)
+	}
+	return ids, nil
+}
+
+// AutoFollowByQuery automatically follows the authors of the recent tweets
+// matching the given search query, a hashtag for instance, and the given 'filter'.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoFollowByQuery(searchQuery string, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowByQuery(searchQuery, filter, sleepPolicy, t.newCampaign("AutoFollowByQuery"))
+}
+
+func (t *TwitterBot) autoFollowByQuery(searchQuery string, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow of authors of tweets matching '%s'...", searchQuery)
+	sleepPolicy.log()
+	campaign.setSource("query:" + searchQuery)
+	ids, err := t.getAuthors(searchQuery, nil, &filter)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+		return
+	}
+	t.followAll(ids, &FollowFilter{}, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
+}
+
+// AutoFollowByQueryAsync automatically asynchronously follows the authors of the
+// recent tweets matching the given search query and the given 'filter'.
+// The sleep policy controls the type of sleep you want between requests.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowByQueryAsync(searchQuery string, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowByQuery")
+	filterCopy := copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		t.autoFollowByQuery(searchQuery, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
+}
+
+// AutoFollowNearby automatically follows the authors of the recent tweets
+// matching the given search query and located in the given 'geocode' area,
+// so that a local business bot follows people near it for instance.
+// Only the authors matching the given 'filter' are followed.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoFollowNearby(searchQuery string, geocode GeoCode, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowNearby(searchQuery, geocode, filter, sleepPolicy, t.newCampaign("AutoFollowNearby"))
+}
+
+func (t *TwitterBot) autoFollowNearby(searchQuery string, geocode GeoCode, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow of authors of tweets matching '%s' near %s...", searchQuery, geocode)
+	sleepPolicy.log()
+	campaign.setSource(fmt.Sprintf("nearby:%s@%s", searchQuery, geocode))
+	ids, err := t.getAuthors(searchQuery, &geocode, &filter)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+		return
+	}
+	t.followAll(ids, &FollowFilter{}, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
+}
+
+// AutoFollowNearbyAsync automatically asynchronously follows the authors of
+// the recent tweets matching the given search query, located in the given
+// 'geocode' area and matching the given 'filter'.
+// The sleep policy controls the type of sleep you want between requests.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowNearbyAsync(searchQuery string, geocode GeoCode, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowNearby")
+	filterCopy := copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		t.autoFollowNearby(searchQuery, geocode, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
+}
+
+// GetSuggestedUserSlugs returns the slugs of the categories of users
+// suggested by twitter, to be used with GetSuggestedUsers.
+func (t *TwitterBot) GetSuggestedUserSlugs() ([]string, error) {
+	categories, err := t.twitterClient.GetSuggestedCategories(nil)
+	if err != nil {
+		return nil, err
+	}
+	slugs := []string{}
+	for _, category := range categories {
+		slugs = append(slugs, category.Slug)
+	}
+	return slugs, nil
+}
+
+// GetSuggestedUsers returns the users suggested by twitter
+// in the category of the given 'slug'.
+func (t *TwitterBot) GetSuggestedUsers(slug string) ([]anaconda.User, error) {
+	return t.twitterClient.GetSuggestedUsers(slug, nil)
+}
+
+// getSuggested returns the ids of the users suggested in the categories
+// of the given slugs and matching the given 'filter'.
+func (t *TwitterBot) getSuggested(slugs []string, filter *FollowFilter) ([]int64, error) {
+	ids := []int64{}
+	for _, slug := range slugs {
+		users, err := t.GetSuggestedUsers(slug)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if !filter.match(&user) {
+				print(t, fmt.Sprintf("[twitter] filtering out user (id:%d, name:%s)\n", user.Id, user.Name))
+				continue
+			}
+			ids = append(ids, user.Id)
+		}
+	}
+	return ids, nil
+}
+
+// AutoFollowSuggested automatically follows the users suggested by twitter
+// in the categories of the given slugs and matching the given 'filter',
+// see GetSuggestedUserSlugs. The sleep policy controls the type of sleep
+// you want between requests.
+func (t *TwitterBot) AutoFollowSuggested(slugs []string, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowSuggested(slugs, filter, sleepPolicy, t.newCampaign("AutoFollowSuggested"))
+}
+
+func (t *TwitterBot) autoFollowSuggested(slugs []string, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow of suggested users in %v...", slugs)
+	sleepPolicy.log()
+	campaign.setSource("suggestions:" + strings.Join(slugs, ","))
+	ids, err := t.getSuggested(slugs, &filter)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+		return
+	}
+	t.followAll(ids, &FollowFilter{}, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
+}
+
+// AutoFollowSuggestedAsync automatically asynchronously follows the users
+// suggested by twitter in the categories of the given slugs and matching
+// the given 'filter'. The sleep policy controls the type of sleep
+// you want between requests.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowSuggestedAsync(slugs []string, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowSuggested")
+	slugsCopy := make([]string, len(slugs))
+	copy(slugsCopy, slugs)
+	filterCopy := copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		t.autoFollowSuggested(slugsCopy, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
+}
+
+type seedCursor struct {
+	user   anaconda.User
+	cursor string
+	done   bool
+}
+
+// resolveSeed returns the user corresponding to the given seed: the user of
+// the given screen name if prefixed by '@', the first user found using
+// the seed as a search query otherwise.
+func (t *TwitterBot) resolveSeed(seed string) (anaconda.User, error) {
+	if strings.HasPrefix(seed, "@") {
+		return t.twitterClient.GetUsersShow(strings.TrimPrefix(seed, "@"), nil)
+	}
+	users, err := t.twitterClient.GetUserSearch(seed, nil)
+	if err != nil {
+		return anaconda.User{}, err
+	}
+	if len(users) == 0 {
+		return anaconda.User{}, fmt.Errorf("[twitter] no user found for seed '%s'", seed)
+	}
+	return users[0], nil
+}
+
+// fetchSeedsUserIds returns the ids of the followers of the users resolved from
+// the given seeds, interleaving their pages of followers up to 'maxPage' pages by seed.
+func (t *TwitterBot) fetchSeedsUserIds(seeds []string, maxPage int) []int64 {
+	cursors := []*seedCursor{}
+	for _, seed := range seeds {
+		user, err := t.resolveSeed(seed)
+		if err != nil {
+			t.checkBotRestriction(err)
+			continue
+		}
+		SubsystemFollow.debug("[twitter] seed '%s' resolved to user (id:%d, name:%s)", seed, user.Id, user.ScreenName)
+		cursors = append(cursors, &seedCursor{
+			user:   user,
+			cursor: "-1",
+		})
+	}
+	ids := []int64{}
+	added := map[int64]struct{}{}
+	for page := 0; page < maxPage; page++ {
+		active := false
+		for _, c := range cursors {
+			if c.done {
+				continue
+			}
+			pageIds, next, err := t.fetchFollowersPage(c.user.Id, c.cursor)
+			if err != nil {
+				t.checkBotRestriction(err)
+				c.done = true
+				continue
+			}
+			for _, id := range pageIds {
+				if _, ok := added[id]; ok {
+					continue
+				}
+				added[id] = struct{}{}
+				ids = append(ids, id)
+			}
+			c.cursor = next
+			c.done = c.cursor == "0" || c.cursor == ""
+			active = active || !c.done
+		}
+		if !active {
+			break
+		}
+	}
+	return ids
+}
+
+// AutoFollowFollowersOf automatically follows the followers of the users resolved
+// from the given seeds: screen names when prefixed by '@', e.g "@nasa", search queries
+// otherwise, in which case the first user found is used. The pages of followers
+// (5000 users max by page) of each seed are interleaved up to 'maxPage' pages by seed.
+// Only the followers matching the given 'filter' are followed. The sleep policy
+// controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoFollowFollowersOf(seeds []string, maxPage int, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowFollowersOf(seeds, maxPage, filter, sleepPolicy, t.newCampaign("AutoFollowFollowersOf"))
+}
+
+func (t *TwitterBot) autoFollowFollowersOf(seeds []string, maxPage int, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow with %v over %d page(s)...", seeds, maxPage)
+	sleepPolicy.log()
+	campaign.setSource("followers-of:" + strings.Join(seeds, ","))
+	campaign.key = fmt.Sprintf("followers-of:%s:%d", strings.Join(seeds, ","), maxPage)
+	if t.resumeProgress(&sleepPolicy, campaign) {
+		SubsystemFollow.info("[twitter] auto follow disabled")
+		return
+	}
+	t.followAll(t.fetchSeedsUserIds(seeds, maxPage), &filter, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
+}
+
+// AutoFollowFollowersOfAsync automatically asynchronously follows the followers
+// of the users resolved from the given seeds, see AutoFollowFollowersOf.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowFollowersOfAsync(seeds []string, maxPage int, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowFollowersOf")
+	seedsCopy := make([]string, len(seeds))
+	copy(seedsCopy, seeds)
+	filterCopy := copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		t.autoFollowFollowersOf(seedsCopy, maxPage, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
+}
@@ -0,0 +1,246 @@
+package twbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestFollowFilter(c *C) {
+	user := &anaconda.User{
+		CreatedAt:      time.Now().Add(-48 * time.Hour).Format(time.RubyDate),
+		Description:    "Space enthusiast",
+		FollowersCount: 100,
+		FriendsCount:   50,
+		Lang:           "en",
+	}
+	filter := &FollowFilter{}
+	c.Assert(filter.empty(), Equals, true)
+	c.Assert(filter.match(user), Equals, true)
+
+	filter = &FollowFilter{MinFollowersCount: 10, MaxFollowersCount: 1000}
+	c.Assert(filter.empty(), Equals, false)
+	c.Assert(filter.match(user), Equals, true)
+	filter.MaxFollowersCount = 99
+	c.Assert(filter.match(user), Equals, false)
+
+	filter = &FollowFilter{MinAccountAge: 24 * time.Hour}
+	c.Assert(filter.match(user), Equals, true)
+	filter.MinAccountAge = 72 * time.Hour
+	c.Assert(filter.match(user), Equals, false)
+
+	filter = &FollowFilter{RequireProfileImage: true}
+	c.Assert(filter.match(user), Equals, true)
+	user.DefaultProfileImage = true
+	c.Assert(filter.match(user), Equals, false)
+	user.DefaultProfileImage = false
+
+	filter = &FollowFilter{IncludeKeywords: []string{"SPACE"}}
+	c.Assert(filter.match(user), Equals, true)
+	filter.ExcludeKeywords = []string{"enthusiast"}
+	c.Assert(filter.match(user), Equals, false)
+
+	filter = &FollowFilter{MinRatio: 0.1, MaxRatio: 1}
+	c.Assert(filter.match(user), Equals, true)
+	filter.MaxRatio = 0.4
+	c.Assert(filter.match(user), Equals, false)
+
+	filter = &FollowFilter{Languages: []string{"fr", "en"}}
+	c.Assert(filter.match(user), Equals, true)
+	filter.Languages = []string{"fr"}
+	c.Assert(filter.match(user), Equals, false)
+
+	filter = &FollowFilter{SkipProtected: true}
+	c.Assert(filter.empty(), Equals, false)
+	c.Assert(filter.match(user), Equals, true)
+	user.Protected = true
+	c.Assert(filter.match(user), Equals, false)
+}
+
+func (s *MySuite) TestRefollowCooldown(c *C) {
+	now := time.Now()
+	bot := &TwitterBot{
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				"1": {Timestamp: now.UnixNano(), Follow: true},
+				"2": {Timestamp: now.UnixNano(), Unfollowed: now.Add(-48 * time.Hour).UnixNano()},
+				"3": {Timestamp: now.Add(-48 * time.Hour).UnixNano()},
+			},
+		},
+		unfollowPolicy: &unfollowPolicy{},
+	}
+	c.Assert(bot.canFollow(1), Equals, false)
+	c.Assert(bot.canFollow(2), Equals, false)
+	c.Assert(bot.canFollow(3), Equals, false)
+	c.Assert(bot.canFollow(4), Equals, true)
+
+	bot.SetRefollowCooldown(24 * time.Hour)
+	c.Assert(bot.canFollow(1), Equals, false)
+	c.Assert(bot.canFollow(2), Equals, true)
+	c.Assert(bot.canFollow(3), Equals, true)
+
+	bot.SetRefollowCooldown(72 * time.Hour)
+	c.Assert(bot.canFollow(2), Equals, false)
+}
+
+func (s *MySuite) TestLookupUsers(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	ids := []int64{-1}
+	for i := int64(0); i < 250; i++ {
+		ids = append(ids, i)
+	}
+	users, err := bot.LookupUsers(ids)
+	c.Assert(err, IsNil)
+	c.Assert(users, HasLen, 250)
+	c.Assert(client.lookups, DeepEquals, []int{100, 100, 51})
+
+	client.lookups = nil
+	users, err = bot.LookupUsers(nil)
+	c.Assert(err, IsNil)
+	c.Assert(users, HasLen, 0)
+	c.Assert(client.lookups, HasLen, 0)
+}
+
+func (s *MySuite) TestSuggested(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	slugs, err := bot.GetSuggestedUserSlugs()
+	c.Assert(err, IsNil)
+	c.Assert(slugs, DeepEquals, []string{"sports", "music"})
+
+	ids, err := bot.getSuggested([]string{"sports"}, &FollowFilter{MinFollowersCount: 100})
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{2})
+
+	_, err = bot.getSuggested([]string{"sports", "unknown"}, &FollowFilter{})
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestGetAuthorsNearby(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	ids, err := bot.getAuthors("coffee", nil, &FollowFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1})
+	c.Assert(client.geocode, Equals, "")
+
+	geocode := GeoCode{Latitude: 48.8566, Longitude: 2.3522, Radius: "10km"}
+	ids, err = bot.getAuthors("coffee", &geocode, &FollowFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 2})
+	c.Assert(client.geocode, Equals, "48.8566,2.3522,10km")
+}
+
+func (s *MySuite) TestFollowBack(c *C) {
+	clock := NewFakeClock(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(clock)
+	defer SetClock(nil)
+	client := &fakeClient{
+		myFollowers: []int64{1, 2},
+	}
+	bot := makeFakeBot(client)
+	bot.state = &twitterState{SinceIDs: map[string]int64{}}
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{}
+	bot.growth = &twitterGrowth{}
+	bot.noSleep = true
+	campaign := newCampaignContext(context.Background(), "test")
+
+	// the first run only records the current followers
+	c.Assert(bot.followBack(&FollowFilter{}, nil, campaign), IsNil)
+	c.Assert(client.followed, HasLen, 0)
+
+	clock.Advance(time.Hour)
+	client.myFollowers = []int64{1, 2, 3}
+	c.Assert(bot.followBack(&FollowFilter{}, nil, campaign), IsNil)
+	c.Assert(client.followed, DeepEquals, []int64{3})
+	c.Assert(bot.followers.Ids["3"].FollowedBack, Equals, true)
+
+	// followers are only followed back once
+	clock.Advance(time.Hour)
+	c.Assert(bot.followBack(&FollowFilter{}, nil, campaign), IsNil)
+	c.Assert(client.followed, DeepEquals, []int64{3})
+}
+
+func (s *MySuite) TestAutoFollowEngagers(c *C) {
+	client := &fakeClient{
+		retweetedMe: []anaconda.Tweet{{Id: 10}, {Id: 20}, {Id: 30}},
+		retweets: map[int64][]anaconda.Tweet{
+			10: {{User: anaconda.User{Id: 1}}, {User: anaconda.User{Id: 2}}},
+			20: {{User: anaconda.User{Id: 2}}, {User: anaconda.User{Id: 3}}},
+			30: {{User: anaconda.User{Id: 4}}},
+		},
+	}
+	bot := makeFakeBot(client)
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{}
+	bot.noSleep = true
+	ids, err := bot.getEngagers(2)
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 2, 3})
+
+	// followers are not followed again
+	bot.followers.Ids["3"] = &twitterUser{Follow: true}
+	bot.AutoFollowEngagers(3, FollowFilter{}, SleepPolicy{})
+	c.Assert(client.followed, DeepEquals, []int64{1, 2, 4})
+	c.Assert(bot.friends.Ids["4"].Source, Equals, "engagers")
+}
+
+func (s *MySuite) TestAutoFollowByQuery(c *C) {
+	client := &fakeClient{
+		found: []anaconda.Tweet{
+			{User: anaconda.User{Id: 1, FollowersCount: 1000}},
+			{User: anaconda.User{Id: 2, FollowersCount: 10}},
+			{User: anaconda.User{Id: 1, FollowersCount: 1000}},
+			{User: anaconda.User{Id: 3, FollowersCount: 1000}},
+		},
+	}
+	bot := makeFakeBot(client)
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{}
+	bot.noSleep = true
+	bot.friends.Ids["3"] = &twitterUser{Follow: true}
+	bot.AutoFollowByQuery("#golang", FollowFilter{MinFollowersCount: 100}, SleepPolicy{})
+	c.Assert(client.followed, DeepEquals, []int64{1})
+	c.Assert(bot.friends.Ids["1"].Source, Equals, "query:#golang")
+
+	campaign := bot.AutoFollowByQueryAsync("#golang", FollowFilter{}, &SleepPolicy{})
+	select {
+	case <-campaign.Done():
+	case <-time.After(time.Second):
+		c.Fatal("follow campaign should be done")
+	}
+	c.Assert(campaign.Err(), IsNil)
+	c.Assert(client.followed, DeepEquals, []int64{1, 2})
+}
+
+func (s *MySuite) TestFetchSeedsUserIds(c *C) {
+	client := &fakeClient{
+		users: map[string]anaconda.User{
+			"nasa":  {Id: 10, ScreenName: "nasa"},
+			"esa":   {Id: 20, ScreenName: "esa"},
+			"empty": {Id: 30, ScreenName: "empty"},
+		},
+		followers: map[int64][]int64{
+			10: {1, 2, 3, 4},
+			20: {5, 6, 1},
+		},
+		pageSize: 2,
+	}
+	bot := makeFakeBot(client)
+	// the pages of followers of the seeds are interleaved
+	ids := bot.fetchSeedsUserIds([]string{"@nasa", "esa", "@unknown", "unknown", "empty"}, 2)
+	c.Assert(ids, DeepEquals, []int64{1, 2, 5, 6, 3, 4})
+	ids = bot.fetchSeedsUserIds([]string{"@nasa", "esa"}, 1)
+	c.Assert(ids, DeepEquals, []int64{1, 2, 5, 6})
+
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{}
+	bot.noSleep = true
+	bot.AutoFollowFollowersOf([]string{"@esa", "nasa"}, 1, FollowFilter{}, SleepPolicy{})
+	c.Assert(client.followed, DeepEquals, []int64{5, 6, 1, 2})
+	c.Assert(bot.friends.Ids["5"].Source, Equals, "followers-of:@esa,nasa")
+}
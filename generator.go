@@ -0,0 +1,68 @@
+package twbot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	promptTextTag        = "{text}"
+	generatedTextMaxSize = 280 // characters of a tweet
+)
+
+// TextGenerator generates texts, a large language model for instance,
+// see SetTextGenerator and NewOpenAIGenerator.
+type TextGenerator interface {
+	// Generate returns the text generated from the given prompt.
+	Generate(prompt string) (string, error)
+}
+
+// SetTextGenerator sets the generator of the replies to the mentions and of
+// the comments of the quotes. The mentions matching no reply rule get the text
+// generated from 'replyPrompt', see AutoReplyMentions, and the quotes the
+// comment generated from 'quotePrompt', see SetQuotePolicy, instead of the
+// fallback reply and the quote template, which are still used if the
+// generation fails. The "{author}" and "{text}" tags of the prompts are
+// replaced by the screen name of the author and the text of the tweet.
+// The generated texts are truncated to fit in their tweet.
+// An empty prompt disables the generation, as a nil generator.
+func (t *TwitterBot) SetTextGenerator(generator TextGenerator, replyPrompt, quotePrompt string) {
+	logInfo("[twitter] setting text generator -> replyPrompt: %s, quotePrompt: %s", replyPrompt, quotePrompt)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.generator = generator
+	t.replyPrompt = replyPrompt
+	t.quotePrompt = quotePrompt
+}
+
+// getQuoteGenerator returns the generator of the comments of the quotes,
+// with its prompt, or nil if none.
+func (t *TwitterBot) getQuoteGenerator() (TextGenerator, string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.quotePrompt == "" {
+		return nil, ""
+	}
+	return t.generator, t.quotePrompt
+}
+
+func formatPrompt(prompt string, tweet *anaconda.Tweet) string {
+	return strings.Replace(formatQuote(prompt, tweet), promptTextTag, tweet.Text, -1)
+}
+
+// generateText returns the text generated from the given prompt applied to
+// the given tweet, without its surrounding quotes and truncated with an
+// ellipsis to 'maxSize' characters.
+func generateText(generator TextGenerator, prompt string, tweet *anaconda.Tweet, maxSize int) (string, error) {
+	text, err := generator.Generate(formatPrompt(prompt, tweet))
+	if err != nil {
+		return "", err
+	}
+	text = strings.Trim(strings.TrimSpace(text), `"`)
+	if text == "" {
+		return "", fmt.Errorf("[twitter] empty generated text")
+	}
+	return fitPost(Post{Text: text}, maxSize, 0), nil
+}
@@ -0,0 +1,132 @@
+package twbot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+type fakeGenerator struct {
+	prompts []string
+	text    string
+	err     error
+}
+
+func (g *fakeGenerator) Generate(prompt string) (string, error) {
+	g.prompts = append(g.prompts, prompt)
+	if g.text != "" {
+		return g.text, g.err
+	}
+	return ` "generated" `, g.err
+}
+
+func (s *MySuite) TestGeneratedReplies(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.replyRules = &replyRules{
+		byUser: map[int64]int{},
+	}
+	bot.AddReplyKeyword("hello", "hi {author}!")
+	generator := &fakeGenerator{}
+	bot.SetTextGenerator(generator, "reply to {author}: {text}", "")
+	mention := anaconda.Tweet{Text: "@bot how are you?", User: anaconda.User{Id: 10, ScreenName: "user"}}
+
+	reply, ok := bot.replyByRules(anaconda.Tweet{Text: "@bot hello", User: mention.User})
+	c.Assert(ok, Equals, true)
+	c.Assert(reply, Equals, "hi user!")
+	reply, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, true)
+	c.Assert(reply, Equals, "generated")
+	c.Assert(generator.prompts, DeepEquals, []string{"reply to user: @bot how are you?"})
+
+	// the fallback reply is used if the generation fails
+	generator.err = errors.New("failed")
+	_, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, false)
+	bot.SetReplyPolicy(0, "sorry {author}")
+	reply, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, true)
+	c.Assert(reply, Equals, "sorry user")
+
+	// the generated reply fits in a tweet with the mention of the author
+	generator.err = nil
+	generator.text = strings.Repeat("a", 300)
+	reply, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, true)
+	c.Assert(len([]rune("@user "+reply)), Equals, generatedTextMaxSize)
+	c.Assert(strings.HasSuffix(reply, "a…"), Equals, true)
+
+	// only the replies made count against the daily cap
+	bot.SetReplyPolicy(1, "")
+	bot.replyRules.byUser = map[int64]int{}
+	generator.err = errors.New("failed")
+	_, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, false)
+	generator.err = nil
+	_, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, true)
+	_, ok = bot.replyByRules(mention)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MySuite) TestGeneratedQuotes(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	generator := &fakeGenerator{}
+	policy := &retweetPolicy{quoteTemplate: "via @{author}"}
+	tweet := &anaconda.Tweet{Id: 1, Text: "news", User: anaconda.User{ScreenName: "user"}}
+
+	_, err := bot.quote(tweet, policy)
+	c.Assert(err, IsNil)
+	bot.SetTextGenerator(generator, "", "comment {text}")
+	_, err = bot.quote(tweet, policy)
+	c.Assert(err, IsNil)
+	generator.err = errors.New("failed")
+	_, err = bot.quote(tweet, policy)
+	c.Assert(err, IsNil)
+	c.Assert(generator.prompts, DeepEquals, []string{"comment news", "comment news"})
+	c.Assert(client.tweets, DeepEquals, []string{
+		"via @user https://twitter.com/user/status/1",
+		"generated https://twitter.com/user/status/1",
+		"via @user https://twitter.com/user/status/1",
+	})
+
+	// the generated comment fits in a tweet with the link of the quote
+	generator.err = nil
+	generator.text = strings.Repeat("a", 300)
+	_, err = bot.quote(tweet, policy)
+	c.Assert(err, IsNil)
+	comment := strings.TrimSuffix(client.tweets[3], " https://twitter.com/user/status/1")
+	c.Assert(comment, Not(Equals), client.tweets[3])
+	c.Assert(len(comment) <= tweetTextMaxSize-tcoLinksMaxLength-1, Equals, true)
+}
+
+func (s *MySuite) TestOpenAIGenerator(c *C) {
+	request := struct {
+		Model    string          `json:"model"`
+		Messages []openAIMessage `json:"messages"`
+	}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v1/chat/completions")
+		c.Check(r.Header.Get("Authorization"), Equals, "Bearer key")
+		c.Check(json.NewDecoder(r.Body).Decode(&request), IsNil)
+		if strings.Contains(request.Messages[0].Content, "fail") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hello"}}]}`))
+	}))
+	defer server.Close()
+	generator := NewOpenAIGenerator(server.URL+"/v1/", "key", "model")
+	text, err := generator.Generate("say hello")
+	c.Assert(err, IsNil)
+	c.Assert(text, Equals, "hello")
+	c.Assert(request.Model, Equals, "model")
+	c.Assert(request.Messages, DeepEquals, []openAIMessage{{Role: "user", Content: "say hello"}})
+	_, err = generator.Generate("fail")
+	c.Assert(err, ErrorMatches, `\[twitter\] text generation failed: 429 .*`)
+}
@@ -0,0 +1,106 @@
+package twbot
+
+import (
+	"time"
+)
+
+type growthPoint struct {
+	Timestamp int64 `json:"timestamp"`
+	Followers int   `json:"followers"`
+	Friends   int   `json:"friends"`
+}
+
+type twitterGrowth struct {
+	Points []growthPoint `json:"points"` // sorted by timestamp
+}
+
+// GrowthStat represents the number of followers and friends of the bot
+// at the end of a day, and their evolution over the day.
+type GrowthStat struct {
+	Day            time.Time
+	Followers      int
+	Friends        int
+	FollowersDelta int
+	FriendsDelta   int
+}
+
+// SetGrowthPath sets the path of the growth database, where the number of
+// followers and friends is recorded on every update of the followers and
+// friends databases. If no path is set, the growth is only kept in memory.
+func (t *TwitterBot) SetGrowthPath(growthPath string) error {
+	growth := &twitterGrowth{}
+	err := t.loadOrCreate(growthPath, growth)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	// keep the points recorded before the database was set
+	growth.Points = append(growth.Points, t.growth.Points...)
+	t.growthPath = growthPath
+	t.growth = growth
+	return t.store.Save(t.growthPath, t.growth)
+}
+
+// recordGrowth records the current number of followers and friends.
+// The reached followers milestones are alerted, see SetFollowersMilestones.
+func (t *TwitterBot) recordGrowth() {
+	t.mutex.Lock()
+	point := growthPoint{
+		Timestamp: timeNow().UnixNano(),
+		Followers: countFollowing(t.followers),
+		Friends:   countFollowing(t.friends),
+	}
+	reached := []int{}
+	if len(t.growth.Points) > 0 {
+		previous := t.growth.Points[len(t.growth.Points)-1]
+		reached = reachedMilestones(t.milestones, previous.Followers, point.Followers)
+	}
+	t.growth.Points = append(t.growth.Points, point)
+	if t.growthPath != "" {
+		err := t.store.Save(t.growthPath, t.growth)
+		if err != nil {
+			logError("%v", err)
+		}
+	}
+	t.mutex.Unlock()
+	for _, milestone := range reached {
+		t.alert("reached %d followers", milestone)
+	}
+}
+
+// GetGrowthStats returns the daily growth of the number of followers and
+// friends of the bot over the given 'period', the oldest day first.
+// Days are UTC days and days without any record are omitted.
+func (t *TwitterBot) GetGrowthStats(period time.Duration) []GrowthStat {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return computeGrowthStats(t.growth.Points, timeNow().Add(-period))
+}
+
+func computeGrowthStats(points []growthPoint, since time.Time) []GrowthStat {
+	stats := []GrowthStat{}
+	for _, point := range points {
+		day := time.Unix(0, point.Timestamp).UTC().Truncate(24 * time.Hour)
+		if len(stats) == 0 || !stats[len(stats)-1].Day.Equal(day) {
+			stats = append(stats, GrowthStat{Day: day})
+		}
+		// the last record of the day gives its numbers
+		stats[len(stats)-1].Followers = point.Followers
+		stats[len(stats)-1].Friends = point.Friends
+	}
+	filtered := []GrowthStat{}
+	for i, stat := range stats {
+		// the first day evolves from the first record
+		previous := GrowthStat{Followers: points[0].Followers, Friends: points[0].Friends}
+		if i > 0 {
+			previous = stats[i-1]
+		}
+		stat.FollowersDelta = stat.Followers - previous.Followers
+		stat.FriendsDelta = stat.Friends - previous.Friends
+		if stat.Day.Add(24 * time.Hour).After(since) {
+			filtered = append(filtered, stat)
+		}
+	}
+	return filtered
+}
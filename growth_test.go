@@ -0,0 +1,26 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestComputeGrowthStats(c *C) {
+	day := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := []growthPoint{
+		{Timestamp: day.Add(1 * time.Hour).UnixNano(), Followers: 10, Friends: 20},
+		{Timestamp: day.Add(5 * time.Hour).UnixNano(), Followers: 12, Friends: 25},
+		{Timestamp: day.Add(26 * time.Hour).UnixNano(), Followers: 15, Friends: 24},
+		{Timestamp: day.Add(74 * time.Hour).UnixNano(), Followers: 14, Friends: 30},
+	}
+	stats := computeGrowthStats(points, day)
+	c.Assert(stats, DeepEquals, []GrowthStat{
+		{Day: day, Followers: 12, Friends: 25, FollowersDelta: 2, FriendsDelta: 5},
+		{Day: day.Add(24 * time.Hour), Followers: 15, Friends: 24, FollowersDelta: 3, FriendsDelta: -1},
+		{Day: day.Add(72 * time.Hour), Followers: 14, Friends: 30, FollowersDelta: -1, FriendsDelta: 6},
+	})
+	stats = computeGrowthStats(points, day.Add(48*time.Hour))
+	c.Assert(stats, HasLen, 1)
+	c.Assert(computeGrowthStats(nil, day), HasLen, 0)
+}
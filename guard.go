@@ -0,0 +1,143 @@
+package twbot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMaxFollowsPerDay   = 400
+	defaultMaxUnfollowsPerDay = 400
+	followingLimit            = 5000 // twitter following limit before the ratio rule applies
+	followingRatioLimit       = 1.1  // maximum ratio friends / followers above the following limit
+)
+
+// followGuard keeps track of the daily follows and unfollows
+// in order to stay under twitter limits.
+type followGuard struct {
+	maxFollowsPerDay   int
+	maxUnfollowsPerDay int
+	dayStart           time.Time
+	follows            int
+	unfollows          int
+	lastFollow         time.Time
+}
+
+func (g *followGuard) update() {
+	if timeSince(g.dayStart) >= 24*time.Hour {
+		g.dayStart = timeNow()
+		g.follows = 0
+		g.unfollows = 0
+	}
+}
+
+// SetFollowQuota sets the maximum number of follows and unfollows per day
+// of the follow campaigns, 0 meaning no limit. Campaigns pause automatically
+// when a quota is reached, as well as when the following limit of 5000 users
+// is reached while following more than 1.1 times the number of followers.
+// Default quotas are 400 follows and 400 unfollows per day.
+func (t *TwitterBot) SetFollowQuota(maxFollowsPerDay, maxUnfollowsPerDay int) {
+	logInfo("[twitter] setting follow quota -> maxFollowsPerDay: %d, maxUnfollowsPerDay: %d",
+		maxFollowsPerDay, maxUnfollowsPerDay)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.followGuard.maxFollowsPerDay = maxFollowsPerDay
+	t.followGuard.maxUnfollowsPerDay = maxUnfollowsPerDay
+}
+
+func countFollowing(users *twitterUsers) int {
+	count := 0
+	for _, user := range users.Ids {
+		if user.Follow {
+			count++
+		}
+	}
+	return count
+}
+
+// checkFollowQuota returns an error if a follow would exceed the daily
+// quota or the following ratio limit.
+func (t *TwitterBot) checkFollowQuota() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.followGuard.update()
+	if t.followGuard.maxFollowsPerDay > 0 && t.followGuard.follows >= t.followGuard.maxFollowsPerDay {
+		return fmt.Errorf("[twitter] daily follow quota of %d reached", t.followGuard.maxFollowsPerDay)
+	}
+	friends := countFollowing(t.friends)
+	followers := countFollowing(t.followers)
+	if friends >= followingLimit && float64(friends) >= followingRatioLimit*float64(followers) {
+		return fmt.Errorf("[twitter] following ratio limit reached (friends: %d, followers: %d)", friends, followers)
+	}
+	return nil
+}
+
+// checkUnfollowQuota returns an error if an unfollow would exceed the daily quota.
+func (t *TwitterBot) checkUnfollowQuota() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.followGuard.update()
+	if t.followGuard.maxUnfollowsPerDay > 0 && t.followGuard.unfollows >= t.followGuard.maxUnfollowsPerDay {
+		return fmt.Errorf("[twitter] daily unfollow quota of %d reached", t.followGuard.maxUnfollowsPerDay)
+	}
+	return nil
+}
+
+// waitFollowQuota pauses until a follow is allowed by the follow guard.
+// It returns false if the context is done before.
+func (t *TwitterBot) waitFollowQuota(ctx context.Context) bool {
+	err := t.checkFollowQuota()
+	if err != nil {
+		t.alert("%s, pausing until allowed again", err)
+	}
+	for ; err != nil; err = t.checkFollowQuota() {
+		wait := t.getTiming().QuotaWait
+		SubsystemFollow.warn("%s, pausing for %s...", err, wait)
+		if !sleepContext(ctx, wait) {
+			return false
+		}
+	}
+	return true
+}
+
+// waitUnfollowQuota pauses until an unfollow is allowed by the follow guard,
+// then until the end of the wait after the last follow, see Timing.
+// It returns false if the context is done before.
+func (t *TwitterBot) waitUnfollowQuota(ctx context.Context) bool {
+	err := t.checkUnfollowQuota()
+	if err != nil {
+		t.alert("%s, pausing until allowed again", err)
+	}
+	for ; err != nil; err = t.checkUnfollowQuota() {
+		wait := t.getTiming().QuotaWait
+		SubsystemFollow.warn("%s, pausing for %s...", err, wait)
+		if !sleepContext(ctx, wait) {
+			return false
+		}
+	}
+	t.mutex.Lock()
+	lastFollow := t.followGuard.lastFollow
+	t.mutex.Unlock()
+	wait := t.getTiming().FollowUnfollowWait - timeSince(lastFollow)
+	if t.noSleep || lastFollow.IsZero() || wait <= 0 {
+		return ctx.Err() == nil
+	}
+	SubsystemFollow.info("[twitter] followed a user recently, pausing for %s before unfollowing...", wait)
+	return sleepContext(ctx, wait)
+}
+
+func (t *TwitterBot) countFollow() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.followGuard.update()
+	t.followGuard.follows++
+	t.followGuard.lastFollow = timeNow()
+}
+
+func (t *TwitterBot) countUnfollow() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.followGuard.update()
+	t.followGuard.unfollows++
+}
@@ -0,0 +1,100 @@
+package twbot
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func makeUsers(count int) *twitterUsers {
+	users := &twitterUsers{
+		Ids: make(map[string]*twitterUser),
+	}
+	for i := 0; i < count; i++ {
+		users.Ids[strconv.Itoa(i)] = &twitterUser{Follow: true}
+	}
+	return users
+}
+
+func (s *MySuite) TestFollowQuota(c *C) {
+	bot := &TwitterBot{
+		followers: makeUsers(0),
+		friends:   makeUsers(0),
+		followGuard: &followGuard{
+			maxFollowsPerDay:   2,
+			maxUnfollowsPerDay: 1,
+		},
+	}
+	c.Assert(bot.checkFollowQuota(), IsNil)
+	bot.countFollow()
+	bot.countFollow()
+	c.Assert(bot.checkFollowQuota(), NotNil)
+	c.Assert(bot.checkUnfollowQuota(), IsNil)
+	bot.countUnfollow()
+	c.Assert(bot.checkUnfollowQuota(), NotNil)
+
+	bot.SetFollowQuota(0, 0)
+	c.Assert(bot.checkFollowQuota(), IsNil)
+	c.Assert(bot.checkUnfollowQuota(), IsNil)
+
+	bot.friends = makeUsers(followingLimit)
+	bot.followers = makeUsers(4000)
+	c.Assert(bot.checkFollowQuota(), NotNil)
+	bot.followers = makeUsers(4600)
+	c.Assert(bot.checkFollowQuota(), IsNil)
+}
+
+func (s *MySuite) TestFollowUserQuota(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{
+		maxFollowsPerDay: 1,
+	}
+	// failed follows do not count in the quota
+	client.followErr = errors.New("follow failed")
+	bot.followUser(&anaconda.User{Id: 1})
+	c.Assert(bot.checkFollowQuota(), IsNil)
+
+	client.followErr = nil
+	bot.followUser(&anaconda.User{Id: 1})
+	c.Assert(client.followed, DeepEquals, []int64{1})
+	c.Assert(bot.checkFollowQuota(), NotNil)
+	bot.followUser(&anaconda.User{Id: 2})
+	c.Assert(client.followed, DeepEquals, []int64{1})
+}
+
+func (s *MySuite) TestFollowUnfollowWait(c *C) {
+	clock := NewFakeClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+	bot := makeFakeBot(&fakeClient{})
+	bot.followGuard = &followGuard{}
+	// no wait before any follow
+	c.Assert(bot.waitUnfollowQuota(context.Background()), Equals, true)
+
+	// unfollows wait after a follow
+	bot.countFollow()
+	clock.Advance(time.Minute)
+	done := make(chan bool, 1)
+	go func() {
+		done <- bot.waitUnfollowQuota(context.Background())
+	}()
+	for i := 0; i < 100 && clock.Waiters() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(clock.Waiters(), Equals, 1)
+	clock.Advance(4 * time.Minute)
+	select {
+	case ok := <-done:
+		c.Assert(ok, Equals, true)
+	case <-time.After(time.Second):
+		c.Fatal("unfollow should be allowed once the wait is over")
+	}
+	c.Assert(bot.waitUnfollowQuota(context.Background()), Equals, true)
+}
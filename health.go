@@ -0,0 +1,114 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthStoreExt is the extension of the small database saved next to the
+// followers database to check that the store is writable.
+const healthStoreExt = ".health"
+
+// ErrUnhealthy is wrapped by the errors returned by Healthy.
+var ErrUnhealthy = errors.New("[twitter] bot unhealthy")
+
+type healthState struct {
+	mutex       sync.Mutex
+	storeMutex  sync.Mutex // serializes the store checks
+	maxIdle     time.Duration
+	lastSuccess time.Time
+	authErr     error // credentials error since the last successful call
+}
+
+// record records the result of a call to the twitter API.
+func (h *healthState) record(err error, now time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if err == nil {
+		h.lastSuccess = now
+		h.authErr = nil
+		return
+	}
+	err = wrapAPIError(err)
+	if errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrAccountLocked) {
+		h.authErr = err
+	}
+}
+
+// SetHealthMaxIdle sets the longest time without a successful call to the
+// twitter API for the bot to be healthy, see Healthy. A zero 'maxIdle',
+// the default, disables the check. It must be longer than the longest wait
+// of the bot between two calls, like the period of its periodic tweets.
+func (t *TwitterBot) SetHealthMaxIdle(maxIdle time.Duration) {
+	logInfo("[twitter] setting health max idle -> maxIdle: %s", maxIdle)
+	t.health.mutex.Lock()
+	defer t.health.mutex.Unlock()
+	t.health.maxIdle = maxIdle
+}
+
+// Healthy returns nil if the bot works, otherwise an error wrapping
+// ErrUnhealthy if the credentials of the bot were rejected by the last
+// calls, if the store cannot save the databases, or if no call to the
+// twitter API succeeded recently, see SetHealthMaxIdle. It is meant for
+// supervisors restarting the bot when it silently stops working, see
+// HealthHandler.
+func (t *TwitterBot) Healthy() error {
+	t.health.mutex.Lock()
+	maxIdle, lastSuccess, authErr := t.health.maxIdle, t.health.lastSuccess, t.health.authErr
+	t.health.mutex.Unlock()
+	if authErr != nil {
+		return fmt.Errorf("%w: invalid credentials: %w", ErrUnhealthy, authErr)
+	}
+	err := t.checkStore()
+	if err != nil {
+		return fmt.Errorf("%w: store not writable: %w", ErrUnhealthy, err)
+	}
+	if maxIdle <= 0 {
+		return nil
+	}
+	if lastSuccess.IsZero() {
+		return fmt.Errorf("%w: no successful call to the twitter API", ErrUnhealthy)
+	}
+	if idle := timeSince(lastSuccess); idle > maxIdle {
+		return fmt.Errorf("%w: no successful call to the twitter API for %s", ErrUnhealthy, idle.Round(time.Second))
+	}
+	return nil
+}
+
+// checkStore saves a small database next to the followers database to check
+// that the store is writable, bypassing the batches and the dry-run mode.
+func (t *TwitterBot) checkStore() error {
+	t.mutex.Lock()
+	store, path := t.store, t.followersPath+healthStoreExt
+	t.mutex.Unlock()
+	if dryRun, ok := store.(*dryRunStore); ok {
+		store = dryRun.Store
+	}
+	if batch, ok := store.(*batchStore); ok {
+		store = batch.store
+	}
+	t.health.storeMutex.Lock()
+	defer t.health.storeMutex.Unlock()
+	return store.Save(path, struct {
+		Checked time.Time `json:"checked"`
+	}{timeNow()})
+}
+
+// HealthHandler returns an HTTP handler answering "ok" if the bot is
+// healthy, see Healthy, and the error with a 503 status code otherwise,
+// suitable for liveness probes. It is served at /healthz by the
+// administration of the bot, see NewAdmin.
+func (t *TwitterBot) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		err := t.Healthy()
+		if err != nil {
+			logWarn("%v", err)
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		rw.Write([]byte("ok\n"))
+	})
+}
@@ -0,0 +1,68 @@
+package twbot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestHealthy(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(401, anaconda.TwitterErrorInvalidToken),
+		failures:   1,
+	}
+	bot := makeFakeBot(client.fakeClient)
+	bot.noSleep = true
+	bot.followersPath = "followers.json"
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.SetHealthMaxIdle(time.Hour)
+
+	err := bot.Healthy()
+	c.Assert(errors.Is(err, ErrUnhealthy), Equals, true)
+	c.Assert(err, ErrorMatches, ".*no successful call to the twitter API")
+
+	// rejected credentials
+	_, err = bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(errors.Is(err, ErrTokenExpired), Equals, true)
+	err = bot.Healthy()
+	c.Assert(errors.Is(err, ErrUnhealthy), Equals, true)
+	c.Assert(errors.Is(err, ErrTokenExpired), Equals, true)
+
+	_, err = bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(err, IsNil)
+	c.Assert(bot.Healthy(), IsNil)
+	recorder := httptest.NewRecorder()
+	bot.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+
+	// no recent successful call
+	bot.health.lastSuccess = time.Now().Add(-2 * time.Hour)
+	c.Assert(bot.Healthy(), ErrorMatches, ".*no successful call to the twitter API for 2h0m0s")
+	bot.SetHealthMaxIdle(0)
+	c.Assert(bot.Healthy(), IsNil)
+
+	// the store is checked without saving the databases
+	saves := &failingStore{Store: bot.store}
+	bot.store = saves
+	bot.SetSaveBatching(10, 0)
+	c.Assert(bot.Healthy(), IsNil)
+	c.Assert(saves.saves, Equals, 1)
+	exists, err := saves.Exists("followers.json")
+	c.Assert(err, IsNil)
+	c.Assert(exists, Equals, false)
+	c.Assert(bot.SetSaveBatching(0, 0), IsNil)
+
+	// store not writable
+	bot.store = &failingStore{Store: bot.store, failures: 1}
+	c.Assert(bot.Healthy(), ErrorMatches, ".*store not writable: save failed")
+	recorder = httptest.NewRecorder()
+	bot.store = &failingStore{Store: bot.store, failures: 1}
+	bot.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	c.Assert(recorder.Code, Equals, http.StatusServiceUnavailable)
+	c.Assert(bot.Healthy(), IsNil)
+}
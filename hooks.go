@@ -0,0 +1,112 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVetoed is wrapped by the error of the actions cancelled by a hook, see Hooks.
+var ErrVetoed = errors.New("[twitter] action vetoed")
+
+// Hooks holds callbacks called for the actions of the bot, see AddHooks.
+// The action callbacks are called before the action with its context, the
+// event of the action whose 'Action' is not suffixed yet, "follow" for
+// instance: the action is cancelled if one of them returns an error.
+// Nil callbacks are ignored.
+type Hooks struct {
+	OnTweet         func(event Event) error
+	OnRetweet       func(event Event) error
+	OnLike          func(event Event) error
+	OnUnlike        func(event Event) error
+	OnFollow        func(event Event) error
+	OnUnfollow      func(event Event) error
+	OnBlock         func(event Event) error
+	OnUnblock       func(event Event) error
+	OnMute          func(event Event) error
+	OnUnmute        func(event Event) error
+	OnDirectMessage func(event Event) error
+	// OnSuccess is called once an action is done, and OnError once an action
+	// failed, the vetoed ones included, with the event logged for the action.
+	OnSuccess func(event Event)
+	OnError   func(event Event)
+}
+
+// hook returns the callback of the given 'action', if any.
+func (h *Hooks) hook(action string) func(event Event) error {
+	switch action {
+	case ActionTweet:
+		return h.OnTweet
+	case ActionRetweet:
+		return h.OnRetweet
+	case ActionLike:
+		return h.OnLike
+	case ActionUnlike:
+		return h.OnUnlike
+	case ActionFollow:
+		return h.OnFollow
+	case ActionUnfollow:
+		return h.OnUnfollow
+	case ActionBlock:
+		return h.OnBlock
+	case ActionUnblock:
+		return h.OnUnblock
+	case ActionMute:
+		return h.OnMute
+	case ActionUnmute:
+		return h.OnUnmute
+	case ActionDirectMessage:
+		return h.OnDirectMessage
+	}
+	return nil
+}
+
+// AddHooks registers the given hooks, so that applications are called back
+// for every action of the bot, to record custom metrics, to send notifications
+// or to veto actions for instance. The hooks are called in their registration
+// order and may be called concurrently by the asynchronous jobs.
+func (t *TwitterBot) AddHooks(hooks Hooks) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.hooks = append(t.hooks, hooks)
+}
+
+func (t *TwitterBot) getHooks() []Hooks {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.hooks
+}
+
+// hook calls the hooks of the given 'action' before it is made. It returns
+// an error wrapping ErrVetoed, and reports the failed action, if one of
+// them vetoes the action.
+func (c *apiClient) hook(action string, event Event) error {
+	for _, hooks := range c.bot.getHooks() {
+		hook := hooks.hook(action)
+		if hook == nil {
+			continue
+		}
+		event.Action = action
+		err := hook(event)
+		if err != nil {
+			err = fmt.Errorf("%w %s: %w", ErrVetoed, action, err)
+			c.report(action, event, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// report logs the event of the given 'action', see logEvent, records it in
+// the audit log, see SetAuditPath, and calls the hooks once the action is done.
+func (c *apiClient) report(action string, event Event, err error) {
+	event = logEvent(action, event, err)
+	c.bot.audit.record(action, event)
+	c.bot.actions.record(action, event)
+	for _, hooks := range c.bot.getHooks() {
+		if err != nil && hooks.OnError != nil {
+			hooks.OnError(event)
+		} else if err == nil && hooks.OnSuccess != nil {
+			hooks.OnSuccess(event)
+		}
+	}
+}
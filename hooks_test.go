@@ -0,0 +1,45 @@
+package twbot
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestHooks(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	tweeted := []string{}
+	succeeded := []string{}
+	failed := []Event{}
+	bot.AddHooks(Hooks{
+		OnTweet: func(event Event) error {
+			c.Assert(event.Action, Equals, ActionTweet)
+			tweeted = append(tweeted, event.Text)
+			if event.Text == "spam" {
+				return errors.New("no spam")
+			}
+			return nil
+		},
+		OnSuccess: func(event Event) { succeeded = append(succeeded, event.Action) },
+		OnError:   func(event Event) { failed = append(failed, event) },
+	})
+	bot.AddHooks(Hooks{
+		OnTweet: func(event Event) error {
+			c.Assert(event.Text, Not(Equals), "spam")
+			return nil
+		},
+	})
+
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	err := bot.TweetOnce(func() (string, error) { return "spam", nil })
+	c.Assert(errors.Is(err, ErrVetoed), Equals, true)
+	c.Assert(err, ErrorMatches, ".*vetoed tweet: no spam")
+	c.Assert(client.tweets, DeepEquals, []string{"hello"})
+	c.Assert(tweeted, DeepEquals, []string{"hello", "spam"})
+	c.Assert(succeeded, DeepEquals, []string{"tweet_success"})
+	c.Assert(failed, HasLen, 1)
+	c.Assert(failed[0].Action, Equals, "tweet_failed")
+	c.Assert(failed[0].Text, Equals, "spam")
+}
@@ -0,0 +1,56 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestHTTPClientOptions(c *C) {
+	deadlines := []bool{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		_, ok := req.Context().Deadline()
+		deadlines = append(deadlines, ok)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id": 42}`)),
+			Request:    req,
+		}, nil
+	})
+	bot := newTwitterBot("", "", "", "key", "secret", "token", "access", false,
+		WithHTTPClient(&http.Client{Transport: transport}), WithTimeout(time.Minute))
+	user, err := bot.twitterClient.GetUsersShow("someone", nil)
+	c.Assert(err, IsNil)
+	c.Assert(user.Id, Equals, int64(42))
+	c.Assert(deadlines, DeepEquals, []bool{true})
+
+	// streams are not bounded by the timeout
+	timeout := &timeoutTransport{base: transport, timeout: time.Minute}
+	req, err := http.NewRequest(http.MethodGet, "https://stream.twitter.com/1.1/statuses/filter.json", nil)
+	c.Assert(err, IsNil)
+	resp, err := timeout.RoundTrip(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Body.Close(), IsNil)
+	c.Assert(deadlines, DeepEquals, []bool{true, false})
+
+	proxyURL, err := url.Parse("socks5://proxy:1080")
+	c.Assert(err, IsNil)
+	bot = newTwitterBot("", "", "", "key", "secret", "token", "access", false,
+		WithHTTPClient(&http.Client{Timeout: time.Second}), WithProxy(proxyURL))
+	c.Assert(bot.httpClient.Timeout, Equals, time.Second)
+	c.Assert(bot.newHTTPClient(bot.baseTransport()).Timeout, Equals, time.Duration(0))
+	base, ok := bot.baseTransport().(*timeoutTransport)
+	c.Assert(ok, Equals, true)
+	c.Assert(base.timeout, Equals, time.Second)
+	proxy, err := base.base.(*http.Transport).Proxy(req)
+	c.Assert(err, IsNil)
+	c.Assert(proxy, DeepEquals, proxyURL)
+
+	_, err = (&HTTPConfig{Proxy: "://proxy"}).options()
+	c.Assert(err, ErrorMatches, `\[twitter\] invalid proxy: .*`)
+}
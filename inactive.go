@@ -0,0 +1,104 @@
+package twbot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// isInactive returns true if the most recent tweet of the user is older
+// than 'maxInactivity'. Users who never tweeted are inactive while users
+// whose tweets are not visible, like protected users, are never inactive.
+func isInactive(user *anaconda.User, maxInactivity time.Duration) bool {
+	if user.Status == nil {
+		return !user.Protected && user.StatusesCount == 0
+	}
+	created, err := user.Status.CreatedAtTime()
+	if err != nil {
+		return false
+	}
+	return timeSince(created) > maxInactivity
+}
+
+// getFriendsToCheck returns the ids of the friends that can be
+// unfollowed regardless of their age, following the unfollow policy.
+func (t *TwitterBot) getFriendsToCheck() []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	for strID, user := range t.friends.Ids {
+		if !user.Follow || t.isWhitelisted(strID) ||
+			t.unfollowPolicy.keepFollowers && t.isFollowingBack(strID) {
+			continue
+		}
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			SubsystemFollow.error("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (t *TwitterBot) unfollowInactive(maxInactivity time.Duration, sleepPolicy *SleepPolicy, campaign *Campaign) error {
+	// the users lookup includes the most recent tweet of each user
+	users, err := t.LookupUsers(t.getFriendsToCheck())
+	if err != nil {
+		return err
+	}
+	inactive := []anaconda.User{}
+	for _, user := range users {
+		if isInactive(&user, maxInactivity) {
+			inactive = append(inactive, user)
+		}
+	}
+	for i, user := range inactive {
+		campaign.setRemaining(len(inactive) - i)
+		if !campaign.wait() {
+			return nil
+		}
+		if !t.waitUnfollowQuota(campaign.ctx) {
+			return nil
+		}
+		_, err := t.twitterClient.UnfollowUserId(user.Id)
+		if err != nil {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to unfollow inactive user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
+			continue
+		}
+		t.countUnfollow()
+		t.unfollowFriend(user.Id)
+		campaign.done()
+		t.controlledSleepContext(campaign.ctx, sleepPolicy)
+	}
+	campaign.setRemaining(0)
+	return nil
+}
+
+// UnfollowInactive unfollows the friends whose most recent tweet is older than
+// 'maxInactivity', keeping the following list of the bot high quality.
+// Whitelisted friends are never unfollowed and friends following back the
+// bot are kept if asked to by the unfollow policy, see SetUnfollowPolicy.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) UnfollowInactive(maxInactivity time.Duration, sleepPolicy SleepPolicy) error {
+	return t.unfollowInactive(maxInactivity, &sleepPolicy, t.newCampaign("UnfollowInactive"))
+}
+
+// UnfollowInactiveAsync asynchronously unfollows the friends whose
+// most recent tweet is older than 'maxInactivity', see UnfollowInactive.
+// The returned campaign allows to pause, resume or stop the unfollows.
+func (t *TwitterBot) UnfollowInactiveAsync(maxInactivity time.Duration, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("UnfollowInactive")
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		SubsystemFollow.info("[twitter] launching unfollow of friends inactive for %s...", maxInactivity)
+		sleepPolicyCopy.log()
+		err := t.unfollowInactive(maxInactivity, &sleepPolicyCopy, campaign)
+		SubsystemFollow.info("[twitter] unfollow of inactive friends done")
+		return err
+	})
+	return campaign
+}
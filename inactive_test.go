@@ -0,0 +1,28 @@
+package twbot
+
+import (
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestIsInactive(c *C) {
+	tweet := func(age time.Duration) *anaconda.Tweet {
+		return &anaconda.Tweet{CreatedAt: time.Now().Add(-age).Format(time.RubyDate)}
+	}
+	user := &anaconda.User{Status: tweet(time.Hour), StatusesCount: 10}
+	c.Assert(isInactive(user, 24*time.Hour), Equals, false)
+	user.Status = tweet(48 * time.Hour)
+	c.Assert(isInactive(user, 24*time.Hour), Equals, true)
+
+	// never tweeted
+	user = &anaconda.User{}
+	c.Assert(isInactive(user, 24*time.Hour), Equals, true)
+	// tweets not visible
+	user = &anaconda.User{Protected: true}
+	c.Assert(isInactive(user, 24*time.Hour), Equals, false)
+	user = &anaconda.User{StatusesCount: 10}
+	c.Assert(isInactive(user, 24*time.Hour), Equals, false)
+}
@@ -0,0 +1,269 @@
+package twbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrPanic is wrapped by the error of the jobs which panicked, see SetRestartPolicy.
+var ErrPanic = errors.New("[twitter] job panicked")
+
+type restartPolicy struct {
+	mutex       sync.Mutex
+	maxRestarts int
+	delay       time.Duration
+}
+
+// Job represents an asynchronous call of the bot, as returned by the
+// ...Async methods. It allows to stop the call, to wait for its end
+// and to follow its runs.
+type Job struct {
+	ctx     context.Context // done when the job is stopped or done
+	parent  context.Context // done when the bot is stopped
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mutex   sync.Mutex
+	stopped bool
+	err     error
+	stats   JobStats
+	queue   func() int // number of queued items, if any
+}
+
+// JobStats holds the run statistics of a job. Periodic jobs run once
+// per period while the other jobs run only once.
+type JobStats struct {
+	Name      string
+	Started   time.Time
+	Runs      int
+	Failures  int
+	LastRun   time.Time
+	LastError error
+	// NextRun is the time of the next run of the periodic jobs.
+	NextRun time.Time
+	// Queued is the number of items queued by the job, such as the users
+	// remaining to be followed by a campaign.
+	Queued int
+}
+
+// newJob creates a job stopped when the given context is done.
+func newJob(parent context.Context, name string) *Job {
+	ctx, cancel := context.WithCancel(parent)
+	return &Job{
+		ctx:    ctx,
+		parent: parent,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		stats: JobStats{
+			Name:    name,
+			Started: timeNow(),
+		},
+	}
+}
+
+// newJob creates a job stopped when the bot is stopped, see Stop.
+func (t *TwitterBot) newJob(name string) *Job {
+	return newJob(t.botContext(), name)
+}
+
+// Name returns the name of the job.
+func (j *Job) Name() string {
+	return j.stats.Name
+}
+
+// Stop stops the job after the current request, interrupting its sleeps
+// and waits. A stopped job cannot be restarted.
+func (j *Job) Stop() {
+	j.mutex.Lock()
+	j.stopped = true
+	j.mutex.Unlock()
+	j.cancel()
+}
+
+// Stopped returns true if the job is stopped,
+// either by Stop or because the bot is stopped.
+func (j *Job) Stopped() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.stopped || j.parent.Err() != nil
+}
+
+// Done returns a channel closed once the job is done.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Err returns the error the job ended with. It returns nil while
+// the job is running and if the job succeeded or was stopped.
+// Errors of the runs of periodic jobs are reported by Stats.
+func (j *Job) Err() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.err
+}
+
+// Stats returns the run statistics of the job.
+func (j *Job) Stats() JobStats {
+	j.mutex.Lock()
+	stats := j.stats
+	j.mutex.Unlock()
+	if j.queue != nil {
+		stats.Queued = j.queue()
+	}
+	return stats
+}
+
+// scheduleNextRun records the time of the next run of a periodic job.
+func (j *Job) scheduleNextRun(next time.Time) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.stats.NextRun = next
+}
+
+// record records a run of the job ending with the given error.
+func (j *Job) record(err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.stats.Runs++
+	j.stats.LastRun = timeNow()
+	if err != nil {
+		j.stats.Failures++
+		j.stats.LastError = err
+	}
+}
+
+// runPeriodically runs the given 'run' callback every 'freq' until
+// the job is stopped, at a fixed rate like a time.Ticker: a run lasting
+// longer than 'freq' delays the next one. It logs and records the errors
+// of the runs.
+func (j *Job) runPeriodically(freq time.Duration, run func() error) {
+	ticker := newTicker(freq)
+	for {
+		j.scheduleNextRun(ticker.next)
+		if !ticker.wait(j.ctx) {
+			return
+		}
+		err := run()
+		if err != nil {
+			logError("%v", err)
+		}
+		j.record(err)
+	}
+}
+
+// nextDailyRun returns the first time after 'now' at the given time of
+// day 'at', a duration since midnight, in the location of 'now'.
+func nextDailyRun(now time.Time, at time.Duration) time.Time {
+	year, month, day := now.Date()
+	next := time.Date(year, month, day, 0, 0, 0, 0, now.Location()).Add(at)
+	if !next.After(now) {
+		next = time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Add(at)
+	}
+	return next
+}
+
+// runDaily runs the given 'run' callback every day at the given time of
+// day 'at' until the job is stopped. It logs and records the errors of the runs.
+func (j *Job) runDaily(at time.Duration, run func() error) {
+	for {
+		next := nextDailyRun(timeNow(), at)
+		j.scheduleNextRun(next)
+		if !sleepContext(j.ctx, next.Sub(timeNow())) {
+			return
+		}
+		err := run()
+		if err != nil {
+			logError("%v", err)
+		}
+		j.record(err)
+	}
+}
+
+// SetRestartPolicy sets how the asynchronous jobs which panic, in a user
+// callback for instance, are handled: the panic is recovered and logged with
+// its stack, so that it does not take the whole process down, and the job is
+// restarted after the given 'delay', up to 'maxRestarts' times. Once the
+// restarts are exhausted, the job is done with an error wrapping ErrPanic.
+// A zero 'maxRestarts', the default, never restarts the jobs.
+func (t *TwitterBot) SetRestartPolicy(maxRestarts int, delay time.Duration) {
+	logInfo("[twitter] setting restart policy -> maxRestarts: %d, delay: %s", maxRestarts, delay)
+	t.restartPolicy.mutex.Lock()
+	defer t.restartPolicy.mutex.Unlock()
+	t.restartPolicy.maxRestarts = maxRestarts
+	t.restartPolicy.delay = delay
+}
+
+func (t *TwitterBot) getRestartPolicy() (int, time.Duration) {
+	t.restartPolicy.mutex.Lock()
+	defer t.restartPolicy.mutex.Unlock()
+	return t.restartPolicy.maxRestarts, t.restartPolicy.delay
+}
+
+// recoverRun runs the given 'run' callback of the job,
+// turning a panic into an error wrapping ErrPanic.
+func (j *Job) recoverRun(run func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logError("[twitter] job %s panicked: %v\n%s", j.Name(), r, debug.Stack())
+			err = fmt.Errorf("%w: %s: %v", ErrPanic, j.Name(), r)
+		}
+	}()
+	return run()
+}
+
+// run runs asynchronously the given job with the 'run' callback.
+// The job is done once the callback returns, with the returned error.
+// A panicking job is restarted following the restart policy.
+func (t *TwitterBot) run(job *Job, run func() error) *Job {
+	t.quit.Add(1)
+	t.addJob(job)
+	go func() {
+		defer t.quit.Done()
+		defer close(job.done)
+		defer t.removeJob(job)
+		// release the job context once done
+		defer job.cancel()
+		err := job.recoverRun(run)
+		maxRestarts, delay := t.getRestartPolicy()
+		for restarts := 0; errors.Is(err, ErrPanic); restarts++ {
+			job.record(err)
+			if restarts >= maxRestarts || !sleepContext(job.ctx, delay) {
+				break
+			}
+			logWarn("[twitter] restarting job %s (%d/%d)", job.Name(), restarts+1, maxRestarts)
+			err = job.recoverRun(run)
+		}
+		if err != nil {
+			logError("%v", err)
+		}
+		job.mutex.Lock()
+		defer job.mutex.Unlock()
+		job.err = err
+	}()
+	return job
+}
+
+// runOnceAsync runs asynchronously and only once the given 'run' callback.
+func (t *TwitterBot) runOnceAsync(name string, run func(ctx context.Context) error) *Job {
+	job := t.newJob(name)
+	return t.run(job, func() error {
+		err := run(job.ctx)
+		job.record(err)
+		return err
+	})
+}
+
+// runPeriodicallyAsync runs asynchronously and periodically the given 'run'
+// callback every 'freq' until the job is stopped.
+func (t *TwitterBot) runPeriodicallyAsync(name string, freq time.Duration, run func(ctx context.Context) error) *Job {
+	job := t.newJob(name)
+	return t.run(job, func() error {
+		job.runPeriodically(freq, func() error {
+			return run(job.ctx)
+		})
+		return nil
+	})
+}
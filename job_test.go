@@ -0,0 +1,71 @@
+package twbot
+
+import (
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestPeriodicJob(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	runs := make(chan struct{}, 2)
+	job := bot.TweetPeriodicallyAsync(func() (string, error) {
+		// never block the runs following the two awaited ones
+		select {
+		case runs <- struct{}{}:
+		default:
+		}
+		return "", errors.New("failed")
+	}, time.Millisecond)
+	c.Assert(job.Name(), Equals, "TweetPeriodically")
+	<-runs
+	<-runs
+	job.Stop()
+	select {
+	case <-job.Done():
+	case <-time.After(time.Second):
+		c.Fatal("stopped job should be done")
+	}
+	c.Assert(job.Stopped(), Equals, true)
+	c.Assert(job.Err(), IsNil)
+	stats := job.Stats()
+	c.Assert(stats.Runs >= 2, Equals, true)
+	c.Assert(stats.Failures, Equals, stats.Runs)
+	c.Assert(stats.LastError, ErrorMatches, "failed")
+}
+
+func (s *MySuite) TestOnceJob(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	job := bot.TweetOnceAsync(func() (string, error) {
+		return "", errors.New("failed")
+	})
+	select {
+	case <-job.Done():
+	case <-time.After(time.Second):
+		c.Fatal("job should be done once run")
+	}
+	c.Assert(job.Err(), ErrorMatches, "failed")
+	c.Assert(job.Stats().Runs, Equals, 1)
+	c.Assert(job.Stats().Failures, Equals, 1)
+	c.Assert(job.Stopped(), Equals, false)
+}
+
+func (s *MySuite) TestPanicJob(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.SetRestartPolicy(2, time.Millisecond)
+	runs := 0
+	job := bot.TweetOnceAsync(func() (string, error) {
+		runs++
+		panic("boom")
+	})
+	select {
+	case <-job.Done():
+	case <-time.After(time.Second):
+		c.Fatal("panicking job should be done once its restarts are exhausted")
+	}
+	c.Assert(runs, Equals, 3)
+	c.Assert(errors.Is(job.Err(), ErrPanic), Equals, true)
+	c.Assert(job.Err(), ErrorMatches, ".*TweetOnce: boom")
+	c.Assert(job.Stats().Failures, Equals, 3)
+}
@@ -0,0 +1,372 @@
+package twbot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	defaultMaxLikeBySearch = 15
+	maxFavoritesCount      = 200 // twitter API limit
+	maxMentionsCount       = 200 // twitter API limit
+	mentionLikesSinceID    = "mention_likes"
+)
+
+type twitterLikes struct {
+	// note: we cannot use integers as keys in encode/json so use string instead
+	Ids map[string]int64 `json:"ids"` // map id -> timestamp of the like
+}
+
+// LikeFilter represents the filter applied on tweets found by
+// the auto like campaigns before liking them.
+type LikeFilter struct {
+	// BannedQueries removes tweets whose text or author name
+	// contains one of the banned queries.
+	BannedQueries []string
+	// MinFavoriteCount and MinRetweetCount remove tweets that are not
+	// liked or retweeted at least that many times.
+	MinFavoriteCount int
+	MinRetweetCount  int
+	// SkipRetweets removes retweets and only keeps original tweets.
+	SkipRetweets bool
+	// MaxLikes is the maximum number of likes by run, 0 meaning no limit.
+	MaxLikes int
+}
+
+func (f *LikeFilter) match(tweet *anaconda.Tweet) bool {
+	if f.SkipRetweets && tweet.RetweetedStatus != nil {
+		return false
+	}
+	return tweet.FavoriteCount >= f.MinFavoriteCount &&
+		tweet.RetweetCount >= f.MinRetweetCount
+}
+
+func copyLikeFilter(filter LikeFilter) LikeFilter {
+	banned := make([]string, len(filter.BannedQueries))
+	copy(banned, filter.BannedQueries)
+	filter.BannedQueries = banned
+	return filter
+}
+
+// SetLikesPath sets the path of the likes database, keeping track of the tweets
+// liked by the like policy and the auto like campaigns so that they are never
+// liked twice. If no path is set, liked tweets are only remembered in memory.
+func (t *TwitterBot) SetLikesPath(likesPath string) error {
+	likes := &twitterLikes{
+		Ids: make(map[string]int64),
+	}
+	err := t.loadOrCreate(likesPath, likes)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.likesPath = likesPath
+	t.likes = likes
+	return nil
+}
+
+func (t *TwitterBot) isLiked(id int64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, ok := t.likes.Ids[strconv.FormatInt(id, 10)]
+	return ok
+}
+
+// GetLikedIDs returns the ids of the tweets liked by the bot
+// and registered in the likes database, sorted by id.
+func (t *TwitterBot) GetLikedIDs() []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	for strID := range t.likes.Ids {
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			SubsystemStore.error("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (t *TwitterBot) addLike(id int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.likes.Ids[strconv.FormatInt(id, 10)] = timeNow().UnixNano()
+	if t.likesPath == "" {
+		return
+	}
+	err := t.store.Save(t.likesPath, t.likes)
+	if err != nil {
+		SubsystemStore.error("%v", err)
+	}
+}
+
+func (t *TwitterBot) getTweetsToLike(queries []string, filter *LikeFilter) ([]anaconda.Tweet, error) {
+	sorted := make([]string, len(queries))
+	copy(sorted, queries)
+	sort.Strings(sorted)
+	query := randomElement(sorted)
+	logDebug("[twitter] searching tweets to like with query: %s", query)
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(defaultMaxLikeBySearch))
+	results, err := t.twitterClient.GetSearch(query, v)
+	if err != nil {
+		return nil, err
+	}
+	current := t.removeBanned(results.Statuses, filter.BannedQueries)
+	tweets := []anaconda.Tweet{}
+	for _, tweet := range current {
+		if tweet.Favorited || t.isLiked(tweet.Id) || !filter.match(&tweet) {
+			continue
+		}
+		tweets = append(tweets, tweet)
+	}
+	logDebug("[twitter] found %d tweet(s) to like matching pattern", len(tweets))
+	return tweets, nil
+}
+
+// AutoLikeOnce likes the tweets matching a random element of the input
+// queries slice and the given 'filter'. Already liked tweets are skipped.
+// It returns an error if the search failed and only logs errors
+// for each failed like tentative.
+func (t *TwitterBot) AutoLikeOnce(queries []string, filter LikeFilter) error {
+	return t.autoLike(t.botContext(), queries, filter)
+}
+
+func (t *TwitterBot) autoLike(ctx context.Context, queries []string, filter LikeFilter) error {
+	tweets, err := t.getTweetsToLike(queries, &filter)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, tweet := range tweets {
+		if filter.MaxLikes > 0 && count >= filter.MaxLikes {
+			break
+		}
+		if !t.sleepContext(ctx) {
+			return ctx.Err()
+		}
+		_, err := t.twitterClient.Favorite(tweet.Id)
+		if err != nil {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to like tweet (id:%d), error: %v\n", tweet.Id, err))
+			continue
+		}
+		t.addLike(tweet.Id)
+		count++
+	}
+	return nil
+}
+
+// AutoLikeOnceAsync likes asynchronously the tweets matching a random element
+// of the input queries slice and the given 'filter'.
+// It logs errors if the search failed or if the likes themselves failed.
+func (t *TwitterBot) AutoLikeOnceAsync(queries []string, filter LikeFilter) *Job {
+	queriesCopy := make([]string, len(queries))
+	copy(queriesCopy, queries)
+	filterCopy := copyLikeFilter(filter)
+	return t.runOnceAsync("AutoLikeOnce", func(ctx context.Context) error {
+		return t.autoLike(ctx, queriesCopy, filterCopy)
+	})
+}
+
+// AutoLikePeriodically likes periodically the tweets matching a random element
+// of the input queries slice and the given 'filter'.
+// The like frequencies is set up by the given 'freq' input parameter.
+// It logs errors if the search failed or if the likes themselves failed.
+func (t *TwitterBot) AutoLikePeriodically(queries []string, filter LikeFilter, freq time.Duration) {
+	job := t.newJob("AutoLikePeriodically")
+	job.runPeriodically(freq, func() error {
+		return t.autoLike(job.ctx, queries, filter)
+	})
+}
+
+// AutoLikePeriodicallyAsync likes asynchronously and periodically the tweets
+// matching a random element of the input queries slice and the given 'filter'.
+// The like frequencies is set up by the given 'freq' input parameter.
+// It logs errors if the search failed or if the likes themselves failed.
+func (t *TwitterBot) AutoLikePeriodicallyAsync(queries []string, filter LikeFilter, freq time.Duration) *Job {
+	queriesCopy := make([]string, len(queries))
+	copy(queriesCopy, queries)
+	filterCopy := copyLikeFilter(filter)
+	return t.runPeriodicallyAsync("AutoLikePeriodically", freq, func(ctx context.Context) error {
+		return t.autoLike(ctx, queriesCopy, filterCopy)
+	})
+}
+
+// likedSince returns the time the tweet was liked if known by the likes
+// database, or its creation time otherwise.
+func (t *TwitterBot) likedSince(tweet *anaconda.Tweet) (time.Time, error) {
+	t.mutex.Lock()
+	timestamp, ok := t.likes.Ids[strconv.FormatInt(tweet.Id, 10)]
+	t.mutex.Unlock()
+	if ok {
+		return time.Unix(0, timestamp), nil
+	}
+	return tweet.CreatedAtTime()
+}
+
+// getFavoritesOlderThan pages through the favorites of the authenticated user
+// and returns the ones liked at least 'age' ago. If a page fails, the favorites
+// found so far are returned along with the error.
+func (t *TwitterBot) getFavoritesOlderThan(age time.Duration) ([]anaconda.Tweet, error) {
+	old := []anaconda.Tweet{}
+	maxID := int64(0)
+	for {
+		if maxID > 0 {
+			t.sleep()
+		}
+		v := url.Values{}
+		v.Set("count", strconv.Itoa(maxFavoritesCount))
+		if maxID > 0 {
+			v.Set("max_id", strconv.FormatInt(maxID, 10))
+		}
+		favorites, err := t.twitterClient.GetFavorites(v)
+		if err != nil {
+			return old, err
+		}
+		if len(favorites) == 0 {
+			break
+		}
+		for _, tweet := range favorites {
+			since, err := t.likedSince(&tweet)
+			if err != nil {
+				logError("%v", err)
+				continue
+			}
+			if timeSince(since) >= age {
+				old = append(old, tweet)
+			}
+		}
+		maxID = favorites[len(favorites)-1].Id - 1
+	}
+	return old, nil
+}
+
+// AutoUnlikeOlderThan automatically unlikes the favorites of the authenticated
+// user that were liked at least 'age' ago. When unknown from the likes database,
+// the liking time is approximated with the tweet creation time.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoUnlikeOlderThan(age time.Duration, sleepPolicy SleepPolicy) {
+	logInfo("[twitter] launching auto unlike of favorites older than %s...", age)
+	sleepPolicy.log()
+	tweets, err := t.getFavoritesOlderThan(age)
+	if err != nil {
+		// unlike the favorites found before the failure anyway
+		logError("%v", err)
+	}
+	for _, tweet := range tweets {
+		_, err := t.twitterClient.Unfavorite(tweet.Id)
+		if err != nil {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to unlike tweet (id:%d), error: %v\n", tweet.Id, err))
+			continue
+		}
+		t.controlledSleep(&sleepPolicy)
+	}
+	logInfo("[twitter] auto unlike done")
+}
+
+// AutoUnlikeOlderThanAsync automatically asynchronously unlikes the favorites
+// of the authenticated user that were liked at least 'age' ago.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoUnlikeOlderThanAsync(age time.Duration, sleepPolicy *SleepPolicy) *Job {
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	return t.runOnceAsync("AutoUnlikeOlderThan", func(ctx context.Context) error {
+		t.AutoUnlikeOlderThan(age, sleepPolicyCopy)
+		return nil
+	})
+}
+
+type mentionLikes struct {
+	dayStart time.Time
+	byUser   map[int64]int // map user id -> likes of the day
+}
+
+// allow allows a like of a mention from the given user as long as the
+// daily cap by user 'maxPerUser' is not reached, 0 meaning no limit.
+func (m *mentionLikes) allow(userID int64, maxPerUser int) bool {
+	if timeSince(m.dayStart) >= 24*time.Hour {
+		m.dayStart = timeNow()
+		m.byUser = make(map[int64]int)
+	}
+	return maxPerUser <= 0 || m.byUser[userID] < maxPerUser
+}
+
+// AutoLikeMentionsOnce likes the tweets mentioning the authenticated user,
+// replies included, posted since the last call. The last liked mention is kept
+// in the state database, see SetStatePath. A user can only get
+// 'maxPerUser' likes per day, 0 meaning no limit.
+// It returns an error if fetching the mentions failed and only logs errors
+// for each failed like tentative.
+func (t *TwitterBot) AutoLikeMentionsOnce(maxPerUser int) error {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxMentionsCount))
+	sinceID, ok := t.getSinceID(mentionLikesSinceID)
+	if ok && sinceID > 0 {
+		v.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+	mentions, err := t.twitterClient.GetMentionsTimeline(v)
+	if err != nil {
+		return err
+	}
+	// mentions are sorted from the most recent to the oldest one
+	for i := len(mentions) - 1; i >= 0; i-- {
+		tweet := mentions[i]
+		t.mutex.Lock()
+		allowed := t.mentionLikes.allow(tweet.User.Id, maxPerUser)
+		t.mutex.Unlock()
+		if tweet.Favorited || t.isLiked(tweet.Id) {
+			continue
+		}
+		if !allowed {
+			print(t, fmt.Sprintf("[twitter] daily likes limit reached for user (id:%d, name:%s)\n", tweet.User.Id, tweet.User.Name))
+			continue
+		}
+		_, err := t.twitterClient.Favorite(tweet.Id)
+		if err != nil {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to like mention (id:%d), error: %v\n", tweet.Id, err))
+			continue
+		}
+		t.addLike(tweet.Id)
+		t.mutex.Lock()
+		t.mentionLikes.byUser[tweet.User.Id]++
+		t.mutex.Unlock()
+	}
+	if len(mentions) > 0 {
+		t.setSinceID(mentionLikesSinceID, mentions[0].Id)
+	}
+	return nil
+}
+
+// AutoLikeMentionsPeriodically likes periodically the tweets mentioning the
+// authenticated user, replies included, with a maximum of 'maxPerUser' likes
+// per user and per day, 0 meaning no limit.
+// The like frequencies is set up by the given 'freq' input parameter.
+// It logs errors if fetching the mentions failed or if the likes themselves failed.
+func (t *TwitterBot) AutoLikeMentionsPeriodically(maxPerUser int, freq time.Duration) {
+	t.newJob("AutoLikeMentionsPeriodically").runPeriodically(freq, func() error {
+		return t.AutoLikeMentionsOnce(maxPerUser)
+	})
+}
+
+// AutoLikeMentionsPeriodicallyAsync likes asynchronously and periodically the tweets
+// mentioning the authenticated user, replies included, with a maximum of 'maxPerUser'
+// likes per user and per day, 0 meaning no limit.
+// The like frequencies is set up by the given 'freq' input parameter.
+// It logs errors if fetching the mentions failed or if the likes themselves failed.
+func (t *TwitterBot) AutoLikeMentionsPeriodicallyAsync(maxPerUser int, freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("AutoLikeMentionsPeriodically", freq, func(ctx context.Context) error {
+		return t.AutoLikeMentionsOnce(maxPerUser)
+	})
+}
@@ -0,0 +1,136 @@
+package twbot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestAutoLike(c *C) {
+	client := &fakeClient{
+		found: []anaconda.Tweet{
+			{Id: 1, Text: "a nice tweet", FavoriteCount: 10},
+			{Id: 2, Text: "a banned tweet", FavoriteCount: 10},
+			{Id: 3, Text: "an unpopular tweet"},
+			{Id: 4, Text: "an already liked tweet", FavoriteCount: 10, Favorited: true},
+			{Id: 5, Text: "another nice tweet", FavoriteCount: 10},
+		},
+	}
+	bot := makeFakeBot(client)
+	bot.noSleep = true
+	bot.blocks = &twitterBlocks{
+		Blocked: map[string]int64{},
+		Muted:   map[string]int64{},
+	}
+	c.Assert(bot.SetLikesPath(""), IsNil)
+	filter := LikeFilter{
+		BannedQueries:    []string{"banned"},
+		MinFavoriteCount: 5,
+	}
+	c.Assert(bot.AutoLikeOnce([]string{"nice"}, filter), IsNil)
+	c.Assert(client.liked, DeepEquals, []int64{1, 5})
+
+	// liked tweets are never liked twice
+	client.liked = nil
+	c.Assert(bot.AutoLikeOnce([]string{"nice"}, filter), IsNil)
+	c.Assert(client.liked, HasLen, 0)
+
+	client.found = append(client.found,
+		anaconda.Tweet{Id: 6, FavoriteCount: 10},
+		anaconda.Tweet{Id: 7, FavoriteCount: 10})
+	filter.MaxLikes = 1
+	c.Assert(bot.AutoLikeOnce([]string{"nice"}, filter), IsNil)
+	c.Assert(client.liked, DeepEquals, []int64{6})
+}
+
+func (s *MySuite) TestAutoUnlikeOlderThan(c *C) {
+	now := time.Now()
+	client := &fakeClient{
+		pageSize: 2,
+		favorites: []anaconda.Tweet{
+			{Id: 50, CreatedAt: now.Format(time.RubyDate)},
+			{Id: 40, CreatedAt: now.Add(-72 * time.Hour).Format(time.RubyDate)},
+			{Id: 30, CreatedAt: now.Format(time.RubyDate)},
+			{Id: 20, CreatedAt: now.Add(-72 * time.Hour).Format(time.RubyDate)},
+			{Id: 10, CreatedAt: now.Add(-72 * time.Hour).Format(time.RubyDate)},
+		},
+	}
+	bot := makeFakeBot(client)
+	bot.noSleep = true
+	c.Assert(bot.SetLikesPath(""), IsNil)
+	// recently liked old tweet
+	bot.likes.Ids["10"] = now.UnixNano()
+
+	tweets, err := bot.getFavoritesOlderThan(48 * time.Hour)
+	c.Assert(err, IsNil)
+	c.Assert(tweets, HasLen, 2)
+	c.Assert(tweets[0].Id, Equals, int64(40))
+	c.Assert(tweets[1].Id, Equals, int64(20))
+
+	bot.AutoUnlikeOlderThan(48*time.Hour, SleepPolicy{})
+	c.Assert(client.unliked, DeepEquals, []int64{40, 20})
+
+	// the favorites found before a failed page are still unliked
+	client.unliked = nil
+	client.favsErr = errors.New("page failed")
+	tweets, err = bot.getFavoritesOlderThan(48 * time.Hour)
+	c.Assert(err, NotNil)
+	c.Assert(tweets, HasLen, 1)
+	bot.AutoUnlikeOlderThan(48*time.Hour, SleepPolicy{})
+	c.Assert(client.unliked, DeepEquals, []int64{40})
+}
+
+func (s *MySuite) TestLikesDatabase(c *C) {
+	store := NewMemStore()
+	bot := makeFakeBot(&fakeClient{})
+	bot.store = store
+	c.Assert(bot.SetLikesPath("likes.json"), IsNil)
+	c.Assert(bot.GetLikedIDs(), HasLen, 0)
+	bot.addLike(42)
+	bot.addLike(7)
+	c.Assert(bot.isLiked(42), Equals, true)
+	c.Assert(bot.isLiked(8), Equals, false)
+	c.Assert(bot.GetLikedIDs(), DeepEquals, []int64{7, 42})
+
+	// the likes are reloaded from the database
+	bot = makeFakeBot(&fakeClient{})
+	bot.store = store
+	c.Assert(bot.SetLikesPath("likes.json"), IsNil)
+	c.Assert(bot.GetLikedIDs(), DeepEquals, []int64{7, 42})
+}
+
+func (s *MySuite) TestAutoLikeMentions(c *C) {
+	client := &fakeClient{
+		mentions: []anaconda.Tweet{
+			{Id: 101, User: anaconda.User{Id: 1}},
+			{Id: 102, User: anaconda.User{Id: 1}},
+			{Id: 103, User: anaconda.User{Id: 2}},
+		},
+	}
+	bot := makeFakeBot(client)
+	bot.mentionLikes = &mentionLikes{
+		byUser: map[int64]int{},
+	}
+	c.Assert(bot.SetLikesPath(""), IsNil)
+	c.Assert(bot.SetStatePath("state.json"), IsNil)
+	c.Assert(bot.AutoLikeMentionsOnce(1), IsNil)
+	c.Assert(client.liked, DeepEquals, []int64{101, 103})
+	c.Assert(bot.state.SinceIDs[mentionLikesSinceID], Equals, int64(103))
+
+	// the last liked mention is kept across restarts
+	client.liked = nil
+	client.mentions = append(client.mentions, anaconda.Tweet{Id: 104, User: anaconda.User{Id: 3}})
+	store := bot.store
+	bot = makeFakeBot(client)
+	bot.store = store
+	bot.mentionLikes = &mentionLikes{
+		byUser: map[int64]int{},
+	}
+	c.Assert(bot.SetLikesPath(""), IsNil)
+	c.Assert(bot.SetStatePath("state.json"), IsNil)
+	c.Assert(bot.AutoLikeMentionsOnce(1), IsNil)
+	c.Assert(client.liked, DeepEquals, []int64{104})
+}
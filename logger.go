@@ -0,0 +1,156 @@
+package twbot
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+// Logger is the interface the bot logs through, see SetLogger. The messages
+// are formatted like fmt.Printf, without a trailing newline.
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
+// stdLogger logs all the messages with the standard logger.
+type stdLogger struct{}
+
+func (stdLogger) Debug(format string, args ...interface{}) { log.Printf(format, args...) }
+func (stdLogger) Info(format string, args ...interface{})  { log.Printf(format, args...) }
+func (stdLogger) Warn(format string, args ...interface{})  { log.Printf(format, args...) }
+func (stdLogger) Error(format string, args ...interface{}) { log.Printf(format, args...) }
+
+// LogLevel is the minimum level of the logged messages, see SetLogLevel.
+type LogLevel int
+
+const (
+	// LogDebug logs all the messages.
+	LogDebug LogLevel = iota
+	// LogInfo logs the informational messages, the warnings and the errors.
+	LogInfo
+	// LogWarn logs the warnings and the errors.
+	LogWarn
+	// LogError logs only the errors.
+	LogError
+)
+
+// Subsystem is a group of features of the bot whose logs can be
+// disabled altogether, see SetSubsystemLogging.
+type Subsystem string
+
+// Subsystems of the bot.
+const (
+	SubsystemFollow  Subsystem = "follow"
+	SubsystemRetweet Subsystem = "retweet"
+	SubsystemTweet   Subsystem = "tweet"
+	SubsystemStore   Subsystem = "store"
+)
+
+var (
+	// exit exits the process after a fatal error, see logFatal.
+	exit        = os.Exit
+	loggerMutex sync.RWMutex
+	logger      Logger = stdLogger{}
+	logLevel           = LogDebug
+	disabled           = map[Subsystem]bool{}
+)
+
+// SetLogger sets the logger of the bots, so that their logs can be routed to
+// the logger of the embedding application. A nil 'l' restores the default
+// logger, which logs all the messages with the standard logger.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = stdLogger{}
+	}
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+	logger = l
+}
+
+// SetLogLevel sets the minimum 'level' of the logged messages.
+// All the messages are logged by default.
+func SetLogLevel(level LogLevel) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+	logLevel = level
+}
+
+// SetSubsystemLogging enables or disables all the logs of the given
+// 'subsystem', its events included. The errors are always logged.
+// All the subsystems are enabled by default.
+func SetSubsystemLogging(subsystem Subsystem, enabled bool) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+	disabled[subsystem] = !enabled
+}
+
+// getLogger returns the logger if the messages of the given 'level'
+// and 'subsystem' are logged, nil otherwise.
+func getLogger(level LogLevel, subsystem Subsystem) Logger {
+	loggerMutex.RLock()
+	defer loggerMutex.RUnlock()
+	if level < logLevel || level < LogError && disabled[subsystem] {
+		return nil
+	}
+	return logger
+}
+
+func (s Subsystem) log(level LogLevel, format string, args ...interface{}) {
+	logger := getLogger(level, s)
+	switch {
+	case logger == nil:
+	case level == LogDebug:
+		logger.Debug(format, args...)
+	case level == LogInfo:
+		logger.Info(format, args...)
+	case level == LogWarn:
+		logger.Warn(format, args...)
+	default:
+		logger.Error(format, args...)
+	}
+}
+
+func (s Subsystem) debug(format string, args ...interface{}) {
+	s.log(LogDebug, format, args...)
+}
+
+func (s Subsystem) info(format string, args ...interface{}) {
+	s.log(LogInfo, format, args...)
+}
+
+func (s Subsystem) warn(format string, args ...interface{}) {
+	s.log(LogWarn, format, args...)
+}
+
+func (s Subsystem) error(format string, args ...interface{}) {
+	s.log(LogError, format, args...)
+}
+
+// noSubsystem logs the messages which are not part of a subsystem.
+const noSubsystem Subsystem = ""
+
+func logDebug(format string, args ...interface{}) {
+	noSubsystem.debug(format, args...)
+}
+
+func logInfo(format string, args ...interface{}) {
+	noSubsystem.info(format, args...)
+}
+
+func logWarn(format string, args ...interface{}) {
+	noSubsystem.warn(format, args...)
+}
+
+func logError(format string, args ...interface{}) {
+	noSubsystem.error(format, args...)
+}
+
+// logFatal logs the given error message, which is always logged,
+// and exits the process.
+func logFatal(format string, args ...interface{}) {
+	logError(format, args...)
+	exit(1)
+}
@@ -0,0 +1,82 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type recordLogger struct {
+	messages []string
+}
+
+func (l *recordLogger) log(level, format string, args ...interface{}) {
+	l.messages = append(l.messages, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) Debug(format string, args ...interface{}) { l.log("debug", format, args...) }
+func (l *recordLogger) Info(format string, args ...interface{})  { l.log("info", format, args...) }
+func (l *recordLogger) Warn(format string, args ...interface{})  { l.log("warn", format, args...) }
+func (l *recordLogger) Error(format string, args ...interface{}) { l.log("error", format, args...) }
+
+func (s *MySuite) TestSetLogger(c *C) {
+	logger := &recordLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	bot := makeFakeBot(&fakeClient{})
+	bot.SetRetryPolicy(2, time.Second, time.Minute, 0)
+	bot.alert("down")
+	c.Assert(logger.messages, DeepEquals, []string{
+		"info [twitter] setting retry policy -> maxAttempts: 2, baseDelay: 1s, maxDelay: 1m0s, jitter: 0",
+		"warn [twitter] alert: down",
+	})
+	SetLogger(nil)
+	c.Assert(getLogger(LogError, noSubsystem), Equals, Logger(stdLogger{}))
+}
+
+func (s *MySuite) TestLogLevels(c *C) {
+	logger := &recordLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	SetLogLevel(LogInfo)
+	defer SetLogLevel(LogDebug)
+	SetSubsystemLogging(SubsystemFollow, false)
+	defer SetSubsystemLogging(SubsystemFollow, true)
+
+	logDebug("hidden")
+	SubsystemFollow.info("hidden")
+	SubsystemFollow.error("follow error")
+	SubsystemTweet.info("tweet info")
+	logEvent(ActionFollow, Event{UserID: 1}, nil)
+	logEvent(ActionLike, Event{TweetID: 1}, nil)
+	c.Assert(logger.messages, HasLen, 3)
+	c.Assert(logger.messages[0], Equals, "error follow error")
+	c.Assert(logger.messages[1], Equals, "info tweet info")
+	c.Assert(logger.messages[2], Matches, `info \{.*"action":"like_success".*`)
+}
+
+func (s *MySuite) TestLogFatal(c *C) {
+	logger := &recordLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	codes := []int{}
+	exit = func(code int) {
+		codes = append(codes, code)
+	}
+	defer func() { exit = os.Exit }()
+	bot := makeFakeBot(&fakeClient{})
+	bot.verbose = true
+
+	// messages are logged without their trailing newline
+	print(bot, "followed\n")
+	// fatal errors go through the logger before exiting
+	bot.handleError(errors.New("failed"), nil)
+	c.Assert(logger.messages, DeepEquals, []string{
+		"debug followed",
+		"error failed",
+	})
+	c.Assert(codes, DeepEquals, []int{1})
+}
@@ -0,0 +1,102 @@
+package twbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	mastodonMaxSize  = 500 // default maximum characters of a toot
+	mastodonLinkSize = 23  // characters counted for any link
+)
+
+// mastodonPoster mirrors the posts of the bot to a Mastodon account.
+type mastodonPoster struct {
+	server string
+	token  string
+	client *http.Client
+}
+
+// NewMastodonCrossPoster returns a cross-poster publishing the posts of the
+// bot, their image included, to the Mastodon account of the given access
+// token on the given server, "https://mastodon.social" for instance.
+// The token needs the "write:statuses" and "write:media" scopes.
+func NewMastodonCrossPoster(server, accessToken string) CrossPoster {
+	return &mastodonPoster{
+		server: strings.TrimSuffix(server, "/"),
+		token:  accessToken,
+		client: &http.Client{Timeout: crossPosterTimeout},
+	}
+}
+
+func (m *mastodonPoster) setHTTPClient(client *http.Client) {
+	m.client = client
+}
+
+func (m *mastodonPoster) CrossPost(post Post) error {
+	form := url.Values{}
+	form.Set("status", fitPost(post, mastodonMaxSize, mastodonLinkSize))
+	if len(post.Image) > 0 {
+		id, err := m.uploadMedia(post.Image)
+		if err != nil {
+			return err
+		}
+		form.Set("media_ids[]", id)
+	}
+	resp := struct {
+		ID string `json:"id"`
+	}{}
+	err := m.do("/api/v1/statuses", "application/x-www-form-urlencoded",
+		strings.NewReader(form.Encode()), &resp)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] cross-posted to mastodon (id: %s)", resp.ID)
+	return nil
+}
+
+// uploadMedia uploads the given image and returns its media id.
+func (m *mastodonPoster) uploadMedia(image []byte) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "image")
+	if err != nil {
+		return "", err
+	}
+	part.Write(image)
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+	media := struct {
+		ID string `json:"id"`
+	}{}
+	err = m.do("/api/v2/media", writer.FormDataContentType(), body, &media)
+	return media.ID, err
+}
+
+// do posts the given body to the given API 'path' of the server
+// and decodes the JSON response in 'data'.
+func (m *mastodonPoster) do(path, contentType string, body io.Reader, data interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, m.server+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.token)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("[twitter] mastodon request %s failed: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(data)
+}
@@ -0,0 +1,54 @@
+package twbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// memStore keeps the databases in memory, as JSON documents
+// so that saved databases are not modified by later changes.
+type memStore struct {
+	mutex     sync.Mutex
+	databases map[string][]byte // map path -> database
+}
+
+// NewMemStore returns a store keeping the databases in memory, without
+// writing any file, for tests or short-lived bots for instance, see SetStore.
+func NewMemStore() Store {
+	return &memStore{
+		databases: make(map[string][]byte),
+	}
+}
+
+func (s *memStore) Exists(path string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.databases[path]
+	return ok, nil
+}
+
+func (s *memStore) Load(path string, v interface{}) error {
+	s.mutex.Lock()
+	data, ok := s.databases[path]
+	s.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("[twitter] database %s not found in memory store", path)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *memStore) Save(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.databases[path] = data
+	return nil
+}
+
+func (s *memStore) Close() error {
+	return nil
+}
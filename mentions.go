@@ -0,0 +1,84 @@
+package twbot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// replyTo replies in-thread to the given tweet with the given text.
+func (t *TwitterBot) replyTo(tweet *anaconda.Tweet, text string) error {
+	v := url.Values{}
+	v.Set("in_reply_to_status_id", tweet.IdStr)
+	reply, err := t.twitterClient.PostTweet(fmt.Sprintf("@%s %s", tweet.User.ScreenName, text), v)
+	if err != nil {
+		t.checkBotRestriction(err)
+		return err
+	}
+	logInfo("[twitter] replying to tweet (id:%d) of user (id:%d, name:%s) (id:%d): %s",
+		tweet.Id, tweet.User.Id, tweet.User.Name, reply.Id, reply.Text)
+	return nil
+}
+
+// checkMentions calls the handler on the tweets mentioning the bot since
+// the last check, the oldest first, and replies with the handler reply if any.
+// The first check only records the most recent mention.
+func (t *TwitterBot) checkMentions(handler func(tweet anaconda.Tweet) (string, bool)) error {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxMentionsCount))
+	sinceID, ok := t.getSinceID(mentionsSinceID)
+	if sinceID > 0 {
+		v.Set("since_id", strconv.FormatInt(sinceID, 10))
+	}
+	mentions, err := t.twitterClient.GetMentionsTimeline(v)
+	if err != nil {
+		return err
+	}
+	if len(mentions) == 0 {
+		if !ok {
+			t.setSinceID(mentionsSinceID, 0)
+		}
+		return nil
+	}
+	if ok {
+		// mentions are sorted from the most recent to the oldest one
+		for i := len(mentions) - 1; i >= 0; i-- {
+			tweet := mentions[i]
+			reply, ok := handler(tweet)
+			if !ok {
+				continue
+			}
+			err := t.replyTo(&tweet, reply)
+			if err != nil {
+				print(t, fmt.Sprintf("[twitter] failed to reply to mention (id:%d), error: %v\n", tweet.Id, err))
+			}
+		}
+	}
+	t.setSinceID(mentionsSinceID, mentions[0].Id)
+	return nil
+}
+
+// OnMention polls the tweets mentioning the bot and calls the given 'handler'
+// on each new mention, the oldest first. If the handler returns true, its reply
+// is posted in-thread as a reply to the mention, the building block of
+// interactive reply bots. Mentions posted before the first poll are ignored,
+// see SetStatePath to keep track of the handled mentions across restarts.
+// The poll frequency is set up by the given 'freq' input parameter.
+// It logs errors if the poll or the replies failed.
+func (t *TwitterBot) OnMention(handler func(tweet anaconda.Tweet) (string, bool), freq time.Duration) {
+	t.newJob("OnMention").runPeriodically(freq, func() error {
+		return t.checkMentions(handler)
+	})
+}
+
+// OnMentionAsync polls asynchronously the tweets
+// mentioning the bot, see OnMention.
+func (t *TwitterBot) OnMentionAsync(handler func(tweet anaconda.Tweet) (string, bool), freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("OnMention", freq, func(ctx context.Context) error {
+		return t.checkMentions(handler)
+	})
+}
@@ -0,0 +1,47 @@
+package twbot
+
+import (
+	"strconv"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func makeMention(id int64, text string) anaconda.Tweet {
+	return anaconda.Tweet{
+		Id:    id,
+		IdStr: strconv.FormatInt(id, 10),
+		Text:  text,
+		User:  anaconda.User{Id: 10, ScreenName: "user"},
+	}
+}
+
+func (s *MySuite) TestCheckMentions(c *C) {
+	client := &fakeClient{
+		mentions: []anaconda.Tweet{makeMention(100, "old")},
+	}
+	bot := makeFakeBot(client)
+	bot.state = &twitterState{
+		SinceIDs: map[string]int64{},
+	}
+	handled := []string{}
+	handler := func(tweet anaconda.Tweet) (string, bool) {
+		handled = append(handled, tweet.Text)
+		return "pong", tweet.Text == "ping"
+	}
+	// mentions posted before the first check are ignored
+	c.Assert(bot.checkMentions(handler), IsNil)
+	c.Assert(handled, HasLen, 0)
+
+	client.mentions = append(client.mentions, makeMention(101, "ping"), makeMention(102, "hello"))
+	c.Assert(bot.checkMentions(handler), IsNil)
+	c.Assert(handled, DeepEquals, []string{"ping", "hello"})
+	c.Assert(client.tweets, DeepEquals, []string{"@user pong"})
+	c.Assert(client.replies, DeepEquals, map[string]string{"1": "101"})
+	c.Assert(bot.state.SinceIDs[mentionsSinceID], Equals, int64(102))
+
+	// already handled mentions are not handled twice
+	c.Assert(bot.checkMentions(handler), IsNil)
+	c.Assert(handled, HasLen, 2)
+}
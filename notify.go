@@ -0,0 +1,287 @@
+package twbot
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	notifierTimeout   = 10 * time.Second
+	notificationsSize = 100 // alerts waiting to be delivered before being dropped
+)
+
+// Notifier delivers the alerts of the bot to a human, see AddNotifier.
+type Notifier interface {
+	// Notify delivers the given alert text.
+	Notify(text string) error
+}
+
+// NotifierFunc is an adapter to use ordinary functions as notifiers.
+type NotifierFunc func(text string) error
+
+// Notify calls f(text).
+func (f NotifierFunc) Notify(text string) error {
+	return f(text)
+}
+
+// webhookNotifier posts the alerts as JSON objects to a chat webhook,
+// the alert text being set as the given field.
+type webhookNotifier struct {
+	url    string
+	field  string
+	client *http.Client
+}
+
+func (n *webhookNotifier) Notify(text string) error {
+	data, err := json.Marshal(map[string]string{n.field: text})
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("[twitter] notification webhook failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// NewSlackNotifier returns a notifier posting the alerts to the
+// Slack incoming webhook of the given URL.
+func NewSlackNotifier(webhookURL string) Notifier {
+	return &webhookNotifier{
+		url:    webhookURL,
+		field:  "text",
+		client: &http.Client{Timeout: notifierTimeout},
+	}
+}
+
+// NewDiscordNotifier returns a notifier posting the alerts to the
+// Discord webhook of the given URL.
+func NewDiscordNotifier(webhookURL string) Notifier {
+	return &webhookNotifier{
+		url:    webhookURL,
+		field:  "content",
+		client: &http.Client{Timeout: notifierTimeout},
+	}
+}
+
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// Notify sends the alert like smtp.SendMail, the whole exchange
+// with the server being bounded by the notifier timeout.
+func (n *smtpNotifier) Notify(text string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: [twbot] alert\r\n\r\n%s\r\n",
+		n.from, strings.Join(n.to, ", "), text)
+	conn, err := net.DialTimeout("tcp", n.addr, notifierTimeout)
+	if err != nil {
+		return err
+	}
+	err = conn.SetDeadline(time.Now().Add(notifierTimeout))
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	host, _, err := net.SplitHostPort(n.addr)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("[twitter] smtp server does not support authentication")
+		}
+		err = client.Auth(n.auth)
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(n.from)
+	if err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		err = client.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(msg))
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// NewSMTPNotifier returns a notifier sending the alerts by email from the
+// 'from' address to the 'to' addresses through the SMTP server of the given
+// 'addr' address, "smtp.example.com:587" for instance. The server is
+// authenticated with the given username and password if the username is
+// not empty.
+func NewSMTPNotifier(addr, username, password, from string, to ...string) Notifier {
+	notifier := &smtpNotifier{
+		addr: addr,
+		from: from,
+		to:   to,
+	}
+	if username != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return notifier
+}
+
+// AddNotifier registers the given notifier, so that the alerts of the bot,
+// like a locked account, an expired token or a followers milestone, reach a
+// human immediately, see SetOwner and SetFollowersMilestones. The alerts are
+// delivered asynchronously, one at a time, so that a slow notifier does not
+// delay the bot. The first
+// action failing because the account is locked or its token expired is
+// notified too, through the hooks of the bot, until an action succeeds
+// again, see AddHooks.
+func (t *TwitterBot) AddNotifier(notifier Notifier) {
+	t.mutex.Lock()
+	t.notifiers = append(t.notifiers, notifier)
+	t.mutex.Unlock()
+	var mutex sync.Mutex
+	failing := false
+	t.AddHooks(Hooks{
+		OnSuccess: func(event Event) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			failing = false
+		},
+		OnError: func(event Event) {
+			if event.ErrorCode != twitterErrorAccountLocked && event.ErrorCode != anaconda.TwitterErrorInvalidToken {
+				return
+			}
+			mutex.Lock()
+			notified := failing
+			failing = true
+			mutex.Unlock()
+			if !notified {
+				t.notify(notifier, fmt.Sprintf("%s: %s", event.Action, event.Error))
+			}
+		},
+	})
+}
+
+func (t *TwitterBot) getNotifiers() []Notifier {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.notifiers
+}
+
+// notification is an alert waiting to be delivered to its notifier.
+type notification struct {
+	notifier Notifier
+	text     string
+}
+
+// notifications delivers the alerts in the background until the bot is stopped.
+type notifications struct {
+	once  sync.Once
+	queue chan notification
+}
+
+// notify queues the given text to be delivered to the given notifier,
+// dropping it if too many alerts are waiting already.
+func (t *TwitterBot) notify(notifier Notifier, text string) {
+	t.notifications.once.Do(func() {
+		t.notifications.queue = make(chan notification, notificationsSize)
+		go t.deliverNotifications(t.newJob("notifications"))
+	})
+	select {
+	case t.notifications.queue <- notification{notifier: notifier, text: "[twbot] " + text}:
+	default:
+		logError("[twitter] too many pending notifications, dropping alert: %s", text)
+	}
+}
+
+// deliverNotifications delivers the queued alerts until the bot is stopped,
+// logging their failure. A panicking notifier is recovered and only drops
+// its alert. It is not waited by Wait since it only returns once the bot
+// is stopped.
+func (t *TwitterBot) deliverNotifications(job *Job) {
+	for {
+		err := job.recoverRun(func() error {
+			for {
+				select {
+				case n := <-t.notifications.queue:
+					err := n.notifier.Notify(n.text)
+					if err != nil {
+						logError("[twitter] failed to notify alert, error: %v", err)
+					}
+				case <-job.ctx.Done():
+					return nil
+				}
+			}
+		})
+		if err == nil {
+			return
+		}
+	}
+}
+
+// SetFollowersMilestones sets the numbers of followers whose reach is
+// alerted, 10000 for instance, see AddNotifier. The milestones are checked
+// on every update of the followers database.
+func (t *TwitterBot) SetFollowersMilestones(milestones ...int) {
+	logInfo("[twitter] setting followers milestones -> milestones: %v", milestones)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.milestones = milestones
+}
+
+// reachedMilestones returns the milestones reached when going
+// from 'previous' to 'current' followers.
+func reachedMilestones(milestones []int, previous, current int) []int {
+	reached := []int{}
+	for _, milestone := range milestones {
+		if previous < milestone && milestone <= current {
+			reached = append(reached, milestone)
+		}
+	}
+	return reached
+}
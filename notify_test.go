@@ -0,0 +1,161 @@
+package twbot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestWebhookNotifiers(c *C) {
+	bodies := []map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if r.URL.Path == "/fail" {
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c.Assert(NewSlackNotifier(server.URL+"/slack").Notify("locked"), IsNil)
+	c.Assert(NewDiscordNotifier(server.URL+"/discord").Notify("locked"), IsNil)
+	c.Assert(NewSlackNotifier(server.URL+"/fail").Notify("locked"), ErrorMatches, ".*404 Not Found")
+	c.Assert(bodies, DeepEquals, []map[string]string{{"text": "locked"}, {"content": "locked"}, {"text": "locked"}})
+}
+
+func (s *MySuite) TestNotifier(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(403, twitterErrorAccountLocked),
+		failures:   2,
+	}
+	bot := makeFakeBot(client.fakeClient)
+	bot.noSleep = true
+	bot.growth = &twitterGrowth{}
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	defer bot.Stop()
+	notified := make(chan string, 10)
+	bot.AddNotifier(NotifierFunc(func(text string) error {
+		notified <- text
+		return nil
+	}))
+	// alerts are delivered asynchronously
+	received := func(count int) []string {
+		alerts := []string{}
+		for len(alerts) < count {
+			select {
+			case text := <-notified:
+				alerts = append(alerts, text)
+			case <-time.After(time.Second):
+				c.Fatalf("missing alerts, received: %v", alerts)
+			}
+		}
+		select {
+		case text := <-notified:
+			c.Fatalf("unexpected alert: %s", text)
+		case <-time.After(10 * time.Millisecond):
+		}
+		return alerts
+	}
+
+	bot.alert("campaign %s finished", "query")
+	c.Assert(received(1), DeepEquals, []string{"[twbot] campaign query finished"})
+
+	// the locked account is notified once until an action succeeds
+	_, err := bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(err, NotNil)
+	_, err = bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(err, NotNil)
+	alerts := received(1)
+	c.Assert(alerts[0], Matches, `\[twbot\] tweet_failed: .*`)
+	_, err = bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(err, IsNil)
+	client.calls, client.err = 0, makeAPIError(401, anaconda.TwitterErrorInvalidToken)
+	_, err = bot.twitterClient.PostTweet("hello", nil)
+	c.Assert(err, NotNil)
+	c.Assert(received(1), HasLen, 1)
+
+	// followers milestones
+	bot.SetFollowersMilestones(2, 3, 10)
+	bot.recordGrowth()
+	bot.followers.Ids["1"] = &twitterUser{Follow: true}
+	bot.followers.Ids["2"] = &twitterUser{Follow: true}
+	bot.followers.Ids["3"] = &twitterUser{Follow: true}
+	bot.recordGrowth()
+	bot.recordGrowth()
+	c.Assert(received(2), DeepEquals, []string{"[twbot] reached 2 followers", "[twbot] reached 3 followers"})
+}
+
+func (s *MySuite) TestPanickingNotifier(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	defer bot.Stop()
+	notified := make(chan string, 10)
+	bot.AddNotifier(NotifierFunc(func(text string) error {
+		if strings.Contains(text, "panic") {
+			panic(text)
+		}
+		notified <- text
+		return nil
+	}))
+	// the panicking alert is dropped and the next ones are still delivered
+	bot.alert("panic")
+	bot.alert("hello")
+	select {
+	case text := <-notified:
+		c.Assert(text, Equals, "[twbot] hello")
+	case <-time.After(time.Second):
+		c.Fatal("missing alert")
+	}
+}
+
+func (s *MySuite) TestSMTPNotifier(c *C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer listener.Close()
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost\r\n")
+		data := false
+		message := ""
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case data && line == ".\r\n":
+				data = false
+				messages <- message
+				fmt.Fprint(conn, "250 ok\r\n")
+			case data:
+				message += line
+			case strings.HasPrefix(line, "DATA"):
+				data = true
+				fmt.Fprint(conn, "354 go ahead\r\n")
+			case strings.HasPrefix(line, "QUIT"):
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	notifier := NewSMTPNotifier(listener.Addr().String(), "", "", "bot@example.com", "owner@example.com")
+	c.Assert(notifier.Notify("locked"), IsNil)
+	message := <-messages
+	c.Assert(message, Matches, "(?s)From: bot@example.com\r\nTo: owner@example.com\r\n.*locked\r\n")
+}
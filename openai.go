@@ -0,0 +1,87 @@
+package twbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	openAIURL     = "https://api.openai.com/v1"
+	openAITimeout = time.Minute
+)
+
+// openAIGenerator generates texts with the chat completions
+// endpoint of an OpenAI compatible API.
+type openAIGenerator struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIGenerator returns a text generator calling the chat completions
+// endpoint of the OpenAI compatible API of the given base URL, the OpenAI API
+// "https://api.openai.com/v1" if empty, with the given key and model, e.g.
+// "gpt-4o-mini". The key can be empty for local servers.
+func NewOpenAIGenerator(baseURL, apiKey, model string) TextGenerator {
+	if baseURL == "" {
+		baseURL = openAIURL
+	}
+	return &openAIGenerator{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: openAITimeout},
+	}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (g *openAIGenerator) Generate(prompt string) (string, error) {
+	body, err := json.Marshal(struct {
+		Model    string          `json:"model"`
+		Messages []openAIMessage `json:"messages"`
+	}{
+		Model:    g.model,
+		Messages: []openAIMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, g.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	if g.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("[twitter] text generation failed: %s", resp.Status)
+	}
+	completion := struct {
+		Choices []struct {
+			Message openAIMessage `json:"message"`
+		} `json:"choices"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&completion)
+	if err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("[twitter] text generation returned no choice")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,105 @@
+package twbot
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Profiles holds named sets of twitter keys, one per account, so that
+// several bots can be run from the same credentials file or environment:
+//
+//  # profiles.yaml
+//  news:
+//    consumer_key: ...
+//    consumer_secret: ...
+//    access_token: ...
+//    access_secret: ...
+//  jokes:
+//    access_token: ...
+//
+// Empty keys of a profile are read from the environment variables prefixed by
+// the upper case name of the profile, TWITTER_JOKES_CONSUMER_KEY for instance,
+// see ProfileEnvPrefix.
+type Profiles map[string]CredentialsConfig
+
+// LoadProfiles loads the profiles of the given JSON, YAML or TOML file,
+// depending on its extension.
+func LoadProfiles(path string) (Profiles, error) {
+	profiles := Profiles{}
+	err := decodeFile(path, &profiles)
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// ProfileEnvPrefix returns the prefix of the environment variables holding
+// the keys of the profile of the given 'name': "TWITTER_" followed by the
+// name in upper case, its non alphanumeric characters replaced by
+// underscores, and an underscore, TWITTER_MY_BOT_ for "my-bot" for instance.
+func ProfileEnvPrefix(name string) string {
+	return "TWITTER_" + strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, name) + "_"
+}
+
+// Credentials returns the keys of the profile of the given 'name', the empty
+// ones being read from the environment, see ProfileEnvPrefix. The profiles
+// can be nil to read all the keys from the environment.
+// It returns an error if a key is missing.
+func (p Profiles) Credentials(name string) (CredentialsConfig, error) {
+	credentials := p[name]
+	err := credentials.fromEnv(ProfileEnvPrefix(name))
+	if err != nil {
+		return credentials, fmt.Errorf("[twitter] profile %q: %v", name, err)
+	}
+	return credentials, nil
+}
+
+// loadProfile returns the keys of the named profile of
+// the given profiles file, if any, see Profiles.Credentials.
+func loadProfile(path, name string) (CredentialsConfig, error) {
+	profiles := Profiles{}
+	if path != "" {
+		var err error
+		profiles, err = LoadProfiles(path)
+		if err != nil {
+			return CredentialsConfig{}, err
+		}
+	}
+	return profiles.Credentials(name)
+}
+
+// MakeTwitterBotWithProfile creates a twitter bot.
+// Same as MakeTwitterBot but the twitter keys are the ones of the profile
+// of the given 'name', see Profiles.Credentials.
+func MakeTwitterBotWithProfile(followersPath, friendsPath, tweetsPath string, profiles Profiles, name string, debug bool, opts ...Option) *TwitterBot {
+	logInfo("[twitter] making twitter bot of profile %s", name)
+	credentials, err := profiles.Credentials(name)
+	if err != nil {
+		logFatal("%v", err)
+	}
+	return MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, credentials.ConsumerKey,
+		credentials.ConsumerSecret, credentials.AccessToken, credentials.AccessSecret, debug, opts...)
+}
+
+// SwitchProfile switches the bot at runtime to the account of the profile of
+// the given 'name', see Profiles.Credentials. The in-flight calls are done
+// with the previous client, closed once they return, and the next ones with
+// the new account. The databases are kept as is, so the profiles should be
+// the keys of the same account, a rotated or a backup set of keys for
+// instance, or the databases should be synchronized afterwards, see Sync.
+// It returns an error, and keeps the current account, if a key is missing.
+func (t *TwitterBot) SwitchProfile(profiles Profiles, name string) error {
+	credentials, err := profiles.Credentials(name)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] switching to profile %s", name)
+	t.setCredentials(credentials.ConsumerKey, credentials.ConsumerSecret, credentials.AccessToken, credentials.AccessSecret)
+	return nil
+}
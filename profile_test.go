@@ -0,0 +1,143 @@
+package twbot
+
+import (
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestProfiles(c *C) {
+	c.Assert(ProfileEnvPrefix("my-bot2"), Equals, "TWITTER_MY_BOT2_")
+
+	profiles, err := LoadProfiles(writeConfig(c, "profiles.yaml", `
+news:
+  consumer_key: key
+  consumer_secret: secret
+  access_token: token
+  access_secret: access
+jokes:
+  consumer_key: jokes key
+`))
+	c.Assert(err, IsNil)
+	credentials, err := profiles.Credentials("news")
+	c.Assert(err, IsNil)
+	c.Assert(credentials, DeepEquals, CredentialsConfig{
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+		AccessToken:    "token",
+		AccessSecret:   "access",
+	})
+
+	// missing keys are read from the environment of the profile
+	_, err = profiles.Credentials("jokes")
+	c.Assert(err, ErrorMatches, `(?s)\[twitter\] profile "jokes": .*"TWITTER_JOKES_CONSUMER_SECRET" is not defined.*`)
+	for key, value := range map[string]string{
+		"TWITTER_JOKES_CONSUMER_KEY":    "env key",
+		"TWITTER_JOKES_CONSUMER_SECRET": "env secret",
+		"TWITTER_JOKES_ACCESS_TOKEN":    "env token",
+		"TWITTER_JOKES_ACCESS_SECRET":   "env access",
+	} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+	credentials, err = profiles.Credentials("jokes")
+	c.Assert(err, IsNil)
+	c.Assert(credentials.ConsumerKey, Equals, "jokes key")
+	c.Assert(credentials.AccessSecret, Equals, "env access")
+	credentials, err = Profiles(nil).Credentials("jokes")
+	c.Assert(err, IsNil)
+	c.Assert(credentials.ConsumerKey, Equals, "env key")
+
+	_, err = LoadProfiles(writeConfig(c, "profiles.json", `{"news": {"token": "token"}}`))
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestSwitchProfile(c *C) {
+	client := &closingClient{fakeClient: &fakeClient{}}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = client
+	profiles := Profiles{"backup": {
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+		AccessToken:    "token",
+		AccessSecret:   "access",
+	}}
+	c.Assert(bot.SwitchProfile(profiles, "missing"), NotNil)
+	c.Assert(bot.twitterClient, Equals, client)
+	c.Assert(bot.SwitchProfile(profiles, "backup"), IsNil)
+	c.Assert(client.closed, Equals, true)
+	api, ok := bot.twitterClient.(*apiClient)
+	c.Assert(ok, Equals, true)
+	c.Assert(api.limits, NotNil)
+}
+
+// blockingClient blocks GetSelf until released.
+type blockingClient struct {
+	*fakeClient
+	started  chan struct{}
+	released chan struct{}
+	closed   chan struct{}
+}
+
+func (c *blockingClient) GetSelf(v url.Values) (anaconda.User, error) {
+	close(c.started)
+	<-c.released
+	return anaconda.User{Id: 1}, nil
+}
+
+func (c *blockingClient) Close() {
+	close(c.closed)
+}
+
+func (s *MySuite) TestSwitchProfileInFlight(c *C) {
+	client := &blockingClient{
+		fakeClient: &fakeClient{},
+		started:    make(chan struct{}),
+		released:   make(chan struct{}),
+		closed:     make(chan struct{}),
+	}
+	bot := makeFakeBot(nil)
+	api := &apiClient{TwitterClient: client, bot: bot}
+	bot.twitterClient = api
+	done := make(chan error)
+	go func() {
+		_, err := bot.twitterClient.GetSelf(nil)
+		done <- err
+	}()
+	<-client.started
+	switched := make(chan error)
+	go func() {
+		switched <- bot.SwitchProfile(Profiles{"backup": {
+			ConsumerKey:    "key",
+			ConsumerSecret: "secret",
+			AccessToken:    "token",
+			AccessSecret:   "access",
+		}}, "backup")
+	}()
+	// the previous client is closed once the in-flight call is done
+	select {
+	case <-client.closed:
+		c.Fatal("client closed during a call")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(client.released)
+	c.Assert(<-done, IsNil)
+	c.Assert(<-switched, IsNil)
+	select {
+	case <-client.closed:
+	case <-time.After(time.Second):
+		c.Fatal("client not closed")
+	}
+	c.Assert(bot.twitterClient, Equals, api)
+	api.inner(func(inner TwitterClient) error {
+		_, ok := inner.(*anacondaClient)
+		c.Assert(ok, Equals, true)
+		return nil
+	})
+	c.Assert(api.getLimits(), NotNil)
+	bot.Close()
+}
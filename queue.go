@@ -0,0 +1,151 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// QueuedTweet is a tweet waiting in the queue of the bot, see Enqueue.
+type QueuedTweet struct {
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Link     string    `json:"link,omitempty"`
+	Image    []byte    `json:"image,omitempty"`
+	Enqueued time.Time `json:"enqueued"`
+}
+
+type twitterQueue struct {
+	LastID int64          `json:"last_id"`
+	Tweets []*QueuedTweet `json:"tweets"` // the oldest first
+}
+
+// SetQueuePath sets the path of the queue database, where the tweets enqueued
+// by Enqueue wait to be tweeted, so that they survive a restart. If no path
+// is set, the queue is only kept in memory.
+func (t *TwitterBot) SetQueuePath(queuePath string) error {
+	queue := &twitterQueue{}
+	err := t.loadOrCreate(queuePath, queue)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	// keep the tweets enqueued before the database was set
+	for _, tweet := range t.queue.Tweets {
+		queue.LastID++
+		tweet.ID = strconv.FormatInt(queue.LastID, 10)
+		queue.Tweets = append(queue.Tweets, tweet)
+	}
+	t.queuePath = queuePath
+	t.queue = queue
+	return t.store.Save(t.queuePath, t.queue)
+}
+
+// saveQueue saves the queue database, if any. The bot mutex must be held.
+func (t *TwitterBot) saveQueue() {
+	if t.queuePath == "" {
+		return
+	}
+	err := t.store.Save(t.queuePath, t.queue)
+	if err != nil {
+		SubsystemStore.error("%v", err)
+	}
+}
+
+// Enqueue adds the given message, followed by the given link if any, and
+// image, raw data or empty, to the queue of the tweets of the bot, tweeted
+// in order by TweetFromQueueOnce. It returns the queued tweet or an error
+// if both the message and the image are empty.
+func (t *TwitterBot) Enqueue(msg, link string, img []byte) (*QueuedTweet, error) {
+	if msg == "" && len(img) == 0 {
+		return nil, fmt.Errorf("[twitter] empty tweet")
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.queue.LastID++
+	tweet := &QueuedTweet{
+		ID:       strconv.FormatInt(t.queue.LastID, 10),
+		Text:     msg,
+		Link:     link,
+		Image:    img,
+		Enqueued: timeNow(),
+	}
+	t.queue.Tweets = append(t.queue.Tweets, tweet)
+	t.saveQueue()
+	SubsystemTweet.info("[twitter] enqueued tweet (id: %s), %d tweet(s) queued", tweet.ID, len(t.queue.Tweets))
+	return tweet, nil
+}
+
+// QueueLen returns the number of tweets waiting in the queue of the bot.
+func (t *TwitterBot) QueueLen() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.queue.Tweets)
+}
+
+// removeQueued removes the queued tweet of the given id.
+func (t *TwitterBot) removeQueued(id string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i, tweet := range t.queue.Tweets {
+		if tweet.ID == id {
+			t.queue.Tweets = append(t.queue.Tweets[:i], t.queue.Tweets[i+1:]...)
+			t.saveQueue()
+			return
+		}
+	}
+}
+
+// TweetFromQueueOnce tweets the oldest tweet of the queue, see Enqueue, and
+// removes it from the queue. A tweet rejected as a duplicate or blocked by the
+// content filter, see SetContentFilter, is removed too.
+// It returns an error, and keeps the tweet queued, if the tweet failed.
+func (t *TwitterBot) TweetFromQueueOnce() error {
+	t.mutex.Lock()
+	var tweet *QueuedTweet
+	if len(t.queue.Tweets) > 0 {
+		tweet = t.queue.Tweets[0]
+	}
+	t.mutex.Unlock()
+	if tweet == nil {
+		SubsystemTweet.debug("[twitter] no queued tweet")
+		return nil
+	}
+	if !t.allowContent("queue", tweet.Text) {
+		SubsystemTweet.warn("[twitter] dropping blocked queued tweet (id: %s)", tweet.ID)
+		t.removeQueued(tweet.ID)
+		return nil
+	}
+	err := t.tweetItem(Item{Text: tweet.Text, Link: tweet.Link, Image: tweet.Image})
+	if errors.Is(err, ErrDuplicateStatus) {
+		SubsystemTweet.warn("[twitter] dropping duplicate queued tweet (id: %s)", tweet.ID)
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	t.removeQueued(tweet.ID)
+	return nil
+}
+
+// TweetFromQueuePeriodically tweets periodically the oldest tweet of the queue,
+// see TweetFromQueueOnce. The tweet frequency is set up by the given 'freq'
+// input parameter. It only logs the error if the tweet failed.
+func (t *TwitterBot) TweetFromQueuePeriodically(freq time.Duration) {
+	job := t.newJob("TweetFromQueuePeriodically")
+	job.queue = t.QueueLen
+	job.runPeriodically(freq, t.TweetFromQueueOnce)
+}
+
+// TweetFromQueuePeriodicallyAsync tweets asynchronously and periodically
+// the oldest tweet of the queue, see TweetFromQueuePeriodically.
+func (t *TwitterBot) TweetFromQueuePeriodicallyAsync(freq time.Duration) *Job {
+	job := t.newJob("TweetFromQueuePeriodically")
+	job.queue = t.QueueLen
+	return t.run(job, func() error {
+		job.runPeriodically(freq, t.TweetFromQueueOnce)
+		return nil
+	})
+}
@@ -0,0 +1,87 @@
+package twbot
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestTweetFromQueueOnce(c *C) {
+	client := &flakyClient{fakeClient: &fakeClient{}}
+	bot := makeFakeBot(client.fakeClient)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.queue = &twitterQueue{}
+
+	_, err := bot.Enqueue("", "", nil)
+	c.Assert(err, ErrorMatches, `\[twitter\] empty tweet`)
+	c.Assert(bot.TweetFromQueueOnce(), IsNil)
+	c.Assert(client.tweets, HasLen, 0)
+
+	c.Assert(bot.SetQueuePath("queue.json"), IsNil)
+	first, err := bot.Enqueue("first", "https://example.com", nil)
+	c.Assert(err, IsNil)
+	c.Assert(first.ID, Equals, "1")
+	_, err = bot.Enqueue("second", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(bot.QueueLen(), Equals, 2)
+
+	// the queue survives a restart
+	other := makeFakeBot(&fakeClient{})
+	other.store = bot.store
+	other.queue = &twitterQueue{}
+	c.Assert(other.SetQueuePath("queue.json"), IsNil)
+	c.Assert(other.QueueLen(), Equals, 2)
+
+	// a failed tweet stays queued
+	client.failures, client.err = 1, makeAPIError(400, 0)
+	c.Assert(bot.TweetFromQueueOnce(), NotNil)
+	c.Assert(bot.QueueLen(), Equals, 2)
+	c.Assert(bot.TweetFromQueueOnce(), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"first https://example.com"})
+	c.Assert(bot.QueueLen(), Equals, 1)
+
+	// a duplicate is dropped
+	client.failures, client.err = client.calls+1, makeAPIError(403, anaconda.TwitterErrorStatusIsADuplicate)
+	c.Assert(bot.TweetFromQueueOnce(), IsNil)
+	c.Assert(bot.QueueLen(), Equals, 0)
+}
+
+func (s *MySuite) TestAdminEnqueue(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.queue = &twitterQueue{}
+	admin := bot.NewAdmin("secret")
+
+	c.Assert(serveAdmin(admin, http.MethodPost, "/enqueue", "", `{"text":"hello"}`).Code, Equals, http.StatusUnauthorized)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/enqueue", "secret", `{"text":" "}`).Code, Equals, http.StatusBadRequest)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/enqueue", "secret", `{"text":`).Code, Equals, http.StatusBadRequest)
+	tooLarge := `{"text":"` + strings.Repeat("a", adminMaxEnqueueSize) + `"}`
+	c.Assert(serveAdmin(admin, http.MethodPost, "/enqueue", "secret", tooLarge).Code, Equals, http.StatusRequestEntityTooLarge)
+	c.Assert(serveAdmin(admin, http.MethodPost, "/enqueue", "secret",
+		`{"text":"hello","link":"https://example.com","image":"aW1n"}`).Code, Equals, http.StatusNoContent)
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	form.WriteField("text", "form")
+	file, err := form.CreateFormFile("image", "image.png")
+	c.Assert(err, IsNil)
+	file.Write([]byte("png"))
+	c.Assert(form.Close(), IsNil)
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/enqueue", body)
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	admin.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, Equals, http.StatusNoContent)
+
+	c.Assert(bot.queue.Tweets, HasLen, 2)
+	c.Assert(bot.queue.Tweets[0].Text, Equals, "hello")
+	c.Assert(bot.queue.Tweets[0].Link, Equals, "https://example.com")
+	c.Assert(string(bot.queue.Tweets[0].Image), Equals, "img")
+	c.Assert(bot.queue.Tweets[1].Text, Equals, "form")
+	c.Assert(string(bot.queue.Tweets[1].Image), Equals, "png")
+}
@@ -0,0 +1,116 @@
+package twbot
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitMargin  = 1                // requests kept in reserve in each endpoint family
+	rateLimitMaxWait = 15 * time.Minute // twitter rate limit window
+)
+
+// rateLimit is the rate limit budget of an endpoint family.
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+// rateLimits keeps track of the rate limit budgets of the endpoint families,
+// as reported by the X-Rate-Limit headers of the twitter API responses.
+type rateLimits struct {
+	mutex    sync.Mutex
+	families map[string]rateLimit // map endpoint family -> budget
+}
+
+func newRateLimits() *rateLimits {
+	return &rateLimits{
+		families: make(map[string]rateLimit),
+	}
+}
+
+// endpointFamily returns the endpoint family of the given twitter API url,
+// which is the endpoint without its api version, ".json" extension and
+// numeric ids, as twitter keeps a rate limit budget per endpoint:
+// "statuses/update" for https://api.twitter.com/1.1/statuses/update.json
+// or "statuses/retweet" for https://api.twitter.com/1.1/statuses/retweet/42.json.
+func endpointFamily(u *url.URL) string {
+	parts := strings.Split(strings.TrimSuffix(strings.Trim(u.Path, "/"), ".json"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	family := []string{}
+	for _, part := range parts[1:] {
+		if _, err := strconv.ParseInt(part, 10, 64); err == nil {
+			continue
+		}
+		family = append(family, part)
+	}
+	return strings.Join(family, "/")
+}
+
+// update updates the budget of the given endpoint family from the response headers.
+func (l *rateLimits) update(family string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-Rate-Limit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.families[family] = rateLimit{
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}
+}
+
+// delay returns how long to wait before the next request of the given endpoint
+// family so that its budget is not exhausted, 0 if it can be sent right away.
+func (l *rateLimits) delay(family string, now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	limit, ok := l.families[family]
+	if !ok || limit.remaining > rateLimitMargin || !now.Before(limit.reset) {
+		return 0
+	}
+	delay := limit.reset.Sub(now)
+	if delay > rateLimitMaxWait {
+		delay = rateLimitMaxWait
+	}
+	return delay
+}
+
+// rateLimitTransport records the rate limit headers of the twitter API responses.
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	limits *rateLimits
+}
+
+func (r *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err == nil {
+		r.limits.update(endpointFamily(req.URL), resp.Header)
+	}
+	return resp, err
+}
+
+// waitRateLimit waits for the rate limit window of the given endpoint family to
+// reset if its budget is nearly exhausted. It returns false if the bot is stopped.
+func (c *apiClient) waitRateLimit(family string) bool {
+	delay := c.getLimits().delay(family, timeNow())
+	if delay <= 0 {
+		return true
+	}
+	logWarn("[twitter] rate limit of %s nearly exhausted, waiting %s...", family, delay)
+	return sleepContext(c.bot.botContext(), delay)
+}
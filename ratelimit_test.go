@@ -0,0 +1,192 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestEndpointFamily(c *C) {
+	families := map[string]string{
+		"https://api.twitter.com/1.1/statuses/update.json":                "statuses/update",
+		"https://api.twitter.com/1.1/statuses/user_timeline.json?count=1": "statuses/user_timeline",
+		"https://api.twitter.com/1.1/statuses/retweet/42.json":            "statuses/retweet",
+		"https://api.twitter.com/1.1/direct_messages/events/new.json":     "direct_messages/events/new",
+		"https://upload.twitter.com/1.1/media/upload.json":                "media/upload",
+		"https://api.twitter.com/1.1/users/suggestions/music.json":        "users/suggestions/music",
+	}
+	for raw, family := range families {
+		u, err := url.Parse(raw)
+		c.Assert(err, IsNil)
+		c.Assert(endpointFamily(u), Equals, family, Commentf("url: %s", raw))
+	}
+}
+
+// familyClient records the twitter API calls through an http client
+// reporting an exhausted budget for each endpoint.
+type familyClient struct {
+	*fakeClient
+	client *http.Client
+}
+
+func (f *familyClient) do(path string) error {
+	resp, err := f.client.Get("https://api.twitter.com/1.1" + path)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (f *familyClient) PostTweet(status string, v url.Values) (anaconda.Tweet, error) {
+	return anaconda.Tweet{}, f.do("/statuses/update.json")
+}
+
+func (f *familyClient) PostDMToUserId(text string, userID int64) (anaconda.DirectMessage, error) {
+	return anaconda.DirectMessage{}, f.do("/direct_messages/events/new.json")
+}
+
+func (f *familyClient) GetUserTimeline(v url.Values) ([]anaconda.Tweet, error) {
+	return nil, f.do("/statuses/user_timeline.json")
+}
+
+func (s *MySuite) TestRateLimitsFamilies(c *C) {
+	reset := time.Now().Add(time.Hour).Unix()
+	limits := newRateLimits()
+	client := &familyClient{
+		fakeClient: &fakeClient{},
+		client: &http.Client{
+			Transport: &rateLimitTransport{
+				base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					header := http.Header{}
+					header.Set("X-Rate-Limit-Remaining", "0")
+					header.Set("X-Rate-Limit-Reset", strconv.FormatInt(reset, 10))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     header,
+						Body:       ioutil.NopCloser(strings.NewReader("")),
+						Request:    req,
+					}, nil
+				}),
+				limits: limits,
+			},
+		},
+	}
+	clock := NewFakeClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+	bot := makeFakeBot(client.fakeClient)
+	api := &apiClient{TwitterClient: client, bot: bot, limits: limits}
+	defer bot.Stop()
+
+	// the budgets recorded by the transport are honoured by the calls
+	// of the same endpoint only
+	_, err := api.PostTweet("hello", nil)
+	c.Assert(err, IsNil)
+	_, err = api.PostDMToUserId("hello", 1)
+	c.Assert(err, IsNil)
+	_, err = api.GetUserTimeline(nil)
+	c.Assert(err, IsNil)
+	done := make(chan error, 1)
+	go func() {
+		_, err := api.PostDMToUserId("hello", 1)
+		done <- err
+	}()
+	for i := 0; i < 100 && clock.Waiters() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(clock.Waiters(), Equals, 1)
+	bot.Stop()
+	c.Assert(<-done, NotNil)
+}
+
+func (s *MySuite) TestReadFamilies(c *C) {
+	clock := NewFakeClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("X-Rate-Limit-Remaining", "0")
+		header.Set("X-Rate-Limit-Reset", strconv.FormatInt(timeNow().Add(time.Minute).Unix(), 10))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			Request:    req,
+		}, nil
+	})
+	bot := newTwitterBot("", "", "", "key", "secret", "token", "access", false, WithHTTPClient(&http.Client{Transport: transport}))
+	defer bot.twitterClient.Close()
+
+	// the family of each read is the endpoint family of its request,
+	// so that the budget recorded by the transport delays the next read
+	reads := map[string]func(){
+		"GetFollowersIds":     func() { bot.twitterClient.GetFollowersIds(nil) },
+		"GetFriendsIds":       func() { bot.twitterClient.GetFriendsIds(nil) },
+		"GetFollowersUser":    func() { bot.twitterClient.GetFollowersUser(1, nil) },
+		"GetSearch":           func() { bot.twitterClient.GetSearch("golang", nil) },
+		"GetUsersLookup":      func() { bot.twitterClient.GetUsersLookup("user", nil) },
+		"GetUsersLookupByIds": func() { bot.twitterClient.GetUsersLookupByIds([]int64{1}, nil) },
+		"GetUsersShow":        func() { bot.twitterClient.GetUsersShow("user", nil) },
+	}
+	for name, read := range reads {
+		// reset the budgets of the previous reads
+		clock.Advance(time.Minute)
+		read()
+		done := make(chan bool)
+		go func() {
+			read()
+			done <- true
+		}()
+		for i := 0; i < 100 && clock.Waiters() == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.Assert(clock.Waiters(), Equals, 1, Commentf("%s not delayed", name))
+		clock.Advance(time.Minute)
+		<-done
+	}
+}
+
+func (s *MySuite) TestRateLimits(c *C) {
+	now := time.Now()
+	reset := now.Add(time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Rate-Limit-Remaining", r.URL.Query().Get("remaining"))
+		w.Header().Set("X-Rate-Limit-Reset", strconv.FormatInt(reset, 10))
+	}))
+	defer server.Close()
+	limits := newRateLimits()
+	client := &http.Client{
+		Transport: &rateLimitTransport{
+			base:   http.DefaultTransport,
+			limits: limits,
+		},
+	}
+
+	// budgets are kept per endpoint family
+	resp, err := client.Get(server.URL + "/1.1/statuses/update.json?remaining=10")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	resp, err = client.Get(server.URL + "/1.1/search/tweets.json?remaining=1")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(limits.delay("statuses/update", now), Equals, time.Duration(0))
+	c.Assert(limits.delay("users/show", now), Equals, time.Duration(0))
+	delay := limits.delay("search/tweets", now)
+	c.Assert(delay > 0 && delay <= time.Minute, Equals, true)
+	// the budget is restored once the window is reset
+	c.Assert(limits.delay("search/tweets", now.Add(2*time.Minute)), Equals, time.Duration(0))
+
+	// waits are interrupted once the bot is stopped
+	bot := makeFakeBot(&fakeClient{})
+	api := &apiClient{TwitterClient: bot.twitterClient, bot: bot, limits: limits}
+	bot.Stop()
+	c.Assert(api.waitRateLimit("statuses/update"), Equals, true)
+	c.Assert(api.waitRateLimit("search/tweets"), Equals, false)
+}
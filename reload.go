@@ -0,0 +1,128 @@
+package twbot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func (t *TwitterBot) setConfig(cfg *Config) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.config = cfg
+}
+
+// getConfig returns the current configuration of the bot. The returned
+// configuration is never modified: reloads replace it as a whole.
+func (t *TwitterBot) getConfig() *Config {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.config
+}
+
+// schedulePeriodicallyAsync runs periodically and asynchronously the given
+// 'run' callback with the current configuration, so that the reloaded
+// queries are used from the next run on.
+func (t *TwitterBot) schedulePeriodicallyAsync(name string, freq time.Duration, run func(ctx context.Context, cfg *Config) error) *Job {
+	return t.runPeriodicallyAsync(name, freq, func(ctx context.Context) error {
+		return run(ctx, t.getConfig())
+	})
+}
+
+// ReloadConfig applies the given configuration to a bot made by NewFromConfig
+// without restarting it: the search queries, the banned queries and users
+// and the policies are replaced and used by the running schedules from their
+// next run on. The configuration is applied as a whole or not at all if it is
+// invalid. The other settings, like the credentials, the store, the paths or
+// the schedules themselves, require a restart and are ignored.
+// The configuration must not be modified afterwards.
+func (t *TwitterBot) ReloadConfig(cfg *Config) error {
+	current := t.getConfig()
+	if current == nil {
+		return errors.New("[twitter] bot not made from a configuration, see NewFromConfig")
+	}
+	reloaded := *current
+	reloaded.Policies = cfg.Policies
+	reloaded.Queries = cfg.Queries
+	reloaded.Banned.Queries = cfg.Banned.Queries
+	reloaded.Banned.Users = cfg.Banned.Users
+	// the policies are validated before any of them is applied
+	err := t.applyPolicies(&reloaded.Policies)
+	if err != nil {
+		return err
+	}
+	t.SetBannedUsers(reloaded.Banned.Users...)
+	t.setConfig(&reloaded)
+	logInfo("[twitter] configuration reloaded")
+	return nil
+}
+
+func (t *TwitterBot) reloadConfigFile(path string) {
+	cfg, err := LoadConfig(path)
+	if err == nil {
+		err = t.ReloadConfig(cfg)
+	}
+	if err != nil {
+		logError("[twitter] failed to reload configuration %s: %v", path, err)
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// WatchConfig reloads the configuration file of the given 'path', see
+// LoadConfig and ReloadConfig, when the process receives a SIGHUP signal
+// or when the file changes. The file is checked for changes with the given
+// 'freq' frequency, a zero frequency disabling the check.
+// It logs errors if the reload failed, keeping the previous configuration.
+func (t *TwitterBot) WatchConfig(path string, freq time.Duration) {
+	t.watchConfig(t.botContext(), path, freq)
+}
+
+func (t *TwitterBot) watchConfig(ctx context.Context, path string, freq time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	last := modTime(path)
+	for {
+		var timer Timer
+		var tick <-chan time.Time
+		if freq > 0 {
+			timer = getClock().NewTimer(freq)
+			tick = timer.C()
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-hup:
+			stopTimer(timer)
+			logInfo("[twitter] SIGHUP received, reloading configuration %s", path)
+		case <-tick:
+			current := modTime(path)
+			if current.Equal(last) {
+				continue
+			}
+			logInfo("[twitter] configuration %s changed, reloading", path)
+		}
+		last = modTime(path)
+		t.reloadConfigFile(path)
+	}
+}
+
+// WatchConfigAsync reloads asynchronously the configuration file
+// of the given 'path' on SIGHUP or on change, see WatchConfig.
+func (t *TwitterBot) WatchConfigAsync(path string, freq time.Duration) *Job {
+	return t.runOnceAsync("WatchConfig", func(ctx context.Context) error {
+		t.watchConfig(ctx, path, freq)
+		return nil
+	})
+}
@@ -0,0 +1,59 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestReloadConfig(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.likePolicy = &likePolicy{}
+	bot.retweetPolicy = &retweetPolicy{}
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{}
+	c.Assert(bot.ReloadConfig(&Config{}), ErrorMatches, `\[twitter\] bot not made from a configuration.*`)
+
+	path := writeConfig(c, "bot.json", testConfigJSON)
+	cfg, err := LoadConfig(path)
+	c.Assert(err, IsNil)
+	bot.setConfig(cfg)
+
+	// an invalid configuration is not applied at all
+	invalid := `{"queries": {"retweet": ["gopher"]}, "policies": {"error": "panic"}}`
+	c.Assert(ioutil.WriteFile(path, []byte(invalid), 0644), IsNil)
+	bot.reloadConfigFile(path)
+	c.Assert(bot.getConfig(), Equals, cfg)
+
+	reloaded := &Config{
+		Owner: "other",
+		Policies: PoliciesConfig{
+			Unfollow: &UnfollowPolicyConfig{MinAge: Duration(time.Hour)},
+		},
+		Queries: QueriesConfig{Retweet: []string{"gopher"}},
+		Banned:  BannedConfig{Queries: []string{"ads"}, Users: []string{"Spammer"}},
+	}
+	c.Assert(bot.ReloadConfig(reloaded), IsNil)
+	current := bot.getConfig()
+	c.Assert(current.Owner, Equals, "owner")
+	c.Assert(current.Paths, DeepEquals, cfg.Paths)
+	c.Assert(current.Queries.Retweet, DeepEquals, []string{"gopher"})
+	c.Assert(current.Banned.Queries, DeepEquals, []string{"ads"})
+	c.Assert(bot.unfollowPolicy.minAge, Equals, time.Hour)
+	c.Assert(bot.unfollowPolicy.order, Equals, UnfollowAnyOrder)
+
+	// tweets of banned users are removed
+	bot.blocks = &twitterBlocks{
+		Blocked: map[string]int64{},
+		Muted:   map[string]int64{},
+	}
+	tweets := []anaconda.Tweet{
+		{Id: 1, User: anaconda.User{Id: 1, ScreenName: "spammer"}},
+		{Id: 2, User: anaconda.User{Id: 2, ScreenName: "gopher"}},
+	}
+	allowed := bot.removeBanned(tweets, nil)
+	c.Assert(allowed, HasLen, 1)
+	c.Assert(allowed[0].Id, Equals, int64(2))
+}
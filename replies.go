@@ -0,0 +1,179 @@
+package twbot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// replyRule replies with its template to the mentions matching its
+// regular expression if any, or containing its keyword otherwise.
+type replyRule struct {
+	re       *regexp.Regexp
+	keyword  string
+	template string
+}
+
+type replyRules struct {
+	rules      []*replyRule
+	fallback   string
+	maxPerUser int
+	dayStart   time.Time
+	byUser     map[int64]int // map user id -> replies of the day
+}
+
+// allow allows a reply to the given user as long as the daily
+// cap by user 'maxPerUser' is not reached, 0 meaning no limit.
+func (r *replyRules) allow(userID int64) bool {
+	if timeSince(r.dayStart) >= 24*time.Hour {
+		r.dayStart = timeNow()
+		r.byUser = make(map[int64]int)
+	}
+	return r.maxPerUser <= 0 || r.byUser[userID] < r.maxPerUser
+}
+
+// render returns the reply of the first rule matching the given mention.
+// It returns false if no rule matches.
+func (r *replyRules) render(tweet *anaconda.Tweet) (string, bool) {
+	for _, rule := range r.rules {
+		reply := ""
+		if rule.re != nil {
+			match := rule.re.FindStringSubmatchIndex(tweet.Text)
+			if match == nil {
+				continue
+			}
+			reply = string(rule.re.ExpandString(nil, rule.template, tweet.Text, match))
+		} else if strings.Contains(strings.ToLower(tweet.Text), rule.keyword) {
+			reply = rule.template
+		} else {
+			continue
+		}
+		return formatQuote(reply, tweet), true
+	}
+	return "", false
+}
+
+// AddReplyRule adds a rule replying to the mentions matching the regular
+// expression 'pattern' with the given 'template'. The "{author}" tag of the
+// template is replaced by the screen name of the author of the mention and
+// the submatches of the pattern can be used as $1, $2 or ${name}.
+// Rules are tried in the order they are added, see AutoReplyMentions.
+func (t *TwitterBot) AddReplyRule(pattern, template string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] adding reply rule -> pattern: %s, template: %s", pattern, template)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.replyRules.rules = append(t.replyRules.rules, &replyRule{
+		re:       re,
+		template: template,
+	})
+	return nil
+}
+
+// AddReplyKeyword adds a rule replying to the mentions containing the given
+// 'keyword', whatever its case, with the given 'template', see AddReplyRule.
+func (t *TwitterBot) AddReplyKeyword(keyword, template string) {
+	logInfo("[twitter] adding reply rule -> keyword: %s, template: %s", keyword, template)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.replyRules.rules = append(t.replyRules.rules, &replyRule{
+		keyword:  strings.ToLower(keyword),
+		template: template,
+	})
+}
+
+// SetReplyPolicy sets the reply policy of the reply rules: a user only gets
+// 'maxPerUser' replies per day, 0 meaning no limit, and the mentions matching
+// no rule get the 'fallback' reply, an empty fallback meaning no reply.
+func (t *TwitterBot) SetReplyPolicy(maxPerUser int, fallback string) {
+	logInfo("[twitter] setting reply policy -> maxPerUser: %d, fallback: %s", maxPerUser, fallback)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.replyRules.maxPerUser = maxPerUser
+	t.replyRules.fallback = fallback
+}
+
+// replyByRules returns the reply to the given mention following the reply
+// rules, generated by the text generator if no rule matches, see
+// SetTextGenerator.
+// The mentions and the replies blocked by the content filter are skipped,
+// see SetContentFilter.
+func (t *TwitterBot) replyByRules(tweet anaconda.Tweet) (string, bool) {
+	if !t.allowContent("reply", tweet.Text) {
+		return "", false
+	}
+	reply, generator, prompt, ok := t.prepareReply(&tweet)
+	if ok && generator != nil {
+		// the reply is prefixed by the mention of the author, see replyTo
+		maxSize := generatedTextMaxSize - len([]rune("@"+tweet.User.ScreenName+" "))
+		generated, err := generateText(generator, prompt, &tweet, maxSize)
+		if err != nil {
+			logWarn("[twitter] failed to generate reply to mention (id:%d): %v", tweet.Id, err)
+			ok = reply != ""
+		} else {
+			reply = generated
+		}
+	}
+	if !ok || !t.allowContent("reply", reply) {
+		return "", false
+	}
+	t.countReply(tweet.User.Id)
+	return reply, true
+}
+
+// prepareReply returns the reply to the given mention following the reply
+// rules, the fallback reply if no rule matches, or the generator and its
+// prompt if no rule matches and a generator is set. It returns false if
+// there is no reply or the daily cap of the author is reached, the reply
+// being counted by countReply once made.
+func (t *TwitterBot) prepareReply(tweet *anaconda.Tweet) (string, TextGenerator, string, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	reply, ok := t.replyRules.render(tweet)
+	var generator TextGenerator
+	if !ok {
+		if t.replyRules.fallback != "" {
+			reply = formatQuote(t.replyRules.fallback, tweet)
+		}
+		if t.replyPrompt != "" {
+			generator = t.generator
+		}
+		if reply == "" && generator == nil {
+			return "", nil, "", false
+		}
+	}
+	if !t.replyRules.allow(tweet.User.Id) {
+		print(t, fmt.Sprintf("[twitter] daily replies limit reached for user (id:%d, name:%s)\n", tweet.User.Id, tweet.User.Name))
+		return "", nil, "", false
+	}
+	return reply, generator, t.replyPrompt, true
+}
+
+// countReply counts a reply to the given user against its daily cap.
+func (t *TwitterBot) countReply(userID int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.replyRules.byUser[userID]++
+}
+
+// AutoReplyMentions polls the tweets mentioning the bot and replies in-thread
+// with the reply of the first matching reply rule, see AddReplyRule,
+// AddReplyKeyword and SetReplyPolicy, or with a generated reply, see
+// SetTextGenerator.
+// The poll frequency is set up by the given 'freq' input parameter.
+// It logs errors if the poll or the replies failed.
+func (t *TwitterBot) AutoReplyMentions(freq time.Duration) {
+	t.OnMention(t.replyByRules, freq)
+}
+
+// AutoReplyMentionsAsync replies asynchronously to the
+// tweets mentioning the bot, see AutoReplyMentions.
+func (t *TwitterBot) AutoReplyMentionsAsync(freq time.Duration) *Job {
+	return t.OnMentionAsync(t.replyByRules, freq)
+}
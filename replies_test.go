@@ -0,0 +1,36 @@
+package twbot
+
+import (
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestReplyByRules(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.replyRules = &replyRules{
+		byUser: map[int64]int{},
+	}
+	c.Assert(bot.AddReplyRule("(", "invalid"), NotNil)
+	c.Assert(bot.AddReplyRule(`price of (\w+)`, "{author}, $1 is free"), IsNil)
+	bot.AddReplyKeyword("Hello", "hi {author}!")
+	mention := func(text string) anaconda.Tweet {
+		return anaconda.Tweet{Text: text, User: anaconda.User{Id: 10, ScreenName: "user"}}
+	}
+	reply, ok := bot.replyByRules(mention("@bot what is the price of coffee?"))
+	c.Assert(ok, Equals, true)
+	c.Assert(reply, Equals, "user, coffee is free")
+	reply, ok = bot.replyByRules(mention("@bot HELLO"))
+	c.Assert(ok, Equals, true)
+	c.Assert(reply, Equals, "hi user!")
+	_, ok = bot.replyByRules(mention("@bot nothing"))
+	c.Assert(ok, Equals, false)
+
+	// fallback reply and rate limiting by user
+	bot.SetReplyPolicy(3, "sorry {author}, I do not understand")
+	reply, ok = bot.replyByRules(mention("@bot nothing"))
+	c.Assert(ok, Equals, true)
+	c.Assert(reply, Equals, "sorry user, I do not understand")
+	_, ok = bot.replyByRules(mention("@bot hello"))
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,153 @@
+package twbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+type retentionPolicy struct {
+	maxTweets int
+	maxAge    time.Duration
+}
+
+// SetRetention sets the retention policy of the databases, which otherwise
+// grow forever: the tweets history, used to detect duplicates, is capped to
+// the 'maxTweets' most recent tweets, and the users who stopped following or
+// being followed by the bot more than 'maxAge' ago as well as the growth
+// records older than 'maxAge' are removed by the compaction, see Compact.
+// Unfollowed friends are kept if they must never be followed again, or until
+// the end of the refollow cooldown if longer than 'maxAge', see
+// SetRefollowCooldown. Zero values, the default, keep everything.
+func (t *TwitterBot) SetRetention(maxTweets int, maxAge time.Duration) {
+	logInfo("[twitter] setting retention -> maxTweets: %d, maxAge: %s", maxTweets, maxAge)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.retention.maxTweets = maxTweets
+	t.retention.maxAge = maxAge
+}
+
+// trimTweets returns the 'max' most recent tweets of the history, 0 meaning no limit.
+func trimTweets(tweets []anaconda.Tweet, max int) []anaconda.Tweet {
+	if max <= 0 || len(tweets) <= max {
+		return tweets
+	}
+	return tweets[len(tweets)-max:]
+}
+
+// pruneUsers removes the users who stopped following or being followed
+// before the given timestamp and returns the number of removed users.
+func pruneUsers(users *twitterUsers, before int64) int {
+	count := 0
+	for strID, user := range users.Ids {
+		if !user.Follow && user.Unfollowed != 0 && user.Unfollowed < before {
+			delete(users.Ids, strID)
+			count++
+		}
+	}
+	return count
+}
+
+// pruneGrowth removes the growth records older than the given
+// timestamp and returns the number of removed records.
+func pruneGrowth(growth *twitterGrowth, before int64) int {
+	i := 0
+	for i < len(growth.Points) && growth.Points[i].Timestamp < before {
+		i++
+	}
+	growth.Points = growth.Points[i:]
+	return i
+}
+
+// compactUsers prunes the followers, friends and growth databases
+// following the retention policy.
+func (t *TwitterBot) compactUsers() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.retention.maxAge <= 0 {
+		return nil
+	}
+	before := timeNow().Add(-t.retention.maxAge).UnixNano()
+	count := pruneUsers(t.followers, before)
+	if count > 0 {
+		SubsystemStore.info("[twitter] compaction removed %d unfollower(s)", count)
+		err := t.store.Save(t.followersPath, t.followers)
+		if err != nil {
+			return err
+		}
+	}
+	// unfollowed friends are never followed again without refollow cooldown,
+	// and must be kept until the end of the cooldown otherwise
+	if cooldown := t.unfollowPolicy.refollowCooldown; cooldown > 0 {
+		friendsBefore := before
+		if cooldown > t.retention.maxAge {
+			friendsBefore = timeNow().Add(-cooldown).UnixNano()
+		}
+		count = pruneUsers(t.friends, friendsBefore)
+		if count > 0 {
+			SubsystemStore.info("[twitter] compaction removed %d unfollowed friend(s)", count)
+			err := t.store.Save(t.friendsPath, t.friends)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	count = pruneGrowth(t.growth, before)
+	if count > 0 && t.growthPath != "" {
+		SubsystemStore.info("[twitter] compaction removed %d growth record(s)", count)
+		return t.store.Save(t.growthPath, t.growth)
+	}
+	return nil
+}
+
+// compactTweets caps the tweets history following the retention policy.
+func (t *TwitterBot) compactTweets() error {
+	maxTweets := t.getRetention().maxTweets
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	tweets := &[]anaconda.Tweet{}
+	err := t.loadOrCreate(t.tweetsPath, tweets)
+	if err != nil {
+		return err
+	}
+	trimmed := trimTweets(*tweets, maxTweets)
+	if len(trimmed) == len(*tweets) {
+		return nil
+	}
+	SubsystemStore.info("[twitter] compaction removed %d tweet(s)", len(*tweets)-len(trimmed))
+	return t.store.Save(t.tweetsPath, trimmed)
+}
+
+func (t *TwitterBot) getRetention() retentionPolicy {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.retention
+}
+
+// Compact removes from the databases the entries outdated
+// by the retention policy, see SetRetention.
+func (t *TwitterBot) Compact() error {
+	err := t.compactUsers()
+	if err != nil {
+		return err
+	}
+	return t.compactTweets()
+}
+
+// CompactPeriodically compacts periodically the databases, see Compact.
+// The compaction frequency is set up by the given 'freq' input parameter.
+// It logs errors if the compaction failed.
+func (t *TwitterBot) CompactPeriodically(freq time.Duration) {
+	t.newJob("CompactPeriodically").runPeriodically(freq, func() error {
+		return t.Compact()
+	})
+}
+
+// CompactPeriodicallyAsync compacts asynchronously and
+// periodically the databases, see CompactPeriodically.
+func (t *TwitterBot) CompactPeriodicallyAsync(freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("CompactPeriodically", freq, func(ctx context.Context) error {
+		return t.Compact()
+	})
+}
@@ -0,0 +1,65 @@
+package twbot
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestTrimTweets(c *C) {
+	tweets := []anaconda.Tweet{{Id: 1}, {Id: 2}, {Id: 3}}
+	c.Assert(trimTweets(tweets, 0), HasLen, 3)
+	c.Assert(trimTweets(tweets, 5), HasLen, 3)
+	c.Assert(trimTweets(tweets, 2), DeepEquals, []anaconda.Tweet{{Id: 2}, {Id: 3}})
+}
+
+func (s *MySuite) TestCompact(c *C) {
+	dir := c.MkDir()
+	bot := makeFakeBot(&fakeClient{})
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.growth = &twitterGrowth{}
+	bot.followersPath = filepath.Join(dir, "followers.json")
+	bot.friendsPath = filepath.Join(dir, "friends.json")
+	bot.tweetsPath = filepath.Join(dir, "tweets.json")
+	old := time.Now().Add(-48 * time.Hour).UnixNano()
+	recent := time.Now().Add(-time.Hour).UnixNano()
+	bot.followers.Ids = map[string]*twitterUser{
+		"1": {Timestamp: old, Follow: true},
+		"2": {Timestamp: old, Unfollowed: old},
+		"3": {Timestamp: old, Unfollowed: recent},
+	}
+	bot.friends.Ids = map[string]*twitterUser{
+		"4": {Timestamp: old, Unfollowed: old},
+	}
+	bot.growth.Points = []growthPoint{{Timestamp: old}, {Timestamp: recent}}
+	c.Assert(bot.store.Save(bot.tweetsPath, []anaconda.Tweet{{Id: 1}, {Id: 2}, {Id: 3}}), IsNil)
+
+	// nothing is removed by default
+	c.Assert(bot.Compact(), IsNil)
+	c.Assert(bot.followers.Ids, HasLen, 3)
+	tweets, err := bot.loadTweets()
+	c.Assert(err, IsNil)
+	c.Assert(tweets, HasLen, 3)
+
+	bot.SetRetention(2, 24*time.Hour)
+	c.Assert(bot.Compact(), IsNil)
+	c.Assert(bot.followers.Ids, HasLen, 2)
+	c.Assert(bot.followers.Ids["2"], IsNil)
+	// unfollowed friends are never followed again by default so they are kept
+	c.Assert(bot.friends.Ids, HasLen, 1)
+	c.Assert(bot.growth.Points, DeepEquals, []growthPoint{{Timestamp: recent}})
+	tweets = []anaconda.Tweet{}
+	c.Assert(bot.store.Load(bot.tweetsPath, &tweets), IsNil)
+	c.Assert(tweets, DeepEquals, []anaconda.Tweet{{Id: 2}, {Id: 3}})
+
+	// unfollowed friends are kept until the end of the refollow cooldown
+	bot.unfollowPolicy.refollowCooldown = 72 * time.Hour
+	c.Assert(bot.Compact(), IsNil)
+	c.Assert(bot.friends.Ids, HasLen, 1)
+	bot.unfollowPolicy.refollowCooldown = time.Hour
+	c.Assert(bot.Compact(), IsNil)
+	c.Assert(bot.friends.Ids, HasLen, 0)
+}
@@ -0,0 +1,110 @@
+package twbot
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = time.Second
+	defaultRetryMaxDelay    = 30 * time.Second
+	defaultRetryJitter      = 0.2
+)
+
+type retryPolicy struct {
+	mutex       sync.Mutex
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	jitter      float64
+}
+
+// SetRetryPolicy sets how the calls to the twitter API failing with a
+// transient error, like a 5xx response, a timeout or a connection reset,
+// are retried, so that a network blip does not abort a periodic run.
+// Only the reads are retried on these errors: the writes, like tweets or
+// follows, are retried only if the request never reached twitter, such
+// as a refused connection, so that they are never done twice.
+// A call is tried at most 'maxAttempts' times, 1 disabling the retries.
+// The delay between two attempts starts at 'baseDelay' and doubles after
+// each attempt up to 'maxDelay', randomized by plus or minus the 'jitter'
+// fraction of the delay, from 0 to 1. By default, calls are tried 3 times
+// with a delay starting at 1 second, up to 30 seconds, and a 0.2 jitter.
+func (t *TwitterBot) SetRetryPolicy(maxAttempts int, baseDelay, maxDelay time.Duration, jitter float64) {
+	logInfo("[twitter] setting retry policy -> maxAttempts: %d, baseDelay: %s, maxDelay: %s, jitter: %g",
+		maxAttempts, baseDelay, maxDelay, jitter)
+	t.retryPolicy.mutex.Lock()
+	defer t.retryPolicy.mutex.Unlock()
+	t.retryPolicy.maxAttempts = maxAttempts
+	t.retryPolicy.baseDelay = baseDelay
+	t.retryPolicy.maxDelay = maxDelay
+	t.retryPolicy.jitter = jitter
+}
+
+func (t *TwitterBot) getRetryPolicy() (int, time.Duration, time.Duration, float64) {
+	t.retryPolicy.mutex.Lock()
+	defer t.retryPolicy.mutex.Unlock()
+	return t.retryPolicy.maxAttempts, t.retryPolicy.baseDelay, t.retryPolicy.maxDelay, t.retryPolicy.jitter
+}
+
+// isTransientError returns true if the given error is worth retrying:
+// a twitter API server error, a timeout or a connection reset.
+func isTransientError(err error) bool {
+	if apiErr, ok := asAPIError(err); ok {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// isUnsentError returns true if the given error proves that the request
+// never reached the twitter API, so that it can be retried even if not
+// idempotent: the connection was refused or the host was not resolved.
+func isUnsentError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.As(err, &dnsErr)
+}
+
+// retryDelay returns the delay before the given 'attempt', starting at 1.
+func retryDelay(attempt int, baseDelay, maxDelay time.Duration, jitter float64) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if jitter > 0 {
+		delay += time.Duration(jitter * float64(delay) * (2*getRand().Float64() - 1))
+	}
+	return delay
+}
+
+// retry runs the given 'run' callback until it succeeds, fails with an
+// error which is not 'retryable' or reaches the maximum number of attempts
+// of the retry policy. It returns the last error.
+func (t *TwitterBot) retry(retryable func(err error) bool, run func() error) error {
+	maxAttempts, baseDelay, maxDelay, jitter := t.getRetryPolicy()
+	err := run()
+	for attempt := 1; attempt < maxAttempts && err != nil && retryable(err); attempt++ {
+		delay := retryDelay(attempt, baseDelay, maxDelay, jitter)
+		logWarn("[twitter] retrying in %s after transient error (%d/%d): %v", delay, attempt, maxAttempts-1, err)
+		if !t.noSleep && !sleepContext(t.botContext(), delay) {
+			break
+		}
+		err = run()
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package twbot
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+// flakyClient fails the first 'failures' tweets, self reads
+// or followers ids pages with the given error.
+type flakyClient struct {
+	*fakeClient
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *flakyClient) PostTweet(status string, v url.Values) (anaconda.Tweet, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return anaconda.Tweet{}, f.err
+	}
+	return f.fakeClient.PostTweet(status, v)
+}
+
+func (f *flakyClient) GetSelf(v url.Values) (anaconda.User, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return anaconda.User{}, f.err
+	}
+	return anaconda.User{Id: 1}, nil
+}
+
+func (f *flakyClient) GetFollowersIds(v url.Values) (anaconda.Cursor, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return anaconda.Cursor{}, f.err
+	}
+	return f.fakeClient.GetFollowersIds(v)
+}
+
+func (s *MySuite) TestIsTransientError(c *C) {
+	c.Assert(isTransientError(makeAPIError(503, 0)), Equals, true)
+	c.Assert(isTransientError(fmt.Errorf("post: %w", syscall.ECONNRESET)), Equals, true)
+	c.Assert(isTransientError(makeAPIError(403, anaconda.TwitterErrorStatusIsADuplicate)), Equals, false)
+	c.Assert(isTransientError(errors.New("failed")), Equals, false)
+}
+
+func (s *MySuite) TestIsUnsentError(c *C) {
+	c.Assert(isUnsentError(fmt.Errorf("post: %w", syscall.ECONNREFUSED)), Equals, true)
+	c.Assert(isUnsentError(fmt.Errorf("post: %w", &net.DNSError{Err: "no such host"})), Equals, true)
+	c.Assert(isUnsentError(fmt.Errorf("post: %w", syscall.ECONNRESET)), Equals, false)
+	c.Assert(isUnsentError(makeAPIError(503, 0)), Equals, false)
+}
+
+func (s *MySuite) TestRetryDelay(c *C) {
+	c.Assert(retryDelay(1, time.Second, time.Minute, 0), Equals, time.Second)
+	c.Assert(retryDelay(3, time.Second, time.Minute, 0), Equals, 4*time.Second)
+	c.Assert(retryDelay(10, time.Second, time.Minute, 0), Equals, time.Minute)
+	for i := 0; i < 10; i++ {
+		delay := retryDelay(1, time.Second, time.Minute, 0.5)
+		c.Assert(delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond, Equals, true)
+	}
+}
+
+func (s *MySuite) TestRetry(c *C) {
+	client := &flakyClient{
+		fakeClient: &fakeClient{},
+		err:        makeAPIError(503, 0),
+		failures:   2,
+	}
+	bot := makeFakeBot(nil)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	bot.SetRetryPolicy(3, time.Millisecond, time.Millisecond, 0)
+
+	// transient errors of the reads are retried
+	_, err := bot.twitterClient.GetSelf(nil)
+	c.Assert(err, IsNil)
+	c.Assert(client.calls, Equals, 3)
+
+	// up to the maximum number of attempts
+	client.calls, client.failures = 0, 5
+	_, err = bot.twitterClient.GetSelf(nil)
+	c.Assert(err, NotNil)
+	c.Assert(client.calls, Equals, 3)
+
+	// writes are not retried since they could have been done
+	client.calls, client.failures = 0, 2
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), NotNil)
+	c.Assert(client.calls, Equals, 1)
+
+	// unless the request never reached twitter
+	client.calls, client.err = 0, fmt.Errorf("post: %w", syscall.ECONNREFUSED)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(client.calls, Equals, 3)
+
+	// other errors are not retried and are wrapped
+	client.calls, client.failures, client.err = 0, 2, makeAPIError(403, anaconda.TwitterErrorStatusIsADuplicate)
+	_, err = bot.twitterClient.GetSelf(nil)
+	c.Assert(client.calls, Equals, 1)
+	err = bot.TweetOnce(func() (string, error) { return "hello", nil })
+	c.Assert(errors.Is(err, ErrDuplicateStatus), Equals, true)
+	c.Assert(client.calls, Equals, 2)
+
+	// the pages of the followers ids of the bot are retried too
+	client.calls, client.failures, client.err = 0, 2, makeAPIError(503, 0)
+	client.myFollowers = []int64{1, 2}
+	ids, err := bot.fetchFollowersIds()
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{1, 2})
+	c.Assert(client.calls, Equals, 4)
+}
@@ -0,0 +1,145 @@
+package twbot
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// SentimentScorer scores the sentiment of texts, from -1, strongly negative
+// or toxic, to 1, strongly positive, see SetSentimentFilter.
+type SentimentScorer interface {
+	Score(text string) (float64, error)
+}
+
+// sentimentFilter removes the retweet candidates whose score
+// is not between 'min' and 'max'.
+type sentimentFilter struct {
+	scorer SentimentScorer
+	min    float64
+	max    float64
+}
+
+// defaultPositiveWords and defaultNegativeWords are the english
+// words of the default lexicon scorer.
+var (
+	defaultPositiveWords = []string{
+		"amazing", "awesome", "beautiful", "best", "brilliant", "congrats",
+		"congratulations", "excellent", "fantastic", "glad", "good", "great",
+		"happy", "incredible", "inspiring", "love", "lovely", "nice", "perfect",
+		"thanks", "thank", "useful", "win", "wonderful", "wow",
+	}
+	defaultNegativeWords = []string{
+		"abuse", "angry", "awful", "bad", "crap", "damn", "dead", "disgusting",
+		"dumb", "fail", "fake", "hate", "horrible", "idiot", "kill", "loser",
+		"pathetic", "racist", "sad", "scam", "shame", "stupid", "terrible",
+		"ugly", "worst",
+	}
+)
+
+// lexiconScorer scores texts by counting their positive and negative words.
+type lexiconScorer struct {
+	positive map[string]bool
+	negative map[string]bool
+}
+
+// NewLexiconScorer returns a scorer counting the positive and negative words
+// of the texts, whatever their case: the score of a text is the difference
+// between its positive and negative words divided by their sum, 0 if none.
+// The default english lexicon is used if both lists are empty.
+func NewLexiconScorer(positive, negative []string) SentimentScorer {
+	if len(positive) == 0 && len(negative) == 0 {
+		positive, negative = defaultPositiveWords, defaultNegativeWords
+	}
+	scorer := &lexiconScorer{
+		positive: map[string]bool{},
+		negative: map[string]bool{},
+	}
+	for _, word := range positive {
+		scorer.positive[strings.ToLower(word)] = true
+	}
+	for _, word := range negative {
+		scorer.negative[strings.ToLower(word)] = true
+	}
+	return scorer
+}
+
+func (s *lexiconScorer) Score(text string) (float64, error) {
+	positive, negative := 0, 0
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+	for _, word := range words {
+		if s.positive[word] {
+			positive++
+		} else if s.negative[word] {
+			negative++
+		}
+	}
+	if positive+negative == 0 {
+		return 0, nil
+	}
+	return float64(positive-negative) / float64(positive+negative), nil
+}
+
+func validateSentimentFilter(min, max float64) error {
+	if min < -1 || max > 1 || min > max {
+		return fmt.Errorf("[twitter] invalid sentiment filter: scores %g to %g not between -1 and 1", min, max)
+	}
+	return nil
+}
+
+// SetSentimentFilter sets the sentiment filter of the tweets to retweet: the
+// tweets whose score by the given scorer is not between 'min' and 'max' are not
+// retweeted. A min of -0.5 skips the strongly negative tweets for instance,
+// and a min of 0.1 only keeps the positive ones. A nil scorer disables the
+// filter. The tweets the scorer fails to score are kept.
+// It returns an error, and leaves the filter unchanged, if the scores are not
+// ordered between -1 and 1.
+func (t *TwitterBot) SetSentimentFilter(scorer SentimentScorer, min, max float64) error {
+	err := validateSentimentFilter(min, max)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] setting sentiment filter -> min: %g, max: %g", min, max)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if scorer == nil {
+		t.sentiment = nil
+		return nil
+	}
+	t.sentiment = &sentimentFilter{
+		scorer: scorer,
+		min:    min,
+		max:    max,
+	}
+	return nil
+}
+
+func (t *TwitterBot) getSentimentFilter() *sentimentFilter {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.sentiment
+}
+
+// removeBySentiment removes the tweets rejected by the sentiment filter.
+func (t *TwitterBot) removeBySentiment(current []anaconda.Tweet) []anaconda.Tweet {
+	filter := t.getSentimentFilter()
+	if filter == nil {
+		return current
+	}
+	allowed := []anaconda.Tweet{}
+	for _, tweet := range current {
+		score, err := filter.scorer.Score(tweet.Text)
+		if err != nil {
+			logWarn("[twitter] failed to score tweet (id:%d): %v", tweet.Id, err)
+		} else if score < filter.min || score > filter.max {
+			print(t, fmt.Sprintf("[twitter] removing tweet (id:%d) of sentiment score %.2f, text:%s\n", tweet.Id, score, tweet.Text))
+			continue
+		}
+		allowed = append(allowed, tweet)
+	}
+	return allowed
+}
@@ -0,0 +1,50 @@
+package twbot
+
+import (
+	"errors"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+type failingScorer struct{}
+
+func (failingScorer) Score(text string) (float64, error) {
+	return 0, errors.New("failed")
+}
+
+func (s *MySuite) TestLexiconScorer(c *C) {
+	scorer := NewLexiconScorer(nil, nil)
+	for text, expected := range map[string]float64{
+		"Great news, I love it!":         1,
+		"This is the WORST scam ever":    -1,
+		"good or bad, who knows":         0,
+		"thanks for this great, sad day": 1.0 / 3,
+		"nothing to say":                 0,
+	} {
+		score, err := scorer.Score(text)
+		c.Assert(err, IsNil)
+		c.Assert(score, Equals, expected, Commentf(text))
+	}
+	score, err := NewLexiconScorer([]string{"Gopher"}, nil).Score("gophers and gopher")
+	c.Assert(err, IsNil)
+	c.Assert(score, Equals, 1.0)
+}
+
+func (s *MySuite) TestRemoveBySentiment(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	tweets := []anaconda.Tweet{{Id: 1, Text: "great"}, {Id: 2, Text: "neutral"}, {Id: 3, Text: "awful"}}
+	c.Assert(bot.removeBySentiment(tweets), HasLen, 3)
+
+	c.Assert(bot.SetSentimentFilter(NewLexiconScorer(nil, nil), 1, -1), ErrorMatches, `\[twitter\] invalid sentiment filter: .*`)
+	c.Assert(bot.SetSentimentFilter(NewLexiconScorer(nil, nil), -0.5, 1), IsNil)
+	c.Assert(bot.removeBySentiment(tweets), DeepEquals, tweets[:2])
+	c.Assert(bot.SetSentimentFilter(NewLexiconScorer(nil, nil), 0.1, 1), IsNil)
+	c.Assert(bot.removeBySentiment(tweets), DeepEquals, tweets[:1])
+
+	// tweets failing to be scored are kept
+	c.Assert(bot.SetSentimentFilter(failingScorer{}, 0.1, 1), IsNil)
+	c.Assert(bot.removeBySentiment(tweets), HasLen, 3)
+	c.Assert(bot.SetSentimentFilter(nil, -1, 1), IsNil)
+	c.Assert(bot.sentiment, IsNil)
+}
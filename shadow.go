@@ -0,0 +1,47 @@
+package twbot
+
+// SetShadowAccount redirects all the write actions of the bot, its tweets,
+// retweets, likes, follows, unfollows, direct messages and the others, to the
+// secondary account of the given twitter keys, a test account for instance,
+// while the reads, like the searches and the followers and friends lookups,
+// still use the account of the bot, so that a campaign can be rehearsed end
+// to end before being run on the production account. The databases record
+// the rehearsed actions as if made by the bot, so the bot should use its own
+// database paths while rehearsing. The shadow account sends its requests
+// through the http client of the bot, see WithHTTPClient, WithProxy and
+// WithTimeout. Empty keys disable the shadow account.
+func (t *TwitterBot) SetShadowAccount(consumerKey, consumerSecret, accessToken, accessSecret string) {
+	if accessToken == "" {
+		t.SetShadowClient(nil)
+		return
+	}
+	client := newAnacondaClient(consumerKey, consumerSecret, accessToken, accessSecret)
+	client.HttpClient = t.newHTTPClient(t.transport(newRateLimits()))
+	t.SetShadowClient(client)
+}
+
+// SetShadowClient is the same as SetShadowAccount but the write actions
+// are redirected to the given client, a fake for instance in unit tests.
+// A nil client disables the shadow account. The previous shadow client,
+// if any, is closed.
+func (t *TwitterBot) SetShadowClient(client TwitterClient) {
+	logInfo("[twitter] setting shadow account -> enabled: %t", client != nil)
+	t.mutex.Lock()
+	previous := t.shadow
+	t.shadow = client
+	t.mutex.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+}
+
+// writer returns the client of the write calls, the
+// shadow account if any, see SetShadowAccount.
+func (c *apiClient) writer() TwitterClient {
+	c.bot.mutex.Lock()
+	defer c.bot.mutex.Unlock()
+	if c.bot.shadow != nil {
+		return c.bot.shadow
+	}
+	return c.TwitterClient
+}
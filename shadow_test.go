@@ -0,0 +1,76 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+	. "gopkg.in/check.v1"
+)
+
+type closingClient struct {
+	*fakeClient
+	closed bool
+}
+
+func (c *closingClient) Close() {
+	c.closed = true
+}
+
+func (s *MySuite) TestShadowAccount(c *C) {
+	client := &fakeClient{
+		mentions: []anaconda.Tweet{{Id: 1, IdStr: "1", Text: "@bot hello"}},
+	}
+	bot := makeFakeBot(client)
+	bot.twitterClient = &apiClient{TwitterClient: client, bot: bot}
+	shadow := &closingClient{fakeClient: &fakeClient{}}
+	bot.SetShadowClient(shadow)
+
+	c.Assert(bot.TweetOnce(func() (string, error) { return "hello", nil }), IsNil)
+	c.Assert(bot.SendDM(1, "hello"), IsNil)
+	_, err := bot.twitterClient.Retweet(7, false)
+	c.Assert(err, IsNil)
+	// reads use the account of the bot
+	mentions, err := bot.twitterClient.GetMentionsTimeline(nil)
+	c.Assert(err, IsNil)
+	c.Assert(mentions, HasLen, 1)
+	// writes go to the shadow account
+	c.Assert(client.tweets, HasLen, 0)
+	c.Assert(client.messages, HasLen, 0)
+	c.Assert(client.retweeted, HasLen, 0)
+	c.Assert(shadow.tweets, DeepEquals, []string{"hello"})
+	c.Assert(shadow.messages, HasLen, 1)
+	c.Assert(shadow.retweeted, DeepEquals, []int64{7})
+
+	bot.SetShadowClient(nil)
+	c.Assert(shadow.closed, Equals, true)
+	c.Assert(bot.TweetOnce(func() (string, error) { return "world", nil }), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"world"})
+	c.Assert(shadow.tweets, HasLen, 1)
+}
+
+func (s *MySuite) TestShadowAccountHTTPClient(c *C) {
+	deadlines := []bool{}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		_, ok := req.Context().Deadline()
+		deadlines = append(deadlines, ok)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id": 42}`)),
+			Request:    req,
+		}, nil
+	})
+	bot := newTwitterBot("", "", "", "key", "secret", "token", "access", false,
+		WithHTTPClient(&http.Client{Transport: transport, Timeout: time.Minute}))
+	bot.SetShadowAccount("key", "secret", "shadow", "access")
+	defer bot.SetShadowClient(nil)
+	shadow := bot.shadow.(*anacondaClient)
+	c.Assert(shadow.HttpClient.Timeout, Equals, time.Duration(0))
+	tweet, err := shadow.PostTweet("hello", nil)
+	c.Assert(err, IsNil)
+	c.Assert(tweet.Id, Equals, int64(42))
+	c.Assert(deadlines, DeepEquals, []bool{true})
+}
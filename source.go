@@ -0,0 +1,430 @@
+package twbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	sourceMaxKeys = 1000 // tweeted items remembered by source
+)
+
+// ErrNoItem is returned by Source.Next when the source has no item to tweet
+// for now.
+var ErrNoItem = errors.New("[twitter] no item")
+
+// Item is a content to tweet returned by a Source.
+type Item struct {
+	// Text is the message of the tweet, without its link.
+	Text string
+	// Link is the URL appended to the message, if any.
+	Link string
+	// Image is the raw image, or media, of the tweet, if any.
+	Image []byte
+	// Key identifies the item so that it is tweeted only once, see
+	// TweetFromSourceOnce. Items without key can be tweeted several times.
+	Key string
+}
+
+// Source is a source of contents to tweet, see TweetFromSourcePeriodically.
+//
+// Next returns the next item of the source, or ErrNoItem if the source has
+// nothing to tweet for now. The sources of the package go through their items,
+// the oldest first, then return ErrNoItem at the end of each pass, so that the
+// items not tweeted yet, according to their keys, are returned again by the
+// next pass.
+type Source interface {
+	Next() (Item, error)
+}
+
+// feedSource returns the entries of an RSS or Atom feed.
+type feedSource struct {
+	bot     *TwitterBot
+	feedURL string
+	tmpl    string
+	items   []Item
+}
+
+// NewFeedSource returns the source of the entries of the RSS or Atom feed
+// of the given URL, the oldest first, each rendered by the given text/template
+// 'tmpl' executed on its FeedEntry, "{{.Title}} {{.Link}}" if empty. The feed
+// is downloaded at each pass and its entries are keyed by their ids.
+// Unlike TweetFromFeedOnce, the entries published before the first pass
+// are returned too.
+func (t *TwitterBot) NewFeedSource(feedURL, tmpl string) Source {
+	if tmpl == "" {
+		tmpl = defaultFeedTemplate
+	}
+	return &feedSource{
+		bot:     t,
+		feedURL: feedURL,
+		tmpl:    tmpl,
+	}
+}
+
+func (s *feedSource) load() ([]Item, error) {
+	render, err := template.New("feed").Parse(s.tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("[twitter] invalid feed template: %v", err)
+	}
+	entries, err := s.bot.fetchFeed(s.feedURL)
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	// feeds list the most recent entries first
+	for i := len(entries) - 1; i >= 0; i-- {
+		buffer := &bytes.Buffer{}
+		err := render.Execute(buffer, entries[i])
+		if err != nil {
+			return nil, fmt.Errorf("[twitter] invalid feed template: %v", err)
+		}
+		items = append(items, Item{
+			Text: strings.TrimSpace(buffer.String()),
+			Key:  entries[i].GUID,
+		})
+	}
+	return items, nil
+}
+
+func (s *feedSource) Next() (Item, error) {
+	if s.items == nil {
+		items, err := s.load()
+		if err != nil {
+			return Item{}, err
+		}
+		s.items = items
+	}
+	if len(s.items) == 0 {
+		s.items = nil
+		return Item{}, ErrNoItem
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+// directorySource returns the text files of a directory.
+type directorySource struct {
+	dir   string
+	names []string
+}
+
+// sourceImageExts are the extensions of the images of a directory source.
+var sourceImageExts = []string{".png", ".jpg", ".jpeg", ".gif"}
+
+// NewDirectorySource returns the source of the ".txt" files of the given
+// directory, in the order of their names and keyed by them. The text of an
+// item is the trimmed content of its file and its image, if any, the image of
+// the same name with a ".png", ".jpg", ".jpeg" or ".gif" extension. The
+// directory is listed at each pass, so that files can be added at any time.
+func NewDirectorySource(dir string) Source {
+	return &directorySource{
+		dir: dir,
+	}
+}
+
+func (s *directorySource) load() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".txt" {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+func (s *directorySource) Next() (Item, error) {
+	if s.names == nil {
+		names, err := s.load()
+		if err != nil {
+			return Item{}, err
+		}
+		s.names = names
+	}
+	if len(s.names) == 0 {
+		s.names = nil
+		return Item{}, ErrNoItem
+	}
+	name := s.names[0]
+	s.names = s.names[1:]
+	text, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return Item{}, err
+	}
+	item := Item{
+		Text: strings.TrimSpace(string(text)),
+		Key:  name,
+	}
+	base := strings.TrimSuffix(name, ".txt")
+	for _, ext := range sourceImageExts {
+		img, err := ioutil.ReadFile(filepath.Join(s.dir, base+ext))
+		if err == nil {
+			item.Image = img
+			break
+		}
+		if !os.IsNotExist(err) {
+			return Item{}, err
+		}
+	}
+	return item, nil
+}
+
+// csvRow is a row of a CSV schedule.
+type csvRow struct {
+	date  time.Time
+	key   string
+	text  string
+	link  string
+	image string
+}
+
+// csvSource returns the due rows of a CSV schedule.
+type csvSource struct {
+	path string
+	rows []csvRow
+}
+
+// csvDateLayouts are the supported layouts of the dates of a CSV schedule.
+var csvDateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+// NewCSVSource returns the source of the due rows of the CSV schedule of the
+// given path, the earliest first. Each row is made of a date, either RFC 3339
+// or local "2006-01-02 15:04" or "2006-01-02", a text and optionally a link and
+// the path of an image, relative to the schedule. Lines starting with '#' are
+// ignored. A row is due once its date is passed and keyed by its date and
+// text. The schedule is read at each pass, so that rows can be added at any
+// time.
+func NewCSVSource(path string) Source {
+	return &csvSource{
+		path: path,
+	}
+}
+
+func parseCSVDate(value string) (time.Time, error) {
+	for _, layout := range csvDateLayouts {
+		date, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("[twitter] invalid schedule date: %s", value)
+}
+
+func (s *csvSource) load() ([]csvRow, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	now := timeNow()
+	rows := []csvRow{}
+	for _, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("[twitter] invalid schedule row: %v", record)
+		}
+		date, err := parseCSVDate(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, err
+		}
+		if date.After(now) {
+			continue
+		}
+		row := csvRow{
+			date: date,
+			key:  record[0] + "," + record[1],
+			text: strings.TrimSpace(record[1]),
+		}
+		if len(record) > 2 {
+			row.link = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			row.image = strings.TrimSpace(record[3])
+			if !filepath.IsAbs(row.image) {
+				row.image = filepath.Join(filepath.Dir(s.path), row.image)
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].date.Before(rows[j].date)
+	})
+	return rows, nil
+}
+
+func (s *csvSource) Next() (Item, error) {
+	if s.rows == nil {
+		rows, err := s.load()
+		if err != nil {
+			return Item{}, err
+		}
+		s.rows = rows
+	}
+	if len(s.rows) == 0 {
+		s.rows = nil
+		return Item{}, ErrNoItem
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	item := Item{
+		Text: row.text,
+		Link: row.link,
+		Key:  row.key,
+	}
+	if row.image != "" {
+		img, err := ioutil.ReadFile(row.image)
+		if err != nil {
+			return Item{}, err
+		}
+		item.Image = img
+	}
+	return item, nil
+}
+
+// rotationSource returns its texts in turn.
+type rotationSource struct {
+	texts []string
+	next  int
+}
+
+// NewRotationSource returns the source of the given texts, in turn and
+// endlessly. Its items have no key, so that they are tweeted again and again.
+func NewRotationSource(texts ...string) Source {
+	return &rotationSource{
+		texts: append([]string{}, texts...),
+	}
+}
+
+func (s *rotationSource) Next() (Item, error) {
+	if len(s.texts) == 0 {
+		return Item{}, ErrNoItem
+	}
+	text := s.texts[s.next]
+	s.next = (s.next + 1) % len(s.texts)
+	return Item{Text: text}, nil
+}
+
+// getSourceKeys returns the keys of the tweeted items of the given source,
+// or false if none was ever recorded.
+func (t *TwitterBot) getSourceKeys(name string) (map[string]bool, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	keys, ok := t.state.Sources[name]
+	tweeted := map[string]bool{}
+	for _, key := range keys {
+		tweeted[key] = true
+	}
+	return tweeted, ok
+}
+
+// addSourceKeys records the given keys as tweeted items of the given source,
+// keeping the 'sourceMaxKeys' most recent ones, in the state database.
+func (t *TwitterBot) addSourceKeys(name string, keys ...string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.state.Sources == nil {
+		t.state.Sources = make(map[string][]string)
+	}
+	tweeted := append(t.state.Sources[name], keys...)
+	if len(tweeted) > sourceMaxKeys {
+		tweeted = tweeted[len(tweeted)-sourceMaxKeys:]
+	}
+	t.state.Sources[name] = tweeted
+	if t.statePath == "" {
+		return
+	}
+	err := t.store.Save(t.statePath, t.state)
+	if err != nil {
+		SubsystemStore.error("%v", err)
+	}
+}
+
+// tweetItem tweets the given item, with its image if any.
+func (t *TwitterBot) tweetItem(item Item) error {
+	if len(item.Image) > 0 {
+		return t.TweetImageOnce(item.Text, item.Link, string(item.Image))
+	}
+	msg := item.Text
+	if item.Link != "" {
+		msg += " " + item.Link
+	}
+	return t.TweetOnce(func() (string, error) {
+		return msg, nil
+	})
+}
+
+// TweetFromSourceOnce tweets the next item of the given source not tweeted yet,
+// if any. The keys of the tweeted items are kept by source 'name' in the state
+// database, see SetStatePath, the 'sourceMaxKeys' most recent ones.
+// It returns an error if the source or the tweet failed, the item being
+// returned again by the next pass of the source.
+func (t *TwitterBot) TweetFromSourceOnce(name string, source Source) error {
+	tweeted, _ := t.getSourceKeys(name)
+	for i := 0; i < sourceMaxKeys; i++ {
+		item, err := source.Next()
+		if errors.Is(err, ErrNoItem) {
+			SubsystemTweet.debug("[twitter] no item to tweet from source %s", name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if item.Key != "" && tweeted[item.Key] {
+			continue
+		}
+		err = t.tweetItem(item)
+		if errors.Is(err, ErrDuplicateStatus) {
+			SubsystemTweet.warn("[twitter] skipping duplicate item %s of source %s", item.Key, name)
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		if item.Key != "" {
+			t.addSourceKeys(name, item.Key)
+		}
+		return nil
+	}
+	return nil
+}
+
+// TweetFromSourcePeriodically tweets periodically the next item of the given
+// source, see TweetFromSourceOnce. A bot can be made of sources only, see
+// NewFeedSource, NewDirectorySource, NewCSVSource and NewRotationSource.
+// The tweet frequency is set up by the given 'freq' input parameter.
+// It only logs the error if the source or the tweet failed.
+func (t *TwitterBot) TweetFromSourcePeriodically(name string, source Source, freq time.Duration) {
+	t.newJob("TweetFromSourcePeriodically").runPeriodically(freq, func() error {
+		return t.TweetFromSourceOnce(name, source)
+	})
+}
+
+// TweetFromSourcePeriodicallyAsync tweets asynchronously and periodically
+// the next item of the given source, see TweetFromSourcePeriodically.
+func (t *TwitterBot) TweetFromSourcePeriodicallyAsync(name string, source Source, freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("TweetFromSourcePeriodically", freq, func(ctx context.Context) error {
+		return t.TweetFromSourceOnce(name, source)
+	})
+}
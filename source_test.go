@@ -0,0 +1,105 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// nextItems returns the items of the next pass of the given source.
+func nextItems(c *C, source Source) []Item {
+	items := []Item{}
+	for {
+		item, err := source.Next()
+		if err == ErrNoItem {
+			return items
+		}
+		c.Assert(err, IsNil)
+		items = append(items, item)
+	}
+}
+
+func (s *MySuite) TestFeedSource(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(makeRSS("b", "a")))
+	}))
+	defer server.Close()
+	bot := makeFakeBot(&fakeClient{})
+	source := bot.NewFeedSource(server.URL, "")
+	expected := []Item{
+		{Text: "a https://example.com/a", Key: "id-a"},
+		{Text: "b https://example.com/b", Key: "id-b"},
+	}
+	c.Assert(nextItems(c, source), DeepEquals, expected)
+	c.Assert(nextItems(c, source), DeepEquals, expected)
+	_, err := bot.NewFeedSource(server.URL, "{{.Title").Next()
+	c.Assert(err, ErrorMatches, `\[twitter\] invalid feed template: .*`)
+}
+
+func (s *MySuite) TestDirectorySource(c *C) {
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "2.txt"), []byte(" second \n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "1.txt"), []byte("first"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "1.png"), []byte("png"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "notes.md"), []byte("ignored"), 0644), IsNil)
+	source := NewDirectorySource(dir)
+	c.Assert(nextItems(c, source), DeepEquals, []Item{
+		{Text: "first", Image: []byte("png"), Key: "1.txt"},
+		{Text: "second", Key: "2.txt"},
+	})
+	_, err := NewDirectorySource(filepath.Join(dir, "missing")).Next()
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestCSVSource(c *C) {
+	SetClock(NewFakeClock(time.Date(2017, 3, 1, 12, 0, 0, 0, time.Local)))
+	defer SetClock(nil)
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "image.png"), []byte("png"), 0644), IsNil)
+	path := filepath.Join(dir, "schedule.csv")
+	c.Assert(ioutil.WriteFile(path, []byte(`# date, text, link, image
+2017-03-01 10:00, "second, with a comma", https://example.com
+2017-02-28, first,, image.png
+2017-03-02, future
+`), 0644), IsNil)
+	source := NewCSVSource(path)
+	c.Assert(nextItems(c, source), DeepEquals, []Item{
+		{Text: "first", Image: []byte("png"), Key: "2017-02-28,first"},
+		{Text: "second, with a comma", Link: "https://example.com", Key: "2017-03-01 10:00,second, with a comma"},
+	})
+	c.Assert(ioutil.WriteFile(path, []byte("tomorrow, text\n"), 0644), IsNil)
+	_, err := source.Next()
+	c.Assert(err, ErrorMatches, `\[twitter\] invalid schedule date: tomorrow`)
+}
+
+func (s *MySuite) TestTweetFromSourceOnce(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.state = &twitterState{SinceIDs: map[string]int64{}}
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "1.txt"), []byte("first"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "2.txt"), []byte("second"), 0644), IsNil)
+	source := NewDirectorySource(dir)
+	for i := 0; i < 3; i++ {
+		c.Assert(bot.TweetFromSourceOnce("dir", source), IsNil)
+	}
+	c.Assert(client.tweets, DeepEquals, []string{"first", "second"})
+	c.Assert(bot.state.Sources["dir"], DeepEquals, []string{"1.txt", "2.txt"})
+
+	// the tweeted items are skipped by a new source
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "3.txt"), []byte("third"), 0644), IsNil)
+	c.Assert(bot.TweetFromSourceOnce("dir", NewDirectorySource(dir)), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"first", "second", "third"})
+
+	// items without key are tweeted again and again
+	rotation := NewRotationSource("a", "b")
+	for i := 0; i < 3; i++ {
+		c.Assert(bot.TweetFromSourceOnce("rotation", rotation), IsNil)
+	}
+	c.Assert(client.tweets[3:], DeepEquals, []string{"a", "b", "a"})
+	c.Assert(bot.TweetFromSourceOnce("empty", NewRotationSource()), IsNil)
+}
@@ -0,0 +1,68 @@
+package twbot
+
+import ()
+
+const (
+	directMessagesSinceID = "direct_messages"
+	mentionsSinceID       = "mentions"
+)
+
+type twitterState struct {
+	SinceIDs map[string]int64    `json:"since_ids"`       // map timeline -> id of the last handled item
+	Feeds    map[string][]string `json:"feeds,omitempty"` // legacy map feed URL -> ids of the handled entries, moved to Sources
+	Sources  map[string][]string `json:"sources"`         // map source name, or feed URL -> keys of the tweeted items
+}
+
+// SetStatePath sets the path of the state database, where the bot keeps track
+// of the last direct messages, mentions, feed entries and source items handled
+// so that they are not handled twice after a restart. If no path is set, the state is only kept
+// in memory.
+func (t *TwitterBot) SetStatePath(statePath string) error {
+	state := &twitterState{
+		SinceIDs: make(map[string]int64),
+	}
+	err := t.loadOrCreate(statePath, state)
+	if err != nil {
+		return err
+	}
+	state.moveFeeds()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.statePath = statePath
+	t.state = state
+	return nil
+}
+
+// moveFeeds moves the handled entries of the feeds of a legacy state to
+// the sources named by their URLs, see TweetFromFeedOnce.
+func (s *twitterState) moveFeeds() {
+	for feedURL, guids := range s.Feeds {
+		if s.Sources == nil {
+			s.Sources = make(map[string][]string)
+		}
+		if _, ok := s.Sources[feedURL]; !ok {
+			s.Sources[feedURL] = guids
+		}
+	}
+	s.Feeds = nil
+}
+
+func (t *TwitterBot) getSinceID(timeline string) (int64, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	sinceID, ok := t.state.SinceIDs[timeline]
+	return sinceID, ok
+}
+
+func (t *TwitterBot) setSinceID(timeline string, sinceID int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.state.SinceIDs[timeline] = sinceID
+	if t.statePath == "" {
+		return
+	}
+	err := t.store.Save(t.statePath, t.state)
+	if err != nil {
+		SubsystemStore.error("%v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package twbot
+
+import (
+	"sort"
+	"time"
+)
+
+// CampaignStat represents the follow conversion of the follow campaigns
+// sharing the same source, like "followers:golang" for the campaigns
+// following the followers of the user found with the "golang" query.
+type CampaignStat struct {
+	Source string
+	// Followed is the number of users followed by the campaigns.
+	Followed int
+	// FollowedBack is the number of followed users who followed back.
+	FollowedBack int
+	// Rate is the ratio of followed users who followed back.
+	Rate float64
+}
+
+// CampaignStats returns the follow conversion of the follow campaigns,
+// by correlating the friends and followers databases: a followed user
+// is counted as followed back if it started following the bot 'within'
+// the given duration after being followed. Stats are sorted by source.
+// Users followed before sources were recorded are reported under an
+// empty source.
+func (t *TwitterBot) CampaignStats(within time.Duration) []CampaignStat {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	bySource := map[string]*CampaignStat{}
+	for strID, friend := range t.friends.Ids {
+		stat, ok := bySource[friend.Source]
+		if !ok {
+			stat = &CampaignStat{Source: friend.Source}
+			bySource[friend.Source] = stat
+		}
+		stat.Followed++
+		follower, ok := t.followers.Ids[strID]
+		if !ok {
+			continue
+		}
+		delay := follower.Timestamp - friend.Timestamp
+		if delay >= 0 && delay <= within.Nanoseconds() {
+			stat.FollowedBack++
+		}
+	}
+	stats := []CampaignStat{}
+	for _, stat := range bySource {
+		stat.Rate = float64(stat.FollowedBack) / float64(stat.Followed)
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Source < stats[j].Source })
+	return stats
+}
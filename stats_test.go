@@ -0,0 +1,36 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestCampaignStats(c *C) {
+	now := time.Now()
+	bot := &TwitterBot{
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				"1": {Timestamp: now.UnixNano(), Source: "followers:golang"},
+				"2": {Timestamp: now.UnixNano(), Source: "followers:golang"},
+				"3": {Timestamp: now.UnixNano(), Source: "query:space"},
+				"4": {Timestamp: now.UnixNano()},
+			},
+		},
+		followers: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				// followed back within a day
+				"1": {Timestamp: now.Add(time.Hour).UnixNano()},
+				// followed back too late
+				"3": {Timestamp: now.Add(72 * time.Hour).UnixNano()},
+				// follower before being followed
+				"4": {Timestamp: now.Add(-time.Hour).UnixNano()},
+			},
+		},
+	}
+	c.Assert(bot.CampaignStats(24*time.Hour), DeepEquals, []CampaignStat{
+		{Source: "", Followed: 1, FollowedBack: 0, Rate: 0},
+		{Source: "followers:golang", Followed: 2, FollowedBack: 1, Rate: 0.5},
+		{Source: "query:space", Followed: 1, FollowedBack: 0, Rate: 0},
+	})
+}
@@ -0,0 +1,23 @@
+package twbot
+
+import (
+	"context"
+)
+
+// botContext returns the context of the bot, done once the bot is stopped.
+func (t *TwitterBot) botContext() context.Context {
+	t.ctxOnce.Do(func() {
+		t.ctx, t.cancel = context.WithCancel(context.Background())
+	})
+	return t.ctx
+}
+
+// Stop stops all the asynchronous calls of the bot: the periodic loops,
+// the campaigns, the streams and the webhook servers return as soon as their
+// current request is done, interrupting their sleeps and waits, so that Wait
+// returns. A stopped bot cannot be restarted, use Close once it is stopped.
+func (t *TwitterBot) Stop() {
+	logInfo("[twitter] stopping bot...")
+	t.botContext()
+	t.cancel()
+}
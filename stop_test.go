@@ -0,0 +1,32 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestStop(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.defaultSleepPolicy = &SleepPolicy{}
+	bot.unfollowPolicy = &unfollowPolicy{
+		idleWait: 3 * time.Hour,
+	}
+	bot.SyncPeriodicallyAsync(time.Hour)
+	bot.CompactPeriodicallyAsync(time.Hour)
+	bot.TweetPeriodicallyAsync(func() (string, error) { return "", nil }, time.Hour)
+	campaign := bot.AutoUnfollowFriendsAsync(nil)
+
+	stopped := make(chan struct{})
+	go func() {
+		bot.Wait()
+		close(stopped)
+	}()
+	bot.Stop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		c.Fatal("stopped bot should return from Wait")
+	}
+	c.Assert(campaign.Stopped(), Equals, true)
+}
@@ -0,0 +1,247 @@
+package twbot
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/dns-gh/tojson"
+)
+
+// Store persists the databases of the bot: followers, friends, tweets, likes...
+// Each database is identified by its path, as given to the bot, and stored
+// as a JSON document. The default store saves each database in its own
+// JSON file, see SetStore to use another store.
+type Store interface {
+	// Exists returns true if the database of the given path exists.
+	Exists(path string) (bool, error)
+	// Load loads the database of the given path into 'v'.
+	Load(path string, v interface{}) error
+	// Save saves 'v' as the database of the given path.
+	Save(path string, v interface{}) error
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// NewJSONStore returns the default store of the bot, saving
+// each database in its own JSON file named after its path.
+func NewJSONStore() Store {
+	return jsonStore{}
+}
+
+// jsonStore saves each database in its own JSON file. Saves are atomic: the
+// database is written to a temporary file, synced and renamed in place of the
+// previous one, which is kept as a backup in case of corruption.
+type jsonStore struct{}
+
+const (
+	backupExt = ".bak"
+	tmpExt    = ".tmp"
+)
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s jsonStore) Exists(path string) (bool, error) {
+	ok, err := fileExists(path)
+	if ok || err != nil {
+		return ok, err
+	}
+	// a crash between the backup and the rename leaves only the backup
+	return fileExists(path + backupExt)
+}
+
+// Load loads the database of the given path, recovering it
+// from its backup if it is missing or fails to parse.
+func (s jsonStore) Load(path string, v interface{}) error {
+	err := tojson.Load(path, v)
+	if err == nil {
+		return nil
+	}
+	if ok, _ := fileExists(path + backupExt); !ok {
+		return err
+	}
+	SubsystemStore.warn("[twitter] failed to load %s, recovering from backup: %v", path, err)
+	return tojson.Load(path+backupExt, v)
+}
+
+func syncFile(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (s jsonStore) Save(path string, v interface{}) error {
+	tmp := path + tmpExt
+	err := tojson.Save(tmp, v)
+	if err == nil {
+		err = syncFile(tmp)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	ok, err := fileExists(path)
+	if err != nil {
+		return err
+	}
+	if ok {
+		err = os.Rename(path, path+backupExt)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s jsonStore) Close() error {
+	return nil
+}
+
+// SetStore sets the store persisting the databases of the bot and closes the
+// previous one. The followers and friends databases are synchronized again
+// from twitter into the new store. The other databases are loaded from the
+// current store when their path is set, so SetStore should be called first,
+// see MigrateStore to keep the history of the previous store.
+func (t *TwitterBot) SetStore(store Store) error {
+	logInfo("[twitter] setting store -> %T", store)
+	t.mutex.Lock()
+	previous := t.store
+	if dryRun, ok := previous.(*dryRunStore); ok {
+		// keep the new store untouched until the end of the dry run
+		previous = dryRun.Store
+		store = newDryRunStore(store)
+	}
+	t.store = store
+	t.mutex.Unlock()
+	err := previous.Close()
+	if err != nil {
+		SubsystemStore.error("%v", err)
+	}
+	return t.Sync()
+}
+
+// loadOrCreate loads the database of the given path into 'v',
+// saving 'v' as the initial database if it does not exist yet.
+func (t *TwitterBot) loadOrCreate(path string, v interface{}) error {
+	ok, err := t.store.Exists(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return t.store.Save(path, v)
+	}
+	return t.store.Load(path, v)
+}
+
+// database is a database of the bot in the store, see databases.
+type database struct {
+	path string
+	// empty returns the empty database to load and set keeps the loaded
+	// database in memory, both nil if the database is only kept in the store.
+	empty func() interface{}
+	set   func(v interface{})
+}
+
+// databases returns the databases of the bot, a database without path
+// being disabled. It must be called with the bot mutex locked.
+func (t *TwitterBot) databases() []database {
+	return []database{
+		{t.followersPath, func() interface{} {
+			return &twitterUsers{Ids: make(map[string]*twitterUser)}
+		}, func(v interface{}) { t.followers = v.(*twitterUsers) }},
+		{t.friendsPath, func() interface{} {
+			return &twitterUsers{Ids: make(map[string]*twitterUser)}
+		}, func(v interface{}) { t.friends = v.(*twitterUsers) }},
+		{t.tweetsPath, nil, nil},
+		{t.likesPath, func() interface{} {
+			return &twitterLikes{Ids: make(map[string]int64)}
+		}, func(v interface{}) { t.likes = v.(*twitterLikes) }},
+		{t.whitelistPath, func() interface{} {
+			return &twitterWhitelist{Ids: make(map[string]string)}
+		}, func(v interface{}) { t.whitelist = v.(*twitterWhitelist) }},
+		{t.statePath, func() interface{} {
+			return &twitterState{SinceIDs: make(map[string]int64)}
+		}, func(v interface{}) {
+			state := v.(*twitterState)
+			state.moveFeeds()
+			t.state = state
+		}},
+		{t.growthPath, func() interface{} {
+			return &twitterGrowth{}
+		}, func(v interface{}) { t.growth = v.(*twitterGrowth) }},
+		{t.blocksPath, func() interface{} {
+			return &twitterBlocks{Blocked: make(map[string]int64), Muted: make(map[string]int64)}
+		}, func(v interface{}) { t.blocks = v.(*twitterBlocks) }},
+		{t.campaignsPath, func() interface{} {
+			return &twitterCampaigns{Campaigns: make(map[string]*campaignProgress)}
+		}, func(v interface{}) { t.campaigns = v.(*twitterCampaigns) }},
+		{t.engagementPath, func() interface{} {
+			return &twitterEngagement{Tweets: make(map[string]*TweetEngagement)}
+		}, func(v interface{}) { t.engagement = v.(*twitterEngagement) }},
+		{t.queuePath, func() interface{} {
+			return &twitterQueue{}
+		}, func(v interface{}) { t.queue = v.(*twitterQueue) }},
+	}
+}
+
+// databasePaths returns the paths of the databases of the bot.
+func (t *TwitterBot) databasePaths() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	paths := []string{}
+	for _, db := range t.databases() {
+		if db.path != "" {
+			paths = append(paths, db.path)
+		}
+	}
+	return paths
+}
+
+// MigrateStore copies the databases of the bot, as set up by MakeTwitterBot
+// and the Set*Path methods, from the store 'from' to the store 'to', as is so
+// that no timestamp is lost. Databases missing from 'from' are skipped.
+// For instance, to move the JSON files of a bot to a bbolt file:
+//
+//  bot := twbot.MakeTwitterBot("followers.json", "friends.json", "tweets.json", false)
+//  bot.SetLikesPath("likes.json")
+//  store, err := twbot.OpenBoltStore("twbot.db")
+//  ...
+//  err = bot.MigrateStore(twbot.NewJSONStore(), store)
+//  ...
+//  err = bot.SetStore(store)
+//
+// The paths remain the keys of the databases in the new store.
+func (t *TwitterBot) MigrateStore(from, to Store) error {
+	for _, path := range t.databasePaths() {
+		ok, err := from.Exists(path)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		data := json.RawMessage{}
+		err = from.Load(path, &data)
+		if err != nil {
+			return err
+		}
+		err = to.Save(path, data)
+		if err != nil {
+			return err
+		}
+		SubsystemStore.info("[twitter] migrated database %s", path)
+	}
+	return nil
+}
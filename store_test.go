@@ -0,0 +1,111 @@
+package twbot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func testStore(c *C, store Store, path string) {
+	ok, err := store.Exists(path)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+	c.Assert(store.Load(path, &twitterUsers{}), NotNil)
+
+	friends := &twitterUsers{
+		Ids: map[string]*twitterUser{"1": {Timestamp: 42, Follow: true}},
+	}
+	c.Assert(store.Save(path, friends), IsNil)
+	ok, err = store.Exists(path)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	loaded := &twitterUsers{}
+	c.Assert(store.Load(path, loaded), IsNil)
+	c.Assert(loaded, DeepEquals, friends)
+	c.Assert(store.Close(), IsNil)
+}
+
+func (s *MySuite) TestJSONStore(c *C) {
+	testStore(c, jsonStore{}, filepath.Join(c.MkDir(), "friends.json"))
+}
+
+func (s *MySuite) TestBoltStore(c *C) {
+	filename := filepath.Join(c.MkDir(), "twbot.db")
+	store, err := OpenBoltStore(filename)
+	c.Assert(err, IsNil)
+	testStore(c, store, "friends.json")
+
+	// databases are kept across reopenings
+	store, err = OpenBoltStore(filename)
+	c.Assert(err, IsNil)
+	defer store.Close()
+	loaded := &twitterUsers{}
+	c.Assert(store.Load("friends.json", loaded), IsNil)
+	c.Assert(loaded.Ids["1"].Timestamp, Equals, int64(42))
+}
+
+func (s *MySuite) TestJSONStoreRecovery(c *C) {
+	path := filepath.Join(c.MkDir(), "friends.json")
+	store := jsonStore{}
+	first := &twitterUsers{
+		Ids: map[string]*twitterUser{"1": {Timestamp: 1, Follow: true}},
+	}
+	second := &twitterUsers{
+		Ids: map[string]*twitterUser{"2": {Timestamp: 2, Follow: true}},
+	}
+	c.Assert(store.Save(path, first), IsNil)
+	c.Assert(store.Save(path, second), IsNil)
+	_, err := os.Stat(path + tmpExt)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// a corrupted database is recovered from its backup
+	c.Assert(ioutil.WriteFile(path, []byte(`{"ids":{"2":`), 0644), IsNil)
+	loaded := &twitterUsers{}
+	c.Assert(store.Load(path, loaded), IsNil)
+	c.Assert(loaded, DeepEquals, first)
+
+	// as well as a missing one
+	c.Assert(os.Remove(path), IsNil)
+	ok, err := store.Exists(path)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	loaded = &twitterUsers{}
+	c.Assert(store.Load(path, loaded), IsNil)
+	c.Assert(loaded, DeepEquals, first)
+}
+
+func (s *MySuite) TestMigrateStore(c *C) {
+	dir := c.MkDir()
+	bot := makeFakeBot(&fakeClient{})
+	bot.followersPath = filepath.Join(dir, "followers.json")
+	bot.friendsPath = filepath.Join(dir, "friends.json")
+	bot.likesPath = filepath.Join(dir, "likes.json")
+	followers := &twitterUsers{
+		Ids: map[string]*twitterUser{"1": {Timestamp: 42, Follow: true, FollowedBack: true}},
+	}
+	c.Assert(bot.store.Save(bot.followersPath, followers), IsNil)
+	c.Assert(bot.store.Save(bot.friendsPath, &twitterUsers{Ids: map[string]*twitterUser{}}), IsNil)
+
+	to, err := OpenBoltStore(filepath.Join(dir, "twbot.db"))
+	c.Assert(err, IsNil)
+	defer to.Close()
+	c.Assert(bot.MigrateStore(bot.store, to), IsNil)
+	loaded := &twitterUsers{}
+	c.Assert(to.Load(bot.followersPath, loaded), IsNil)
+	c.Assert(loaded, DeepEquals, followers)
+	ok, err := to.Exists(bot.friendsPath)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	// the likes database does not exist yet
+	ok, err = to.Exists(bot.likesPath)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MySuite) TestMemStore(c *C) {
+	testStore(c, NewMemStore(), "friends.json")
+	_, err := os.Stat("friends.json")
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
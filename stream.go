@@ -0,0 +1,186 @@
+package twbot
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	minStreamBackoff = 5 * time.Second
+	maxStreamBackoff = 320 * time.Second
+)
+
+// Stream represents a running twitter stream, reconnected automatically
+// with an exponential backoff when the connection is lost.
+// It allows to stop the stream, each handled tweet being a run of its job.
+type Stream struct {
+	*Job
+}
+
+// newStream creates a stream stopped when the given context is done.
+func newStream(ctx context.Context, name string) *Stream {
+	return &Stream{
+		Job: newJob(ctx, name),
+	}
+}
+
+// newStream creates a stream stopped when the bot is stopped, see Stop.
+func (t *TwitterBot) newStream(name string) *Stream {
+	return newStream(t.botContext(), name)
+}
+
+// listen calls the handler on the tweets of the given stream until the
+// connection is lost or the stream is stopped. It returns true if at least
+// one message was received and false as second value if the stream is stopped.
+func (s *Stream) listen(stream *anaconda.Stream, handler func(tweet anaconda.Tweet)) (bool, bool) {
+	received := false
+	for {
+		select {
+		case msg, ok := <-stream.C:
+			if !ok {
+				return received, true
+			}
+			received = true
+			if tweet, ok := msg.(anaconda.Tweet); ok {
+				handler(tweet)
+				s.record(nil)
+			}
+		case <-s.ctx.Done():
+			stream.Stop()
+			// drain the stream so that its loop can terminate
+			go func() {
+				for _ = range stream.C {
+				}
+			}()
+			return received, false
+		}
+	}
+}
+
+// run opens the stream with the given 'open' callback and reconnects it
+// with an exponential backoff until it is stopped.
+func (s *Stream) run(open func() *anaconda.Stream, handler func(tweet anaconda.Tweet)) {
+	backoff := minStreamBackoff
+	for {
+		received, running := s.listen(open(), handler)
+		if !running {
+			return
+		}
+		if received {
+			backoff = minStreamBackoff
+		}
+		logWarn("[twitter] stream %s disconnected, reconnecting in %s...", s.Name(), backoff)
+		if !sleepContext(s.ctx, backoff) {
+			return
+		}
+		backoff *= 2
+		if backoff > maxStreamBackoff {
+			backoff = maxStreamBackoff
+		}
+	}
+}
+
+func (t *TwitterBot) streamAsync(stream *Stream, open func() *anaconda.Stream, handler func(tweet anaconda.Tweet)) *Stream {
+	t.run(stream.Job, func() error {
+		logInfo("[twitter] launching stream %s...", stream.Name())
+		stream.run(open, handler)
+		logInfo("[twitter] stream %s stopped", stream.Name())
+		return nil
+	})
+	return stream
+}
+
+// StreamFilterAsync calls asynchronously the given 'handler' on the tweets
+// matching the 'track' keywords as soon as they are posted, using the filter
+// streaming endpoint, so that the bot reacts in real time instead of polling.
+// The stream is reconnected automatically with an exponential backoff.
+// The returned stream allows to stop it.
+func (t *TwitterBot) StreamFilterAsync(track []string, handler func(tweet anaconda.Tweet)) *Stream {
+	v := url.Values{}
+	v.Set("track", strings.Join(track, ","))
+	open := func() *anaconda.Stream {
+		return t.twitterClient.PublicStreamFilter(v)
+	}
+	return t.streamAsync(t.newStream("filter:"+v.Get("track")), open, handler)
+}
+
+// StreamUserAsync calls asynchronously the given 'handler' on the tweets of
+// the timeline of the authenticated user as soon as they are posted, using the
+// user streaming endpoint, see StreamFilterAsync.
+func (t *TwitterBot) StreamUserAsync(handler func(tweet anaconda.Tweet)) *Stream {
+	open := func() *anaconda.Stream {
+		return t.twitterClient.UserStream(url.Values{})
+	}
+	return t.streamAsync(t.newStream("user"), open, handler)
+}
+
+// streamRetweeter keeps track of the tweets retweeted from a stream.
+type streamRetweeter struct {
+	banned      []string
+	policy      *retweetPolicy
+	minInterval time.Duration
+	last        time.Time
+}
+
+// retweetStreamed retweets the streamed tweet, or the original tweet if it is
+// a retweet, unless it is banned, filtered out like the searched tweets,
+// already retweeted or streamed too soon.
+func (t *TwitterBot) retweetStreamed(r *streamRetweeter, tweet anaconda.Tweet) error {
+	if tweet.RetweetedStatus != nil {
+		tweet = *tweet.RetweetedStatus
+	}
+	if timeSince(r.last) < r.minInterval {
+		return nil
+	}
+	// the tweets database is shared with the retweet schedules
+	previous, err := t.loadTweets()
+	if err != nil {
+		return err
+	}
+	current := t.removeBanned([]anaconda.Tweet{tweet}, r.banned)
+	current = t.removeBySentiment(current)
+	current = t.removeByContent(current)
+	current = t.removeDuplicates(current)
+	current = t.takeDifference(previous, current)
+	if len(current) == 0 {
+		return nil
+	}
+	_, err = t.retweet(current, r.policy)
+	if err != nil {
+		return err
+	}
+	r.last = timeNow()
+	// save the original tweet so that its retweets
+	// streamed afterwards are detected as duplicates
+	return t.saveRetweeted(tweet)
+}
+
+// RetweetFromStreamAsync retweets asynchronously the tweets matching the 'track'
+// keywords within seconds of posting, see StreamFilterAsync. Streamed tweets go
+// through the same pipeline as the searched ones: tweets matching one of the
+// 'bannedQueries', filtered out by the content and sentiment filters, see
+// SetContentFilter and SetSentimentFilter, or already retweeted are skipped. The retweet behavior is
+// controlled by the given 'policy'. The returned stream allows to stop it.
+func (t *TwitterBot) RetweetFromStreamAsync(track, bannedQueries []string, policy RetweetPolicy) *Stream {
+	SubsystemRetweet.info("[twitter] retweeting from stream -> like: %t, quoteEvery: %d, quoteTemplate: %s, minInterval: %s",
+		policy.Like, policy.QuoteEvery, policy.QuoteTemplate, policy.MinInterval)
+	retweeter := &streamRetweeter{
+		banned: append([]string{}, bannedQueries...),
+		policy: &retweetPolicy{
+			like:          policy.Like,
+			quoteEvery:    policy.QuoteEvery,
+			quoteTemplate: policy.QuoteTemplate,
+		},
+		minInterval: policy.MinInterval,
+	}
+	return t.StreamFilterAsync(track, func(tweet anaconda.Tweet) {
+		err := t.retweetStreamed(retweeter, tweet)
+		if err != nil {
+			SubsystemRetweet.error("%v", err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package twbot
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestStreamListen(c *C) {
+	stream := newStream(context.Background(), "test")
+	handled := []int64{}
+	handler := func(tweet anaconda.Tweet) {
+		handled = append(handled, tweet.Id)
+	}
+	// non tweet messages are ignored and a closed connection is reconnected
+	messages := make(chan interface{}, 3)
+	messages <- anaconda.Tweet{Id: 1}
+	messages <- anaconda.LimitNotice{Track: 10}
+	messages <- anaconda.Tweet{Id: 2}
+	close(messages)
+	received, running := stream.listen(&anaconda.Stream{C: messages}, handler)
+	c.Assert(received, Equals, true)
+	c.Assert(running, Equals, true)
+	c.Assert(handled, DeepEquals, []int64{1, 2})
+
+	// a stopped stream is not reconnected
+	stream.Stop()
+	c.Assert(stream.Stopped(), Equals, true)
+	received, running = stream.listen(&anaconda.Stream{C: make(chan interface{})}, handler)
+	c.Assert(received, Equals, false)
+	c.Assert(running, Equals, false)
+	stream.run(func() *anaconda.Stream {
+		return &anaconda.Stream{C: make(chan interface{})}
+	}, handler)
+}
+
+func (s *MySuite) TestRetweetStreamed(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.tweetsPath = filepath.Join(c.MkDir(), "tweets.json")
+	bot.blocks = &twitterBlocks{
+		Blocked: map[string]int64{},
+		Muted:   map[string]int64{},
+	}
+	// the authors are already followed
+	bot.friends.Ids["10"] = &twitterUser{Follow: true}
+	retweeter := &streamRetweeter{
+		banned: []string{"spam"},
+		policy: &retweetPolicy{},
+	}
+	author := anaconda.User{Id: 10}
+	original := anaconda.Tweet{Id: 1, Text: "news", User: author}
+	c.Assert(bot.retweetStreamed(retweeter, original), IsNil)
+	// retweets of an already retweeted tweet are skipped
+	c.Assert(bot.retweetStreamed(retweeter, anaconda.Tweet{Id: 2, RetweetedStatus: &original, User: author}), IsNil)
+	c.Assert(bot.retweetStreamed(retweeter, anaconda.Tweet{Id: 3, Text: "spam", User: author}), IsNil)
+	c.Assert(client.retweeted, DeepEquals, []int64{1})
+
+	// tweets streamed too soon are skipped
+	retweeter.minInterval = time.Hour
+	c.Assert(bot.retweetStreamed(retweeter, anaconda.Tweet{Id: 4, Text: "other news", User: author}), IsNil)
+	c.Assert(client.retweeted, DeepEquals, []int64{1})
+
+	// the tweets retweeted meanwhile by the schedules are skipped and kept
+	retweeter.minInterval = 0
+	scheduled := anaconda.Tweet{Id: 5, Text: "scheduled news", User: author}
+	c.Assert(bot.saveRetweeted(scheduled), IsNil)
+	c.Assert(bot.retweetStreamed(retweeter, scheduled), IsNil)
+	c.Assert(client.retweeted, DeepEquals, []int64{1})
+	c.Assert(bot.retweetStreamed(retweeter, anaconda.Tweet{Id: 6, Text: "latest news", User: author}), IsNil)
+	c.Assert(client.retweeted, DeepEquals, []int64{1, 6})
+	tweets, err := bot.loadTweets()
+	c.Assert(err, IsNil)
+	ids := []int64{}
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.Id)
+	}
+	c.Assert(ids, DeepEquals, []int64{1, 5, 6})
+
+	// tweets blocked by the content filter are skipped
+	c.Assert(bot.SetContentFilter("en"), IsNil)
+	c.Assert(bot.retweetStreamed(retweeter, anaconda.Tweet{Id: 7, Text: "shit news", User: author}), IsNil)
+	c.Assert(client.retweeted, DeepEquals, []int64{1, 6})
+	c.Assert(bot.ContentReport(), HasLen, 1)
+}
@@ -0,0 +1,99 @@
+package twbot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DailySummary summarizes the activity of the bot since midnight,
+// see GetDailySummary.
+type DailySummary struct {
+	Day          time.Time
+	NewFollowers int
+	Unfollowers  int
+	// Tweets and Retweets count the tweets and retweets posted by the bot,
+	// see Stats.
+	Tweets   int
+	Retweets int
+	// TopTweet is the tweet of the day with the most engagement, if any,
+	// see TrackEngagement.
+	TopTweet *TweetEngagement
+}
+
+// String returns the text of the summary sent by SendDailySummary.
+func (s DailySummary) String() string {
+	text := fmt.Sprintf("summary of %s: %d new followers, %d unfollowers, %d tweets, %d retweets",
+		s.Day.Format("2006-01-02"), s.NewFollowers, s.Unfollowers, s.Tweets, s.Retweets)
+	if s.TopTweet != nil {
+		text += fmt.Sprintf(", top tweet (id:%d) with %d likes, %d retweets and %d replies",
+			s.TopTweet.ID, s.TopTweet.Favorites, s.TopTweet.Retweets, s.TopTweet.Replies)
+	}
+	return text
+}
+
+// GetDailySummary returns the summary of the activity of the bot since
+// midnight: its new followers and unfollowers according to the followers
+// database, the tweets and retweets it posted and its top tweet.
+func (t *TwitterBot) GetDailySummary() DailySummary {
+	now := timeNow()
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	stats := t.Stats()
+	summary := DailySummary{
+		Day:          midnight,
+		NewFollowers: len(t.getNewFollowerIDs(midnight)),
+		Unfollowers:  len(t.getUnfollowerIDs(midnight)),
+		Tweets:       stats.ActionsToday[ActionTweet],
+		Retweets:     stats.ActionsToday[ActionRetweet],
+	}
+	top := t.TopTweets(now.Sub(midnight))
+	if len(top) > 0 {
+		summary.TopTweet = &top[0]
+	}
+	return summary
+}
+
+// SendDailySummary sends the summary of the day, see GetDailySummary, to
+// the owner of the bot as a direct message, see SetOwner, or tweets it if
+// 'public' is true. It returns an error if the summary is not public and
+// the bot has no owner, or if the direct message or the tweet failed.
+func (t *TwitterBot) SendDailySummary(public bool) error {
+	return t.sendDailySummary(t.botContext(), public)
+}
+
+func (t *TwitterBot) sendDailySummary(ctx context.Context, public bool) error {
+	text := t.GetDailySummary().String()
+	if public {
+		return t.TweetOnceCtx(ctx, func() (string, error) {
+			return text, nil
+		})
+	}
+	owner := t.getOwner()
+	if owner == "" {
+		return fmt.Errorf("[twitter] no owner to send the daily summary to")
+	}
+	return t.SendDMToScreenName(owner, "[twbot] "+text)
+}
+
+// SendDailySummaryAt sends the summary of the day every day at the given
+// time of day 'at', a duration since midnight in local time, 23h55m for
+// instance, see SendDailySummary. It logs errors if the summary failed.
+func (t *TwitterBot) SendDailySummaryAt(at time.Duration, public bool) {
+	job := t.newJob("SendDailySummary")
+	job.runDaily(at, func() error {
+		return t.sendDailySummary(job.ctx, public)
+	})
+}
+
+// SendDailySummaryAtAsync sends asynchronously the summary
+// of the day every day, see SendDailySummaryAt.
+func (t *TwitterBot) SendDailySummaryAtAsync(at time.Duration, public bool) *Job {
+	job := t.newJob("SendDailySummary")
+	return t.run(job, func() error {
+		job.runDaily(at, func() error {
+			return t.sendDailySummary(job.ctx, public)
+		})
+		return nil
+	})
+}
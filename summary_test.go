@@ -0,0 +1,51 @@
+package twbot
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestNextDailyRun(c *C) {
+	now := time.Date(2017, 3, 1, 10, 30, 0, 0, time.UTC)
+	c.Assert(nextDailyRun(now, 23*time.Hour), Equals, time.Date(2017, 3, 1, 23, 0, 0, 0, time.UTC))
+	c.Assert(nextDailyRun(now, 10*time.Hour+30*time.Minute), Equals, time.Date(2017, 3, 2, 10, 30, 0, 0, time.UTC))
+	c.Assert(nextDailyRun(now, time.Hour), Equals, time.Date(2017, 3, 2, 1, 0, 0, 0, time.UTC))
+}
+
+func (s *MySuite) TestDailySummary(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.noSleep = true
+	bot.engagement = &twitterEngagement{Tweets: map[string]*TweetEngagement{}}
+	now := time.Now()
+	yesterday := now.Add(-48 * time.Hour).UnixNano()
+	bot.followers.Ids["1"] = &twitterUser{Follow: true, Timestamp: now.UnixNano()}
+	bot.followers.Ids["2"] = &twitterUser{Follow: true, Timestamp: yesterday}
+	bot.followers.Ids["3"] = &twitterUser{Follow: false, Timestamp: yesterday, Unfollowed: now.UnixNano()}
+	bot.actions.record(ActionTweet, Event{})
+	bot.actions.record(ActionRetweet, Event{})
+	bot.actions.record(ActionRetweet, Event{})
+	bot.actions.record(ActionRetweet, Event{Action: ActionRetweet + "_failed", Error: "failed"})
+	bot.engagement.Tweets["10"] = &TweetEngagement{ID: 10, Created: now, Favorites: 3, Retweets: 2, Replies: 1}
+	bot.engagement.Tweets["11"] = &TweetEngagement{ID: 11, Created: now, Favorites: 1}
+
+	summary := bot.GetDailySummary()
+	c.Assert(summary.NewFollowers, Equals, 1)
+	c.Assert(summary.Unfollowers, Equals, 1)
+	c.Assert(summary.Tweets, Equals, 1)
+	c.Assert(summary.Retweets, Equals, 2)
+	c.Assert(summary.TopTweet, NotNil)
+	c.Assert(summary.TopTweet.ID, Equals, int64(10))
+	c.Assert(strings.HasSuffix(summary.String(), ": 1 new followers, 1 unfollowers, 1 tweets, 2 retweets, "+
+		"top tweet (id:10) with 3 likes, 2 retweets and 1 replies"), Equals, true)
+
+	// sent to the owner or tweeted
+	c.Assert(bot.SendDailySummary(false), ErrorMatches, ".*no owner.*")
+	bot.SetOwner("owner")
+	c.Assert(bot.SendDailySummary(false), IsNil)
+	c.Assert(client.messages, DeepEquals, []string{"owner: [twbot] " + summary.String()})
+	c.Assert(bot.SendDailySummary(true), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{summary.String()})
+}
@@ -0,0 +1,140 @@
+package twbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getNewFollowerIDs returns the ids of the users following the bot
+// who were first seen as followers since the given time, the oldest first.
+func (t *TwitterBot) getNewFollowerIDs(since time.Time) []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	timestamps := map[int64]int64{}
+	for strID, user := range t.followers.Ids {
+		if !user.Follow || user.Timestamp < since.UnixNano() {
+			continue
+		}
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			logError("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+		timestamps[id] = user.Timestamp
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if timestamps[ids[i]] != timestamps[ids[j]] {
+			return timestamps[ids[i]] < timestamps[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// chunkMentions returns the tweets made of the given 'message' followed by
+// the mentions of the given screen names, as many per tweet as fit in
+// 'maxSize' characters.
+func chunkMentions(message string, screenNames []string, maxSize int) []string {
+	tweets := []string{}
+	current := ""
+	for _, screenName := range screenNames {
+		mention := " @" + screenName
+		if current != "" && len([]rune(current+mention)) > maxSize {
+			tweets = append(tweets, current)
+			current = ""
+		}
+		if current == "" {
+			current = message
+		}
+		current += mention
+	}
+	if current != "" {
+		tweets = append(tweets, current)
+	}
+	return tweets
+}
+
+// ThankNewFollowersOnce tweets the given 'message', "thanks to my new
+// followers" for instance, followed by the mentions of the users who started
+// following the bot since the given time, according to the followers database.
+// Mentions are split into as many tweets as needed to fit the tweet length
+// limit. The users of the 'exclude' screen names, as well as blocked and muted
+// users, are not mentioned.
+// It returns an error if the lookup of the new followers failed and only logs
+// errors for each failed tweet tentative.
+func (t *TwitterBot) ThankNewFollowersOnce(message string, since time.Time, exclude []string) error {
+	users, err := t.LookupUsers(t.getNewFollowerIDs(since))
+	if err != nil {
+		return err
+	}
+	excluded := map[string]bool{}
+	for _, screenName := range exclude {
+		excluded[strings.ToLower(strings.TrimPrefix(screenName, "@"))] = true
+	}
+	screenNames := []string{}
+	for _, user := range users {
+		if excluded[strings.ToLower(user.ScreenName)] || t.isBlockedOrMuted(user.Id) {
+			continue
+		}
+		screenNames = append(screenNames, user.ScreenName)
+	}
+	if len(screenNames) == 0 {
+		SubsystemTweet.info("[twitter] no new followers to thank")
+		return nil
+	}
+	for _, msg := range chunkMentions(message, screenNames, tweetTextMaxSize) {
+		tweet, err := t.twitterClient.PostTweet(msg, nil)
+		if err != nil {
+			t.checkBotRestriction(err)
+			continue
+		}
+		print(t, fmt.Sprintf("tweeting message (id: %d): %s\n", tweet.Id, tweet.Text))
+	}
+	SubsystemTweet.info("[twitter] thanked %d new follower(s)", len(screenNames))
+	return nil
+}
+
+// ThankNewFollowersPeriodically updates periodically the followers database
+// and thanks the users who started following the bot since the last thanks,
+// see ThankNewFollowersOnce. A weekly frequency is a good choice.
+// The thanks frequency is set up by the given 'freq' input parameter.
+// It logs errors if the update or the thanks failed.
+func (t *TwitterBot) ThankNewFollowersPeriodically(message string, exclude []string, freq time.Duration) {
+	t.newJob("ThankNewFollowersPeriodically").runPeriodically(freq, t.thankNewFollowers(message, exclude))
+}
+
+// thankNewFollowers returns a callback thanking at each call
+// the users who started following the bot since the last thanks.
+func (t *TwitterBot) thankNewFollowers(message string, exclude []string) func() error {
+	since := timeNow()
+	return func() error {
+		err := t.updateFollowers()
+		if err != nil {
+			return err
+		}
+		now := timeNow()
+		err = t.ThankNewFollowersOnce(message, since, exclude)
+		if err != nil {
+			return err
+		}
+		since = now
+		return nil
+	}
+}
+
+// ThankNewFollowersPeriodicallyAsync thanks asynchronously and periodically
+// the new followers of the bot, see ThankNewFollowersPeriodically.
+func (t *TwitterBot) ThankNewFollowersPeriodicallyAsync(message string, exclude []string, freq time.Duration) *Job {
+	excludeCopy := make([]string, len(exclude))
+	copy(excludeCopy, exclude)
+	thank := t.thankNewFollowers(message, excludeCopy)
+	return t.runPeriodicallyAsync("ThankNewFollowersPeriodically", freq, func(ctx context.Context) error {
+		return thank()
+	})
+}
@@ -0,0 +1,38 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestChunkMentions(c *C) {
+	c.Assert(chunkMentions("thanks", nil, 20), HasLen, 0)
+	c.Assert(chunkMentions("thanks", []string{"a", "bb", "ccc", "dddd"}, 16), DeepEquals, []string{
+		"thanks @a @bb",
+		"thanks @ccc",
+		"thanks @dddd",
+	})
+}
+
+func (s *MySuite) TestThankNewFollowers(c *C) {
+	client := &fakeClient{}
+	bot := makeFakeBot(client)
+	bot.blocks = &twitterBlocks{
+		Blocked: map[string]int64{"4": 1},
+		Muted:   map[string]int64{},
+	}
+	since := time.Now()
+	old := since.Add(-time.Hour).UnixNano()
+	bot.followers.Ids = map[string]*twitterUser{
+		"1": {Timestamp: old, Follow: true},
+		"2": {Timestamp: since.Add(2 * time.Second).UnixNano(), Follow: true},
+		"3": {Timestamp: since.Add(time.Second).UnixNano(), Follow: true},
+		"4": {Timestamp: since.Add(time.Second).UnixNano(), Follow: true},
+		"5": {Timestamp: since.Add(time.Second).UnixNano(), Follow: true},
+		"6": {Timestamp: since.Add(time.Second).UnixNano(), Follow: false},
+	}
+	c.Assert(bot.getNewFollowerIDs(since), DeepEquals, []int64{3, 4, 5, 2})
+	c.Assert(bot.ThankNewFollowersOnce("thanks to my new followers", since, []string{"@User5"}), IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"thanks to my new followers @user3 @user2"})
+}
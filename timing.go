@@ -0,0 +1,83 @@
+package twbot
+
+import (
+	"time"
+)
+
+const (
+	defaultUnableToFollowWait = 15 * time.Minute
+	defaultQuotaWait          = 15 * time.Minute
+	defaultFollowBackWait     = 1 * time.Hour
+	// timeSleepBetweenFollowUnFollow is the default FollowUnfollowWait.
+	timeSleepBetweenFollowUnFollow = 300 * time.Second
+)
+
+// Timing holds the waits of the bot, see SetTiming.
+// Zero durations keep their default.
+type Timing struct {
+	// UnableToFollowWait is the wait once twitter refuses to follow
+	// more users at this time, 15 minutes by default.
+	UnableToFollowWait time.Duration
+	// QuotaWait is the wait between two checks of the follow quota once
+	// exhausted, see SetFollowQuota, 15 minutes by default.
+	QuotaWait time.Duration
+	// FollowBackWait is the wait of the auto follow back once all
+	// the followers are followed back, 1 hour by default.
+	FollowBackWait time.Duration
+	// UnfollowIdleWait is the time between two runs of the auto unfollow,
+	// 3 hours by default, see SetUnfollowIdleWait.
+	UnfollowIdleWait time.Duration
+	// FollowUnfollowWait is the minimum wait between a follow and the next
+	// unfollow, so that the bot does not unfollow users right after
+	// following others, 5 minutes by default.
+	FollowUnfollowWait time.Duration
+}
+
+// SetTiming sets the waits of the bot, so that its cadence can be tuned to the
+// limits of the account. The waits only apply to the waits started afterwards.
+func (t *TwitterBot) SetTiming(timing Timing) {
+	logInfo("[twitter] setting timing -> unableToFollowWait: %s, quotaWait: %s, followBackWait: %s, unfollowIdleWait: %s, followUnfollowWait: %s",
+		timing.UnableToFollowWait, timing.QuotaWait, timing.FollowBackWait, timing.UnfollowIdleWait, timing.FollowUnfollowWait)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if timing.UnableToFollowWait > 0 {
+		t.timing.UnableToFollowWait = timing.UnableToFollowWait
+	}
+	if timing.QuotaWait > 0 {
+		t.timing.QuotaWait = timing.QuotaWait
+	}
+	if timing.FollowBackWait > 0 {
+		t.timing.FollowBackWait = timing.FollowBackWait
+	}
+	if timing.FollowUnfollowWait > 0 {
+		t.timing.FollowUnfollowWait = timing.FollowUnfollowWait
+	}
+	if timing.UnfollowIdleWait > 0 && t.unfollowPolicy != nil {
+		t.unfollowPolicy.idleWait = timing.UnfollowIdleWait
+	}
+}
+
+// getTiming returns the current waits of the bot, the unset ones
+// being replaced by their default.
+func (t *TwitterBot) getTiming() Timing {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	timing := t.timing
+	if timing.UnableToFollowWait <= 0 {
+		timing.UnableToFollowWait = defaultUnableToFollowWait
+	}
+	if timing.QuotaWait <= 0 {
+		timing.QuotaWait = defaultQuotaWait
+	}
+	if timing.FollowBackWait <= 0 {
+		timing.FollowBackWait = defaultFollowBackWait
+	}
+	if timing.FollowUnfollowWait <= 0 {
+		timing.FollowUnfollowWait = timeSleepBetweenFollowUnFollow
+	}
+	timing.UnfollowIdleWait = defaultUnfollowIdleWait
+	if t.unfollowPolicy != nil && t.unfollowPolicy.idleWait > 0 {
+		timing.UnfollowIdleWait = t.unfollowPolicy.idleWait
+	}
+	return timing
+}
@@ -0,0 +1,25 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestTiming(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.unfollowPolicy = &unfollowPolicy{}
+	c.Assert(bot.getTiming(), Equals, Timing{
+		UnableToFollowWait: 15 * time.Minute,
+		QuotaWait:          15 * time.Minute,
+		FollowBackWait:     time.Hour,
+		UnfollowIdleWait:   3 * time.Hour,
+		FollowUnfollowWait: 5 * time.Minute,
+	})
+	bot.SetTiming(Timing{QuotaWait: time.Minute, UnfollowIdleWait: time.Hour})
+	timing := bot.getTiming()
+	c.Assert(timing.UnableToFollowWait, Equals, 15*time.Minute)
+	c.Assert(timing.QuotaWait, Equals, time.Minute)
+	c.Assert(timing.UnfollowIdleWait, Equals, time.Hour)
+	c.Assert(bot.unfollowPolicy.idleWait, Equals, time.Hour)
+}
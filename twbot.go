@@ -1,49 +1,58 @@
 package twbot
 
 // TODO:
-// - add an errorPolicy ? exported ?
-// - get list of suggestions of friendship
 // - get list of trending tweets
-// - send messages to friends
 // - extract the retweet policy and pass it as argument
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	// waiting for https://github.com/ChimeraCoder/anaconda/pull/166 to be merged
-	"github.com/dns-gh/anaconda"
-	"github.com/dns-gh/freeze"
-	"github.com/dns-gh/tojson"
+	"github.com/ChimeraCoder/anaconda"
 )
 
 const (
-	defaultAutoLikeThreshold              = 1000
-	defaultMaxRetweetBySearch             = 5 // keep 3 tweets, the 2 first tweets being useless ?
-	retweetTextTag                        = "RT @"
-	retweetTextIndex                      = ": "
-	tweetTCOHTTPTag                       = "http://t.co" // not sure if we can encouter unsecure links with t.co twitter wrapping tool, don't think so...
-	tweetTCOHTTPSTag                      = "https://t.co"
-	tweetTCOTextIndex                     = " " // either the t.co links is at the end of the tweet or the next separator from what follows is an empty space
-	tweetTextMaxSize                      = 140
-	tweetTruncatedTextMin                 = 30
-	oneDayInNano                    int64 = 86400000000000
-	timeSleepBetweenFollowUnFollow        = 300 * time.Second // seconds
-	maxRandTimeSleepBetweenRequests       = 120               // seconds
-	tcoLinksMaxLength                     = 24
+	defaultAutoLikeThreshold        = 1000
+	defaultMaxRetweetBySearch       = 5 // keep 3 tweets, the 2 first tweets being useless ?
+	retweetTextTag                  = "RT @"
+	retweetTextIndex                = ": "
+	tweetTCOHTTPTag                 = "http://t.co" // not sure if we can encouter unsecure links with t.co twitter wrapping tool, don't think so...
+	tweetTCOHTTPSTag                = "https://t.co"
+	tweetTCOTextIndex               = " " // either the t.co links is at the end of the tweet or the next separator from what follows is an empty space
+	tweetTextMaxSize                = 140
+	tweetTruncatedTextMin           = 30
+	defaultUnfollowMinAge           = 24 * time.Hour
+	defaultUnfollowIdleWait         = 3 * time.Hour
+	maxRandTimeSleepBetweenRequests = 120 // seconds
+	tcoLinksMaxLength               = 24
+	maxRetweetersCount              = 100 // twitter API limit
+	quoteAuthorTag                  = "{author}"
+	defaultQuoteTemplate            = "via @" + quoteAuthorTag
 )
 
+// twitterUser represents a user of the followers or friends databases.
+// The timestamp is the time the user was first seen as a follower, or
+// followed as a friend. Metadata fields are empty for the users recorded
+// by older versions of the bot or not followed by a follow campaign.
 type twitterUser struct {
-	Timestamp int64 `json:"timestamp"`
-	Follow    bool  `json:"follow"`
+	Timestamp      int64  `json:"timestamp"`
+	Follow         bool   `json:"follow"`
+	FollowedBack   bool   `json:"followed_back,omitempty"`
+	Unfollowed     int64  `json:"unfollowed,omitempty"`      // unfollow timestamp
+	Source         string `json:"source,omitempty"`          // campaign that followed the user
+	ScreenName     string `json:"screen_name,omitempty"`     // screen name at follow time
+	FollowersCount int    `json:"followers_count,omitempty"` // number of followers at follow time
 }
 
 type twitterUsers struct {
@@ -52,13 +61,57 @@ type twitterUsers struct {
 }
 
 type likePolicy struct {
-	auto      bool
-	threshold int
+	auto        bool
+	threshold   int
+	probability float64
+	maxPerDay   int
+	dayStart    time.Time
+	dayCount    int
 }
 
+type unfollowPolicy struct {
+	minAge           time.Duration
+	keepFollowers    bool
+	refollowCooldown time.Duration
+	order            UnfollowOrder
+	maxPerRun        int
+	idleWait         time.Duration
+}
+
+// UnfollowOrder is the order in which friends are unfollowed.
+type UnfollowOrder int
+
+const (
+	// UnfollowAnyOrder unfollows friends in no particular order.
+	UnfollowAnyOrder UnfollowOrder = iota
+	// UnfollowOldestFirst unfollows the oldest friends first.
+	UnfollowOldestFirst
+	// UnfollowNonFollowersFirst unfollows the friends not following
+	// back the bot first, the oldest ones first.
+	UnfollowNonFollowersFirst
+)
+
 type retweetPolicy struct {
-	maxTry int
-	like   bool
+	maxTry        int
+	like          bool
+	quoteEvery    int
+	quoteTemplate string
+	count         int
+}
+
+// RetweetPolicy represents the retweet behavior of the bot when retweeting
+// streamed tweets, see RetweetFromStreamAsync.
+type RetweetPolicy struct {
+	// Like likes the tweet or the retweet using the like policy
+	Like bool
+	// QuoteEvery quotes the tweet instead of retweeting it every 'QuoteEvery'
+	// retweets, with a comment made from 'QuoteTemplate', see SetQuotePolicy.
+	// A zero 'QuoteEvery' disables the quote mode.
+	QuoteEvery    int
+	QuoteTemplate string
+	// MinInterval is the minimum duration between two retweets, qualifying
+	// tweets streamed in between being skipped
+	MinInterval time.Duration
 }
 
 // SleepPolicy represents the sleeping behavior of the bot between requests
@@ -79,25 +132,101 @@ type SleepPolicy struct {
 	MaybeSleepMax         int
 }
 
+// Validate returns an error if the sleep policy has a negative parameter,
+// a 'MaybeSleepMin' greater than 'MaybeSleepMax' or a 'MaybeSleepChance'
+// greater than 'MaybeSleepTotalChance'.
+func (s *SleepPolicy) Validate() error {
+	if s.MaxRand < 0 || s.MaybeSleepChance < 0 || s.MaybeSleepTotalChance < 0 ||
+		s.MaybeSleepMin < 0 || s.MaybeSleepMax < 0 {
+		return fmt.Errorf("[twitter] invalid sleep policy: negative parameter in %+v", *s)
+	}
+	if s.MaybeSleepMin > s.MaybeSleepMax {
+		return fmt.Errorf("[twitter] invalid sleep policy: maybeSleepMin %d greater than maybeSleepMax %d",
+			s.MaybeSleepMin, s.MaybeSleepMax)
+	}
+	if s.MaybeSleepChance > s.MaybeSleepTotalChance {
+		return fmt.Errorf("[twitter] invalid sleep policy: maybeSleepChance %d greater than maybeSleepTotalChance %d",
+			s.MaybeSleepChance, s.MaybeSleepTotalChance)
+	}
+	return nil
+}
+
 func (s *SleepPolicy) log() {
-	log.Printf("[twitter] sleeping policy: %d, %d, %d, %d, %d\n", s.MaxRand, s.MaybeSleepChance,
+	logDebug("[twitter] sleeping policy: %d, %d, %d, %d, %d", s.MaxRand, s.MaybeSleepChance,
 		s.MaybeSleepTotalChance, s.MaybeSleepMin, s.MaybeSleepMax)
 }
 
 // TwitterBot represents the twitter bot.
 type TwitterBot struct {
-	twitterClient      *anaconda.TwitterApi
+	twitterClient      TwitterClient
+	store              Store
 	followersPath      string
 	followers          *twitterUsers
 	friendsPath        string
 	friends            *twitterUsers
 	tweetsPath         string
-	debug              bool
+	likesPath          string
+	likes              *twitterLikes
+	mentionLikes       *mentionLikes
+	replyRules         *replyRules
+	generator          TextGenerator
+	replyPrompt        string
+	quotePrompt        string
+	sentiment          *sentimentFilter
+	content            *contentFilter
+	whitelistPath      string
+	whitelist          *twitterWhitelist
+	statePath          string
+	state              *twitterState
+	growthPath         string
+	growth             *twitterGrowth
+	engagementPath     string
+	engagement         *twitterEngagement
+	queuePath          string
+	queue              *twitterQueue
+	blocksPath         string
+	blocks             *twitterBlocks
+	banHits            map[int64]int // map author id -> number of banned tweets
+	bannedUsers        map[string]struct{}
+	autoMuteThreshold  int
+	owner              string  // screen name of the user alerted on critical events
+	consumerSecret     string  // signs the webhook challenges and checks the webhook events
+	config             *Config // configuration of the bot if made by NewFromConfig, see ReloadConfig
+	verbose            bool
+	noSleep            bool
+	timing             Timing
+	dryRun             bool
+	shadow             TwitterClient // client of the write calls, see SetShadowAccount
+	app                *appClient    // client of some read calls, see SetAppAuth
+	httpClient         *http.Client  // see WithHTTPClient
+	timeout            time.Duration // see WithTimeout
+	pause              pauseState
+	hooks              []Hooks
+	notifiers          []Notifier
+	crossPosters       []CrossPoster
+	milestones         []int
+	notifications      notifications
+	audit              auditLog
+	jobs               map[*Job]struct{} // running asynchronous jobs
+	actions            actionStats
 	likePolicy         *likePolicy
 	retweetPolicy      *retweetPolicy
+	unfollowPolicy     *unfollowPolicy
+	retention          retentionPolicy
+	errorPolicy        errorPolicy
+	retryPolicy        retryPolicy
+	breaker            circuitBreaker
+	restartPolicy      restartPolicy
+	health             healthState
 	defaultSleepPolicy *SleepPolicy
+	followGuard        *followGuard
+	campaignsPath      string
+	campaigns          *twitterCampaigns
 	mutex              sync.Mutex
 	quit               sync.WaitGroup
+	ctx                context.Context // done once the bot is stopped, see Stop
+	cancel             context.CancelFunc
+	ctxOnce            sync.Once
 }
 
 // MakeTwitterBot creates a twitter bot. The database is made of 3 files: followers, friends and tweets.
@@ -111,30 +240,68 @@ type TwitterBot struct {
 //  TWITTER_CONSUMER_SECRET,
 //  TWITTER_ACCESS_TOKEN,
 //  TWITTER_ACCESS_SECRET.
-// They can be found here by creating a twitter app: https://apps.twitter.com/.
+// They can be found here by creating a twitter app: https://apps.twitter.com/,
+// the access keys can also be obtained with AuthorizeInteractive.
 //
-// The 'debug' mode creates more logs and remove all sleeps between API twitter calls.
-func MakeTwitterBot(followersPath, friendsPath, tweetsPath string, debug bool) *TwitterBot {
-	log.Println("[twitter] making twitter bot")
+// The 'debug' mode creates more logs and remove all sleeps between API twitter calls,
+// see SetVerbose and SetNoSleep to enable them separately.
+// The options configure the http client of the bot, see WithHTTPClient.
+func MakeTwitterBot(followersPath, friendsPath, tweetsPath string, debug bool, opts ...Option) *TwitterBot {
+	logInfo("[twitter] making twitter bot")
 	errorList := []string{}
 	consumerKey := getEnv(errorList, "TWITTER_CONSUMER_KEY")
 	consumerSecret := getEnv(errorList, "TWITTER_CONSUMER_SECRET")
 	accessToken := getEnv(errorList, "TWITTER_ACCESS_TOKEN")
 	accessSecret := getEnv(errorList, "TWITTER_ACCESS_SECRET")
 	if len(errorList) > 0 {
-		log.Fatalln(fmt.Sprintf("errors:\n%s", strings.Join(errorList, "\n")))
+		logFatal("errors:\n%s", strings.Join(errorList, "\n"))
 	}
-	return MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, consumerKey, consumerSecret, accessToken, accessSecret, debug)
+	return MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, consumerKey, consumerSecret, accessToken, accessSecret, debug, opts...)
 }
 
 // MakeTwitterBotWithCredentials creates a twitter bot.
 // Same as MakeTwitterBot but the twitter keys are given as input.
-func MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, consumerKey, consumerSecret, accessToken, accessSecret string, debug bool) *TwitterBot {
-	anaconda.SetConsumerKey(consumerKey)
-	anaconda.SetConsumerSecret(consumerSecret)
+// It exits if the keys are rejected by twitter, see VerifyCredentials.
+func MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, consumerKey, consumerSecret, accessToken, accessSecret string, debug bool, opts ...Option) *TwitterBot {
+	bot := newTwitterBot(followersPath, friendsPath, tweetsPath, consumerKey, consumerSecret, accessToken, accessSecret, debug, opts...)
+	_, err := bot.VerifyCredentials()
+	if err != nil {
+		logFatal("%v", err)
+	}
+	bot.mustUpdate()
+	return bot
+}
+
+// MakeTwitterBotWithClient creates a twitter bot calling the twitter API
+// through the given client, see SetTwitterClient.
+// Same as MakeTwitterBot but no twitter keys are needed.
+func MakeTwitterBotWithClient(followersPath, friendsPath, tweetsPath string, client TwitterClient, debug bool) *TwitterBot {
+	bot := newTwitterBot(followersPath, friendsPath, tweetsPath, "", "", "", "", debug)
+	bot.SetTwitterClient(client)
+	bot.mustUpdate()
+	return bot
+}
+
+// mustUpdate updates the followers and friends databases
+// of a new bot and exits on failure.
+func (t *TwitterBot) mustUpdate() {
+	err := t.updateFollowers()
+	if err != nil {
+		logFatal("%v", err)
+	}
+	err = t.updateFriends()
+	if err != nil {
+		logFatal("%v", err)
+	}
+}
+
+// newTwitterBot creates a twitter bot with the default policies
+// without synchronizing its followers and friends databases.
+func newTwitterBot(followersPath, friendsPath, tweetsPath, consumerKey, consumerSecret, accessToken, accessSecret string, debug bool, opts ...Option) *TwitterBot {
 	bot := &TwitterBot{
-		twitterClient: anaconda.NewTwitterApi(accessToken, accessSecret),
-		followersPath: followersPath,
+		store:          NewJSONStore(),
+		consumerSecret: consumerSecret,
+		followersPath:  followersPath,
 		followers: &twitterUsers{
 			Ids: make(map[string]*twitterUser),
 		},
@@ -143,14 +310,57 @@ func MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, consu
 			Ids: make(map[string]*twitterUser),
 		},
 		tweetsPath: tweetsPath,
-		debug:      debug,
+		likes: &twitterLikes{
+			Ids: make(map[string]int64),
+		},
+		mentionLikes: &mentionLikes{
+			byUser: make(map[int64]int),
+		},
+		replyRules: &replyRules{
+			byUser: make(map[int64]int),
+		},
+		whitelist: &twitterWhitelist{
+			Ids: make(map[string]string),
+		},
+		blocks: &twitterBlocks{
+			Blocked: make(map[string]int64),
+			Muted:   make(map[string]int64),
+		},
+		state: &twitterState{
+			SinceIDs: make(map[string]int64),
+		},
+		growth: &twitterGrowth{},
+		engagement: &twitterEngagement{
+			Tweets: make(map[string]*TweetEngagement),
+		},
+		queue:   &twitterQueue{},
+		content: &contentFilter{},
+		banHits: make(map[int64]int),
+		verbose: debug,
+		noSleep: debug,
 		likePolicy: &likePolicy{
-			auto:      false,
-			threshold: 1000,
+			auto:        false,
+			threshold:   1000,
+			probability: 1,
+			maxPerDay:   0,
 		},
 		retweetPolicy: &retweetPolicy{
-			maxTry: 5,
-			like:   true,
+			maxTry:        5,
+			like:          true,
+			quoteEvery:    0,
+			quoteTemplate: defaultQuoteTemplate,
+		},
+		unfollowPolicy: &unfollowPolicy{
+			minAge:        defaultUnfollowMinAge,
+			idleWait:      defaultUnfollowIdleWait,
+			keepFollowers: false,
+		},
+		campaigns: &twitterCampaigns{
+			Campaigns: make(map[string]*campaignProgress),
+		},
+		followGuard: &followGuard{
+			maxFollowsPerDay:   defaultMaxFollowsPerDay,
+			maxUnfollowsPerDay: defaultMaxUnfollowsPerDay,
 		},
 		defaultSleepPolicy: &SleepPolicy{
 			MaxRand:               maxRandTimeSleepBetweenRequests,
@@ -159,43 +369,194 @@ func MakeTwitterBotWithCredentials(followersPath, friendsPath, tweetsPath, consu
 			MaybeSleepMin:         2500,
 			MaybeSleepMax:         5000,
 		},
+		retryPolicy: retryPolicy{
+			maxAttempts: defaultRetryMaxAttempts,
+			baseDelay:   defaultRetryBaseDelay,
+			maxDelay:    defaultRetryMaxDelay,
+			jitter:      defaultRetryJitter,
+		},
+		breaker: circuitBreaker{
+			maxFailures: defaultBreakerMaxFailures,
+			cooldown:    defaultBreakerCooldown,
+		},
 	}
-	err := bot.updateFollowers()
-	if err != nil {
-		log.Fatalln(err.Error())
-	}
-	err = bot.updateFriends()
-	if err != nil {
-		log.Fatalln(err.Error())
+	for _, opt := range opts {
+		opt(bot)
 	}
+	bot.setCredentials(consumerKey, consumerSecret, accessToken, accessSecret)
 	return bot
 }
 
-// Wait waits for all the asynchronous calls to return
+// setCredentials sets the anaconda client of the given twitter keys,
+// tracking its own rate limits, see setClient.
+func (t *TwitterBot) setCredentials(consumerKey, consumerSecret, accessToken, accessSecret string) {
+	client := newAnacondaClient(consumerKey, consumerSecret, accessToken, accessSecret)
+	limits := newRateLimits()
+	client.HttpClient = t.newHTTPClient(t.transport(limits))
+	t.setClient(client, limits)
+}
+
+// setClient sets the client calling the twitter API with the given rate
+// limits, nil if untracked. The in-flight calls are done with the previous
+// client, if any, closed once they return.
+func (t *TwitterBot) setClient(client TwitterClient, limits *rateLimits) {
+	if api, ok := t.twitterClient.(*apiClient); ok {
+		api.setClient(client, limits)
+		return
+	}
+	previous := t.twitterClient
+	t.twitterClient = &apiClient{
+		TwitterClient: client,
+		bot:           t,
+		limits:        limits,
+	}
+	if previous != nil {
+		previous.Close()
+	}
+}
+
+// Wait waits for all the asynchronous calls to return, see Stop.
 func (t *TwitterBot) Wait() {
 	t.quit.Wait()
 }
 
-// Close closes the twitter client
+// Close closes the twitter client and the store
 func (t *TwitterBot) Close() {
 	t.twitterClient.Close()
+	if t.shadow != nil {
+		t.SetShadowClient(nil)
+	}
+	err := t.store.Close()
+	if err != nil {
+		logError("%v", err)
+	}
 }
 
 // SetLikePolicy sets the like policy that allows to automatically likes tweets
 // that are already liked above a threshold.
-func (t *TwitterBot) SetLikePolicy(auto bool, threshold int) {
-	log.Printf("[twitter] setting like policy -> auto: %t, threshold: %d\n", auto, threshold)
+// Only a 'probability' (from 0 to 1) of the qualifying tweets are liked, with
+// a maximum of 'maxPerDay' likes per day, 0 meaning no limit.
+// It returns an error, and leaves the policy unchanged, if 'threshold' or
+// 'maxPerDay' is negative or if 'probability' is not between 0 and 1.
+func (t *TwitterBot) SetLikePolicy(auto bool, threshold int, probability float64, maxPerDay int) error {
+	err := validateLikePolicy(threshold, probability, maxPerDay)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] setting like policy -> auto: %t, threshold: %d, probability: %.2f, maxPerDay: %d",
+		auto, threshold, probability, maxPerDay)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.likePolicy.auto = auto
 	t.likePolicy.threshold = threshold
+	t.likePolicy.probability = probability
+	t.likePolicy.maxPerDay = maxPerDay
+	return nil
+}
+
+func validateLikePolicy(threshold int, probability float64, maxPerDay int) error {
+	if threshold < 0 {
+		return fmt.Errorf("[twitter] invalid like policy: negative threshold %d", threshold)
+	}
+	if probability < 0 || probability > 1 {
+		return fmt.Errorf("[twitter] invalid like policy: probability %g not between 0 and 1", probability)
+	}
+	if maxPerDay < 0 {
+		return fmt.Errorf("[twitter] invalid like policy: negative maxPerDay %d", maxPerDay)
+	}
+	return nil
 }
 
 // SetRetweetPolicy sets the retweet policy that allows to try to retweet 'maxTry' times when looping through
 // a list of tweets to retweet. The 'like' parameter controls the ability to like the tweet
 // or the retweet using the like policy.
-func (t *TwitterBot) SetRetweetPolicy(maxTry int, like bool) {
-	log.Printf("[twitter] setting retweet policy -> maxTry: %d, like: %t\n", maxTry, like)
+// It returns an error, and leaves the policy unchanged, if 'maxTry' is negative.
+func (t *TwitterBot) SetRetweetPolicy(maxTry int, like bool) error {
+	err := validateRetweetPolicy(maxTry)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] setting retweet policy -> maxTry: %d, like: %t", maxTry, like)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.retweetPolicy.maxTry = maxTry
 	t.retweetPolicy.like = like
+	return nil
+}
+
+func validateRetweetPolicy(maxTry int) error {
+	if maxTry < 0 {
+		return fmt.Errorf("[twitter] invalid retweet policy: negative maxTry %d", maxTry)
+	}
+	return nil
+}
+
+// SetUnfollowPolicy sets the unfollow policy used by the auto unfollow: friends are
+// unfollowed only once they are friends for at least 'minAge'. If 'keepFollowers'
+// is true, friends following back the bot are never unfollowed: the followers
+// database is then refreshed before each unfollow run to detect them.
+func (t *TwitterBot) SetUnfollowPolicy(minAge time.Duration, keepFollowers bool) {
+	logInfo("[twitter] setting unfollow policy -> minAge: %s, keepFollowers: %t", minAge, keepFollowers)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.unfollowPolicy.minAge = minAge
+	t.unfollowPolicy.keepFollowers = keepFollowers
+}
+
+// SetUnfollowOrder sets the order in which friends are unfollowed by the auto
+// unfollow and the maximum number of unfollows per run, 0 meaning no limit.
+// Once the limit is reached, the auto unfollow waits for the next run.
+func (t *TwitterBot) SetUnfollowOrder(order UnfollowOrder, maxPerRun int) {
+	logInfo("[twitter] setting unfollow order -> order: %d, maxPerRun: %d", order, maxPerRun)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.unfollowPolicy.order = order
+	t.unfollowPolicy.maxPerRun = maxPerRun
+}
+
+// SetUnfollowIdleWait sets the time between two runs of the auto unfollow,
+// 3 hours by default. It only applies to the auto unfollows launched afterwards.
+// It returns an error, and leaves the wait unchanged, if 'wait' is not positive.
+func (t *TwitterBot) SetUnfollowIdleWait(wait time.Duration) error {
+	err := validateUnfollowIdleWait(wait)
+	if err != nil {
+		return err
+	}
+	logInfo("[twitter] setting unfollow idle wait -> wait: %s", wait)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.unfollowPolicy.idleWait = wait
+	return nil
+}
+
+func validateUnfollowIdleWait(wait time.Duration) error {
+	if wait <= 0 {
+		return fmt.Errorf("[twitter] invalid unfollow idle wait: non-positive wait %s", wait)
+	}
+	return nil
+}
+
+// SetRefollowCooldown sets the duration after which an unfollowed user can be
+// followed again by any of the follow methods. A zero 'cooldown', the default,
+// means that unfollowed users are never followed again.
+func (t *TwitterBot) SetRefollowCooldown(cooldown time.Duration) {
+	logInfo("[twitter] setting refollow cooldown -> cooldown: %s", cooldown)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.unfollowPolicy.refollowCooldown = cooldown
+}
+
+// SetQuotePolicy sets the quote mode of the retweet policy: every 'every' retweets,
+// the tweet is quoted instead of being retweeted, with a comment made from
+// the given 'template'. The "{author}" tag of the template is replaced by the
+// screen name of the author of the quoted tweet, e.g. "via @{author}".
+// A zero 'every' disables the quote mode.
+func (t *TwitterBot) SetQuotePolicy(every int, template string) {
+	logInfo("[twitter] setting quote policy -> every: %d, template: %s", every, template)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.retweetPolicy.quoteEvery = every
+	t.retweetPolicy.quoteTemplate = template
 }
 
 // TweetSliceOnce tweets the slice returned by the given 'fetch' callback.
@@ -207,12 +568,10 @@ func (t *TwitterBot) TweetSliceOnce(fetch func() ([]string, error)) error {
 		return err
 	}
 	for _, msg := range list {
-		tweet, err := t.twitterClient.PostTweet(msg, nil)
+		_, err := t.twitterClient.PostTweet(msg, nil)
 		if err != nil {
-			log.Println(err.Error())
-			continue
+			SubsystemTweet.error("%v", err)
 		}
-		log.Println("[twitter] tweeting message (id:", tweet.Id, "):", tweet.Text)
 	}
 	return nil
 }
@@ -220,53 +579,45 @@ func (t *TwitterBot) TweetSliceOnce(fetch func() ([]string, error)) error {
 // TweetSliceOnceAsync tweets asynchronously the slice returned by the
 // given 'fetch' callback.
 // It logs errors for each failed tweet tentative.
-func (t *TwitterBot) TweetSliceOnceAsync(fetch func() ([]string, error)) {
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
+func (t *TwitterBot) TweetSliceOnceAsync(fetch func() ([]string, error)) *Job {
+	return t.runOnceAsync("TweetSliceOnce", func(ctx context.Context) error {
 		list, err := fetch()
 		if err != nil {
-			log.Println(err.Error())
-			return
+			return err
 		}
 		for _, msg := range list {
 			tweet, err := t.twitterClient.PostTweet(msg, nil)
 			if err != nil {
-				log.Println(err.Error())
+				SubsystemTweet.error("%v", err)
 				continue
 			}
 			print(t, fmt.Sprintf("tweeting message (id: %d): %s\n", tweet.Id, tweet.Text))
 		}
-	}()
+		return nil
+	})
 }
 
 // TweetSlicePeriodically tweets periodically the slice returned by the given 'fetch' callback.
 // The slice tweet frequencies is set up by the given 'freq' input parameter.
 // It logs errors for each failed tweet tentative.
 func (t *TwitterBot) TweetSlicePeriodically(fetch func() ([]string, error), freq time.Duration) {
-	ticker := time.NewTicker(freq)
-	defer ticker.Stop()
-	for _ = range ticker.C {
-		err := t.TweetSliceOnce(fetch)
-		if err != nil {
-			log.Println(err)
-		}
-	}
+	t.newJob("TweetSlicePeriodically").runPeriodically(freq, func() error {
+		return t.TweetSliceOnce(fetch)
+	})
 }
 
 // TweetSlicePeriodicallyAsync tweets asynchronously and periodically the
 // slice returned by the given 'fetch' callback.
 // The slice tweet frequencies is set up by the given 'freq' input parameter.
 // It logs errors for each failed tweet tentative.
-func (t *TwitterBot) TweetSlicePeriodicallyAsync(fetch func() ([]string, error), freq time.Duration) {
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
-		t.TweetSlicePeriodically(fetch, freq)
-	}()
+func (t *TwitterBot) TweetSlicePeriodicallyAsync(fetch func() ([]string, error), freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("TweetSlicePeriodically", freq, func(ctx context.Context) error {
+		return t.TweetSliceOnce(fetch)
+	})
 }
 
-// TweetOnce tweets the message returned by the 'fetch' callback.
+// TweetOnce tweets the message returned by the 'fetch' callback,
+// mirrored by the cross-posters if any, see AddCrossPoster.
 // It returns an error if the 'fetch' call failed or if the tweet
 // itself failed.
 func (t *TwitterBot) TweetOnce(fetch func() (string, error)) error {
@@ -279,46 +630,35 @@ func (t *TwitterBot) TweetOnce(fetch func() (string, error)) error {
 		return err
 	}
 	print(t, fmt.Sprintf("tweeting message (id: %d): %s\n", tweet.Id, tweet.Text))
+	t.crossPost(Post{Text: msg})
 	return nil
 }
 
 // TweetOnceAsync tweets asynchronously the message returned by the 'fetch' callback.
 // It only logs the error if the 'fetch' call failed or if the tweet itself failed.
-func (t *TwitterBot) TweetOnceAsync(fetch func() (string, error)) {
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
-		err := t.TweetOnce(fetch)
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+func (t *TwitterBot) TweetOnceAsync(fetch func() (string, error)) *Job {
+	return t.runOnceAsync("TweetOnce", func(ctx context.Context) error {
+		return t.TweetOnce(fetch)
+	})
 }
 
 // TweetPeriodically tweets periodically the message returned by the 'fetch' callback.
 // The tweet frequencies is set up by the given 'freq' input parameter.
 // It only logs the error if the 'fetch' call failed or if the tweet itself failed.
 func (t *TwitterBot) TweetPeriodically(fetch func() (string, error), freq time.Duration) {
-	ticker := time.NewTicker(freq)
-	defer ticker.Stop()
-	for _ = range ticker.C {
-		err := t.TweetOnce(fetch)
-		if err != nil {
-			log.Println(err)
-		}
-	}
+	t.newJob("TweetPeriodically").runPeriodically(freq, func() error {
+		return t.TweetOnce(fetch)
+	})
 }
 
 // TweetPeriodicallyAsync tweets asynchronously and periodically the message returned
 // by the 'fetch' callback.
 // The tweet frequencies is set up by the given 'freq' input parameter.
 // It only logs the error if the 'fetch' call failed or if the tweet itself failed.
-func (t *TwitterBot) TweetPeriodicallyAsync(fetch func() (string, error), freq time.Duration) {
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
-		t.TweetPeriodically(fetch, freq)
-	}()
+func (t *TwitterBot) TweetPeriodicallyAsync(fetch func() (string, error), freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("TweetPeriodically", freq, func(ctx context.Context) error {
+		return t.TweetOnce(fetch)
+	})
 }
 
 // we want to truncate under 'tweetTextMaxSize' characters in this preference order:
@@ -374,6 +714,7 @@ func (t *TwitterBot) tryPostTweet(msg, archiveURL string, v url.Values) (tweet a
 // TweetImageOnce tweets the given 'msg', 'archiveURL' and img' data provided as strings.
 // Note: internally, the 'img' data will be encoded to base 64 in order to be
 // properly tweeted via the twitter API.
+// The tweet is mirrored by the cross-posters if any, see AddCrossPoster.
 func (t *TwitterBot) TweetImageOnce(msg, archiveURL, img string) error {
 	buf := bytes.NewBufferString(img)
 	data := base64.StdEncoding.EncodeToString(buf.Bytes())
@@ -389,6 +730,7 @@ func (t *TwitterBot) TweetImageOnce(msg, archiveURL, img string) error {
 		return err
 	}
 	print(t, fmt.Sprintf("[twitter] tweeting message and image (id: %d): %s\n", tweet.Id, tweet.Text))
+	t.crossPost(Post{Text: msg, Link: archiveURL, Image: []byte(img)})
 	return nil
 }
 
@@ -397,31 +739,27 @@ func (t *TwitterBot) TweetImageOnce(msg, archiveURL, img string) error {
 // The tweet frequencies is set up by the given 'freq' input parameter.
 // It only logs the error if the 'fetch' call failed or if the tweet itself failed.
 func (t *TwitterBot) TweetImagePeriodically(fetch func() (string, string, string, error), freq time.Duration) {
-	ticker := time.NewTicker(freq)
-	defer ticker.Stop()
-	for _ = range ticker.C {
-		msg, img, archive, err := fetch()
-		if err != nil {
-			log.Println(err)
-			continue
-		}
-		err = t.TweetImageOnce(msg, archive, img)
-		if err != nil {
-			log.Println(err)
-		}
+	t.newJob("TweetImagePeriodically").runPeriodically(freq, func() error {
+		return t.tweetImage(fetch)
+	})
+}
+
+func (t *TwitterBot) tweetImage(fetch func() (string, string, string, error)) error {
+	msg, img, archive, err := fetch()
+	if err != nil {
+		return err
 	}
+	return t.TweetImageOnce(msg, archive, img)
 }
 
 // TweetImagePeriodicallyAsync tweets asynchronously and periodically the message and image returned
 // by the 'fetch' callback.
 // The tweet frequencies is set up by the given 'freq' input parameter.
 // It only logs the error if the 'fetch' call failed or if the tweet itself failed.
-func (t *TwitterBot) TweetImagePeriodicallyAsync(fetch func() (string, string, string, error), freq time.Duration) {
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
-		t.TweetImagePeriodically(fetch, freq)
-	}()
+func (t *TwitterBot) TweetImagePeriodicallyAsync(fetch func() (string, string, string, error), freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("TweetImagePeriodically", freq, func(ctx context.Context) error {
+		return t.tweetImage(fetch)
+	})
 }
 
 // RetweetOnce retweets randomly, with a maximum of 'retweetPolicy.maxTry' tries,
@@ -429,7 +767,13 @@ func (t *TwitterBot) TweetImagePeriodicallyAsync(fetch func() (string, string, s
 // It returns an error if the loading of tweets in database failed
 // or if the retweet itself failed.
 func (t *TwitterBot) RetweetOnce(queries, bannedQueries []string) error {
-	err := t.autoRetweet(queries, bannedQueries)
+	return t.RetweetOnceByQuery(makeBannedByQuery(queries, bannedQueries))
+}
+
+// RetweetOnceByQuery is the same as RetweetOnce except that each query
+// of the 'bannedByQuery' map has its own list of banned queries.
+func (t *TwitterBot) RetweetOnceByQuery(bannedByQuery map[string][]string) error {
+	err := t.autoRetweet(t.botContext(), bannedByQuery)
 	if err != nil {
 		return err
 	}
@@ -440,30 +784,24 @@ func (t *TwitterBot) RetweetOnce(queries, bannedQueries []string) error {
 // 'retweetPolicy.maxTry' tries, a tweet matching one element of the input queries slice.
 // It logs errors if the loading of tweets in database failed
 // or if the retweets itself failed.
-func (t *TwitterBot) RetweetOnceAsync(searchQueries, bannedQueries []string) {
-	queries := make([]string, len(searchQueries))
-	copy(queries, searchQueries)
-	banned := make([]string, len(bannedQueries))
-	copy(banned, bannedQueries)
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
-		err := t.RetweetOnce(queries, banned)
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+func (t *TwitterBot) RetweetOnceAsync(searchQueries, bannedQueries []string) *Job {
+	return t.RetweetOnceByQueryAsync(makeBannedByQuery(searchQueries, bannedQueries))
 }
 
-func (t *TwitterBot) retweetPeriodically(queries, bannedQueries []string, freq time.Duration) {
-	ticker := time.NewTicker(freq)
-	defer ticker.Stop()
-	for _ = range ticker.C {
-		err := t.RetweetOnce(queries, bannedQueries)
-		if err != nil {
-			log.Println(err)
-		}
-	}
+// RetweetOnceByQueryAsync is the same as RetweetOnceAsync except that each query
+// of the 'bannedByQuery' map has its own list of banned queries.
+func (t *TwitterBot) RetweetOnceByQueryAsync(bannedByQuery map[string][]string) *Job {
+	banned := copyBannedByQuery(bannedByQuery)
+	return t.runOnceAsync("RetweetOnce", func(ctx context.Context) error {
+		return t.autoRetweet(ctx, banned)
+	})
+}
+
+func (t *TwitterBot) retweetPeriodically(bannedByQuery map[string][]string, freq time.Duration) {
+	job := t.newJob("RetweetPeriodically")
+	job.runPeriodically(freq, func() error {
+		return t.autoRetweet(job.ctx, bannedByQuery)
+	})
 }
 
 // RetweetPeriodically retweets periodically and randomly, with a maximum of
@@ -472,11 +810,13 @@ func (t *TwitterBot) retweetPeriodically(queries, bannedQueries []string, freq t
 // It logs errors if the loading of tweets in database failed
 // or if the retweets itself failed.
 func (t *TwitterBot) RetweetPeriodically(searchQueries, bannedQueries []string, freq time.Duration) {
-	queries := make([]string, len(searchQueries))
-	copy(queries, searchQueries)
-	banned := make([]string, len(bannedQueries))
-	copy(banned, bannedQueries)
-	t.retweetPeriodically(queries, banned, freq)
+	t.retweetPeriodically(makeBannedByQuery(searchQueries, bannedQueries), freq)
+}
+
+// RetweetPeriodicallyByQuery is the same as RetweetPeriodically except that each query
+// of the 'bannedByQuery' map has its own list of banned queries.
+func (t *TwitterBot) RetweetPeriodicallyByQuery(bannedByQuery map[string][]string, freq time.Duration) {
+	t.retweetPeriodically(copyBannedByQuery(bannedByQuery), freq)
 }
 
 // RetweetPeriodicallyAsync retweets asynchronously, periodically and randomly, with a maximum of
@@ -484,73 +824,186 @@ func (t *TwitterBot) RetweetPeriodically(searchQueries, bannedQueries []string,
 // The retweet frequencies is set up by the given 'freq' input parameter.
 // It logs errors if the loading of tweets in database failed
 // or if the retweets itself failed.
-func (t *TwitterBot) RetweetPeriodicallyAsync(searchQueries, bannedQueries []string, freq time.Duration) {
-	queries := make([]string, len(searchQueries))
-	copy(queries, searchQueries)
-	banned := make([]string, len(bannedQueries))
-	copy(banned, bannedQueries)
-	t.quit.Add(1)
-	go func() {
-		defer t.quit.Done()
-		t.retweetPeriodically(queries, banned, freq)
-	}()
+func (t *TwitterBot) RetweetPeriodicallyAsync(searchQueries, bannedQueries []string, freq time.Duration) *Job {
+	return t.RetweetPeriodicallyByQueryAsync(makeBannedByQuery(searchQueries, bannedQueries), freq)
+}
+
+// RetweetPeriodicallyByQueryAsync is the same as RetweetPeriodicallyAsync except that each query
+// of the 'bannedByQuery' map has its own list of banned queries.
+func (t *TwitterBot) RetweetPeriodicallyByQueryAsync(bannedByQuery map[string][]string, freq time.Duration) *Job {
+	banned := copyBannedByQuery(bannedByQuery)
+	return t.runPeriodicallyAsync("RetweetPeriodically", freq, func(ctx context.Context) error {
+		return t.autoRetweet(ctx, banned)
+	})
 }
 
+// makeBannedByQuery makes a map query -> banned queries where all
+// the queries share the same banned queries.
+func makeBannedByQuery(queries, bannedQueries []string) map[string][]string {
+	bannedByQuery := map[string][]string{}
+	for _, query := range queries {
+		banned := make([]string, len(bannedQueries))
+		copy(banned, bannedQueries)
+		bannedByQuery[query] = banned
+	}
+	return bannedByQuery
+}
+
+func copyBannedByQuery(bannedByQuery map[string][]string) map[string][]string {
+	copied := map[string][]string{}
+	for query, bannedQueries := range bannedByQuery {
+		banned := make([]string, len(bannedQueries))
+		copy(banned, bannedQueries)
+		copied[query] = banned
+	}
+	return copied
+}
+
+// checkSleepPolicy returns a copy of the given sleep policy or of the
+// default one if nil or invalid.
 func (t *TwitterBot) checkSleepPolicy(sleepPolicy *SleepPolicy) SleepPolicy {
-	sleepPolicyCopy := *t.defaultSleepPolicy
 	if sleepPolicy != nil {
-		sleepPolicyCopy = *sleepPolicy
+		err := sleepPolicy.Validate()
+		if err == nil {
+			return *sleepPolicy
+		}
+		logError("%v, using the default sleep policy", err)
 	}
-	return sleepPolicyCopy
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return *t.defaultSleepPolicy
 }
 
 // AutoUnfollowFriendsAsync automatically asynchronously unfollows friends
-// from database that were added at least a day ago by default. The sleep policy controls
+// from database that were added at least a day ago by default, see
+// SetUnfollowPolicy to change this behavior. Whitelisted
+// friends are never unfollowed. Friends are unfollowed by runs, every
+// 3 hours by default, see SetUnfollowIdleWait. The sleep policy controls
 // the type of sleep you want between requests.
-func (t *TwitterBot) AutoUnfollowFriendsAsync(sleepPolicy *SleepPolicy) {
-	t.quit.Add(1)
+// The returned campaign allows to pause, resume or stop the unfollows.
+func (t *TwitterBot) AutoUnfollowFriendsAsync(sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoUnfollowFriends")
 	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
-	go func() {
-		defer t.quit.Done()
-		log.Println("[twitter] launching auto unfollow...")
+	t.run(campaign.Job, func() error {
+		SubsystemFollow.info("[twitter] launching auto unfollow...")
 		sleepPolicyCopy.log()
-		t.unfollowAll(&sleepPolicyCopy)
-		log.Println("[twitter] auto unfollow disabled")
-	}()
+		t.unfollowAll(&sleepPolicyCopy, campaign)
+		SubsystemFollow.info("[twitter] auto unfollow disabled")
+		return nil
+	})
+	return campaign
 }
 
 // AutoFollowFollowers automatically follows the
 // followers of the first user fecthed using the given 'query'.
 // The 'maxPage' parameter indicates the number of page of followers
-// (5000 users max by page) we want to fetch. The sleep policy controls
+// (5000 users max by page) we want to fetch. Only the followers matching
+// the given 'filter' are followed. The sleep policy controls
 // the type of sleep you want between requests.
-func (t *TwitterBot) AutoFollowFollowers(query string, maxPage int, sleepPolicy SleepPolicy) {
-	log.Printf("[twitter] launching auto follow with '%s' over %d page(s)...\n", query, maxPage)
+func (t *TwitterBot) AutoFollowFollowers(query string, maxPage int, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowFollowers(query, maxPage, filter, sleepPolicy, t.newCampaign("AutoFollowFollowers"))
+}
+
+func (t *TwitterBot) autoFollowFollowers(query string, maxPage int, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow with '%s' over %d page(s)...", query, maxPage)
 	sleepPolicy.log()
-	t.followAll(t.fetchUserIds(query, maxPage), &sleepPolicy)
-	log.Println("[twitter] auto follow disabled")
+	campaign.setSource("followers:" + query)
+	campaign.key = fmt.Sprintf("followers:%s:%d", query, maxPage)
+	if t.resumeProgress(&sleepPolicy, campaign) {
+		SubsystemFollow.info("[twitter] auto follow disabled")
+		return
+	}
+	ids, err := t.fetchUserIds(query, maxPage)
+	if err != nil {
+		t.checkBotRestriction(err)
+	}
+	t.followAll(ids, &filter, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
 }
 
 // AutoFollowFollowersAsync automatically asynchronously follows the
 // followers of the first user fecthed using the given 'query'.
 // The 'maxPage' parameter indicates the number of page of followers
-// (5000 users max by page) we want to fetch. The sleep policy controls
+// (5000 users max by page) we want to fetch. Only the followers matching
+// the given 'filter' are followed. The sleep policy controls
 // the type of sleep you want between requests.
-func (t *TwitterBot) AutoFollowFollowersAsync(query string, maxPage int, sleepPolicy *SleepPolicy) {
-	t.quit.Add(1)
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowFollowersAsync(query string, maxPage int, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowFollowers")
+	filterCopy := copyFollowFilter(filter)
 	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
-	go func() {
-		defer t.quit.Done()
-		t.AutoFollowFollowers(query, maxPage, sleepPolicyCopy)
-	}()
+	t.run(campaign.Job, func() error {
+		t.autoFollowFollowers(query, maxPage, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
+}
+
+// GetRetweeters returns the users who retweeted the tweet of id 'tweetID'.
+// Note: the twitter API only returns up to 100 of the most recent retweeters.
+func (t *TwitterBot) GetRetweeters(tweetID int64) ([]anaconda.User, error) {
+	v := url.Values{}
+	v.Set("count", strconv.Itoa(maxRetweetersCount))
+	retweets, err := t.twitterClient.GetRetweets(tweetID, v)
+	if err != nil {
+		return nil, err
+	}
+	users := []anaconda.User{}
+	for _, retweet := range retweets {
+		users = append(users, retweet.User)
+	}
+	return users, nil
+}
+
+// AutoFollowRetweeters automatically follows the users who retweeted
+// the tweet of id 'tweetID' and matching the given 'filter'.
+// The sleep policy controls the type of sleep you want between requests.
+func (t *TwitterBot) AutoFollowRetweeters(tweetID int64, filter FollowFilter, sleepPolicy SleepPolicy) {
+	t.autoFollowRetweeters(tweetID, filter, sleepPolicy, t.newCampaign("AutoFollowRetweeters"))
+}
+
+func (t *TwitterBot) autoFollowRetweeters(tweetID int64, filter FollowFilter, sleepPolicy SleepPolicy, campaign *Campaign) {
+	SubsystemFollow.info("[twitter] launching auto follow of retweeters of tweet (id:%d)...", tweetID)
+	sleepPolicy.log()
+	campaign.setSource(fmt.Sprintf("retweeters:%d", tweetID))
+	users, err := t.GetRetweeters(tweetID)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+		return
+	}
+	ids := []int64{}
+	for _, user := range users {
+		if !filter.match(&user) {
+			print(t, fmt.Sprintf("[twitter] filtering out user (id:%d, name:%s)\n", user.Id, user.Name))
+			continue
+		}
+		ids = append(ids, user.Id)
+	}
+	t.followAll(ids, &FollowFilter{}, &sleepPolicy, campaign)
+	SubsystemFollow.info("[twitter] auto follow disabled")
+}
+
+// AutoFollowRetweetersAsync automatically asynchronously follows the users who
+// retweeted the tweet of id 'tweetID' and matching the given 'filter'.
+// The sleep policy controls the type of sleep you want between requests.
+// The returned campaign allows to pause, resume or stop the follows.
+func (t *TwitterBot) AutoFollowRetweetersAsync(tweetID int64, filter FollowFilter, sleepPolicy *SleepPolicy) *Campaign {
+	campaign := t.newCampaign("AutoFollowRetweeters")
+	filterCopy := copyFollowFilter(filter)
+	sleepPolicyCopy := t.checkSleepPolicy(sleepPolicy)
+	t.run(campaign.Job, func() error {
+		t.autoFollowRetweeters(tweetID, filterCopy, sleepPolicyCopy, campaign)
+		return nil
+	})
+	return campaign
 }
 
 func (t *TwitterBot) checkAPIError(err error) error {
 	if err == nil {
 		return err
 	}
-	apiErr := err.(*anaconda.ApiError)
-	if apiErr != nil && apiErr.StatusCode >= 200 && apiErr.StatusCode < 300 {
+	apiErr, ok := asAPIError(err)
+	if ok && apiErr.StatusCode >= 200 && apiErr.StatusCode < 300 {
 		print(t, err.Error())
 		return nil
 	}
@@ -558,13 +1011,7 @@ func (t *TwitterBot) checkAPIError(err error) error {
 }
 
 func (t *TwitterBot) isStatusOver140CharactersError(err error) bool {
-	if err == nil {
-		return false
-	}
-	apiErr := err.(*anaconda.ApiError)
-	if apiErr != nil &&
-		len(apiErr.Decoded.Errors) > 0 &&
-		apiErr.Decoded.Errors[0].Code == anaconda.TwitterErrorStatusOver140Characters {
+	if hasAPIErrorCode(err, twitterErrorTweetTooLong) {
 		print(t, err.Error())
 		return true
 	}
@@ -600,14 +1047,27 @@ func getEnv(errorList []string, key string) string {
 
 func (t *TwitterBot) loadTweets() ([]anaconda.Tweet, error) {
 	tweets := &[]anaconda.Tweet{}
-	if _, err := os.Stat(t.tweetsPath); os.IsNotExist(err) {
-		tojson.Save(t.tweetsPath, tweets)
-	}
-	err := tojson.Load(t.tweetsPath, tweets)
+	err := t.loadOrCreate(t.tweetsPath, tweets)
 	if err != nil {
 		return nil, err
 	}
-	return *tweets, nil
+	// only the most recent tweets are used to detect duplicates
+	return trimTweets(*tweets, t.getRetention().maxTweets), nil
+}
+
+// saveRetweeted adds the given tweet to the tweets database so that it is
+// detected as a duplicate afterwards. The database is reloaded and saved under
+// the bot mutex since the retweet schedules and streams update it concurrently.
+func (t *TwitterBot) saveRetweeted(tweet anaconda.Tweet) error {
+	maxTweets := t.getRetention().maxTweets
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	tweets := &[]anaconda.Tweet{}
+	err := t.loadOrCreate(t.tweetsPath, tweets)
+	if err != nil {
+		return err
+	}
+	return t.store.Save(t.tweetsPath, trimTweets(append(*tweets, tweet), maxTweets))
 }
 
 func stripText(text, tostripped, endSep string) (string, bool) {
@@ -657,7 +1117,7 @@ func (t *TwitterBot) takeDifference(previous, current []anaconda.Tweet) []anacon
 		addedByID[v.Id] = struct{}{}
 		original, err := getOriginalText(v.Text)
 		if err != nil {
-			log.Println(err.Error())
+			SubsystemRetweet.error("%v", err)
 		}
 		addedByText[original] = struct{}{}
 	}
@@ -668,7 +1128,7 @@ func (t *TwitterBot) takeDifference(previous, current []anaconda.Tweet) []anacon
 		}
 		original, err := getOriginalText(v.Text)
 		if err != nil {
-			log.Println(err.Error())
+			SubsystemRetweet.error("%v", err)
 		}
 		if _, ok := addedByText[original]; ok {
 			print(t, fmt.Sprintf("[twitter] found a duplicate (same original text) from database id:%d, text:%s\n", v.Id, v.Text))
@@ -687,7 +1147,7 @@ func (t *TwitterBot) removeDuplicates(current []anaconda.Tweet) []anaconda.Tweet
 	for _, tweet := range current {
 		original, err := getOriginalText(tweet.Text)
 		if err != nil {
-			log.Println(err.Error())
+			SubsystemRetweet.error("%v", err)
 		}
 		if _, ok := temp[original]; !ok {
 			temp[original] = struct{}{}
@@ -702,6 +1162,10 @@ func (t *TwitterBot) removeDuplicates(current []anaconda.Tweet) []anaconda.Tweet
 func (t *TwitterBot) removeBanned(current []anaconda.Tweet, bannedQueries []string) []anaconda.Tweet {
 	allowed := []anaconda.Tweet{}
 	for _, tweet := range current {
+		if t.isBlockedOrMuted(tweet.User.Id) || t.isBannedUser(tweet.User.ScreenName) {
+			print(t, fmt.Sprintf("[twitter] removing tweet of blocked, muted or banned user (id:%d), text:%s\n", tweet.Id, tweet.Text))
+			continue
+		}
 		banned := false
 		for _, bannedQuery := range bannedQueries {
 			if strings.Contains(tweet.Text, bannedQuery) || strings.Contains(tweet.User.Name, bannedQuery) {
@@ -713,79 +1177,141 @@ func (t *TwitterBot) removeBanned(current []anaconda.Tweet, bannedQueries []stri
 			allowed = append(allowed, tweet)
 		} else {
 			print(t, fmt.Sprintf("[twitter] removing banned tweet (id:%d), text:%s\n", tweet.Id, tweet.Text))
+			t.hitBan(&tweet.User)
 		}
 	}
 	return allowed
 }
 
 func (t *TwitterBot) like(tweet *anaconda.Tweet) {
-	if !t.likePolicy.auto {
+	t.mutex.Lock()
+	auto, threshold := t.likePolicy.auto, t.likePolicy.threshold
+	t.mutex.Unlock()
+	if !auto {
 		return
 	}
-	if tweet.FavoriteCount > t.likePolicy.threshold {
+	if tweet.FavoriteCount > threshold {
+		if tweet.Favorited || t.isLiked(tweet.Id) {
+			print(t, fmt.Sprintf("[twitter] tweet (id:%d) already liked\n", tweet.Id))
+			return
+		}
+		if !t.allowLike() {
+			print(t, fmt.Sprintf("[twitter] skipping like of tweet (id:%d) by policy\n", tweet.Id))
+			return
+		}
 		_, err := t.twitterClient.Favorite(tweet.Id)
 		if err != nil {
 			print(t, fmt.Sprintf("[twitter] failed to like tweet (id:%d), error: %v\n", tweet.Id, err))
 			return
 		}
-		log.Printf("[twitter] liked tweet (id:%d)\n", tweet.Id)
+		t.countLike()
+		t.addLike(tweet.Id)
 	} else if tweet.RetweetedStatus != nil &&
-		tweet.RetweetedStatus.FavoriteCount > t.likePolicy.threshold {
+		tweet.RetweetedStatus.FavoriteCount > threshold {
 		t.like(tweet.RetweetedStatus)
 	}
 }
 
+func (t *TwitterBot) allowLike() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.likePolicy.allow()
+}
+
+func (t *TwitterBot) countLike() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.likePolicy.dayCount++
+}
+
+// allow randomly allows a like according to the policy probability
+// as long as the daily cap is not reached.
+func (p *likePolicy) allow() bool {
+	if timeSince(p.dayStart) >= 24*time.Hour {
+		p.dayStart = timeNow()
+		p.dayCount = 0
+	}
+	if p.maxPerDay > 0 && p.dayCount >= p.maxPerDay {
+		return false
+	}
+	return getRand().Float64() < p.probability
+}
+
+// SetVerbose enables or disables the detailed logs of the bot, independently
+// of its sleeps. It must be called before running the bot.
+func (t *TwitterBot) SetVerbose(verbose bool) {
+	logInfo("[twitter] setting verbose -> verbose: %t", verbose)
+	t.verbose = verbose
+}
+
+// SetNoSleep removes or restores all the sleeps between API twitter calls,
+// the ones of the sleep policies included. Removing the sleeps is meant for
+// tests since they protect the account from being detected as a bot.
+// It must be called before running the bot.
+func (t *TwitterBot) SetNoSleep(noSleep bool) {
+	logInfo("[twitter] setting no sleep -> noSleep: %t", noSleep)
+	t.noSleep = noSleep
+}
+
 func print(t *TwitterBot, text string) {
-	if t != nil && t.debug {
-		log.Println(text)
+	if t != nil && t.verbose {
+		logDebug("%s", strings.TrimSuffix(text, "\n"))
 	}
 }
 
 func (t *TwitterBot) sleep() {
-	if !t.debug {
-		freeze.Sleep(maxRandTimeSleepBetweenRequests)
-	}
+	t.sleepContext(t.botContext())
 }
 
-func (t *TwitterBot) maybeSleep(chance, totalChance, min, max int) {
-	if !t.debug {
-		freeze.MaybeSleepMinMax(chance, totalChance, min, max)
+// sleepContext randomly sleeps between requests. It returns false
+// if the context is done before the end of the sleep.
+func (t *TwitterBot) sleepContext(ctx context.Context) bool {
+	if t.noSleep {
+		return ctx.Err() == nil
 	}
+	return randSleep(ctx, maxRandTimeSleepBetweenRequests)
 }
 
 func (t *TwitterBot) controlledSleep(sleepPolicy *SleepPolicy) {
-	if !t.debug && sleepPolicy != nil {
-		freeze.Sleep(sleepPolicy.MaxRand)
-		t.maybeSleep(sleepPolicy.MaybeSleepChance, sleepPolicy.MaybeSleepTotalChance,
-			sleepPolicy.MaybeSleepMin, sleepPolicy.MaybeSleepMax)
+	t.controlledSleepContext(t.botContext(), sleepPolicy)
+}
+
+// controlledSleepContext sleeps following the given sleep policy. It returns
+// false if the context is done before the end of the sleep.
+func (t *TwitterBot) controlledSleepContext(ctx context.Context, sleepPolicy *SleepPolicy) bool {
+	if t.noSleep || sleepPolicy == nil {
+		return ctx.Err() == nil
 	}
+	return randSleep(ctx, sleepPolicy.MaxRand) &&
+		maybeSleep(ctx, sleepPolicy.MaybeSleepChance, sleepPolicy.MaybeSleepTotalChance,
+			sleepPolicy.MaybeSleepMin, sleepPolicy.MaybeSleepMax)
 }
 
-func checkBotRestriction(err error) {
+func (t *TwitterBot) checkBotRestriction(err error) {
 	if err != nil {
-		strErr := err.Error()
-		if strings.Contains(strErr, "Invalid or expired token") ||
-			strings.Contains(strErr, "this account is temporarily locked") {
-			log.Fatalln(err)
+		if errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrAccountLocked) {
+			t.alert("account locked or token expired: %s", err)
+			t.handleError(err, nil)
+			return
 		}
-		log.Println(strErr)
+		logError("%v", err)
 	}
 }
 
 func (t *TwitterBot) unfollowUser(user *anaconda.User) {
-	unfollowed, err := t.twitterClient.UnfollowUserId(user.Id)
+	_, err := t.twitterClient.UnfollowUserId(user.Id)
 	if err != nil {
-		checkBotRestriction(err)
+		t.checkBotRestriction(err)
 		print(t, fmt.Sprintf("[twitter] failed to unfollow user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
 	}
-	log.Printf("[twitter] unfollowing user (id:%d, name:%s)\n", unfollowed.Id, unfollowed.Name)
 }
 
-func checkUnableToFollowAtThisTime(err error) bool {
+func (t *TwitterBot) checkUnableToFollowAtThisTime(ctx context.Context, err error) bool {
 	if err != nil {
-		if strings.Contains(err.Error(), "You are unable to follow more people at this time") {
-			log.Println("unable to follow at this time, waiting 15min...,", err.Error())
-			time.Sleep(15 * time.Minute)
+		if errors.Is(err, ErrUnableToFollow) {
+			wait := t.getTiming().UnableToFollowWait
+			t.alert("unable to follow at this time, waiting %s...: %s", wait, err.Error())
+			sleepContext(ctx, wait)
 			return true
 		}
 		return false
@@ -794,21 +1320,90 @@ func checkUnableToFollowAtThisTime(err error) bool {
 }
 
 func (t *TwitterBot) followUser(user *anaconda.User) {
-	followed, err := t.twitterClient.FollowUserId(user.Id, nil)
-	if err != nil && !checkUnableToFollowAtThisTime(err) {
-		checkBotRestriction(err)
-		print(t, fmt.Sprintf("[twitter] failed to follow user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
+	if !t.canFollow(user.Id) {
+		return
+	}
+	if err := t.checkFollowQuota(); err != nil {
+		print(t, fmt.Sprintf("%s, not following user (id:%d, name:%s)\n", err, user.Id, user.Name))
+		return
+	}
+	_, err := t.twitterClient.FollowUserId(user.Id, nil)
+	if err != nil {
+		if !t.checkUnableToFollowAtThisTime(t.botContext(), err) {
+			t.checkBotRestriction(err)
+			print(t, fmt.Sprintf("[twitter] failed to follow user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
+		}
+		return
+	}
+	t.countFollow()
+}
+
+func formatQuote(template string, tweet *anaconda.Tweet) string {
+	return strings.Replace(template, quoteAuthorTag, tweet.User.ScreenName, -1)
+}
+
+func getTweetURL(tweet *anaconda.Tweet) string {
+	return fmt.Sprintf("https://twitter.com/%s/status/%d", tweet.User.ScreenName, tweet.Id)
+}
+
+// getRetweetPolicy returns a snapshot of the retweet policy, whose
+// retweets are then counted by countRetweets.
+func (t *TwitterBot) getRetweetPolicy() retweetPolicy {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return *t.retweetPolicy
+}
+
+// countRetweets counts the retweets made with a snapshot
+// of the retweet policy so that quotes keep their turn.
+func (t *TwitterBot) countRetweets(retweets int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.retweetPolicy.count += retweets
+}
+
+func (p *retweetPolicy) isQuoteTurn() bool {
+	return p.quoteEvery > 0 && (p.count+1)%p.quoteEvery == 0
+}
+
+// quote quotes the given tweet with a comment generated by the text generator
+// if any, see SetTextGenerator, or made from the quote template otherwise.
+func (t *TwitterBot) quote(tweet *anaconda.Tweet, policy *retweetPolicy) (anaconda.Tweet, error) {
+	comment := formatQuote(policy.quoteTemplate, tweet)
+	if generator, prompt := t.getQuoteGenerator(); generator != nil {
+		// the comment is followed by the link of the tweet, see truncate
+		generated, err := generateText(generator, prompt, tweet, tweetTextMaxSize-tcoLinksMaxLength-1)
+		if err != nil {
+			logWarn("[twitter] failed to generate quote comment of tweet (id:%d): %v", tweet.Id, err)
+		} else {
+			comment = generated
+		}
 	}
-	log.Printf("[twitter] following user (id:%d, name:%s)\n", followed.Id, followed.Name)
+	return t.tryPostTweet(comment, getTweetURL(tweet), nil)
 }
 
-// retweet retweets the first tweet been able to retweet.
+// retweet retweets the first tweet been able to retweet following the given policy.
+// Every 'policy.quoteEvery' retweets, the tweet is quoted instead.
 // It returns an error if no retweet has been possible.
-func (t *TwitterBot) retweet(current []anaconda.Tweet) (rt anaconda.Tweet, err error) {
+func (t *TwitterBot) retweet(current []anaconda.Tweet, policy *retweetPolicy) (rt anaconda.Tweet, err error) {
 	for _, tweet := range current {
-		if t.retweetPolicy.like {
+		if policy.like {
 			t.like(&tweet)
 		}
+		if policy.isQuoteTurn() {
+			quoted, err := t.quote(&tweet, policy)
+			if err != nil {
+				print(t, fmt.Sprintf("[twitter] failed to quote tweet (id:%d), error: %v\n", tweet.Id, err))
+				t.followUser(&tweet.User)
+				continue
+			}
+			policy.count++
+			SubsystemRetweet.info("[twitter] quote (qid:%d, id:%d)", quoted.Id, tweet.Id)
+			t.followUser(&tweet.User)
+			// return the quoted tweet so that it is saved in database
+			// and properly detected as a duplicate afterwards
+			return tweet, nil
+		}
 		retweet, err := t.twitterClient.Retweet(tweet.Id, false)
 		if err != nil {
 			print(t, fmt.Sprintf("[twitter] failed to retweet tweet (id:%d), error: %v\n", tweet.Id, err))
@@ -816,10 +1411,10 @@ func (t *TwitterBot) retweet(current []anaconda.Tweet) (rt anaconda.Tweet, err e
 			continue
 		}
 		rt = retweet
-		if t.retweetPolicy.like {
+		policy.count++
+		if policy.like {
 			t.like(&rt)
 		}
-		log.Printf("[twitter] retweet (rid:%d, id:%d)\n", rt.Id, tweet.Id)
 		t.followUser(&tweet.User)
 		return rt, err
 	}
@@ -827,9 +1422,14 @@ func (t *TwitterBot) retweet(current []anaconda.Tweet) (rt anaconda.Tweet, err e
 	return rt, err
 }
 
-func (t *TwitterBot) getTweets(queries, bannedQueries []string, previous []anaconda.Tweet) ([]anaconda.Tweet, error) {
-	query := freeze.GetRandomElement(queries)
-	log.Println("[twitter] searching tweets to retweet with query:", query)
+func (t *TwitterBot) getTweets(bannedByQuery map[string][]string, previous []anaconda.Tweet) ([]anaconda.Tweet, error) {
+	queries := []string{}
+	for query := range bannedByQuery {
+		queries = append(queries, query)
+	}
+	sort.Strings(queries)
+	query := randomElement(queries)
+	SubsystemRetweet.debug("[twitter] searching tweets to retweet with query: %s", query)
 	v := url.Values{}
 	v.Set("count", strconv.Itoa(defaultMaxRetweetBySearch))
 	results, err := t.twitterClient.GetSearch(query, v)
@@ -837,159 +1437,311 @@ func (t *TwitterBot) getTweets(queries, bannedQueries []string, previous []anaco
 		return nil, err
 	}
 	current := results.Statuses
-	current = t.removeBanned(current, bannedQueries)
+	current = t.removeBanned(current, bannedByQuery[query])
+	current = t.removeBySentiment(current)
+	current = t.removeByContent(current)
 	current = t.removeDuplicates(current)
 	current = t.takeDifference(previous, current)
-	log.Println("[twitter] found", len(current), "tweet(s) to retweet matching pattern")
+	SubsystemRetweet.debug("[twitter] found %d tweet(s) to retweet matching pattern", len(current))
 	return current, nil
 }
 
-func (t *TwitterBot) autoRetweet(queries, bannedQueries []string) error {
+func (t *TwitterBot) autoRetweet(ctx context.Context, bannedByQuery map[string][]string) error {
 	count := 0
 	previous, err := t.loadTweets()
 	if err != nil {
 		return err
 	}
 	for {
-		t.sleep()
-		tweets, err := t.getTweets(queries, bannedQueries, previous)
+		if !t.sleepContext(ctx) {
+			return ctx.Err()
+		}
+		tweets, err := t.getTweets(bannedByQuery, previous)
 		if err != nil {
 			return err
 		}
-		retweeted, err := t.retweet(tweets)
+		policy := t.getRetweetPolicy()
+		start := policy.count
+		retweeted, err := t.retweet(tweets, &policy)
+		t.countRetweets(policy.count - start)
 		if err != nil {
-			if count < t.retweetPolicy.maxTry {
+			if count < policy.maxTry {
 				count++
 				continue
 			} else {
-				return fmt.Errorf("[twitter] unable to retweet something after %d tries\n", t.retweetPolicy.maxTry)
+				return fmt.Errorf("[twitter] unable to retweet something after %d tries\n", policy.maxTry)
 			}
 		}
-		previous = append(previous, retweeted)
-		tojson.Save(t.tweetsPath, previous)
-		return nil
+		return t.saveRetweeted(retweeted)
 	}
 }
 
-func (t *TwitterBot) updateFollowers() error {
-	followers := &twitterUsers{
-		Ids: make(map[string]*twitterUser),
+// fetchIds returns the ids of all the pages of the given cursored
+// endpoint. It fails if a page failed, not to return partial ids.
+func fetchIds(fetch func(v url.Values) (anaconda.Cursor, error)) ([]int64, error) {
+	ids := []int64{}
+	v := url.Values{}
+	cursor := "-1"
+	for {
+		v.Set("cursor", cursor)
+		page, err := fetch(v)
+		if err != nil {
+			return nil, wrapAPIError(err)
+		}
+		ids = append(ids, page.Ids...)
+		cursor = page.Next_cursor_str
+		if cursor == "0" || cursor == "" {
+			return ids, nil
+		}
 	}
-	if _, err := os.Stat(t.followersPath); os.IsNotExist(err) {
-		tojson.Save(t.followersPath, followers)
+}
+
+// fetchFollowersIds returns the ids of all the followers of the bot.
+func (t *TwitterBot) fetchFollowersIds() ([]int64, error) {
+	return fetchIds(t.twitterClient.GetFollowersIds)
+}
+
+// fetchFriendsIds returns the ids of all the friends of the bot.
+func (t *TwitterBot) fetchFriendsIds() ([]int64, error) {
+	return fetchIds(t.twitterClient.GetFriendsIds)
+}
+
+// syncUsers synchronizes the users database at the given path with the
+// given ids of the users currently following or followed by the bot.
+// Users missing from 'ids' are flagged as unfollowed. It must be called
+// with the bot mutex locked since the database may be saved concurrently.
+func (t *TwitterBot) syncUsers(usersPath string, ids []int64) (*twitterUsers, error) {
+	users := &twitterUsers{
+		Ids: make(map[string]*twitterUser),
 	}
-	err := tojson.Load(t.followersPath, followers)
+	err := t.loadOrCreate(usersPath, users)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, v := range followers.Ids {
+	following := map[string]bool{}
+	for strID, v := range users.Ids {
+		following[strID] = v.Follow
 		v.Follow = false
 	}
-	for v := range t.twitterClient.GetFollowersIdsAll(nil) {
-		for _, id := range v.Ids {
-			strID := strconv.FormatInt(id, 10)
-			user, ok := followers.Ids[strID]
-			if ok {
-				user.Follow = true
-			} else {
-				followers.Ids[strID] = &twitterUser{
-					Timestamp: time.Now().UnixNano(),
-					Follow:    true,
-				}
+	for _, id := range ids {
+		strID := strconv.FormatInt(id, 10)
+		user, ok := users.Ids[strID]
+		if ok {
+			user.Follow = true
+		} else {
+			users.Ids[strID] = &twitterUser{
+				Timestamp: timeNow().UnixNano(),
+				Follow:    true,
 			}
 		}
 	}
-	err = tojson.Save(t.followersPath, followers)
+	// keep track of when users stopped following or being followed, so that
+	// unfollowers are detected and friends unfollowed outside of the bot are
+	// not followed again either
+	for strID, v := range users.Ids {
+		if following[strID] && !v.Follow {
+			v.Unfollowed = timeNow().UnixNano()
+		}
+	}
+	err = t.store.Save(usersPath, users)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	t.followers = followers
-	return nil
+	return users, nil
 }
 
-func (t *TwitterBot) updateFriends() error {
-	friends := &twitterUsers{
-		Ids: make(map[string]*twitterUser),
+func (t *TwitterBot) updateFollowers() error {
+	ids, err := t.fetchFollowersIds()
+	if err != nil {
+		return err
 	}
-	if _, err := os.Stat(t.friendsPath); os.IsNotExist(err) {
-		tojson.Save(t.friendsPath, friends)
+	t.mutex.Lock()
+	followers, err := t.syncUsers(t.followersPath, ids)
+	if err == nil {
+		t.followers = followers
 	}
-	err := tojson.Load(t.friendsPath, friends)
+	t.mutex.Unlock()
 	if err != nil {
 		return err
 	}
-	for _, v := range friends.Ids {
-		v.Follow = false
+	t.recordGrowth()
+	return nil
+}
+
+func (t *TwitterBot) updateFriends() error {
+	ids, err := t.fetchFriendsIds()
+	if err != nil {
+		return err
 	}
-	for v := range t.twitterClient.GetFriendsIdsAll(nil) {
-		for _, id := range v.Ids {
-			strID := strconv.FormatInt(id, 10)
-			user, ok := friends.Ids[strID]
-			if ok {
-				user.Follow = true
-			} else {
-				friends.Ids[strID] = &twitterUser{
-					Timestamp: time.Now().UnixNano(),
-					Follow:    true,
-				}
-			}
-		}
+	t.mutex.Lock()
+	friends, err := t.syncUsers(t.friendsPath, ids)
+	if err == nil {
+		t.friends = friends
 	}
-	err = tojson.Save(t.friendsPath, friends)
+	t.mutex.Unlock()
 	if err != nil {
 		return err
 	}
-	t.friends = friends
+	t.recordGrowth()
 	return nil
 }
 
+// Sync refreshes the followers and friends databases from twitter.
+func (t *TwitterBot) Sync() error {
+	err := t.updateFollowers()
+	if err != nil {
+		return err
+	}
+	return t.updateFriends()
+}
+
+// SyncPeriodically refreshes periodically the followers and friends databases
+// so that long running bots stay in sync with the followers and friends
+// followed or unfollowed outside of the bot.
+// The refresh frequency is set up by the given 'freq' input parameter.
+// It logs errors if the refresh failed.
+func (t *TwitterBot) SyncPeriodically(freq time.Duration) {
+	t.newJob("SyncPeriodically").runPeriodically(freq, t.Sync)
+}
+
+// SyncPeriodicallyAsync refreshes asynchronously and periodically the
+// followers and friends databases, see SyncPeriodically.
+func (t *TwitterBot) SyncPeriodicallyAsync(freq time.Duration) *Job {
+	return t.runPeriodicallyAsync("SyncPeriodically", freq, func(ctx context.Context) error {
+		return t.Sync()
+	})
+}
+
 // unfollowFriend flags the friend as not followed anymore.
 // We do not remove friends from database, we just flag them as non friend.
 func (t *TwitterBot) unfollowFriend(id int64) {
 	t.mutex.Lock()
-	defer t.mutex.Unlock()
-	t.friends.Ids[strconv.FormatInt(id, 10)].Follow = false
-	err := tojson.Save(t.friendsPath, t.friends)
-	if err != nil {
-		log.Fatalln(err)
+	user := t.friends.Ids[strconv.FormatInt(id, 10)]
+	user.Follow = false
+	user.Unfollowed = timeNow().UnixNano()
+	t.mutex.Unlock()
+	t.saveFriends()
+}
+
+// isUnfollowedBefore returns true if the friend 'a' must be unfollowed
+// before the friend 'b' according to the unfollow order.
+// It must be called with the bot mutex locked.
+func (t *TwitterBot) isUnfollowedBefore(a, b string) bool {
+	if t.unfollowPolicy.order == UnfollowNonFollowersFirst {
+		aFollower, bFollower := t.isFollowingBack(a), t.isFollowingBack(b)
+		if aFollower != bFollower {
+			return !aFollower
+		}
 	}
+	return t.friends.Ids[a].Timestamp < t.friends.Ids[b].Timestamp
 }
 
-func (t *TwitterBot) getFriendToUnFollow() (int64, bool) {
+// getFriendToUnFollow returns the next friend to unfollow, ignoring
+// the 'skipped' friends.
+func (t *TwitterBot) getFriendToUnFollow(skipped map[int64]bool) (int64, bool) {
 	t.mutex.Lock()
-	defer t.mutex.Unlock()
+	selected := ""
+	var selectedID int64
+	// errors are handled once the mutex is released
+	var errs []error
 	for strID, user := range t.friends.Ids {
-		// unfollow only if is followed and is in database from at least 1 day
-		if time.Now().UnixNano()-user.Timestamp < oneDayInNano || !user.Follow {
+		// unfollow only if is followed and is in database from at least 'unfollowPolicy.minAge'
+		if timeNow().UnixNano()-user.Timestamp < t.unfollowPolicy.minAge.Nanoseconds() || !user.Follow {
+			continue
+		}
+		// keep friends following back if asked to
+		if t.unfollowPolicy.keepFollowers && t.isFollowingBack(strID) {
+			continue
+		}
+		// never unfollow whitelisted friends
+		if t.isWhitelisted(strID) {
 			continue
 		}
 		id, err := strconv.ParseInt(strID, 10, 64)
 		if err != nil {
-			log.Fatalln(err)
+			errs = append(errs, err)
+			continue
+		}
+		if skipped[id] {
+			continue
+		}
+		if selected == "" || t.isUnfollowedBefore(strID, selected) {
+			selected = strID
+			selectedID = id
 		}
-		return id, true
+		if t.unfollowPolicy.order == UnfollowAnyOrder {
+			break
+		}
+	}
+	t.mutex.Unlock()
+	for _, err := range errs {
+		t.handleError(err, nil)
 	}
-	return 0, false
+	return selectedID, selected != ""
 }
 
-func (t *TwitterBot) unfollowAll(sleepPolicy *SleepPolicy) {
-	var id int64
-	for ok := true; ok; id, ok = t.getFriendToUnFollow() {
-		if !ok {
-			break
+// unfollowAll unfollows friends every 'unfollowPolicy.idleWait'
+// until the campaign is stopped.
+func (t *TwitterBot) unfollowAll(sleepPolicy *SleepPolicy, campaign *Campaign) {
+	t.mutex.Lock()
+	idleWait := t.unfollowPolicy.idleWait
+	t.mutex.Unlock()
+	ticker := newTicker(idleWait)
+	for {
+		t.unfollowRun(sleepPolicy, campaign)
+		if campaign.Stopped() {
+			return
+		}
+		SubsystemFollow.info("[twitter] no more friends to unfollow in this run, waiting %s...", idleWait)
+		if !ticker.wait(campaign.ctx) {
+			return
+		}
+	}
+}
+
+// unfollowRun unfollows friends until there is no more friends to unfollow,
+// the maximum number of unfollows per run is reached or the campaign is stopped.
+func (t *TwitterBot) unfollowRun(sleepPolicy *SleepPolicy, campaign *Campaign) {
+	t.mutex.Lock()
+	policy := *t.unfollowPolicy
+	t.mutex.Unlock()
+	if policy.keepFollowers || policy.order == UnfollowNonFollowersFirst {
+		// refresh followers so that friends who followed back
+		// since the last update are properly detected
+		err := t.updateFollowers()
+		if err != nil {
+			SubsystemFollow.error("%v", err)
+		}
+	}
+	// friends failing to be unfollowed are skipped until the next run
+	skipped := map[int64]bool{}
+	for count := 0; policy.maxPerRun <= 0 || count < policy.maxPerRun; {
+		id, ok := t.getFriendToUnFollow(skipped)
+		if !ok || !campaign.wait() {
+			return
+		}
+		if !t.waitUnfollowQuota(campaign.ctx) {
+			return
 		}
-		user, err := t.twitterClient.UnfollowUserId(id)
+		_, err := t.twitterClient.UnfollowUserId(id)
 		if err != nil {
-			checkBotRestriction(err)
+			t.checkBotRestriction(err)
+			skipped[id] = true
 			continue
 		}
+		t.countUnfollow()
 		t.unfollowFriend(id)
-		log.Printf("[twitter] unfollowing (id:%d, name:%s)\n", user.Id, user.Name)
-		t.controlledSleep(sleepPolicy)
+		count++
+		campaign.done()
+		t.controlledSleepContext(campaign.ctx, sleepPolicy)
 	}
-	log.Println("[twitter] no more friends to unfollow, waiting 3 hours...")
-	time.Sleep(3 * time.Hour)
-	t.unfollowAll(sleepPolicy)
+	SubsystemFollow.info("[twitter] maximum of %d unfollows per run reached", policy.maxPerRun)
+}
+
+// isFollowingBack must be called with the bot mutex locked.
+func (t *TwitterBot) isFollowingBack(strID string) bool {
+	user, ok := t.followers.Ids[strID]
+	return ok && user.Follow
 }
 
 func (t *TwitterBot) isFollower(id int64) bool {
@@ -1005,77 +1757,173 @@ func (t *TwitterBot) getFriend(id int64) (*twitterUser, bool) {
 	user, ok := t.friends.Ids[strconv.FormatInt(id, 10)]
 	if ok {
 		return &twitterUser{
-			Timestamp: user.Timestamp,
-			Follow:    user.Follow,
+			Timestamp:  user.Timestamp,
+			Follow:     user.Follow,
+			Unfollowed: user.Unfollowed,
 		}, ok
 	}
 	return nil, false
 }
 
-func (t *TwitterBot) addFriend(id int64) {
+// canFollow returns true if the user of the given id is neither a friend
+// nor an unfollowed friend still under the refollow cooldown.
+func (t *TwitterBot) canFollow(id int64) bool {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	t.friends.Ids[strconv.FormatInt(id, 10)] = &twitterUser{
-		Timestamp: time.Now().UnixNano(),
-		Follow:    true,
+	return t.canRefollow(strconv.FormatInt(id, 10))
+}
+
+// canRefollow must be called with the bot mutex locked.
+func (t *TwitterBot) canRefollow(strID string) bool {
+	user, ok := t.friends.Ids[strID]
+	if !ok {
+		return true
 	}
-	err := tojson.Save(t.friendsPath, t.friends)
+	if user.Follow || t.unfollowPolicy.refollowCooldown == 0 {
+		return false
+	}
+	unfollowed := user.Unfollowed
+	if unfollowed == 0 {
+		// friends unfollowed before the unfollow timestamp existed
+		unfollowed = user.Timestamp
+	}
+	return timeNow().UnixNano()-unfollowed >= t.unfollowPolicy.refollowCooldown.Nanoseconds()
+}
+
+func (t *TwitterBot) addFriend(user *anaconda.User, source string) {
+	t.mutex.Lock()
+	t.friends.Ids[strconv.FormatInt(user.Id, 10)] = &twitterUser{
+		Timestamp:      timeNow().UnixNano(),
+		Follow:         true,
+		Source:         source,
+		ScreenName:     user.ScreenName,
+		FollowersCount: user.FollowersCount,
+	}
+	t.mutex.Unlock()
+	t.saveFriends()
+}
+
+// saveFriends saves the friends database following the error policy.
+// It must be called with the bot mutex unlocked.
+func (t *TwitterBot) saveFriends() {
+	save := func() error {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		return t.store.Save(t.friendsPath, t.friends)
+	}
+	err := save()
 	if err != nil {
-		log.Fatalln(err)
+		t.handleError(err, save)
 	}
 }
 
-func (t *TwitterBot) followAll(ids []int64, sleepPolicy *SleepPolicy) {
+func (t *TwitterBot) followAll(ids []int64, filter *FollowFilter, sleepPolicy *SleepPolicy, campaign *Campaign) {
+	candidates := []int64{}
 	for _, id := range ids {
-		if _, ok := t.getFriend(id); ok || t.isFollower(id) {
+		if !t.canFollow(id) || t.isFollower(id) {
 			continue
 		}
+		candidates = append(candidates, id)
+	}
+	candidates, err := t.filterUsers(candidates, filter)
+	if err != nil {
+		SubsystemFollow.error("%v", err)
+		return
+	}
+	t.startProgress(campaign, candidates)
+	t.followCandidates(candidates, 0, sleepPolicy, campaign)
+}
+
+// followCandidates follows the given candidates starting at the given position
+// and saves the progress of the campaign after each candidate.
+func (t *TwitterBot) followCandidates(candidates []int64, position int, sleepPolicy *SleepPolicy, campaign *Campaign) {
+	for i := position; i < len(candidates); i++ {
+		id := candidates[i]
+		campaign.setRemaining(len(candidates) - i)
+		if !campaign.wait() {
+			t.flushProgress(campaign)
+			return
+		}
+		t.saveProgress(campaign, i+1)
+		// a resumed campaign may have followed the candidate before being interrupted
+		if !t.canFollow(id) {
+			continue
+		}
+		if !t.waitFollowQuota(campaign.ctx) {
+			t.flushProgress(campaign)
+			return
+		}
 		user, err := t.twitterClient.FollowUserId(id, nil)
-		if err != nil && !checkUnableToFollowAtThisTime(err) {
-			checkBotRestriction(err)
-			print(t, fmt.Sprintf("[twitter] failed to follow user (id:%d, name:%s), error: %v\n", user.Id, user.Name, err))
+		if err != nil {
+			if !t.checkUnableToFollowAtThisTime(campaign.ctx, err) {
+				t.checkBotRestriction(err)
+				print(t, fmt.Sprintf("[twitter] failed to follow user (id:%d), error: %v\n", id, err))
+			}
 			continue
 		}
-		t.addFriend(id)
-		log.Printf("[twitter] following (id:%d, name:%s)\n", user.Id, user.Name)
-		t.controlledSleep(sleepPolicy)
+		t.countFollow()
+		t.addFriend(&user, campaign.Source())
+		campaign.done()
+		t.controlledSleepContext(campaign.ctx, sleepPolicy)
 	}
+	campaign.setRemaining(0)
+	t.endProgress(campaign)
+	t.alert("follow campaign '%s' finished, %d user(s) followed", campaign.Source(), campaign.Processed())
 }
 
-func (t *TwitterBot) fetchUserIds(query string, maxPage int) []int64 {
-	users, err := t.twitterClient.GetUserSearch(query, nil)
+// fetchFollowersPage returns the page of followers ids of the user of id 'userID'
+// starting at the given cursor and the cursor of the next page, "0" if none.
+func (t *TwitterBot) fetchFollowersPage(userID int64, cursor string) ([]int64, string, error) {
+	v := url.Values{}
+	v.Set("cursor", cursor)
+	page, err := t.twitterClient.GetFollowersUser(userID, v)
 	if err != nil {
-		log.Fatalln(err.Error())
+		return nil, "", err
 	}
+	return page.Ids, page.Next_cursor_str, nil
+}
+
+// fetchFollowerIDs returns the followers ids of the user of id 'userID'
+// over 'maxPages' pages at most, 0 meaning all pages.
+func (t *TwitterBot) fetchFollowerIDs(userID int64, maxPages int) ([]int64, error) {
 	ids := []int64{}
-	if len(users) == 0 {
-		return nil
-	}
-	// gettings followers of the first user found
-	user := users[0]
-	nextCursor := "-1"
-	currentPage := 1
-	for {
-		v := url.Values{}
-		if nextCursor != "-1" {
-			v.Set("cursor", nextCursor)
-		}
-		cursor, err := t.twitterClient.GetFollowersUser(user.Id, nil)
+	cursor := "-1"
+	for page := 0; maxPages <= 0 || page < maxPages; page++ {
+		pageIds, next, err := t.fetchFollowersPage(userID, cursor)
 		if err != nil {
-			checkBotRestriction(err)
-			continue
-		}
-		for _, v := range cursor.Ids {
-			ids = append(ids, v)
-		}
-		if currentPage >= maxPage {
-			break
+			return ids, err
 		}
-		currentPage++
-		nextCursor = cursor.Next_cursor_str
-		if nextCursor == "0" {
+		ids = append(ids, pageIds...)
+		cursor = next
+		if cursor == "0" || cursor == "" {
 			break
 		}
 	}
-	return ids
+	return ids, nil
+}
+
+// FetchFollowerIDs returns the followers ids of the user of the given
+// screen name over 'maxPages' pages (5000 users max by page) at most,
+// 0 meaning all pages.
+// It returns an error, along with the ids fetched so far, if a page failed.
+func (t *TwitterBot) FetchFollowerIDs(screenName string, maxPages int) ([]int64, error) {
+	user, err := t.twitterClient.GetUsersShow(screenName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return t.fetchFollowerIDs(user.Id, maxPages)
+}
+
+// fetchUserIds returns the followers ids of the first user
+// found using the given 'query' over 'maxPage' pages at most.
+func (t *TwitterBot) fetchUserIds(query string, maxPage int) ([]int64, error) {
+	users, err := t.twitterClient.GetUserSearch(query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	// gettings followers of the first user found
+	return t.fetchFollowerIDs(users[0].Id, maxPage)
 }
@@ -1,7 +1,14 @@
 package twbot
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
 
 	. "gopkg.in/check.v1"
 )
@@ -81,3 +88,371 @@ func (s *MySuite) TestOriginalText(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(original, Equals, "")
 }
+
+func (s *MySuite) TestFormatQuote(c *C) {
+	tweet := &anaconda.Tweet{
+		Id: 42,
+		User: anaconda.User{
+			ScreenName: "nasa",
+		},
+	}
+	c.Assert(formatQuote(defaultQuoteTemplate, tweet), Equals, "via @nasa")
+	c.Assert(formatQuote("no tag", tweet), Equals, "no tag")
+	c.Assert(getTweetURL(tweet), Equals, "https://twitter.com/nasa/status/42")
+}
+
+func (s *MySuite) TestMakeBannedByQuery(c *C) {
+	banned := []string{"giveaway"}
+	bannedByQuery := makeBannedByQuery([]string{"apple", "nasa"}, banned)
+	c.Assert(bannedByQuery, DeepEquals, map[string][]string{
+		"apple": {"giveaway"},
+		"nasa":  {"giveaway"},
+	})
+	banned[0] = "modified"
+	c.Assert(bannedByQuery["apple"][0], Equals, "giveaway")
+	copied := copyBannedByQuery(bannedByQuery)
+	copied["nasa"][0] = "modified"
+	c.Assert(bannedByQuery["nasa"][0], Equals, "giveaway")
+}
+
+func (s *MySuite) TestLikePolicyAllow(c *C) {
+	policy := &likePolicy{
+		probability: 1,
+		maxPerDay:   2,
+	}
+	c.Assert(policy.allow(), Equals, true)
+	policy.dayCount = 2
+	c.Assert(policy.allow(), Equals, false)
+	policy.dayStart = policy.dayStart.Add(-24 * time.Hour)
+	c.Assert(policy.allow(), Equals, true)
+	c.Assert(policy.dayCount, Equals, 0)
+	policy.probability = 0
+	c.Assert(policy.allow(), Equals, false)
+}
+
+// go test -race checks that policies can be changed while loops are running.
+func (s *MySuite) TestSetPoliciesWhileRunning(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.likes = &twitterLikes{Ids: map[string]int64{}}
+	bot.likePolicy = &likePolicy{}
+	bot.retweetPolicy = &retweetPolicy{}
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.defaultSleepPolicy = &SleepPolicy{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			bot.like(&anaconda.Tweet{Id: int64(i), FavoriteCount: 10, Favorited: true})
+			bot.getRetweetPolicy()
+			bot.countRetweets(1)
+			bot.checkSleepPolicy(nil)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		bot.SetLikePolicy(true, 1, 1, 0)
+		bot.SetRetweetPolicy(i, true)
+		bot.SetQuotePolicy(i, defaultQuoteTemplate)
+		bot.SetUnfollowPolicy(time.Hour, true)
+	}
+	<-done
+	c.Assert(bot.getRetweetPolicy().count, Equals, 20)
+}
+
+func (s *MySuite) TestFetchFollowerIDs(c *C) {
+	client := &fakeClient{
+		users: map[string]anaconda.User{
+			"nasa": {Id: 1, ScreenName: "nasa"},
+		},
+		followers: map[int64][]int64{
+			1: {10, 11, 12, 13, 14},
+		},
+		pageSize: 2,
+	}
+	bot := makeFakeBot(client)
+
+	ids, err := bot.FetchFollowerIDs("nasa", 0)
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{10, 11, 12, 13, 14})
+	c.Assert(client.cursors, DeepEquals, []string{"-1", "2", "4"})
+
+	client.cursors = nil
+	ids, err = bot.FetchFollowerIDs("nasa", 2)
+	c.Assert(err, IsNil)
+	c.Assert(ids, DeepEquals, []int64{10, 11, 12, 13})
+	c.Assert(client.cursors, DeepEquals, []string{"-1", "2"})
+
+	client.failAt = "2"
+	ids, err = bot.FetchFollowerIDs("nasa", 0)
+	c.Assert(err, NotNil)
+	c.Assert(ids, DeepEquals, []int64{10, 11})
+
+	_, err = bot.FetchFollowerIDs("unknown", 0)
+	c.Assert(err, NotNil)
+}
+
+func (s *MySuite) TestGetRetweeters(c *C) {
+	client := &fakeClient{
+		retweets: map[int64][]anaconda.Tweet{
+			42: {
+				{User: anaconda.User{Id: 1, FollowersCount: 10}},
+				{User: anaconda.User{Id: 2, FollowersCount: 1000}},
+			},
+		},
+	}
+	bot := makeFakeBot(client)
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.followGuard = &followGuard{}
+	bot.noSleep = true
+	users, err := bot.GetRetweeters(42)
+	c.Assert(err, IsNil)
+	c.Assert(users, HasLen, 2)
+	c.Assert(users[0].Id, Equals, int64(1))
+	c.Assert(users[1].Id, Equals, int64(2))
+
+	users, err = bot.GetRetweeters(43)
+	c.Assert(err, IsNil)
+	c.Assert(users, HasLen, 0)
+
+	bot.AutoFollowRetweeters(42, FollowFilter{MinFollowersCount: 100}, SleepPolicy{})
+	c.Assert(client.followed, DeepEquals, []int64{2})
+	c.Assert(bot.friends.Ids["2"].Source, Equals, "retweeters:42")
+}
+
+func (s *MySuite) TestGetFriendToUnFollow(c *C) {
+	old := time.Now().Add(-72 * time.Hour)
+	bot := &TwitterBot{
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				"1": {Timestamp: old.Add(-2 * time.Hour).UnixNano(), Follow: true},
+				"2": {Timestamp: old.Add(-1 * time.Hour).UnixNano(), Follow: true},
+				"3": {Timestamp: old.UnixNano(), Follow: true},
+				"4": {Timestamp: time.Now().UnixNano(), Follow: true},
+			},
+		},
+		followers: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				"1": {Timestamp: old.UnixNano(), Follow: true},
+			},
+		},
+		whitelist: &twitterWhitelist{
+			Ids: map[string]string{},
+		},
+		unfollowPolicy: &unfollowPolicy{
+			minAge: defaultUnfollowMinAge,
+		},
+	}
+	bot.SetUnfollowOrder(UnfollowOldestFirst, 0)
+	id, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(id, Equals, int64(1))
+
+	bot.SetUnfollowOrder(UnfollowNonFollowersFirst, 0)
+	id, ok = bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(id, Equals, int64(2))
+
+	bot.unfollowPolicy.keepFollowers = true
+	bot.friends.Ids["2"].Follow = false
+	bot.friends.Ids["3"].Follow = false
+	_, ok = bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MySuite) TestUnfollowMinAge(c *C) {
+	now := time.Now()
+	bot := makeFakeBot(&fakeClient{})
+	bot.friends.Ids["1"] = &twitterUser{Timestamp: now.Add(-2 * time.Hour).UnixNano(), Follow: true}
+	bot.friends.Ids["2"] = &twitterUser{Timestamp: now.Add(-30 * time.Hour).UnixNano(), Follow: true}
+	bot.whitelist = &twitterWhitelist{
+		Ids: map[string]string{},
+	}
+	bot.unfollowPolicy = &unfollowPolicy{}
+	bot.SetUnfollowPolicy(48*time.Hour, false)
+	_, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, false)
+
+	bot.SetUnfollowPolicy(24*time.Hour, false)
+	id, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(id, Equals, int64(2))
+
+	bot.SetUnfollowPolicy(time.Hour, false)
+	id, ok = bot.getFriendToUnFollow(map[int64]bool{2: true})
+	c.Assert(ok, Equals, true)
+	c.Assert(id, Equals, int64(1))
+}
+
+func (s *MySuite) TestUnfollowKeepFollowers(c *C) {
+	old := time.Now().Add(-72 * time.Hour)
+	client := &fakeClient{
+		myFollowers: []int64{2},
+	}
+	bot := makeFakeBot(client)
+	for i := int64(1); i <= 3; i++ {
+		bot.friends.Ids[fmt.Sprint(i)] = &twitterUser{Timestamp: old.Add(time.Duration(i) * time.Hour).UnixNano(), Follow: true}
+	}
+	bot.whitelist = &twitterWhitelist{
+		Ids: map[string]string{},
+	}
+	bot.unfollowPolicy = &unfollowPolicy{
+		order: UnfollowOldestFirst,
+	}
+	bot.followGuard = &followGuard{}
+	bot.growth = &twitterGrowth{}
+	bot.noSleep = true
+	bot.SetUnfollowPolicy(24*time.Hour, true)
+	// the followers database is refreshed before the run
+	bot.unfollowRun(nil, newCampaignContext(context.Background(), "test"))
+	c.Assert(client.unfollowed, DeepEquals, []int64{1, 3})
+	c.Assert(bot.friends.Ids["2"].Follow, Equals, true)
+
+	client.unfollowed = nil
+	bot.SetUnfollowPolicy(24*time.Hour, false)
+	bot.unfollowRun(nil, newCampaignContext(context.Background(), "test"))
+	c.Assert(client.unfollowed, DeepEquals, []int64{2})
+}
+
+func (s *MySuite) TestUnfollowAllStop(c *C) {
+	bot := &TwitterBot{
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{},
+		},
+		unfollowPolicy: &unfollowPolicy{
+			idleWait: time.Millisecond,
+		},
+	}
+	campaign := newCampaignContext(context.Background(), "test")
+	stopped := make(chan struct{})
+	go func() {
+		bot.unfollowAll(nil, campaign)
+		close(stopped)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	campaign.Stop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		c.Fatal("stopped unfollow campaign should return")
+	}
+}
+
+func (s *MySuite) TestTwitterUserJSON(c *C) {
+	// databases written by older versions of the bot still load
+	users := &twitterUsers{}
+	err := json.Unmarshal([]byte(`{"ids":{"1":{"timestamp":42,"follow":true}}}`), users)
+	c.Assert(err, IsNil)
+	c.Assert(users.Ids["1"], DeepEquals, &twitterUser{Timestamp: 42, Follow: true})
+
+	// and missing metadata is not written
+	data, err := json.Marshal(users)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"ids":{"1":{"timestamp":42,"follow":true}}}`)
+
+	user := &anaconda.User{Id: 2, ScreenName: "gopher", FollowersCount: 10}
+	bot := &TwitterBot{
+		store: jsonStore{},
+		friends: &twitterUsers{
+			Ids: map[string]*twitterUser{},
+		},
+	}
+	bot.friendsPath = c.MkDir() + "/friends.json"
+	bot.addFriend(user, "query:golang")
+	friend := bot.friends.Ids["2"]
+	c.Assert(friend.Follow, Equals, true)
+	c.Assert(friend.Source, Equals, "query:golang")
+	c.Assert(friend.ScreenName, Equals, "gopher")
+	c.Assert(friend.FollowersCount, Equals, 10)
+}
+
+func (s *MySuite) TestSync(c *C) {
+	dir := c.MkDir()
+	client := &fakeClient{
+		myFollowers: []int64{1, 2},
+		myFriends:   []int64{2, 3},
+	}
+	bot := makeFakeBot(client)
+	bot.followersPath = filepath.Join(dir, "followers.json")
+	bot.friendsPath = filepath.Join(dir, "friends.json")
+	bot.growth = &twitterGrowth{}
+	c.Assert(bot.Sync(), IsNil)
+	c.Assert(bot.isFollower(1), Equals, true)
+	c.Assert(bot.canFollow(3), Equals, false)
+
+	client.myFollowers = []int64{2}
+	client.myFriends = []int64{2}
+	c.Assert(bot.Sync(), IsNil)
+	c.Assert(bot.followers.Ids["1"].Follow, Equals, false)
+	c.Assert(bot.followers.Ids["1"].Unfollowed, Not(Equals), int64(0))
+	c.Assert(bot.friends.Ids["3"].Follow, Equals, false)
+	c.Assert(bot.friends.Ids["2"].Follow, Equals, true)
+	c.Assert(bot.GetGrowthStats(24 * time.Hour)[0].Followers, Equals, 1)
+
+	// a failed refresh keeps the databases untouched
+	client.myFollowers = nil
+	client.idsErr = fmt.Errorf("rate limited")
+	c.Assert(bot.Sync(), NotNil)
+	c.Assert(bot.followers.Ids["2"].Follow, Equals, true)
+}
+
+func (s *MySuite) TestVerboseAndNoSleep(c *C) {
+	logger := &recordLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+	bot := makeFakeBot(&fakeClient{})
+	print(bot, "hidden")
+	bot.SetVerbose(true)
+	print(bot, "detail")
+	c.Assert(logger.messages[len(logger.messages)-1], Equals, "debug detail")
+
+	// verbose logs do not remove the sleeps
+	policy := &SleepPolicy{MaxRand: 3600}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(bot.controlledSleepContext(ctx, policy), Equals, false)
+	bot.SetNoSleep(true)
+	c.Assert(bot.controlledSleepContext(context.Background(), policy), Equals, true)
+}
+
+func (s *MySuite) TestPolicyValidation(c *C) {
+	c.Assert((&SleepPolicy{MaxRand: 10, MaybeSleepChance: 1, MaybeSleepTotalChance: 10, MaybeSleepMin: 30, MaybeSleepMax: 60}).Validate(), IsNil)
+	c.Assert((&SleepPolicy{MaxRand: -1}).Validate(), ErrorMatches, ".*negative parameter.*")
+	c.Assert((&SleepPolicy{MaybeSleepMin: 60, MaybeSleepMax: 30}).Validate(), ErrorMatches, ".*maybeSleepMin 60 greater than maybeSleepMax 30")
+	c.Assert((&SleepPolicy{MaybeSleepChance: 2, MaybeSleepTotalChance: 1}).Validate(), ErrorMatches, ".*maybeSleepChance 2 greater than maybeSleepTotalChance 1")
+
+	bot := makeFakeBot(&fakeClient{})
+	bot.likePolicy = &likePolicy{threshold: 10}
+	bot.retweetPolicy = &retweetPolicy{maxTry: 5}
+	bot.defaultSleepPolicy = &SleepPolicy{MaxRand: 1}
+	c.Assert(bot.SetLikePolicy(true, -1, 1, 0), ErrorMatches, ".*negative threshold -1")
+	c.Assert(bot.SetLikePolicy(true, 1, 1.5, 0), ErrorMatches, ".*probability 1.5 not between 0 and 1")
+	c.Assert(bot.SetLikePolicy(true, 1, 1, -1), ErrorMatches, ".*negative maxPerDay -1")
+	c.Assert(bot.likePolicy.threshold, Equals, 10)
+	c.Assert(bot.SetRetweetPolicy(-1, false), ErrorMatches, ".*negative maxTry -1")
+	c.Assert(bot.retweetPolicy.maxTry, Equals, 5)
+	bot.unfollowPolicy = &unfollowPolicy{idleWait: time.Hour}
+	c.Assert(bot.SetUnfollowIdleWait(0), ErrorMatches, ".*non-positive wait 0s")
+	c.Assert(bot.SetUnfollowIdleWait(-time.Minute), ErrorMatches, ".*non-positive wait -1m0s")
+	c.Assert(bot.unfollowPolicy.idleWait, Equals, time.Hour)
+	c.Assert(bot.SetUnfollowIdleWait(time.Minute), IsNil)
+	c.Assert(bot.unfollowPolicy.idleWait, Equals, time.Minute)
+	// an invalid sleep policy falls back to the default one
+	c.Assert(bot.checkSleepPolicy(&SleepPolicy{MaxRand: -1}), Equals, SleepPolicy{MaxRand: 1})
+}
+
+func (s *MySuite) TestMakeTwitterBotWithClient(c *C) {
+	dir := c.MkDir()
+	client := &fakeClient{
+		myFollowers: []int64{1, 2},
+		myFriends:   []int64{2, 3},
+	}
+	bot := MakeTwitterBotWithClient(filepath.Join(dir, "followers.json"), filepath.Join(dir, "friends.json"),
+		filepath.Join(dir, "tweets.json"), client, true)
+	c.Assert(bot.Stats().Followers, Equals, 2)
+	c.Assert(bot.Stats().Friends, Equals, 2)
+	err := bot.TweetOnce(func() (string, error) {
+		return "hello", nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(client.tweets, DeepEquals, []string{"hello"})
+	c.Assert(bot.Stats().ActionsToday[ActionTweet], Equals, 1)
+}
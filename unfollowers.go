@@ -0,0 +1,81 @@
+package twbot
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+// getUnfollowerIDs returns the ids of the followers who stopped
+// following the bot since the given time.
+func (t *TwitterBot) getUnfollowerIDs(since time.Time) []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	for strID, user := range t.followers.Ids {
+		if user.Follow || user.Unfollowed < since.UnixNano() {
+			continue
+		}
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			logError("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// GetUnfollowers returns the users who stopped following the bot since the
+// given time, as detected by the followers database updates. Users whose
+// account no longer exists are not returned.
+func (t *TwitterBot) GetUnfollowers(since time.Time) ([]anaconda.User, error) {
+	return t.LookupUsers(t.getUnfollowerIDs(since))
+}
+
+// ReportUnfollowersPeriodically updates periodically the followers database
+// and reports the users who stopped following the bot since the last report.
+// Unfollowers are logged and given to the 'report' callback if not nil, in
+// order to be direct messaged or tweeted for instance.
+// The report frequency is set up by the given 'freq' input parameter.
+// It logs errors if the update, the lookup or the report failed.
+func (t *TwitterBot) ReportUnfollowersPeriodically(freq time.Duration, report func([]anaconda.User) error) {
+	t.newJob("ReportUnfollowersPeriodically").runPeriodically(freq, t.reportUnfollowers(report))
+}
+
+// reportUnfollowers returns a callback reporting at each call
+// the users who stopped following the bot since the last report.
+func (t *TwitterBot) reportUnfollowers(report func([]anaconda.User) error) func() error {
+	since := timeNow()
+	return func() error {
+		err := t.updateFollowers()
+		if err != nil {
+			return err
+		}
+		now := timeNow()
+		unfollowers, err := t.GetUnfollowers(since)
+		if err != nil {
+			return err
+		}
+		since = now
+		logInfo("[twitter] %d user(s) stopped following since last report", len(unfollowers))
+		for _, user := range unfollowers {
+			logInfo("[twitter] unfollowed by (id:%d, name:%s)", user.Id, user.ScreenName)
+		}
+		if report == nil || len(unfollowers) == 0 {
+			return nil
+		}
+		return report(unfollowers)
+	}
+}
+
+// ReportUnfollowersPeriodicallyAsync reports asynchronously and periodically
+// the users who stopped following the bot, see ReportUnfollowersPeriodically.
+func (t *TwitterBot) ReportUnfollowersPeriodicallyAsync(freq time.Duration, report func([]anaconda.User) error) *Job {
+	reportUnfollowers := t.reportUnfollowers(report)
+	return t.runPeriodicallyAsync("ReportUnfollowersPeriodically", freq, func(ctx context.Context) error {
+		return reportUnfollowers()
+	})
+}
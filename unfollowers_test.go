@@ -0,0 +1,23 @@
+package twbot
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestGetUnfollowerIDs(c *C) {
+	now := time.Now()
+	bot := &TwitterBot{
+		followers: &twitterUsers{
+			Ids: map[string]*twitterUser{
+				"1": {Follow: true},
+				"2": {Unfollowed: now.Add(-time.Hour).UnixNano()},
+				"3": {Unfollowed: now.Add(-48 * time.Hour).UnixNano()},
+				"4": {},
+			},
+		},
+	}
+	c.Assert(bot.getUnfollowerIDs(now.Add(-24*time.Hour)), DeepEquals, []int64{2})
+	c.Assert(bot.getUnfollowerIDs(now), HasLen, 0)
+}
@@ -0,0 +1,207 @@
+package twbot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+const (
+	webhookSignatureHeader = "X-Twitter-Webhooks-Signature"
+	webhookSignaturePrefix = "sha256="
+)
+
+type webhookFollowEvent struct {
+	Type   string        `json:"type"`
+	Source anaconda.User `json:"source"`
+	Target anaconda.User `json:"target"`
+}
+
+type webhookMessageEvent struct {
+	Type          string `json:"type"`
+	ID            string `json:"id"`
+	MessageCreate struct {
+		Target struct {
+			RecipientID string `json:"recipient_id"`
+		} `json:"target"`
+		SenderID    string `json:"sender_id"`
+		MessageData struct {
+			Text string `json:"text"`
+		} `json:"message_data"`
+	} `json:"message_create"`
+}
+
+type webhookEvents struct {
+	ForUserID           string                `json:"for_user_id"`
+	TweetCreateEvents   []anaconda.Tweet      `json:"tweet_create_events"`
+	FollowEvents        []webhookFollowEvent  `json:"follow_events"`
+	DirectMessageEvents []webhookMessageEvent `json:"direct_message_events"`
+}
+
+// Webhook is an http handler receiving the events of the Account Activity API,
+// for plans where webhooks replace streaming. It answers the CRC challenges
+// of twitter and dispatches the mentions, new followers and direct messages
+// of the bot to the registered handlers.
+type Webhook struct {
+	secret   string
+	mutex    sync.Mutex
+	mentions []func(tweet anaconda.Tweet)
+	follows  []func(user anaconda.User)
+	messages []func(dm anaconda.DirectMessage)
+}
+
+// NewWebhook creates a webhook receiving the account activity events of the
+// bot, see ServeWebhookAsync. The webhook must be registered to twitter.
+func (t *TwitterBot) NewWebhook() *Webhook {
+	return &Webhook{
+		secret: t.consumerSecret,
+	}
+}
+
+// OnMention registers a handler called on each tweet mentioning the bot.
+func (w *Webhook) OnMention(handler func(tweet anaconda.Tweet)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.mentions = append(w.mentions, handler)
+}
+
+// OnFollow registers a handler called on each new follower of the bot.
+func (w *Webhook) OnFollow(handler func(user anaconda.User)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.follows = append(w.follows, handler)
+}
+
+// OnDirectMessage registers a handler called on each direct message received by the bot.
+func (w *Webhook) OnDirectMessage(handler func(dm anaconda.DirectMessage)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.messages = append(w.messages, handler)
+}
+
+// sign returns the base64 encoded HMAC SHA-256 of the given data.
+func (w *Webhook) sign(data []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.secret))
+	mac.Write(data)
+	return webhookSignaturePrefix + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP answers the CRC challenges on GET requests
+// and dispatches the events on POST requests.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		token := r.URL.Query().Get("crc_token")
+		if token == "" {
+			http.Error(rw, "missing crc_token", http.StatusBadRequest)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]string{
+			"response_token": w.sign([]byte(token)),
+		})
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !hmac.Equal([]byte(r.Header.Get(webhookSignatureHeader)), []byte(w.sign(body))) {
+			http.Error(rw, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		events := &webhookEvents{}
+		err = json.Unmarshal(body, events)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.dispatch(events)
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// dispatch calls the registered handlers on the events concerning
+// the bot, ignoring the events triggered by the bot itself.
+func (w *Webhook) dispatch(events *webhookEvents) {
+	w.mutex.Lock()
+	mentions := w.mentions
+	follows := w.follows
+	messages := w.messages
+	w.mutex.Unlock()
+	for _, tweet := range events.TweetCreateEvents {
+		if tweet.User.IdStr == events.ForUserID {
+			continue
+		}
+		for _, handler := range mentions {
+			handler(tweet)
+		}
+	}
+	for _, event := range events.FollowEvents {
+		if event.Type != "follow" || event.Target.IdStr != events.ForUserID {
+			continue
+		}
+		for _, handler := range follows {
+			handler(event.Source)
+		}
+	}
+	for _, event := range events.DirectMessageEvents {
+		if event.Type != "message_create" || event.MessageCreate.SenderID == events.ForUserID {
+			continue
+		}
+		dm := anaconda.DirectMessage{
+			IdStr: event.ID,
+			Text:  event.MessageCreate.MessageData.Text,
+		}
+		dm.Id, _ = strconv.ParseInt(event.ID, 10, 64)
+		dm.SenderId, _ = strconv.ParseInt(event.MessageCreate.SenderID, 10, 64)
+		dm.RecipientId, _ = strconv.ParseInt(event.MessageCreate.Target.RecipientID, 10, 64)
+		for _, handler := range messages {
+			handler(dm)
+		}
+	}
+}
+
+// ServeWebhookAsync serves asynchronously the given 'webhook' at the given
+// 'path' on the given 'addr' address, ":8080" for instance.
+// It logs an error if the server failed. Stopping the returned
+// job shuts the server down.
+func (t *TwitterBot) ServeWebhookAsync(addr, path string, webhook *Webhook) *Job {
+	logInfo("[twitter] serving webhook -> addr: %s, path: %s", addr, path)
+	mux := http.NewServeMux()
+	mux.Handle(path, webhook)
+	return t.serveAsync("ServeWebhook", addr, mux)
+}
+
+// serveAsync serves asynchronously the given 'handler' on the given 'addr'
+// address in a job of the given 'name'. Stopping the job shuts the server down.
+func (t *TwitterBot) serveAsync(name, addr string, handler http.Handler) *Job {
+	server := &http.Server{Addr: addr, Handler: handler}
+	job := t.newJob(name)
+	t.quit.Add(1)
+	go func() {
+		defer t.quit.Done()
+		select {
+		case <-job.ctx.Done():
+			server.Shutdown(context.Background())
+		case <-job.Done():
+		}
+	}()
+	return t.run(job, func() error {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("[twitter] %s server failed: %v", name, err)
+		}
+		return nil
+	})
+}
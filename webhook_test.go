@@ -0,0 +1,74 @@
+package twbot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestWebhookChallenge(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.consumerSecret = "secret"
+	webhook := bot.NewWebhook()
+	recorder := httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/webhook?crc_token=token", nil))
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	response := map[string]string{}
+	c.Assert(json.Unmarshal(recorder.Body.Bytes(), &response), IsNil)
+	// base64 encoded HMAC SHA-256 of the token keyed by the consumer secret
+	c.Assert(response["response_token"], Equals, "sha256=6UERDj0r/oJiHw4+FDRzDXMF0QbF9oyHFl0LJ6RhGko=")
+}
+
+func (s *MySuite) TestWebhookEvents(c *C) {
+	bot := makeFakeBot(&fakeClient{})
+	bot.consumerSecret = "secret"
+	webhook := bot.NewWebhook()
+	mentions, follows, messages := []int64{}, []int64{}, []string{}
+	webhook.OnMention(func(tweet anaconda.Tweet) {
+		mentions = append(mentions, tweet.Id)
+	})
+	webhook.OnFollow(func(user anaconda.User) {
+		follows = append(follows, user.Id)
+	})
+	webhook.OnDirectMessage(func(dm anaconda.DirectMessage) {
+		messages = append(messages, dm.Text)
+	})
+	body := `{
+		"for_user_id": "1",
+		"tweet_create_events": [
+			{"id": 10, "user": {"id": 2, "id_str": "2"}},
+			{"id": 11, "user": {"id": 1, "id_str": "1"}}
+		],
+		"follow_events": [
+			{"type": "follow", "source": {"id": 2, "id_str": "2"}, "target": {"id": 1, "id_str": "1"}},
+			{"type": "follow", "source": {"id": 1, "id_str": "1"}, "target": {"id": 3, "id_str": "3"}}
+		],
+		"direct_message_events": [
+			{"type": "message_create", "id": "20", "message_create": {
+				"target": {"recipient_id": "1"}, "sender_id": "2", "message_data": {"text": "ping"}}},
+			{"type": "message_create", "id": "21", "message_create": {
+				"target": {"recipient_id": "2"}, "sender_id": "1", "message_data": {"text": "pong"}}}
+		]
+	}`
+	// events with an invalid signature are rejected
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	request.Header.Set(webhookSignatureHeader, "sha256=invalid")
+	webhook.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, Equals, http.StatusUnauthorized)
+	c.Assert(mentions, HasLen, 0)
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	request.Header.Set(webhookSignatureHeader, webhook.sign([]byte(body)))
+	webhook.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	c.Assert(mentions, DeepEquals, []int64{10})
+	c.Assert(follows, DeepEquals, []int64{2})
+	c.Assert(messages, DeepEquals, []string{"ping"})
+}
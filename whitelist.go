@@ -0,0 +1,109 @@
+package twbot
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type twitterWhitelist struct {
+	// note: we cannot use integers as keys in encode/json so use string instead
+	Ids map[string]string `json:"ids"` // map id -> screen name
+}
+
+// SetWhitelistPath sets the path of the whitelist database. Whitelisted
+// users are protected friends that are never unfollowed by the bot.
+// If no path is set, the whitelist is only kept in memory.
+func (t *TwitterBot) SetWhitelistPath(whitelistPath string) error {
+	whitelist := &twitterWhitelist{
+		Ids: make(map[string]string),
+	}
+	err := t.loadOrCreate(whitelistPath, whitelist)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.whitelistPath = whitelistPath
+	t.whitelist = whitelist
+	return nil
+}
+
+func (t *TwitterBot) saveWhitelist() error {
+	if t.whitelistPath == "" {
+		return nil
+	}
+	return t.store.Save(t.whitelistPath, t.whitelist)
+}
+
+// AddToWhitelist adds the users of the given ids to the whitelist.
+func (t *TwitterBot) AddToWhitelist(ids ...int64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, id := range ids {
+		strID := strconv.FormatInt(id, 10)
+		if _, ok := t.whitelist.Ids[strID]; !ok {
+			t.whitelist.Ids[strID] = ""
+		}
+	}
+	return t.saveWhitelist()
+}
+
+// AddScreenNamesToWhitelist adds the users of the given screen names to the whitelist.
+// The screen names are resolved to user ids using the twitter API.
+func (t *TwitterBot) AddScreenNamesToWhitelist(screenNames ...string) error {
+	if len(screenNames) == 0 {
+		return nil
+	}
+	users, err := t.twitterClient.GetUsersLookup(strings.Join(screenNames, ","), nil)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, user := range users {
+		t.whitelist.Ids[user.IdStr] = user.ScreenName
+		logInfo("[twitter] whitelisting user (id:%d, name:%s)", user.Id, user.ScreenName)
+	}
+	return t.saveWhitelist()
+}
+
+// RemoveFromWhitelist removes the users of the given ids from the whitelist.
+func (t *TwitterBot) RemoveFromWhitelist(ids ...int64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, id := range ids {
+		delete(t.whitelist.Ids, strconv.FormatInt(id, 10))
+	}
+	return t.saveWhitelist()
+}
+
+// GetWhitelist returns the ids of the whitelisted users, sorted by id.
+func (t *TwitterBot) GetWhitelist() []int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ids := []int64{}
+	for strID := range t.whitelist.Ids {
+		id, err := strconv.ParseInt(strID, 10, 64)
+		if err != nil {
+			logError("%v", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// IsWhitelisted returns true if the user of the given id is whitelisted.
+func (t *TwitterBot) IsWhitelisted(id int64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.isWhitelisted(strconv.FormatInt(id, 10))
+}
+
+// isWhitelisted must be called with the bot mutex locked.
+func (t *TwitterBot) isWhitelisted(strID string) bool {
+	_, ok := t.whitelist.Ids[strID]
+	return ok
+}
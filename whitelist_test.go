@@ -0,0 +1,44 @@
+package twbot
+
+import (
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestWhitelist(c *C) {
+	client := &fakeClient{
+		users: map[string]anaconda.User{
+			"gopher": {Id: 3, IdStr: "3", ScreenName: "gopher"},
+		},
+	}
+	bot := makeFakeBot(client)
+	c.Assert(bot.SetWhitelistPath("whitelist.json"), IsNil)
+	c.Assert(bot.AddToWhitelist(2, 1), IsNil)
+	c.Assert(bot.AddScreenNamesToWhitelist("gopher", "unknown"), IsNil)
+	c.Assert(bot.GetWhitelist(), DeepEquals, []int64{1, 2, 3})
+	c.Assert(bot.IsWhitelisted(3), Equals, true)
+	c.Assert(bot.RemoveFromWhitelist(2), IsNil)
+	c.Assert(bot.IsWhitelisted(2), Equals, false)
+
+	// the whitelist is reloaded from the database
+	store := bot.store
+	bot = makeFakeBot(client)
+	bot.store = store
+	c.Assert(bot.SetWhitelistPath("whitelist.json"), IsNil)
+	c.Assert(bot.GetWhitelist(), DeepEquals, []int64{1, 3})
+
+	// whitelisted friends are never unfollowed
+	old := time.Now().Add(-72 * time.Hour).UnixNano()
+	bot.friends.Ids["1"] = &twitterUser{Timestamp: old, Follow: true}
+	bot.friends.Ids["3"] = &twitterUser{Timestamp: old, Follow: true}
+	bot.unfollowPolicy = &unfollowPolicy{}
+	_, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, false)
+	c.Assert(bot.RemoveFromWhitelist(3), IsNil)
+	id, ok := bot.getFriendToUnFollow(nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(id, Equals, int64(3))
+}